@@ -0,0 +1,93 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	sdkproto "github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	kubeturbo "github.com/turbonomic/kubeturbo/pkg"
+	"github.com/turbonomic/kubeturbo/pkg/discovery"
+)
+
+const (
+	// The command discovering the cluster once and printing the discovery response
+	DiscoverCommand = "discover"
+
+	discoverOutputJSON      = "json"
+	discoverOutputProtoText = "prototext"
+)
+
+// RunDiscover validates and discovers the cluster once, or replays the discovery snapshot if any,
+// and prints the discovery response and its summary, without connecting to the Turbonomic server.
+// The response is written to the output file or the standard output, the summary to the standard error.
+func (s *VMTServer) RunDiscover() {
+	if err := s.checkFlag(); err != nil {
+		glog.Errorf("check flag failed:%v. abort.", err.Error())
+		os.Exit(1)
+	}
+	if s.DiscoverOutputFormat != discoverOutputJSON && s.DiscoverOutputFormat != discoverOutputProtoText {
+		glog.Errorf("DiscoverOutputFormat[%s] should be %s or %s. abort.", s.DiscoverOutputFormat,
+			discoverOutputJSON, discoverOutputProtoText)
+		os.Exit(1)
+	}
+
+	var response *sdkproto.DiscoveryResponse
+	if s.DiscoverySnapshotReplayPath != "" {
+		snapshot, err := discovery.LoadDiscoverySnapshot(s.DiscoverySnapshotReplayPath)
+		if err != nil {
+			glog.Fatalf("Failed to load the discovery snapshot: %v", err)
+		}
+		response, err = discovery.ReplayDiscoverySnapshot(snapshot)
+		if err != nil {
+			glog.Fatalf("Failed to replay the discovery snapshot: %v", err)
+		}
+	} else {
+		vmtConfig := s.createVMTConfigOrDie()
+		defer close(vmtConfig.StopEverything)
+		validationResponse, discoveryResponse, err := kubeturbo.DiscoverOnce(vmtConfig)
+		if err != nil {
+			glog.Fatalf("Failed to discover the cluster: %v", err)
+		}
+		for _, errorDTO := range validationResponse.GetErrorDTO() {
+			fmt.Fprintf(os.Stderr, "VALIDATION %v: %s\n", errorDTO.GetSeverity(), errorDTO.GetDescription())
+		}
+		response = discoveryResponse
+	}
+
+	out := os.Stdout
+	if s.DiscoverOutputPath != "" {
+		file, err := os.Create(s.DiscoverOutputPath)
+		if err != nil {
+			glog.Fatalf("Failed to create the output file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := writeDiscoveryResponse(out, response, s.DiscoverOutputFormat); err != nil {
+		glog.Fatalf("Failed to write the discovery response: %v", err)
+	}
+	if err := discovery.SummarizeDiscoveryResponse(response).Print(os.Stderr); err != nil {
+		glog.Fatalf("Failed to print the discovery summary: %v", err)
+	}
+}
+
+func writeDiscoveryResponse(out io.Writer, response *sdkproto.DiscoveryResponse, format string) error {
+	switch format {
+	case discoverOutputJSON:
+		marshaler := &jsonpb.Marshaler{Indent: "  "}
+		if err := marshaler.Marshal(out, response); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(out)
+		return err
+	case discoverOutputProtoText:
+		return proto.MarshalText(out, response)
+	default:
+		return fmt.Errorf("unsupported output format %s", format)
+	}
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func TestWriteDiscoveryResponse(t *testing.T) {
+	entityType := proto.EntityDTO_CONTAINER_POD
+	id := "pod1-uid"
+	response := &proto.DiscoveryResponse{
+		EntityDTO: []*proto.EntityDTO{{EntityType: &entityType, Id: &id}},
+	}
+
+	var out bytes.Buffer
+	if err := writeDiscoveryResponse(&out, response, discoverOutputJSON); err != nil {
+		t.Fatalf("Failed to write the response as JSON: %v", err)
+	}
+	if !strings.Contains(out.String(), `"entityType": "CONTAINER_POD"`) {
+		t.Errorf("Unexpected JSON response:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := writeDiscoveryResponse(&out, response, discoverOutputProtoText); err != nil {
+		t.Fatalf("Failed to write the response as text: %v", err)
+	}
+	if !strings.Contains(out.String(), "entityType: CONTAINER_POD") {
+		t.Errorf("Unexpected text response:\n%s", out.String())
+	}
+
+	if err := writeDiscoveryResponse(&out, response, "yaml"); err == nil {
+		t.Errorf("Expected an error for an unsupported format")
+	}
+}
@@ -31,14 +31,22 @@ import (
 
 const (
 	// The default port for vmt service server
-	KubeturboPort               = 10265
-	DefaultKubeletPort          = 10255
-	DefaultKubeletHttps         = false
-	defaultVMPriority           = -1
-	defaultVMIsBase             = true
-	defaultDiscoveryIntervalSec = 600
-	defaultValidationWorkers    = 10
-	defaultValidationTimeout    = 60
+	KubeturboPort                          = 10265
+	DefaultKubeletPort                     = 10255
+	DefaultKubeletHttps                    = false
+	defaultVMPriority                      = -1
+	defaultVMIsBase                        = true
+	defaultDiscoveryIntervalSec            = 600
+	defaultIncrementalDiscoveryIntervalSec = 0
+	defaultValidationWorkers               = 10
+	defaultValidationTimeout               = 60
+	defaultSamplingIntervalSec             = 0
+	defaultSamplingPercentile              = 95
+	defaultNodeNetworkCapacityMbps         = 10000
+	defaultClonePodGCIntervalSec           = 0
+	defaultResizeVerificationWindowSec     = 120
+	defaultResizeRestartThreshold          = 3
+	defaultKubeletCacheMaxStalenessSec     = 0
 )
 
 var (
@@ -63,6 +71,8 @@ type VMTServer struct {
 	BindPodsQPS          float32
 	BindPodsBurst        int
 	DiscoveryIntervalSec int
+	// Interval of the incremental discovery, 0 to disable it
+	IncrementalDiscoveryIntervalSec int
 
 	// LeaderElection componentconfig.LeaderElectionConfiguration
 
@@ -81,11 +91,22 @@ type VMTServer struct {
 	// Kubelet related config
 	KubeletPort        int
 	EnableKubeletHttps bool
+	// Access the kubelets through the node proxy of the API server
+	UseKubeletProxy bool
+	// Maximum age of the cached kubelet data used when a kubelet cannot be reached, 0 for no limit
+	KubeletCacheMaxStalenessSec int
 
 	// The cluster processor related config
 	ValidationWorkers int
 	ValidationTimeout int
 
+	// Usage sampling between discoveries
+	UsageSamplingIntervalSec int
+	UsageSamplingPercentile  float64
+
+	// Network throughput capacity of the nodes, which the kubelets do not report
+	NodeNetworkCapacityMbps int
+
 	// The Openshift SCC list allowed for action execution
 	sccSupport []string
 
@@ -95,6 +116,37 @@ type VMTServer struct {
 
 	// The Cluster API namespace
 	ClusterAPINamespace string
+
+	// Clamp the resized container resources to the LimitRanges instead of failing the resize actions
+	ClampResizeToLimitRange bool
+
+	// Scale the controllers managed by a HorizontalPodAutoscaler through the replica bounds of the autoscaler
+	ScaleThroughHPA bool
+
+	// Verification of the pods after the rollout of a consistent resize
+	ResizeVerificationWindowSec int
+	ResizeRestartThreshold      int
+
+	// The default strategy of the pod moves, and the strategies of the namespaces and controller kinds
+	MoveStrategy                string
+	MoveStrategyNamespaces      []string
+	MoveStrategyControllerKinds []string
+
+	// Collection of the clone pods left behind by the interrupted move and resize actions
+	ClonePodGCIntervalSec int
+	ClonePodGCDryRun      bool
+
+	// Suspend the nodes by cordoning and draining them when the Cluster API is not enabled
+	DrainNodes bool
+
+	// Path of the file to record the snapshot of each full discovery
+	DiscoverySnapshotRecordPath string
+	// Path of the snapshot file to replay the discovery from, without connecting to the cluster
+	DiscoverySnapshotReplayPath string
+
+	// The format and the file of the discovery response printed by the discover command
+	DiscoverOutputFormat string
+	DiscoverOutputPath   string
 }
 
 // NewVMTServer creates a new VMTServer with default parameters
@@ -120,14 +172,34 @@ func (s *VMTServer) AddFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&s.UseUUID, "stitch-uuid", true, "Use VirtualMachine's UUID to do stitching, otherwise IP is used.")
 	fs.IntVar(&s.KubeletPort, "kubelet-port", DefaultKubeletPort, "The port of the kubelet runs on")
 	fs.BoolVar(&s.EnableKubeletHttps, "kubelet-https", DefaultKubeletHttps, "Indicate if Kubelet is running on https server")
+	fs.BoolVar(&s.UseKubeletProxy, "kubelet-proxy", false, "Access the kubelets through the node proxy of the API server instead of connecting to the nodes, ignoring --kubelet-port and --kubelet-https")
+	fs.IntVar(&s.KubeletCacheMaxStalenessSec, "kubelet-cache-max-staleness-sec", defaultKubeletCacheMaxStalenessSec, "The maximum age in seconds of the cached kubelet data used when a kubelet cannot be reached, 0 for no limit")
 	fs.BoolVar(&s.ForceSelfSignedCerts, "kubelet-force-selfsigned-cert", true, "Indicate if we must use self-signed cert")
 	fs.StringVar(&k8sVersion, "k8sVersion", k8sVersion, "[deprecated] the kubernetes server version; for openshift, it is the underlying Kubernetes' version.")
 	fs.StringVar(&noneSchedulerName, "noneSchedulerName", noneSchedulerName, "[deprecated] a none-exist scheduler name, to prevent controller to create Running pods during move Action.")
 	fs.IntVar(&s.DiscoveryIntervalSec, "discovery-interval-sec", defaultDiscoveryIntervalSec, "The discovery interval in seconds")
+	fs.IntVar(&s.IncrementalDiscoveryIntervalSec, "incremental-discovery-interval-sec", defaultIncrementalDiscoveryIntervalSec, "The interval in seconds of the incremental discovery of the cluster changes between full discoveries. The incremental discovery is disabled by default (0)")
 	fs.IntVar(&s.ValidationWorkers, "validation-workers", defaultValidationWorkers, "The validation workers")
 	fs.IntVar(&s.ValidationTimeout, "validation-timeout-sec", defaultValidationTimeout, "The validation timeout in seconds")
+	fs.IntVar(&s.UsageSamplingIntervalSec, "usage-sampling-interval-sec", defaultSamplingIntervalSec, "The interval in seconds to sample the kubelet usage between discoveries, 0 to disable sampling")
+	fs.Float64Var(&s.UsageSamplingPercentile, "usage-sampling-percentile", defaultSamplingPercentile, "The percentile of the usage samples reported as the peak")
+	fs.IntVar(&s.NodeNetworkCapacityMbps, "node-network-capacity-mbps", defaultNodeNetworkCapacityMbps, "The network throughput capacity of the nodes in Mbit/s, as it is not reported by the kubelets")
 	fs.StringSliceVar(&s.sccSupport, "scc-support", defaultSccSupport, "The SCC list allowed for executing pod actions, e.g., --scc-support=restricted,anyuid or --scc-support=* to allow all")
 	fs.StringVar(&s.ClusterAPINamespace, "cluster-api-namespace", "default", "The Cluster API namespace.")
+	fs.BoolVar(&s.ClampResizeToLimitRange, "clamp-resize-to-limit-range", false, "Clamp the resized container resources to the minimum, maximum and maximum limit to request ratio of the LimitRanges of the namespace, instead of failing the resize actions violating them")
+	fs.BoolVar(&s.ScaleThroughHPA, "scale-through-hpa", false, "Execute the provision and suspend actions of the pods whose controller is scaled by a HorizontalPodAutoscaler by adjusting the minReplicas and maxReplicas of the autoscaler, instead of failing them. The original bounds are recorded in the kubeturbo.io/hpa-original-bounds annotation of the autoscaler, and the bound which is not in the way of an action is set back to its original value")
+	fs.IntVar(&s.ResizeVerificationWindowSec, "resize-verification-window-sec", defaultResizeVerificationWindowSec, "The time in seconds to verify the health of the pods after the rollout of a consistent resize of a Deployment, before the resize succeeds; the resize is rolled back if a pod is unhealthy during the rollout or this time")
+	fs.IntVar(&s.ResizeRestartThreshold, "resize-restart-threshold", defaultResizeRestartThreshold, "The number of restarts of a container of the pods rolled out by a consistent resize which fails and rolls back the resize, 0 to ignore the restarts")
+	fs.StringVar(&s.MoveStrategy, "pod-move-strategy", "clone", "The default strategy of the pod moves: clone to bind a clone of the pod to the destination and evict the pod once the clone is ready, or evict to evict the pod while the pod template of its controller is constrained to the destination")
+	fs.StringSliceVar(&s.MoveStrategyNamespaces, "pod-move-strategy-namespaces", s.MoveStrategyNamespaces, "The strategies of the pod moves by namespace, overriding the strategies by controller kind, e.g., --pod-move-strategy-namespaces=prod:evict,dev:clone")
+	fs.StringSliceVar(&s.MoveStrategyControllerKinds, "pod-move-strategy-controller-kinds", s.MoveStrategyControllerKinds, "The strategies of the pod moves by kind of the controller of the pod, overriding the default strategy, e.g., --pod-move-strategy-controller-kinds=ReplicaSet:evict")
+	fs.IntVar(&s.ClonePodGCIntervalSec, "clone-pod-gc-interval-sec", defaultClonePodGCIntervalSec, "The interval in seconds to delete the clone pods left behind by the interrupted move and resize actions, 0 (default) to disable the collection. Only the clone pods which record the pod they were cloned from are deleted, the others are logged")
+	fs.BoolVar(&s.ClonePodGCDryRun, "clone-pod-gc-dry-run", false, "Only log the clone pods left behind by the interrupted move and resize actions, without deleting them")
+	fs.BoolVar(&s.DrainNodes, "drain-nodes-without-cluster-api", false, "Execute the suspend actions of the nodes by cordoning them and evicting their pods when the Cluster API is not enabled, leaving the nodes cordoned and labelled with kubeturbo.io/drained=true for an external tool or an operator to terminate them, and execute the provision actions of the nodes by uncordoning the drained nodes not terminated yet")
+	fs.StringVar(&s.DiscoverySnapshotRecordPath, "discovery-snapshot-record", s.DiscoverySnapshotRecordPath, "Path of the file to record the snapshot of the cluster objects and kubelet responses of each full discovery")
+	fs.StringVar(&s.DiscoverySnapshotReplayPath, "discovery-snapshot-replay", s.DiscoverySnapshotReplayPath, "Path of a recorded discovery snapshot to replay the discovery from, printing the discovery response as the discover command and exiting without connecting to the cluster")
+	fs.StringVar(&s.DiscoverOutputFormat, "discover-output-format", discoverOutputJSON, "The format of the discovery response printed by the discover command: json or prototext")
+	fs.StringVar(&s.DiscoverOutputPath, "discover-output", s.DiscoverOutputPath, "Path of the file to write the discovery response of the discover command, the standard output by default")
 }
 
 // create an eventRecorder to send events to Kubernetes APIserver
@@ -174,6 +246,9 @@ func (s *VMTServer) createKubeletClientOrDie(kubeConfig *restclient.Config, forc
 		WithPort(s.KubeletPort).
 		EnableHttps(s.EnableKubeletHttps).
 		ForceSelfSignedCerts(forceSelfSignedCerts && s.ForceSelfSignedCerts).
+		UseProxy(s.UseKubeletProxy).
+		WithMaxCacheStaleness(s.KubeletCacheMaxStalenessSec).
+		RecordResponses(s.DiscoverySnapshotRecordPath != "").
 		// Timeout(to).
 		Create()
 	if err != nil {
@@ -210,16 +285,71 @@ func (s *VMTServer) checkFlag() error {
 		return fmt.Errorf("[KubeletPort[%d] should be bigger than 0.", s.KubeletPort)
 	}
 
+	if s.KubeletCacheMaxStalenessSec < 0 {
+		return fmt.Errorf("KubeletCacheMaxStalenessSec[%d] should not be negative.", s.KubeletCacheMaxStalenessSec)
+	}
+
+	if s.UsageSamplingIntervalSec < 0 {
+		return fmt.Errorf("UsageSamplingIntervalSec[%d] should not be negative.", s.UsageSamplingIntervalSec)
+	}
+
+	if s.NodeNetworkCapacityMbps <= 0 {
+		return fmt.Errorf("NodeNetworkCapacityMbps[%d] should be bigger than 0.", s.NodeNetworkCapacityMbps)
+	}
+
+	if s.ClonePodGCIntervalSec < 0 {
+		return fmt.Errorf("ClonePodGCIntervalSec[%d] should not be negative.", s.ClonePodGCIntervalSec)
+	}
+
+	if s.ResizeVerificationWindowSec < 0 {
+		return fmt.Errorf("ResizeVerificationWindowSec[%d] should not be negative.", s.ResizeVerificationWindowSec)
+	}
+
+	if s.ResizeRestartThreshold < 0 {
+		return fmt.Errorf("ResizeRestartThreshold[%d] should not be negative.", s.ResizeRestartThreshold)
+	}
+
+	if s.UsageSamplingPercentile <= 0 || s.UsageSamplingPercentile > 100 {
+		return fmt.Errorf("UsageSamplingPercentile[%v] should be in (0, 100].", s.UsageSamplingPercentile)
+	}
+
 	return nil
 }
 
 // Run runs the specified VMTServer.  This should never exit.
 func (s *VMTServer) Run() {
+	if s.DiscoverySnapshotReplayPath != "" {
+		s.RunDiscover()
+		return
+	}
+
 	if err := s.checkFlag(); err != nil {
 		glog.Errorf("check flag failed:%v. abort.", err.Error())
 		os.Exit(1)
 	}
 
+	vmtConfig := s.createVMTConfigOrDie()
+
+	// The KubeTurbo TAP service
+	k8sTAPService, err := kubeturbo.NewKubernetesTAPService(vmtConfig)
+	if err != nil {
+		glog.Fatalf("Unexpected error while creating Kuberntes TAP service: %s", err)
+	}
+
+	// The client for healthz, debug, and prometheus
+	go s.startHttp()
+	glog.V(2).Infof("No leader election")
+
+	glog.V(1).Infof("********** Start runnning Kubeturbo Service **********")
+	// Disconnect from Turbo server when Kubeturbo is shutdown
+	handleExit(func() { k8sTAPService.DisconnectFromTurbo() })
+	k8sTAPService.ConnectToTurbo()
+
+	glog.V(1).Info("Kubeturbo service is stopped.")
+}
+
+// createVMTConfigOrDie creates the clients of the cluster and the configuration of the Kubeturbo services.
+func (s *VMTServer) createVMTConfigOrDie() *kubeturbo.Config {
 	kubeConfig := s.createKubeConfigOrDie()
 	glog.V(3).Infof("kubeConfig: %+v", kubeConfig)
 
@@ -251,33 +381,30 @@ func (s *VMTServer) Run() {
 	vmtConfig.WithTapSpec(k8sTAPSpec).
 		WithKubeClient(kubeClient).
 		WithKubeletClient(kubeletClient).
+		WithMetricsServerClient(kubeclient.NewMetricsServerClient(kubeClient.DiscoveryClient.RESTClient())).
 		WithClusterAPIClient(caClient).
 		WithVMPriority(s.VMPriority).
 		WithVMIsBase(s.VMIsBase).
 		UsingUUIDStitch(s.UseUUID).
 		WithDiscoveryInterval(s.DiscoveryIntervalSec).
+		WithIncrementalDiscoveryInterval(s.IncrementalDiscoveryIntervalSec).
 		WithValidationTimeout(s.ValidationTimeout).
 		WithValidationWorkers(s.ValidationWorkers).
+		WithUsageSampling(s.UsageSamplingIntervalSec, s.UsageSamplingPercentile).
+		WithNodeNetworkCapacity(s.NodeNetworkCapacityMbps).
 		WithSccSupport(s.sccSupport).
-		WithCAPINamespace(s.ClusterAPINamespace)
+		WithCAPINamespace(s.ClusterAPINamespace).
+		WithResizeClampedToLimitRange(s.ClampResizeToLimitRange).
+		WithScaleThroughHPA(s.ScaleThroughHPA).
+		WithClonePodGC(s.ClonePodGCIntervalSec, s.ClonePodGCDryRun).
+		WithMoveStrategies(s.MoveStrategy, s.MoveStrategyNamespaces, s.MoveStrategyControllerKinds).
+		WithResizeVerification(s.ResizeVerificationWindowSec, s.ResizeRestartThreshold).
+		WithNodeDrain(s.DrainNodes).
+		WithEventRecorder(createRecorder(kubeClient)).
+		WithDiscoverySnapshotPath(s.DiscoverySnapshotRecordPath)
 	glog.V(3).Infof("Finished creating turbo configuration: %+v", vmtConfig)
 
-	// The KubeTurbo TAP service
-	k8sTAPService, err := kubeturbo.NewKubernetesTAPService(vmtConfig)
-	if err != nil {
-		glog.Fatalf("Unexpected error while creating Kuberntes TAP service: %s", err)
-	}
-
-	// The client for healthz, debug, and prometheus
-	go s.startHttp()
-	glog.V(2).Infof("No leader election")
-
-	glog.V(1).Infof("********** Start runnning Kubeturbo Service **********")
-	// Disconnect from Turbo server when Kubeturbo is shutdown
-	handleExit(func() { k8sTAPService.DisconnectFromTurbo() })
-	k8sTAPService.ConnectToTurbo()
-
-	glog.V(1).Info("Kubeturbo service is stopped.")
+	return vmtConfig
 }
 
 func (s *VMTServer) startHttp() {
@@ -56,11 +56,14 @@ func TestOptions(t *testing.T) {
 		WithVMIsBase(true).
 		WithDiscoveryInterval(1).
 		WithValidationTimeout(2).
-		WithValidationWorkers(3)
+		WithValidationWorkers(3).
+		WithUsageSampling(60, 95)
 	assert.True(t, vmtConfig.VMIsBase)
 	assert.Equal(t, vmtConfig.DiscoveryIntervalSec, 1)
 	assert.Equal(t, vmtConfig.ValidationTimeoutSec, 2)
 	assert.Equal(t, vmtConfig.ValidationWorkers, 3)
+	assert.Equal(t, vmtConfig.UsageSamplingIntervalSec, 60)
+	assert.Equal(t, vmtConfig.UsageSamplingPercentile, 95.0)
 }
 
 func TestOptionsSet(t *testing.T) {
@@ -103,6 +103,12 @@ func main() {
 	defer klog.Flush()
 	defer glog.Flush()
 
+	if pflag.NArg() > 0 && pflag.Arg(0) == app.DiscoverCommand {
+		glog.Infof("Discover the cluster (GIT_COMMIT: %s)", os.Getenv("GIT_COMMIT"))
+		s.RunDiscover()
+		return
+	}
+
 	glog.Infof("Run Kubeturbo service (GIT_COMMIT: %s)", os.Getenv("GIT_COMMIT"))
 
 	s.Run()
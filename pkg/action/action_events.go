@@ -0,0 +1,182 @@
+package action
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/action/executor"
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+// The reasons of the events recorded for the actions
+const (
+	eventReasonActionStarted   = "ActionStarted"
+	eventReasonActionSucceeded = "ActionSucceeded"
+	eventReasonActionFailed    = "ActionFailed"
+	eventReasonPodRenamed      = "PodRenamed"
+	eventReasonPodProvisioned  = "PodProvisioned"
+)
+
+// actionEvents records the Kubernetes events of an action on the objects it involves: the target pod and its
+// controller, and the destination node of a move or the node of a machine action. The events make the actions
+// visible to the owners of these objects, e.g., with kubectl describe.
+// A nil actionEvents records nothing.
+type actionEvents struct {
+	recorder record.EventRecorder
+	// The description of the action, e.g., "move of pod ns/name from node n1 to node n2"
+	description string
+	pod         *api.ObjectReference
+	controller  *api.ObjectReference
+	node        *api.ObjectReference
+}
+
+// newActionEvents returns the recorder of the events of the action on the given pod, which is nil for
+// a machine action. It returns nil if the events are not recorded.
+func (h *ActionHandler) newActionEvents(actionItem *proto.ActionItemDTO, pod *api.Pod) *actionEvents {
+	recorder := h.config.eventRecorder
+	if recorder == nil {
+		return nil
+	}
+	events := &actionEvents{recorder: recorder}
+	podName := ""
+	if pod != nil {
+		events.pod = podReference(pod)
+		events.controller = h.getControllerReference(pod)
+		podName = util.BuildIdentifier(pod.Namespace, pod.Name)
+	}
+	switch getTurboActionType(actionItem) {
+	case turboActionPodMove:
+		destination := actionItem.GetNewSE().GetDisplayName()
+		events.description = fmt.Sprintf("move of pod %s from node %s to node %s",
+			podName, pod.Spec.NodeName, destination)
+		if destination != "" {
+			events.node = nodeReference(destination)
+		}
+	case turboActionPodProvision:
+		events.description = fmt.Sprintf("provision of a replica of pod %s", podName)
+		if destination := actionItem.GetNewSE().GetDisplayName(); destination != "" {
+			events.description += " on node " + destination
+		}
+	case turboActionPodSuspend:
+		events.description = fmt.Sprintf("suspension of pod %s", podName)
+	case turboActionContainerResize:
+		events.description = fmt.Sprintf("resize of container %s of pod %s",
+			actionItem.GetTargetSE().GetDisplayName(), podName)
+	case turboActionMachineProvision:
+		nodeName := actionItem.GetTargetSE().GetDisplayName()
+		events.description = fmt.Sprintf("provision of a node like node %s", nodeName)
+		events.node = nodeReference(nodeName)
+	case turboActionMachineSuspend:
+		nodeName := actionItem.GetTargetSE().GetDisplayName()
+		events.description = fmt.Sprintf("suspension of node %s", nodeName)
+		events.node = nodeReference(nodeName)
+	default:
+		events.description = fmt.Sprintf("%v action on %s", actionItem.GetActionType(),
+			actionItem.GetTargetSE().GetDisplayName())
+	}
+	return events
+}
+
+func (e *actionEvents) started() {
+	if e == nil {
+		return
+	}
+	e.record(api.EventTypeNormal, eventReasonActionStarted, "Started the "+e.description)
+}
+
+// succeeded records the success of the action. If the action replaced the target pod, the success
+// and the rename are recorded on the new pod. If the action provisioned a new pod, the node where the pod
+// landed is recorded on the new pod.
+func (e *actionEvents) succeeded(output *executor.TurboActionExecutorOutput) {
+	if e == nil {
+		return
+	}
+	if output != nil && output.OldPod != nil && output.NewPod != nil {
+		e.pod = podReference(output.NewPod)
+		message := fmt.Sprintf("Pod %s is replaced by pod %s by the %s",
+			util.BuildIdentifier(output.OldPod.Namespace, output.OldPod.Name),
+			util.BuildIdentifier(output.NewPod.Namespace, output.NewPod.Name), e.description)
+		for _, ref := range []*api.ObjectReference{e.pod, e.controller} {
+			if ref != nil {
+				e.recorder.Event(ref, api.EventTypeNormal, eventReasonPodRenamed, message)
+			}
+		}
+	}
+	if output != nil && output.OldPod == nil && output.NewPod != nil {
+		message := fmt.Sprintf("Pod %s is created on node %s by the %s",
+			util.BuildIdentifier(output.NewPod.Namespace, output.NewPod.Name), output.NewPod.Spec.NodeName,
+			e.description)
+		for _, ref := range []*api.ObjectReference{podReference(output.NewPod), e.controller} {
+			if ref != nil {
+				e.recorder.Event(ref, api.EventTypeNormal, eventReasonPodProvisioned, message)
+			}
+		}
+	}
+	e.record(api.EventTypeNormal, eventReasonActionSucceeded, "Completed the "+e.description)
+}
+
+func (e *actionEvents) failed(err error) {
+	if e == nil {
+		return
+	}
+	e.record(api.EventTypeWarning, eventReasonActionFailed, fmt.Sprintf("Failed the %s: %v", e.description, err))
+}
+
+func (e *actionEvents) record(eventType, reason, message string) {
+	for _, ref := range []*api.ObjectReference{e.pod, e.controller, e.node} {
+		if ref != nil {
+			e.recorder.Event(ref, eventType, reason, message)
+		}
+	}
+}
+
+// getControllerReference returns the reference of the controller of the pod, which is the Deployment
+// of its ReplicaSet if any. It returns nil if the pod has no controller.
+func (h *ActionHandler) getControllerReference(pod *api.Pod) *api.ObjectReference {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return nil
+	}
+	if owner.Kind == goutil.KindReplicaSet {
+		rsClient := goutil.NewAppsClient(h.config.kubeClient).ReplicaSets(pod.Namespace)
+		rs, err := rsClient.Get(owner.Name, metav1.GetOptions{})
+		if err != nil {
+			glog.Warningf("Failed to get ReplicaSet %s/%s of pod %s: %v", pod.Namespace, owner.Name, pod.Name, err)
+		} else if rsOwner := metav1.GetControllerOf(rs); rsOwner != nil {
+			owner = rsOwner
+		}
+	}
+	return &api.ObjectReference{
+		APIVersion: owner.APIVersion,
+		Kind:       owner.Kind,
+		Namespace:  pod.Namespace,
+		Name:       owner.Name,
+		UID:        owner.UID,
+	}
+}
+
+func podReference(pod *api.Pod) *api.ObjectReference {
+	return &api.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Namespace:  pod.Namespace,
+		Name:       pod.Name,
+		UID:        pod.UID,
+	}
+}
+
+// nodeReference returns the reference of a node for its events. As for the events of the kubelet,
+// the UID is the name of the node, which is how kubectl describe finds them.
+func nodeReference(nodeName string) *api.ObjectReference {
+	return &api.ObjectReference{
+		Kind: "Node",
+		Name: nodeName,
+		UID:  types.UID(nodeName),
+	}
+}
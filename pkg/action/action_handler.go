@@ -6,6 +6,9 @@ import (
 	"time"
 
 	client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
 
 	"github.com/turbonomic/kubeturbo/pkg/action/executor"
 	"github.com/turbonomic/kubeturbo/pkg/action/util"
@@ -42,14 +45,30 @@ var (
 
 type ActionHandlerConfig struct {
 	kubeClient     *client.Clientset
+	clusterScraper *cluster.ClusterScraper
 	cApiClient     *clientset.Clientset
 	kubeletClient  *kubeclient.KubeletClient
 	StopEverything chan struct{}
 	sccAllowedSet  map[string]struct{}
 	cAPINamespace  string
+	// Clamp the resized container resources to the LimitRanges instead of failing the resize actions
+	clampResizeToLimitRange bool
+	// Scale the controllers managed by a HorizontalPodAutoscaler through the replica bounds of the autoscaler
+	scaleThroughHPA bool
+	// Record the Kubernetes events of the actions if set
+	eventRecorder record.EventRecorder
+	// The time to verify the health of the pods after the rollout of a consistent resize, and the number of
+	// restarts of a container within that time which fails and rolls back the resize
+	resizeVerificationWindow time.Duration
+	resizeRestartThreshold   int32
+	// Select the strategy of the pod moves, the pods are moved by clone if not set
+	moveStrategies *executor.MoveStrategySelector
+	// Suspend the nodes by cordoning and draining them when the Cluster API is not enabled
+	drainNodes bool
 }
 
-func NewActionHandlerConfig(cApiNamespace string, cApiClient *clientset.Clientset, kubeClient *client.Clientset, kubeletClient *kubeclient.KubeletClient, sccSupport []string) *ActionHandlerConfig {
+func NewActionHandlerConfig(cApiNamespace string, cApiClient *clientset.Clientset, kubeClient *client.Clientset, kubeletClient *kubeclient.KubeletClient,
+	clusterScraper *cluster.ClusterScraper, sccSupport []string) *ActionHandlerConfig {
 	sccAllowedSet := make(map[string]struct{})
 	for _, sccAllowed := range sccSupport {
 		sccAllowedSet[strings.TrimSpace(sccAllowed)] = struct{}{}
@@ -58,6 +77,7 @@ func NewActionHandlerConfig(cApiNamespace string, cApiClient *clientset.Clientse
 
 	config := &ActionHandlerConfig{
 		kubeClient:     kubeClient,
+		clusterScraper: clusterScraper,
 		kubeletClient:  kubeletClient,
 		StopEverything: make(chan struct{}),
 		sccAllowedSet:  sccAllowedSet,
@@ -68,6 +88,37 @@ func NewActionHandlerConfig(cApiNamespace string, cApiClient *clientset.Clientse
 	return config
 }
 
+func (c *ActionHandlerConfig) WithResizeClampedToLimitRange(clamp bool) *ActionHandlerConfig {
+	c.clampResizeToLimitRange = clamp
+	return c
+}
+
+func (c *ActionHandlerConfig) WithScaleThroughHPA(scaleThroughHPA bool) *ActionHandlerConfig {
+	c.scaleThroughHPA = scaleThroughHPA
+	return c
+}
+
+func (c *ActionHandlerConfig) WithEventRecorder(recorder record.EventRecorder) *ActionHandlerConfig {
+	c.eventRecorder = recorder
+	return c
+}
+
+func (c *ActionHandlerConfig) WithResizeVerification(window time.Duration, restartThreshold int32) *ActionHandlerConfig {
+	c.resizeVerificationWindow = window
+	c.resizeRestartThreshold = restartThreshold
+	return c
+}
+
+func (c *ActionHandlerConfig) WithMoveStrategies(moveStrategies *executor.MoveStrategySelector) *ActionHandlerConfig {
+	c.moveStrategies = moveStrategies
+	return c
+}
+
+func (c *ActionHandlerConfig) WithNodeDrain(drainNodes bool) *ActionHandlerConfig {
+	c.drainNodes = drainNodes
+	return c
+}
+
 type ActionHandler struct {
 	config *ActionHandlerConfig
 
@@ -82,6 +133,9 @@ type ActionHandler struct {
 // Build new ActionHandler and start it.
 func NewActionHandler(config *ActionHandlerConfig) *ActionHandler {
 	lmap := util.NewExpirationMap(defaultActionCacheTTL)
+	if config.clusterScraper == nil {
+		config.clusterScraper = cluster.NewClusterScraper(config.kubeClient)
+	}
 	podsGetter := config.kubeClient.CoreV1()
 	podCachedManager := util.NewPodCachedManager(turbostore.NewTurboCache(defaultPodNameCacheTTL).Cache, podsGetter)
 
@@ -101,16 +155,17 @@ func NewActionHandler(config *ActionHandlerConfig) *ActionHandler {
 // As action executor is stateless, they can be safely reused.
 func (h *ActionHandler) registerActionExecutors() {
 	c := h.config
-	ae := executor.NewTurboK8sActionExecutor(c.kubeClient, c.cApiClient, h.podManager)
+	ae := executor.NewTurboK8sActionExecutor(c.kubeClient, c.cApiClient, h.podManager, c.clusterScraper)
 
-	reScheduler := executor.NewReScheduler(ae, c.sccAllowedSet)
+	reScheduler := executor.NewReScheduler(ae, c.sccAllowedSet, c.moveStrategies)
 	h.actionExecutors[turboActionPodMove] = reScheduler
 
-	horizontalScaler := executor.NewHorizontalScaler(ae)
+	horizontalScaler := executor.NewHorizontalScaler(ae, c.scaleThroughHPA)
 	h.actionExecutors[turboActionPodProvision] = horizontalScaler
 	h.actionExecutors[turboActionPodSuspend] = horizontalScaler
 
-	containerResizer := executor.NewContainerResizer(ae, c.kubeletClient, c.sccAllowedSet)
+	containerResizer := executor.NewContainerResizer(ae, c.kubeletClient, c.sccAllowedSet, c.clampResizeToLimitRange,
+		c.resizeVerificationWindow, c.resizeRestartThreshold)
 	h.actionExecutors[turboActionContainerResize] = containerResizer
 
 	// Only register the actions when API client is non-nil.
@@ -118,6 +173,11 @@ func (h *ActionHandler) registerActionExecutors() {
 		machineScaler := executor.NewMachineActionExecutor(c.cAPINamespace, ae)
 		h.actionExecutors[turboActionMachineProvision] = machineScaler
 		h.actionExecutors[turboActionMachineSuspend] = machineScaler
+	} else if c.drainNodes {
+		glog.V(1).Info("the Cluster API is unavailable, the nodes are suspended by draining them")
+		nodeDrainer := executor.NewNodeDrainActionExecutor(ae)
+		h.actionExecutors[turboActionMachineProvision] = nodeDrainer
+		h.actionExecutors[turboActionMachineSuspend] = nodeDrainer
 	} else {
 		glog.V(1).Info("the Cluster API is unavailable")
 	}
@@ -138,14 +198,15 @@ func (h *ActionHandler) ExecuteAction(actionExecutionDTO *proto.ActionExecutionD
 
 	actionItemDTO := actionExecutionDTO.GetActionItem()[0]
 
-	// 2. keep sending fake progress to prevent timeout
+	// 2. keep sending progress to prevent timeout, fake until the executor reports the real progress
 	stop := make(chan struct{})
 	defer close(stop)
-	go keepAlive(progressTracker, stop)
+	progress := newActionProgress(progressTracker)
+	go keepAlive(progress, stop)
 
 	// 3. execute the action
 	glog.V(3).Infof("Now wait for action result")
-	err := h.execute(actionItemDTO)
+	err := h.execute(actionItemDTO, progress)
 	if err != nil {
 		return h.failedResult(err.Error()), nil
 	}
@@ -158,7 +219,7 @@ func isPodAction(actionItem *proto.ActionItemDTO) bool {
 		actionItem.GetTargetSE().GetEntityType() == proto.EntityDTO_CONTAINER
 }
 
-func (h *ActionHandler) execute(actionItem *proto.ActionItemDTO) error {
+func (h *ActionHandler) execute(actionItem *proto.ActionItemDTO, progress sdkprobe.ActionProgressTracker) error {
 	// Only acquire lock for pod actions so they can be sequentialized
 	// We sequentialize pod actions because there could be different types of actions
 	// generated for the same pod at the same time, e.g., resize and provision
@@ -189,17 +250,22 @@ func (h *ActionHandler) execute(actionItem *proto.ActionItemDTO) error {
 	input := &executor.TurboActionExecutorInput{
 		ActionItem: actionItem,
 		Pod:        pod,
+		Progress:   progress,
 	}
 
 	actionType := getTurboActionType(actionItem)
 	worker := h.actionExecutors[actionType]
+	events := h.newActionEvents(actionItem, pod)
+	events.started()
 	output, err := worker.Execute(input)
 	if err != nil {
 		glog.Errorf("Failed to execute action %v on %v [%v]: %v",
 			actionType.actionType, actionItem.GetTargetSE().GetEntityType(),
 			actionItem.GetTargetSE().GetDisplayName(), err)
+		events.failed(err)
 		return err
 	}
+	events.succeeded(output)
 	// Process the action execution output, including caching the pod name change.
 	h.processOutput(output)
 	return nil
@@ -281,20 +347,12 @@ func (h *ActionHandler) failedResult(msg string) *proto.ActionResult {
 	}
 }
 
-func keepAlive(tracker sdkprobe.ActionProgressTracker, stop chan struct{}) {
+func keepAlive(progress *actionProgress, stop chan struct{}) {
 
 	// TODO: add timeout
 	go func() {
-		var progress int32 = 0
-		state := proto.ActionResponseState_IN_PROGRESS
-
 		for {
-			progress = progress + 1
-			if progress > 99 {
-				progress = 99
-			}
-
-			tracker.UpdateProgress(state, "in progress", progress)
+			progress.tick()
 
 			t := time.NewTimer(time.Second * 3)
 			select {
@@ -16,6 +16,7 @@ import (
 	client "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/typed/core/v1"
 	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
@@ -70,6 +71,43 @@ func TestActionHandler_ExecuteAction_Succeed(t *testing.T) {
 	}
 }
 
+func TestActionHandler_ExecuteAction_Events(t *testing.T) {
+	var podCache turbostore.ITurboCache = turbostore.NewTurboCache(defaultPodNameCacheTTL).Cache
+	h := newActionHandler(podCache)
+	recorder := record.NewFakeRecorder(10)
+	h.config.eventRecorder = recorder
+	actionExecutionDTO := newActionExecutionDTO(proto.ActionItemDTO_MOVE, newTargetSE())
+	nodeType := proto.EntityDTO_VIRTUAL_MACHINE
+	nodeName := "node-bar"
+	actionExecutionDTO.ActionItem[0].NewSE = &proto.EntityDTO{EntityType: &nodeType, DisplayName: &nodeName}
+	if _, err := h.ExecuteAction(actionExecutionDTO, nil, &mockProgressTrack{}); err != nil {
+		t.Errorf("ActionHandler.ExecuteAction(): error = %v", err)
+	}
+
+	// The start and the success on the pod and the destination node, and the rename of the pod
+	description := "move of pod " + mockPodDispName + " from node  to node node-bar"
+	expected := []string{
+		"Normal ActionStarted Started the " + description,
+		"Normal ActionStarted Started the " + description,
+		"Normal PodRenamed Pod " + mockPodDispName + " is replaced by pod " + mockPodDispName + "-c by the " + description,
+		"Normal ActionSucceeded Completed the " + description,
+		"Normal ActionSucceeded Completed the " + description,
+	}
+	close(recorder.Events)
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events but got %d: %v", len(expected), len(events), events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("Expected event %q but got %q", expected[i], events[i])
+		}
+	}
+}
+
 func TestActionHandler_ExecuteAction_Unsupported_Action(t *testing.T) {
 	var podCache turbostore.ITurboCache = turbostore.NewTurboCache(defaultPodNameCacheTTL).Cache
 	h := newActionHandler(podCache)
@@ -144,6 +182,7 @@ type mockExecutor struct{}
 func (m *mockExecutor) Execute(input *executor.TurboActionExecutorInput) (*executor.TurboActionExecutorOutput, error) {
 	oldPod := input.Pod
 	pod := &api.Pod{}
+	pod.Namespace = oldPod.Namespace
 	pod.Name = oldPod.Name + "-c"
 	pod.UID = oldPod.UID + "-c"
 
@@ -0,0 +1,48 @@
+package action
+
+import (
+	"sync"
+
+	sdkprobe "github.com/turbonomic/turbo-go-sdk/pkg/probe"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+// actionProgress forwards the progress of an action to the tracker of the SDK, and keeps the action alive
+// on the server by reporting its progress again at each tick. Until the executor reports the real progress
+// of the action, a fake progress growing at each tick is reported.
+type actionProgress struct {
+	tracker     sdkprobe.ActionProgressTracker
+	description string
+	progress    int32
+	// Whether the executor reported the progress of the action
+	reported bool
+	sync.Mutex
+}
+
+func newActionProgress(tracker sdkprobe.ActionProgressTracker) *actionProgress {
+	return &actionProgress{
+		tracker:     tracker,
+		description: "in progress",
+	}
+}
+
+// UpdateProgress implements the ActionProgressTracker interface for the executors.
+func (p *actionProgress) UpdateProgress(state proto.ActionResponseState, description string, progress int32) {
+	p.Lock()
+	p.description = description
+	p.progress = progress
+	p.reported = true
+	p.Unlock()
+	p.tracker.UpdateProgress(state, description, progress)
+}
+
+// tick reports the latest progress, after increasing it if it is fake.
+func (p *actionProgress) tick() {
+	p.Lock()
+	if !p.reported && p.progress < 99 {
+		p.progress++
+	}
+	description, progress := p.description, p.progress
+	p.Unlock()
+	p.tracker.UpdateProgress(proto.ActionResponseState_IN_PROGRESS, description, progress)
+}
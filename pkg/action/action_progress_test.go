@@ -0,0 +1,41 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+type recordingProgressTracker struct {
+	descriptions []string
+	progresses   []int32
+}
+
+func (p *recordingProgressTracker) UpdateProgress(actionState proto.ActionResponseState, description string,
+	progress int32) {
+	p.descriptions = append(p.descriptions, description)
+	p.progresses = append(p.progresses, progress)
+}
+
+func TestActionProgress(t *testing.T) {
+	tracker := &recordingProgressTracker{}
+	progress := newActionProgress(tracker)
+
+	// The fake progress grows until the executor reports the real one, which is then reported at each tick
+	progress.tick()
+	progress.tick()
+	progress.UpdateProgress(proto.ActionResponseState_IN_PROGRESS, "Rolling out", 40)
+	progress.tick()
+
+	expectedProgresses := []int32{1, 2, 40, 40}
+	expectedDescriptions := []string{"in progress", "in progress", "Rolling out", "Rolling out"}
+	if len(tracker.progresses) != len(expectedProgresses) {
+		t.Fatalf("expected %d progress updates but got %d", len(expectedProgresses), len(tracker.progresses))
+	}
+	for i := range expectedProgresses {
+		if tracker.progresses[i] != expectedProgresses[i] || tracker.descriptions[i] != expectedDescriptions[i] {
+			t.Errorf("update %d: expected %q %d but got %q %d", i, expectedDescriptions[i], expectedProgresses[i],
+				tracker.descriptions[i], tracker.progresses[i])
+		}
+	}
+}
@@ -1,7 +1,12 @@
 package executor
 
 import (
+	"fmt"
+
+	"github.com/golang/glog"
 	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	sdkprobe "github.com/turbonomic/turbo-go-sdk/pkg/probe"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 	api "k8s.io/api/core/v1"
 	kclient "k8s.io/client-go/kubernetes"
@@ -11,6 +16,8 @@ import (
 type TurboActionExecutorInput struct {
 	ActionItem *proto.ActionItemDTO
 	Pod        *api.Pod
+	// The tracker to report the progress of a long running action to, nil if the progress is not tracked
+	Progress sdkprobe.ActionProgressTracker
 }
 
 type TurboActionExecutorOutput struct {
@@ -24,15 +31,83 @@ type TurboActionExecutor interface {
 }
 
 type TurboK8sActionExecutor struct {
-	kubeClient *kclient.Clientset
-	cApiClient *clientset.Clientset
-	podManager util.IPodManager
+	kubeClient     *kclient.Clientset
+	cApiClient     *clientset.Clientset
+	podManager     util.IPodManager
+	clusterScraper *cluster.ClusterScraper
 }
 
-func NewTurboK8sActionExecutor(kubeClient *kclient.Clientset, cApiClient *clientset.Clientset, podManager util.IPodManager) TurboK8sActionExecutor {
+func NewTurboK8sActionExecutor(kubeClient *kclient.Clientset, cApiClient *clientset.Clientset, podManager util.IPodManager,
+	clusterScraper *cluster.ClusterScraper) TurboK8sActionExecutor {
 	return TurboK8sActionExecutor{
-		kubeClient: kubeClient,
-		cApiClient: cApiClient,
-		podManager: podManager,
+		kubeClient:     kubeClient,
+		cApiClient:     cApiClient,
+		podManager:     podManager,
+		clusterScraper: clusterScraper,
+	}
+}
+
+// reportProgress reports the progress of the action, in percentage, if it is tracked
+func reportProgress(tracker sdkprobe.ActionProgressTracker, description string, progress int32) {
+	if tracker == nil {
+		return
+	}
+	tracker.UpdateProgress(proto.ActionResponseState_IN_PROGRESS, description, progress)
+}
+
+// get k8s.node of the new hosting node
+func (e *TurboK8sActionExecutor) getNode(action *proto.ActionItemDTO) (*api.Node, error) {
+	//1. check host entity
+	hostSE := action.GetNewSE()
+	if hostSE == nil {
+		err := fmt.Errorf("New host entity is empty")
+		glog.Errorf("%v.", err)
+		return nil, err
+	}
+
+	//2. check entity type
+	etype := hostSE.GetEntityType()
+	if etype != proto.EntityDTO_VIRTUAL_MACHINE && etype != proto.EntityDTO_PHYSICAL_MACHINE {
+		err := fmt.Errorf("The destination [%v] is neither a VM nor a PM", etype)
+		glog.Errorf("%v.", err)
+		return nil, err
+	}
+
+	//3. get node from properties
+	node, err := util.GetNodeFromProperties(e.kubeClient, hostSE.GetEntityProperties())
+	if err == nil {
+		glog.V(2).Infof("Get node(%v) from properties.", node.Name)
+		return node, nil
+	}
+
+	//4. get node by displayName
+	node, err = util.GetNodebyName(e.kubeClient, hostSE.GetDisplayName())
+	if err == nil {
+		glog.V(2).Infof("Get node(%v) by displayName.", node.Name)
+		return node, nil
+	}
+
+	//5. get node by UUID
+	node, err = util.GetNodebyUUID(e.clusterScraper, hostSE.GetId())
+	if err == nil {
+		glog.V(2).Infof("Get node(%v) by UUID(%v).", node.Name, hostSE.GetId())
+		return node, nil
+	}
+
+	//6. get node by IP
+	vmIPs := getVMIps(hostSE)
+	if len(vmIPs) > 0 {
+		node, err = util.GetNodebyIP(e.clusterScraper, vmIPs)
+		if err == nil {
+			glog.V(2).Infof("Get node(%v) by IP.", hostSE.GetDisplayName())
+			return node, nil
+		}
+		err = fmt.Errorf("Failed to get node %s by IP %+v: %v",
+			hostSE.GetDisplayName(), vmIPs, err)
+	} else {
+		err = fmt.Errorf("Failed to get node %s: IPs are empty",
+			hostSE.GetDisplayName())
 	}
+	glog.Errorf("%v.", err)
+	return nil, err
 }
@@ -0,0 +1,198 @@
+package executor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kclient "k8s.io/client-go/kubernetes"
+)
+
+const (
+	// The clone pods younger than this are not collected, as their action may still be executed
+	// by another kubeturbo, e.g., during a rolling update of kubeturbo.
+	// It is longer than the wait for a clone pod to be ready.
+	defaultClonePodGCGracePeriod = time.Minute * 15
+)
+
+// The clone pods of the move and resize actions being executed, by namespace/name.
+var clonePodsInFlight = &clonePodRegistry{pods: make(map[string]struct{})}
+
+type clonePodRegistry struct {
+	pods map[string]struct{}
+	sync.Mutex
+}
+
+func (r *clonePodRegistry) add(pod *api.Pod) {
+	r.Lock()
+	defer r.Unlock()
+	r.pods[util.BuildIdentifier(pod.Namespace, pod.Name)] = struct{}{}
+}
+
+func (r *clonePodRegistry) remove(pod *api.Pod) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.pods, util.BuildIdentifier(pod.Namespace, pod.Name))
+}
+
+func (r *clonePodRegistry) contains(pod *api.Pod) bool {
+	r.Lock()
+	defer r.Unlock()
+	_, exists := r.pods[util.BuildIdentifier(pod.Namespace, pod.Name)]
+	return exists
+}
+
+// ClonePodReport is the verdict of the ClonePodCollector on a pod created by a move or resize action.
+type ClonePodReport struct {
+	// The namespace/name of the pod
+	Pod string
+	// Whether the pod is left behind by an interrupted action, and deleted unless in dry run
+	Leftover bool
+	Reason   string
+}
+
+// ClonePodCollector deletes the clone pods left behind by the move and resize actions interrupted before their
+// completion, e.g., when kubeturbo is restarted. Such a pod has no labels and no owner, so it serves nothing
+// and runs forever.
+// A clone pod is only deleted when it is certain that it is a leftover: when the pod it was cloned from is still
+// running, or when the controller of that pod has replaced it. The clone pod of a bare pod is otherwise kept, as
+// it may be the only copy of the pod. So is a clone pod which does not record the pod it was cloned from.
+type ClonePodCollector struct {
+	kubeClient     *kclient.Clientset
+	clusterScraper *cluster.ClusterScraper
+	gracePeriod    time.Duration
+	// Only report the leftover pods, without deleting them
+	dryRun bool
+}
+
+func NewClonePodCollector(kubeClient *kclient.Clientset, clusterScraper *cluster.ClusterScraper,
+	dryRun bool) *ClonePodCollector {
+	return &ClonePodCollector{
+		kubeClient:     kubeClient,
+		clusterScraper: clusterScraper,
+		gracePeriod:    defaultClonePodGCGracePeriod,
+		dryRun:         dryRun,
+	}
+}
+
+// Run collects the clone pods at every interval until the stop channel is closed.
+func (c *ClonePodCollector) Run(interval time.Duration, stopCh <-chan struct{}) {
+	glog.V(2).Infof("Start collecting the clone pods of the interrupted actions every %v, dry run: %v.",
+		interval, c.dryRun)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			glog.V(2).Infof("Stop collecting the clone pods.")
+			return
+		case <-ticker.C:
+			if _, err := c.Collect(); err != nil {
+				glog.Errorf("Failed to collect the clone pods: %v", err)
+			}
+		}
+	}
+}
+
+// Collect finds the pods created by the move and resize actions, and deletes the ones left behind by
+// interrupted actions, unless in dry run. It returns the verdict on each pod of an action not in progress.
+func (c *ClonePodCollector) Collect() ([]*ClonePodReport, error) {
+	pods, err := c.clusterScraper.GetAllPods()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods: %v", err)
+	}
+	podsByName := make(map[string]*api.Pod, len(pods))
+	for _, pod := range pods {
+		podsByName[util.BuildIdentifier(pod.Namespace, pod.Name)] = pod
+	}
+
+	var reports []*ClonePodReport
+	now := time.Now()
+	for _, pod := range pods {
+		if _, isClone := pod.Annotations[TurboActionAnnotationKey]; !isClone || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if clonePodsInFlight.contains(pod) || now.Sub(pod.CreationTimestamp.Time) < c.gracePeriod {
+			glog.V(4).Infof("Skip pod %s/%s, its action may be in progress.", pod.Namespace, pod.Name)
+			continue
+		}
+		report := checkClonePod(pod, podsByName)
+		reports = append(reports, report)
+		if !report.Leftover {
+			if _, recorded := pod.Annotations[TurboActionSourcePodAnnotationKey]; recorded {
+				glog.V(4).Infof("Keep pod %s: %s.", report.Pod, report.Reason)
+			} else {
+				glog.Warningf("Keep pod %s: %s.", report.Pod, report.Reason)
+			}
+			continue
+		}
+		if c.dryRun {
+			glog.V(1).Infof("[Dry run] Pod %s would be deleted: %s.", report.Pod, report.Reason)
+			continue
+		}
+		glog.V(1).Infof("Delete pod %s: %s.", report.Pod, report.Reason)
+		// The precondition makes sure that a pod of the same name created since the list is not deleted
+		uid := pod.UID
+		err := c.kubeClient.CoreV1().Pods(pod.Namespace).Delete(pod.Name,
+			&metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+		if err != nil {
+			glog.Errorf("Failed to delete the leftover pod %s: %v", report.Pod, err)
+		}
+	}
+	return reports, nil
+}
+
+// checkClonePod decides whether a pod created by a move or resize action is left behind by an interrupted action.
+// The actions create the clone pod without labels and owner, and add the labels of the original pod once it is
+// evicted, after which the controller of the pod adopts it.
+func checkClonePod(pod *api.Pod, podsByName map[string]*api.Pod) *ClonePodReport {
+	report := &ClonePodReport{Pod: util.BuildIdentifier(pod.Namespace, pod.Name)}
+	if len(pod.Labels) > 0 || len(pod.OwnerReferences) > 0 {
+		report.Reason = "the action is completed"
+		return report
+	}
+
+	// The pods cloned by older versions only have the action annotation. Their source pod cannot be told for
+	// sure from their name, so they are only reported.
+	sourceName, exists := pod.Annotations[TurboActionSourcePodAnnotationKey]
+	if !exists {
+		report.Reason = "the source pod of the clone pod is not recorded, it is left to be checked manually"
+		return report
+	}
+	// A pod of the same name may have replaced the source pod since, e.g., the pod of a StatefulSet,
+	// so the source pod is told by its uid.
+	source, sourceExists := podsByName[util.BuildIdentifier(pod.Namespace, sourceName)]
+	if sourceExists && source.DeletionTimestamp == nil {
+		sourceUID, exists := pod.Annotations[TurboActionSourcePodUIDAnnotationKey]
+		if !exists {
+			report.Reason = fmt.Sprintf("pod %s/%s exists, but the uid of the source pod is not recorded to tell "+
+				"if it is the source pod, it is left to be checked manually", pod.Namespace, sourceName)
+			return report
+		}
+		if string(source.UID) == sourceUID {
+			report.Leftover = true
+			report.Reason = fmt.Sprintf("the action was interrupted before the eviction of pod %s/%s",
+				pod.Namespace, sourceName)
+			return report
+		}
+	}
+
+	controller, exists := pod.Annotations[TurboActionSourceControllerAnnotationKey]
+	switch {
+	case !exists:
+		report.Reason = fmt.Sprintf("pod %s/%s is evicted, and it is unknown whether the clone pod is its only copy",
+			pod.Namespace, sourceName)
+	case controller == "":
+		report.Reason = fmt.Sprintf("the clone pod replaces the bare pod %s/%s", pod.Namespace, sourceName)
+	default:
+		report.Leftover = true
+		report.Reason = fmt.Sprintf("the action was interrupted after the eviction of pod %s/%s, which is replaced "+
+			"by its controller %s", pod.Namespace, sourceName, controller)
+	}
+	return report
+}
@@ -0,0 +1,133 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newClonePod(name string, annotations map[string]string) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns1",
+			Name:        name,
+			UID:         types.UID(name),
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestCheckClonePod(t *testing.T) {
+	source := newClonePod("web-1", nil)
+	now := metav1.NewTime(time.Now())
+	terminating := newClonePod("web-2", nil)
+	terminating.DeletionTimestamp = &now
+
+	completed := newClonePod("web-3-c", map[string]string{TurboActionAnnotationKey: TurboMoveAnnotationValue})
+	completed.Labels = map[string]string{"app": "web"}
+
+	table := []struct {
+		name     string
+		pod      *api.Pod
+		leftover bool
+	}{
+		{
+			name:     "completed action",
+			pod:      completed,
+			leftover: false,
+		},
+		{
+			name: "source pod still running",
+			pod: newClonePod("web-1-c", map[string]string{
+				TurboActionAnnotationKey:                 TurboMoveAnnotationValue,
+				TurboActionSourcePodAnnotationKey:        "web-1",
+				TurboActionSourcePodUIDAnnotationKey:     "web-1",
+				TurboActionSourceControllerAnnotationKey: "ReplicaSet/web",
+			}),
+			leftover: true,
+		},
+		{
+			name: "source pod replaced by a pod of the same name",
+			pod: newClonePod("web-1-c", map[string]string{
+				TurboActionAnnotationKey:                 TurboMoveAnnotationValue,
+				TurboActionSourcePodAnnotationKey:        "web-1",
+				TurboActionSourcePodUIDAnnotationKey:     "web-1-evicted",
+				TurboActionSourceControllerAnnotationKey: "",
+			}),
+			leftover: false,
+		},
+		{
+			name: "pod of the source name running without the source uid annotation",
+			pod: newClonePod("web-1-c", map[string]string{
+				TurboActionAnnotationKey:                 TurboMoveAnnotationValue,
+				TurboActionSourcePodAnnotationKey:        "web-1",
+				TurboActionSourceControllerAnnotationKey: "ReplicaSet/web",
+			}),
+			leftover: false,
+		},
+		{
+			name: "source pod still running without the source annotation",
+			pod: newClonePod("web-1-c", map[string]string{
+				TurboActionAnnotationKey: TurboResizeAnnotationValue,
+			}),
+			leftover: false,
+		},
+		{
+			name: "source pod terminating and replaced by its controller",
+			pod: newClonePod("web-2-c", map[string]string{
+				TurboActionAnnotationKey:                 TurboMoveAnnotationValue,
+				TurboActionSourcePodAnnotationKey:        "web-2",
+				TurboActionSourcePodUIDAnnotationKey:     "web-2",
+				TurboActionSourceControllerAnnotationKey: "ReplicaSet/web",
+			}),
+			leftover: true,
+		},
+		{
+			name: "source bare pod gone",
+			pod: newClonePod("bare-c", map[string]string{
+				TurboActionAnnotationKey:                 TurboMoveAnnotationValue,
+				TurboActionSourcePodAnnotationKey:        "bare",
+				TurboActionSourcePodUIDAnnotationKey:     "bare",
+				TurboActionSourceControllerAnnotationKey: "",
+			}),
+			leftover: false,
+		},
+		{
+			name: "source pod gone without the controller annotation",
+			pod: newClonePod("old-c", map[string]string{
+				TurboActionAnnotationKey:          TurboMoveAnnotationValue,
+				TurboActionSourcePodAnnotationKey: "old",
+			}),
+			leftover: false,
+		},
+	}
+
+	for _, item := range table {
+		podsByName := map[string]*api.Pod{}
+		for _, pod := range []*api.Pod{source, terminating, item.pod} {
+			podsByName[util.BuildIdentifier(pod.Namespace, pod.Name)] = pod
+		}
+		report := checkClonePod(item.pod, podsByName)
+		if report.Leftover != item.leftover {
+			t.Errorf("%s: expected leftover %v but got %v (%s)", item.name, item.leftover, report.Leftover,
+				report.Reason)
+		}
+	}
+}
+
+func TestClonePodRegistry(t *testing.T) {
+	registry := &clonePodRegistry{pods: make(map[string]struct{})}
+	pod := newClonePod("web-1-c", nil)
+	registry.add(pod)
+	if !registry.contains(pod) {
+		t.Errorf("expected pod %s in the registry", pod.Name)
+	}
+	registry.remove(pod)
+	if registry.contains(pod) {
+		t.Errorf("expected pod %s removed from the registry", pod.Name)
+	}
+}
@@ -18,4 +18,15 @@ const (
 	TurboActionAnnotationKey   string = "kubeturbo.io/action"
 	TurboMoveAnnotationValue   string = "move"
 	TurboResizeAnnotationValue string = "resize"
+	// The name and the uid of the pod cloned by the action, and the kind/name of its controller, empty for a bare pod
+	TurboActionSourcePodAnnotationKey        string = "kubeturbo.io/action-source-pod"
+	TurboActionSourcePodUIDAnnotationKey     string = "kubeturbo.io/action-source-pod-uid"
+	TurboActionSourceControllerAnnotationKey string = "kubeturbo.io/action-source-controller"
+	// The "min,max" replica bounds of a HorizontalPodAutoscaler before they were first adjusted by an action
+	TurboHPAOriginalBoundsAnnotationKey string = "kubeturbo.io/hpa-original-bounds"
+
+	// The label of the nodes cordoned and drained by the suspend actions when the Cluster API is not enabled,
+	// for an external tool or an operator to terminate them
+	TurboDrainedNodeLabelKey   string = "kubeturbo.io/drained"
+	TurboDrainedNodeLabelValue string = "true"
 )
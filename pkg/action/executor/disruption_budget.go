@@ -0,0 +1,72 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	podutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+	api "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kclient "k8s.io/client-go/kubernetes"
+)
+
+// checkPodDisruptionBudgets checks the PodDisruptionBudgets protecting the pod before disrupting it,
+// so that an action fails early instead of taking down a pod that the budgets require to stay healthy.
+// The budgets are read from the cluster cache when it has synced them.
+func checkPodDisruptionBudgets(clusterScraper *cluster.ClusterScraper, pod *api.Pod) error {
+	pdbs, err := clusterScraper.GetPodDisruptionBudgets(pod.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list the PodDisruptionBudgets of namespace %s: %v", pod.Namespace, err)
+	}
+	return podutil.CheckPodDisruptionAllowed(pod, podutil.GetPodDisruptionBudgetsForPod(pod, pdbs))
+}
+
+// evictPod deletes the pod through the Eviction API, which refuses the deletion if it would
+// violate a PodDisruptionBudget of the pod. The call does not block.
+func evictPod(client *kclient.Clientset, pod *api.Pod) error {
+	eviction := &policy.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+		DeleteOptions: &metav1.DeleteOptions{},
+	}
+	var err error
+	if goutil.ServesPolicyV1(client.Discovery()) {
+		err = evictV1(client, eviction)
+	} else {
+		err = client.PolicyV1beta1().Evictions(pod.Namespace).Evict(eviction)
+	}
+	if err == nil {
+		glog.V(3).Infof("Evicted pod %s/%s", pod.Namespace, pod.Name)
+		return nil
+	}
+	if errors.IsTooManyRequests(err) {
+		return fmt.Errorf("eviction of pod %s/%s is refused as it would violate its PodDisruptionBudget: %v",
+			pod.Namespace, pod.Name, err)
+	}
+	return fmt.Errorf("failed to evict pod %s/%s: %v", pod.Namespace, pod.Name, err)
+}
+
+// evictV1 posts the eviction through policy/v1. This version of the client has no policy/v1 types,
+// so the eviction is encoded from the policy/v1beta1 type, which has the same schema.
+func evictV1(client *kclient.Clientset, eviction *policy.Eviction) error {
+	eviction = eviction.DeepCopy()
+	eviction.APIVersion = goutil.PolicyV1GroupVersion
+	eviction.Kind = "Eviction"
+	body, err := json.Marshal(eviction)
+	if err != nil {
+		return err
+	}
+	return client.CoreV1().RESTClient().Post().
+		Namespace(eviction.Namespace).Resource("pods").Name(eviction.Name).SubResource("eviction").
+		SetHeader("Content-Type", runtime.ContentTypeJSON).
+		Body(body).
+		Do().Error()
+}
@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestEvictPod(t *testing.T) {
+	table := []struct {
+		minor      string
+		apiVersion string
+	}{
+		{minor: "21", apiVersion: "policy/v1beta1"},
+		{minor: "22", apiVersion: "policy/v1"},
+	}
+	for _, item := range table {
+		var evictions []*policy.Eviction
+		refuse := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/version":
+				fmt.Fprintf(w, `{"major":"1","minor":%q,"gitVersion":"v1.%s.0"}`, item.minor, item.minor)
+			case "/api/v1/namespaces/ns1/pods/web-1/eviction":
+				eviction := &policy.Eviction{}
+				if err := json.NewDecoder(r.Body).Decode(eviction); err != nil {
+					t.Errorf("Invalid eviction: %v", err)
+				}
+				evictions = append(evictions, eviction)
+				if refuse {
+					w.WriteHeader(http.StatusTooManyRequests)
+					fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"TooManyRequests",`+
+						`"message":"Cannot evict pod as it would violate the pod's disruption budget.","code":429}`)
+					return
+				}
+				fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Success","code":201}`)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		if err != nil {
+			server.Close()
+			t.Fatalf("Failed to create kube client: %v", err)
+		}
+		pod := &api.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "ns1"}}
+
+		if err := evictPod(kubeClient, pod); err != nil {
+			t.Errorf("1.%s: failed to evict the pod: %v", item.minor, err)
+		}
+		refuse = true
+		if err := evictPod(kubeClient, pod); err == nil || !strings.Contains(err.Error(), "PodDisruptionBudget") {
+			t.Errorf("1.%s: expected the eviction to be refused for the PodDisruptionBudget, got %v", item.minor, err)
+		}
+		server.Close()
+
+		if len(evictions) != 2 {
+			t.Fatalf("1.%s: expected 2 evictions, got %d", item.minor, len(evictions))
+		}
+		for _, eviction := range evictions {
+			if eviction.APIVersion != item.apiVersion || eviction.Name != pod.Name {
+				t.Errorf("1.%s: expected the eviction of pod %s through %s, got %s of pod %s", item.minor,
+					pod.Name, item.apiVersion, eviction.APIVersion, eviction.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	podutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	"github.com/turbonomic/kubeturbo/pkg/util"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kclient "k8s.io/client-go/kubernetes"
+)
+
+// getHorizontalPodAutoscaler returns the HorizontalPodAutoscaler scaling the controller of the given kind and name,
+// or nil if the controller is not autoscaled. The autoscalers are read from the cluster cache when it has synced them.
+func getHorizontalPodAutoscaler(clusterScraper *cluster.ClusterScraper, namespace, kind, name string) (
+	*autoscaling.HorizontalPodAutoscaler, error) {
+	hpas, err := clusterScraper.GetHorizontalPodAutoscalers(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the HorizontalPodAutoscalers of namespace %s: %v", namespace, err)
+	}
+	return podutil.GetHorizontalPodAutoscalerForController(namespace, kind, name, hpas), nil
+}
+
+// scaleHorizontalPodAutoscalerWithRetry scales the target of a HorizontalPodAutoscaler by the replica diff
+// by adjusting its replica bounds, with retry and timeout.
+func scaleHorizontalPodAutoscalerWithRetry(client *kclient.Clientset, namespace, name string, current, diff int32) error {
+	retryNum := defaultRetryLess
+	interval := defaultUpdateReplicaSleep
+	timeout := time.Duration(retryNum+1) * interval
+	return util.RetryDuring(retryNum, timeout, interval, func() error {
+		hpaClient := client.AutoscalingV1().HorizontalPodAutoscalers(namespace)
+		hpa, err := hpaClient.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if err := setHorizontalPodAutoscalerBounds(hpa, current, diff); err != nil {
+			return err
+		}
+		if _, err := hpaClient.Update(hpa); err != nil {
+			return err
+		}
+		glog.V(2).Infof("Successfully updated the replica bounds of HorizontalPodAutoscaler %s/%s to [%d, %d]",
+			namespace, name, *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+		return nil
+	})
+}
+
+// setHorizontalPodAutoscalerBounds updates the replica bounds of a HorizontalPodAutoscaler in place, so that it
+// scales its target from the current replicas by the diff: a provision raises the minimum to the new number of
+// replicas, and a suspension lowers the maximum to it. The other bound is set back to its original value, recorded
+// in an annotation by the first update, unless it is in the way. Hence the range of the autoscaler does not
+// shrink with every action.
+// Unlike a suspension through the controller, the pod which is removed is chosen by the controller.
+func setHorizontalPodAutoscalerBounds(hpa *autoscaling.HorizontalPodAutoscaler, current, diff int32) error {
+	result := current + diff
+	glog.V(4).Infof("Current replica %d, diff %d, result %d.", current, diff, result)
+	if result < 1 {
+		return fmt.Errorf("resulting replica is less than 1 after suspension")
+	}
+	minReplicas, maxReplicas := originalHorizontalPodAutoscalerBounds(hpa)
+	if diff > 0 {
+		minReplicas = result
+		if maxReplicas < result {
+			maxReplicas = result
+		}
+	} else {
+		maxReplicas = result
+		if minReplicas > result {
+			minReplicas = result
+		}
+	}
+	glog.V(2).Infof("Try to update the replica bounds of HorizontalPodAutoscaler %s/%s from [%d, %d] to [%d, %d]",
+		hpa.Namespace, hpa.Name, podutil.HorizontalPodAutoscalerMinReplicas(hpa), hpa.Spec.MaxReplicas,
+		minReplicas, maxReplicas)
+	hpa.Spec.MinReplicas = &minReplicas
+	hpa.Spec.MaxReplicas = maxReplicas
+	return nil
+}
+
+// originalHorizontalPodAutoscalerBounds returns the replica bounds of a HorizontalPodAutoscaler before they were
+// adjusted by an action. They are recorded in an annotation of the autoscaler if they are not yet.
+func originalHorizontalPodAutoscalerBounds(hpa *autoscaling.HorizontalPodAutoscaler) (int32, int32) {
+	if value, exists := hpa.Annotations[TurboHPAOriginalBoundsAnnotationKey]; exists {
+		var minReplicas, maxReplicas int32
+		if _, err := fmt.Sscanf(value, "%d,%d", &minReplicas, &maxReplicas); err == nil {
+			return minReplicas, maxReplicas
+		}
+		glog.Warningf("Invalid original replica bounds %q of HorizontalPodAutoscaler %s/%s, replacing them by "+
+			"the current bounds", value, hpa.Namespace, hpa.Name)
+	}
+	minReplicas := podutil.HorizontalPodAutoscalerMinReplicas(hpa)
+	maxReplicas := hpa.Spec.MaxReplicas
+	if hpa.Annotations == nil {
+		hpa.Annotations = make(map[string]string)
+	}
+	hpa.Annotations[TurboHPAOriginalBoundsAnnotationKey] = fmt.Sprintf("%d,%d", minReplicas, maxReplicas)
+	return minReplicas, maxReplicas
+}
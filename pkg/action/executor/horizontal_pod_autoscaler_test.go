@@ -0,0 +1,157 @@
+package executor
+
+import (
+	"testing"
+
+	autoscaling "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetHorizontalPodAutoscalerBounds(t *testing.T) {
+	table := []struct {
+		name        string
+		minReplicas *int32
+		maxReplicas int32
+		original    string
+		current     int32
+		diff        int32
+		err         bool
+		expectedMin int32
+		expectedMax int32
+	}{
+		{
+			name:        "provision raises the minimum",
+			minReplicas: int32Ptr(2),
+			maxReplicas: 10,
+			current:     3,
+			diff:        1,
+			expectedMin: 4,
+			expectedMax: 10,
+		},
+		{
+			name:        "provision raises the maximum",
+			minReplicas: int32Ptr(2),
+			maxReplicas: 3,
+			current:     3,
+			diff:        1,
+			expectedMin: 4,
+			expectedMax: 4,
+		},
+		{
+			name:        "suspension lowers the maximum",
+			maxReplicas: 10,
+			current:     3,
+			diff:        -1,
+			expectedMin: 1,
+			expectedMax: 2,
+		},
+		{
+			name:        "suspension lowers the minimum",
+			minReplicas: int32Ptr(3),
+			maxReplicas: 10,
+			current:     3,
+			diff:        -1,
+			expectedMin: 2,
+			expectedMax: 2,
+		},
+		{
+			name:        "provision restores the original maximum",
+			minReplicas: int32Ptr(2),
+			maxReplicas: 2,
+			original:    "1,10",
+			current:     2,
+			diff:        1,
+			expectedMin: 3,
+			expectedMax: 10,
+		},
+		{
+			name:        "suspension restores the original minimum",
+			minReplicas: int32Ptr(4),
+			maxReplicas: 10,
+			original:    "2,10",
+			current:     4,
+			diff:        -1,
+			expectedMin: 2,
+			expectedMax: 3,
+		},
+		{
+			name:        "invalid original bounds",
+			minReplicas: int32Ptr(2),
+			maxReplicas: 10,
+			original:    "invalid",
+			current:     3,
+			diff:        1,
+			expectedMin: 4,
+			expectedMax: 10,
+		},
+		{
+			name:        "suspension of the last replica",
+			maxReplicas: 10,
+			current:     1,
+			diff:        -1,
+			err:         true,
+		},
+	}
+	for _, item := range table {
+		hpa := newHorizontalPodAutoscaler(item.minReplicas, item.maxReplicas)
+		if item.original != "" {
+			hpa.Annotations = map[string]string{TurboHPAOriginalBoundsAnnotationKey: item.original}
+		}
+		err := setHorizontalPodAutoscalerBounds(hpa, item.current, item.diff)
+		if item.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", item.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", item.name, err)
+			continue
+		}
+		if *hpa.Spec.MinReplicas != item.expectedMin || hpa.Spec.MaxReplicas != item.expectedMax {
+			t.Errorf("%s: expected the replica bounds [%d, %d] but got [%d, %d]", item.name,
+				item.expectedMin, item.expectedMax, *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+		}
+	}
+}
+
+func TestSetHorizontalPodAutoscalerBoundsRepeatedly(t *testing.T) {
+	hpa := newHorizontalPodAutoscaler(int32Ptr(2), 10)
+	// Provisions and suspensions one after the other do not shrink the range of the autoscaler
+	for i, step := range []struct {
+		current     int32
+		diff        int32
+		expectedMin int32
+		expectedMax int32
+	}{
+		{3, 1, 4, 10},
+		{4, -1, 2, 3},
+		{3, 1, 4, 10},
+		{4, -1, 2, 3},
+	} {
+		if err := setHorizontalPodAutoscalerBounds(hpa, step.current, step.diff); err != nil {
+			t.Fatalf("step %d: unexpected error %v", i, err)
+		}
+		if *hpa.Spec.MinReplicas != step.expectedMin || hpa.Spec.MaxReplicas != step.expectedMax {
+			t.Errorf("step %d: expected the replica bounds [%d, %d] but got [%d, %d]", i,
+				step.expectedMin, step.expectedMax, *hpa.Spec.MinReplicas, hpa.Spec.MaxReplicas)
+		}
+	}
+	if original := hpa.Annotations[TurboHPAOriginalBoundsAnnotationKey]; original != "2,10" {
+		t.Errorf("expected the original replica bounds 2,10 but got %q", original)
+	}
+}
+
+func newHorizontalPodAutoscaler(minReplicas *int32, maxReplicas int32) *autoscaling.HorizontalPodAutoscaler {
+	return &autoscaling.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "web-hpa"},
+		Spec: autoscaling.HorizontalPodAutoscalerSpec{
+			MinReplicas: minReplicas,
+			MaxReplicas: maxReplicas,
+		},
+	}
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
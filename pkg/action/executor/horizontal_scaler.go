@@ -5,16 +5,24 @@ import (
 	"github.com/golang/glog"
 
 	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	podutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	api "k8s.io/api/core/v1"
 )
 
 type HorizontalScaler struct {
 	TurboK8sActionExecutor
+	// Scale the controllers managed by a HorizontalPodAutoscaler through the replica bounds of the autoscaler,
+	// otherwise the actions on their pods fail
+	scaleThroughHPA bool
 }
 
-func NewHorizontalScaler(ae TurboK8sActionExecutor) *HorizontalScaler {
+func NewHorizontalScaler(ae TurboK8sActionExecutor, scaleThroughHPA bool) *HorizontalScaler {
 	return &HorizontalScaler{
 		TurboK8sActionExecutor: ae,
+		scaleThroughHPA:        scaleThroughHPA,
 	}
 }
 
@@ -34,15 +42,197 @@ func (h *HorizontalScaler) Execute(input *TurboActionExecutorInput) (*TurboActio
 		glog.Errorf("Failed to create controllerUpdater: %v", err)
 		return &TurboActionExecutorOutput{}, err
 	}
-	//3. Execute the action to update replica diff of the controller
-	err = controllerUpdater.updateWithRetry(&controllerSpec{replicasDiff: diff})
+	//3. Execute the action to update replica diff of the controller, or of its HorizontalPodAutoscaler
+	// which would revert the change of the controller otherwise
+	hpa, err := getHorizontalPodAutoscaler(h.clusterScraper, pod.Namespace, controllerUpdater.kind, controllerUpdater.name)
+	if err != nil {
+		glog.Errorf("Failed to scale %s: %v", pod.Name, err)
+		return &TurboActionExecutorOutput{}, err
+	}
+	var npod *api.Pod
+	if diff > 0 {
+		npod, err = h.provisionPod(controllerUpdater, hpa, pod, actionItem)
+	} else if controllerUpdater.kind == goutil.KindStatefulSet {
+		err = h.suspendStatefulSetPod(controllerUpdater, hpa, pod)
+	} else {
+		err = h.suspendPod(controllerUpdater, hpa, pod)
+	}
 	if err != nil {
 		glog.Errorf("Failed to scale %s: %v", pod.Name, err)
 		return &TurboActionExecutorOutput{}, err
 	}
 	podFullName := util.BuildIdentifier(pod.Namespace, pod.Name)
 	glog.V(2).Infof("Action HorizontalScale for pod[%v] succeeded.", podFullName)
-	return &TurboActionExecutorOutput{Succeeded: true}, nil
+	return &TurboActionExecutorOutput{Succeeded: true, NewPod: npod}, nil
+}
+
+// scaleHorizontalPodAutoscaler scales the controller by adjusting the replica bounds of its HorizontalPodAutoscaler,
+// if allowed, from the current replicas of the controller.
+func (h *HorizontalScaler) scaleHorizontalPodAutoscaler(controllerUpdater *k8sControllerUpdater,
+	hpa *autoscaling.HorizontalPodAutoscaler, diff int32) error {
+	if !h.scaleThroughHPA {
+		return fmt.Errorf("%v %s/%s is scaled by HorizontalPodAutoscaler %s, which would revert the change "+
+			"of its replicas", controllerUpdater.controller, controllerUpdater.namespace, controllerUpdater.name,
+			podutil.HorizontalPodAutoscalerName(hpa))
+	}
+	current, err := controllerUpdater.controller.get(controllerUpdater.name)
+	if err != nil {
+		return fmt.Errorf("failed to get %v %s/%s: %v", controllerUpdater.controller,
+			controllerUpdater.namespace, controllerUpdater.name, err)
+	}
+	replicas := int32(1)
+	if current.replicas != nil {
+		replicas = *current.replicas
+	}
+	return scaleHorizontalPodAutoscalerWithRetry(h.kubeClient, hpa.Namespace, hpa.Name, replicas, diff)
+}
+
+// suspendPod scales down the controller of the pod, directly or through its HorizontalPodAutoscaler, for the
+// controller to remove the pod, and waits until the pod is deleted. On clusters supporting the pod deletion cost,
+// the pod gets the lowest cost first, so that the ReplicaSet controller removes it rather than another pod, and
+// the cost is removed if the pod survives. On older clusters, the pod is evicted when the controller is scaled down
+// directly, which races with the ReplicaSet controller recreating it, while a scale down through the
+// HorizontalPodAutoscaler may remove any pod.
+func (h *HorizontalScaler) suspendPod(controllerUpdater *k8sControllerUpdater,
+	hpa *autoscaling.HorizontalPodAutoscaler, pod *api.Pod) error {
+	deletionCostSet := supportsPodDeletionCost(h.kubeClient)
+	if deletionCostSet {
+		// The pod is removed by the ReplicaSet controller instead of evicted, which does not honor the budgets
+		if err := checkPodDisruptionBudgets(h.clusterScraper, pod); err != nil {
+			return err
+		}
+		if err := setPodDeletionCost(h.kubeClient, pod); err != nil {
+			return err
+		}
+	}
+	var err error
+	if hpa != nil {
+		err = h.scaleHorizontalPodAutoscaler(controllerUpdater, hpa, -1)
+	} else {
+		err = controllerUpdater.updateWithRetry(&controllerSpec{replicasDiff: -1, deletionCostSet: deletionCostSet})
+	}
+	if err == nil && (deletionCostSet || hpa == nil) {
+		glog.V(2).Infof("Wait for pod %s/%s of %v %s to be deleted.", pod.Namespace, pod.Name,
+			controllerUpdater.controller, controllerUpdater.name)
+		if err = waitForPodDeleted(h.kubeClient, pod.Namespace, pod.Name, pod.UID, defaultRetryMore,
+			defaultPodCheckSleep); err != nil {
+			err = fmt.Errorf("%v %s/%s did not remove pod %s: %v", controllerUpdater.controller,
+				controllerUpdater.namespace, controllerUpdater.name, pod.Name, err)
+		}
+	}
+	if err != nil && deletionCostSet {
+		removePodDeletionCost(h.kubeClient, pod)
+	}
+	return err
+}
+
+// provisionPod scales up the controller of the pod, directly or through its HorizontalPodAutoscaler.
+// Without a destination, it returns once the new replicas are accepted, with no new pod. With a destination,
+// the pod template of the controller prefers the destination node until the new pod is scheduled, so that the pod
+// lands there unless the node does not fit it, and it waits until the new pod is ready.
+// It returns the new pod, which tells the node where the pod actually landed.
+func (h *HorizontalScaler) provisionPod(controllerUpdater *k8sControllerUpdater,
+	hpa *autoscaling.HorizontalPodAutoscaler, pod *api.Pod, action *proto.ActionItemDTO) (*api.Pod, error) {
+	if action.GetNewSE() == nil {
+		return nil, h.scaleUp(controllerUpdater, hpa)
+	}
+	node, err := h.getNode(action)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the destination of the new pod: %v", err)
+	}
+	hostname, exists := node.Labels[nodeHostnameLabel]
+	if !exists {
+		return nil, fmt.Errorf("node %s has no %s label to steer the new pod to", node.Name, nodeHostnameLabel)
+	}
+	c, err := newTemplateConstraint(h.kubeClient, pod, node)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := c.listControllerPods()
+	if err != nil {
+		return nil, err
+	}
+	// The new pod of a StatefulSet is the pod of the next ordinal
+	newPodName := ""
+	if controllerUpdater.kind == goutil.KindStatefulSet {
+		replicas, err := getControllerReplicas(controllerUpdater)
+		if err != nil {
+			return nil, err
+		}
+		newPodName = statefulSetPodName(controllerUpdater.name, replicas)
+	}
+
+	//1. prefer the destination in the pod template of the controller
+	if err := c.constrain(hostname, preferAffinityToNode); err != nil {
+		c.restore(nil)
+		return nil, err
+	}
+
+	//2. scale up the controller, and wait until the new pod is scheduled
+	err = h.scaleUp(controllerUpdater, hpa)
+	var npod *api.Pod
+	if err == nil {
+		npod, err = c.waitForNewPod(existing, newPodName, defaultRetryMore)
+	}
+
+	//3. restore the pod template, whether the pod is created or not
+	if restoreErr := c.restore(npod); restoreErr != nil && err == nil {
+		err = restoreErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if npod.Spec.NodeName != node.Name {
+		glog.Warningf("New pod %s/%s of %v %s landed on node %s instead of the destination %s.", npod.Namespace,
+			npod.Name, controllerUpdater.controller, controllerUpdater.name, npod.Spec.NodeName, node.Name)
+	}
+
+	//4. wait until the new pod is ready
+	glog.V(2).Infof("Wait for pod %s/%s of %v %s to be ready on node %s.", npod.Namespace, npod.Name,
+		controllerUpdater.controller, controllerUpdater.name, npod.Spec.NodeName)
+	if err := podutil.WaitForPodReady(h.kubeClient, npod.Namespace, npod.Name, npod.Spec.NodeName,
+		defaultRetryMore, defaultPodCreateSleep); err != nil {
+		return nil, err
+	}
+	return npod, nil
+}
+
+// scaleUp adds a replica to the controller, directly or through its HorizontalPodAutoscaler.
+func (h *HorizontalScaler) scaleUp(controllerUpdater *k8sControllerUpdater,
+	hpa *autoscaling.HorizontalPodAutoscaler) error {
+	if hpa != nil {
+		return h.scaleHorizontalPodAutoscaler(controllerUpdater, hpa, 1)
+	}
+	return controllerUpdater.updateWithRetry(&controllerSpec{replicasDiff: 1})
+}
+
+// suspendStatefulSetPod scales down the StatefulSet of the pod, directly or through its HorizontalPodAutoscaler,
+// and waits until the pod is deleted. The suspension is refused unless the pod is the one of the highest ordinal,
+// which the StatefulSet removes, and its removal is allowed by its PodDisruptionBudgets.
+func (h *HorizontalScaler) suspendStatefulSetPod(controllerUpdater *k8sControllerUpdater,
+	hpa *autoscaling.HorizontalPodAutoscaler, pod *api.Pod) error {
+	replicas, err := getControllerReplicas(controllerUpdater)
+	if err != nil {
+		return err
+	}
+	if _, err := scaleStatefulSetReplicas(controllerUpdater.name, pod.Name, replicas, -1); err != nil {
+		return err
+	}
+	// The pod is removed by the StatefulSet controller instead of evicted, which does not honor the budgets
+	if err := checkPodDisruptionBudgets(h.clusterScraper, pod); err != nil {
+		return err
+	}
+	if hpa != nil {
+		err = h.scaleHorizontalPodAutoscaler(controllerUpdater, hpa, -1)
+	} else {
+		err = controllerUpdater.updateWithRetry(&controllerSpec{replicasDiff: -1})
+	}
+	if err != nil {
+		return err
+	}
+	glog.V(2).Infof("Wait for pod %s/%s of %v %s to be deleted.", pod.Namespace, pod.Name,
+		controllerUpdater.controller, controllerUpdater.name)
+	return waitForPodDeleted(h.kubeClient, pod.Namespace, pod.Name, pod.UID, defaultRetryMore, defaultPodCheckSleep)
 }
 
 func getReplicaDiff(action *proto.ActionItemDTO) (int32, error) {
@@ -57,3 +247,17 @@ func getReplicaDiff(action *proto.ActionItemDTO) (int32, error) {
 		return 0, fmt.Errorf("action %v is not a scaling action", atype.String())
 	}
 }
+
+// getControllerReplicas returns the current replicas of the controller, which default to 1
+func getControllerReplicas(controllerUpdater *k8sControllerUpdater) (int32, error) {
+	current, err := controllerUpdater.controller.get(controllerUpdater.name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %v %s/%s: %v", controllerUpdater.controller,
+			controllerUpdater.namespace, controllerUpdater.name, err)
+	}
+	replicas := int32(1)
+	if current.replicas != nil {
+		replicas = *current.replicas
+	}
+	return replicas, nil
+}
@@ -0,0 +1,184 @@
+package executor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	apiappsv1 "k8s.io/api/apps/v1"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// deletionCostServer is an API server of Kubernetes 1.22 serving a Deployment with 3 replicas and one of its pods.
+// Like a 1.22 server, it does not serve the ReplicaSets and Deployments of extensions/v1beta1 and apps/v1beta1,
+// and the PodDisruptionBudgets are read through policy/v1.
+// The pod is deleted when the Deployment is scaled down, as the ReplicaSet controller would do.
+type deletionCostServer struct {
+	sync.Mutex
+	pod        *api.Pod
+	rs         *apiappsv1.ReplicaSet
+	dep        *apiappsv1.Deployment
+	podDeleted bool
+	evicted    bool
+}
+
+func newDeletionCostServer() *deletionCostServer {
+	replicas := int32(3)
+	isController := true
+	dep := &apiappsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns1", UID: "dep-uid"},
+		Spec:       apiappsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	rs := &apiappsv1.ReplicaSet{
+		TypeMeta: metav1.TypeMeta{Kind: "ReplicaSet", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web-5d8f", Namespace: "ns1", UID: "rs-uid",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web", UID: "dep-uid",
+				Controller: &isController}},
+		},
+	}
+	pod := &api.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web-5d8f-x1", Namespace: "ns1", UID: "pod-uid",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d8f", UID: "rs-uid",
+				Controller: &isController}},
+		},
+	}
+	return &deletionCostServer{pod: pod, rs: rs, dep: dep}
+}
+
+func (s *deletionCostServer) start(t *testing.T) (*kubernetes.Clientset, *httptest.Server) {
+	writeJSON := func(w http.ResponseWriter, obj interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(obj)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, &version.Info{Major: "1", Minor: "22", GitVersion: "v1.22.0"})
+	})
+	mux.HandleFunc("/api/v1/namespaces/ns1/pods/web-5d8f-x1", func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		defer s.Unlock()
+		if s.podDeleted {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodPatch {
+			body, _ := ioutil.ReadAll(r.Body)
+			patch := &api.Pod{}
+			if err := json.Unmarshal(body, patch); err != nil {
+				t.Errorf("Invalid patch of the pod %s: %v", body, err)
+			}
+			if patch.UID != s.pod.UID {
+				t.Errorf("The patch of the pod is not limited to its uid: %s", body)
+			}
+			s.pod.Annotations = patch.Annotations
+		}
+		writeJSON(w, s.pod)
+	})
+	mux.HandleFunc("/api/v1/namespaces/ns1/pods/web-5d8f-x1/eviction", func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		defer s.Unlock()
+		s.evicted = true
+		writeJSON(w, &metav1.Status{Status: metav1.StatusSuccess})
+	})
+	mux.HandleFunc("/apis/apps/v1/namespaces/ns1/replicasets/web-5d8f", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.rs)
+	})
+	mux.HandleFunc("/apis/apps/v1/namespaces/ns1/deployments/web", func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		defer s.Unlock()
+		if r.Method == http.MethodPut {
+			dep := &apiappsv1.Deployment{}
+			if err := json.NewDecoder(r.Body).Decode(dep); err != nil {
+				t.Errorf("Invalid update of the Deployment: %v", err)
+			}
+			s.dep = dep
+			s.podDeleted = *dep.Spec.Replicas < 3
+		}
+		writeJSON(w, s.dep)
+	})
+	mux.HandleFunc("/apis/autoscaling/v1/namespaces/ns1/horizontalpodautoscalers", func(w http.ResponseWriter,
+		r *http.Request) {
+		writeJSON(w, map[string]interface{}{"kind": "HorizontalPodAutoscalerList", "apiVersion": "autoscaling/v1",
+			"items": []interface{}{}})
+	})
+	mux.HandleFunc("/apis/policy/v1/namespaces/ns1/poddisruptionbudgets", func(w http.ResponseWriter,
+		r *http.Request) {
+		writeJSON(w, map[string]interface{}{"kind": "PodDisruptionBudgetList", "apiVersion": "policy/v1",
+			"items": []interface{}{}})
+	})
+	server := httptest.NewServer(mux)
+
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		server.Close()
+		t.Fatalf("Failed to create kube client: %v", err)
+	}
+	return kubeClient, server
+}
+
+func TestSuspendPodWithDeletionCost(t *testing.T) {
+	s := newDeletionCostServer()
+	kubeClient, server := s.start(t)
+	defer server.Close()
+
+	scaler := NewHorizontalScaler(NewTurboK8sActionExecutor(kubeClient, nil, nil,
+		cluster.NewClusterScraper(kubeClient)), false)
+	actionType := proto.ActionItemDTO_SUSPEND
+	output, err := scaler.Execute(&TurboActionExecutorInput{
+		ActionItem: &proto.ActionItemDTO{ActionType: &actionType},
+		Pod:        s.pod.DeepCopy(),
+	})
+	if err != nil || !output.Succeeded {
+		t.Fatalf("Failed to suspend the pod: %v", err)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if cost := s.pod.Annotations[podDeletionCostAnnotation]; cost != podDeletionCostLowest {
+		t.Errorf("Expected the deletion cost %s of the pod, got %q", podDeletionCostLowest, cost)
+	}
+	if replicas := *s.dep.Spec.Replicas; replicas != 2 {
+		t.Errorf("Expected the Deployment to be scaled down to 2 replicas, got %d", replicas)
+	}
+	if s.evicted {
+		t.Errorf("The pod should be removed by the ReplicaSet controller instead of evicted")
+	}
+}
+
+func TestProvisionPodWithoutDestination(t *testing.T) {
+	s := newDeletionCostServer()
+	kubeClient, server := s.start(t)
+	defer server.Close()
+
+	// The server does not list the pods, so the provision fails if it waits for the new pod
+	scaler := NewHorizontalScaler(NewTurboK8sActionExecutor(kubeClient, nil, nil,
+		cluster.NewClusterScraper(kubeClient)), false)
+	actionType := proto.ActionItemDTO_PROVISION
+	output, err := scaler.Execute(&TurboActionExecutorInput{
+		ActionItem: &proto.ActionItemDTO{ActionType: &actionType},
+		Pod:        s.pod.DeepCopy(),
+	})
+	if err != nil || !output.Succeeded {
+		t.Fatalf("Failed to provision the pod: %v", err)
+	}
+	if output.NewPod != nil {
+		t.Errorf("Expected no new pod without a destination, got %s", output.NewPod.Name)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	if replicas := *s.dep.Spec.Replicas; replicas != 4 {
+		t.Errorf("Expected the Deployment to be scaled up to 4 replicas, got %d", replicas)
+	}
+}
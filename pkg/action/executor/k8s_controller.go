@@ -1,13 +1,12 @@
 package executor
 
 import (
-	apiappsv1beta1 "k8s.io/api/apps/v1beta1"
+	apiappsv1 "k8s.io/api/apps/v1"
 	apicorev1 "k8s.io/api/core/v1"
-	apiextv1beta1 "k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	typedappsv1beta1 "k8s.io/client-go/kubernetes/typed/apps/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
+	typedappsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
-	typedextv1beta1 "k8s.io/client-go/kubernetes/typed/extensions/v1beta1"
 )
 
 // k8sController defines a common interface for kubernetes controller actions
@@ -15,18 +14,26 @@ import (
 // - ReplicationController
 // - ReplicaSet
 // - Deployment
+// - StatefulSet
+// - DaemonSet
 type k8sController interface {
 	get(name string) (*k8sControllerSpec, error)
 	update() error
 }
 
 // k8sControllerSpec defines a set of objects that we want to update:
-// - replicas: The replicas of a controller to update for horizontal scale
+// - replicas: The replicas of a controller to update for horizontal scale, nil for a DaemonSet
 // - podSpec: The pod template of a controller to update for consistent resize
+// - replacesPods: Whether the existing pods are replaced with the updated pod template, by update strategy
+// - replacedPods: The number of the existing pods replaced with the updated pod template
+// - selector: The selector of the pods of a controller, which unlike the labels of a pod matches all its pods
 // Note: Use pointer for in-place update
 type k8sControllerSpec struct {
-	replicas *int32
-	podSpec  *apicorev1.PodSpec
+	replicas     *int32
+	podSpec      *apicorev1.PodSpec
+	replacesPods bool
+	replacedPods int32
+	selector     labels.Selector
 }
 
 // replicationController represents the k8s ReplicationController resource
@@ -47,6 +54,7 @@ func (rc *replicationController) get(name string) (*k8sControllerSpec, error) {
 	return &k8sControllerSpec{
 		replicas: rc.rc.Spec.Replicas,
 		podSpec:  &rc.rc.Spec.Template.Spec,
+		selector: labels.SelectorFromSet(rc.rc.Spec.Selector),
 	}, nil
 }
 
@@ -63,8 +71,8 @@ func (rc *replicationController) String() string {
 // replicaSet represents the k8s ReplicaSet resource
 type replicaSet struct {
 	k8sController
-	client typedextv1beta1.ReplicaSetInterface
-	rs     *apiextv1beta1.ReplicaSet
+	client typedappsv1.ReplicaSetInterface
+	rs     *apiappsv1.ReplicaSet
 }
 
 // get takes the name of the replicaset,
@@ -75,9 +83,14 @@ func (rs *replicaSet) get(name string) (*k8sControllerSpec, error) {
 	if err != nil {
 		return nil, err
 	}
+	selector, err := metav1.LabelSelectorAsSelector(rs.rs.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
 	return &k8sControllerSpec{
 		replicas: rs.rs.Spec.Replicas,
 		podSpec:  &rs.rs.Spec.Template.Spec,
+		selector: selector,
 	}, nil
 }
 
@@ -94,8 +107,8 @@ func (rs *replicaSet) String() string {
 // deployment represents the k8s Deployment resource
 type deployment struct {
 	k8sController
-	client typedappsv1beta1.DeploymentInterface
-	dep    *apiappsv1beta1.Deployment
+	client typedappsv1.DeploymentInterface
+	dep    *apiappsv1.Deployment
 }
 
 // get takes the name of the deployment,
@@ -106,9 +119,20 @@ func (dep *deployment) get(name string) (*k8sControllerSpec, error) {
 	if err != nil {
 		return nil, err
 	}
+	selector, err := metav1.LabelSelectorAsSelector(dep.dep.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	replicas := int32(1)
+	if dep.dep.Spec.Replicas != nil {
+		replicas = *dep.dep.Spec.Replicas
+	}
 	return &k8sControllerSpec{
-		replicas: dep.dep.Spec.Replicas,
-		podSpec:  &dep.dep.Spec.Template.Spec,
+		replicas:     dep.dep.Spec.Replicas,
+		podSpec:      &dep.dep.Spec.Template.Spec,
+		replacesPods: true,
+		replacedPods: replicas,
+		selector:     selector,
 	}, nil
 }
 
@@ -121,3 +145,104 @@ func (dep *deployment) update() error {
 func (dep *deployment) String() string {
 	return "Deployment"
 }
+
+// statefulSet represents the k8s StatefulSet resource
+type statefulSet struct {
+	k8sController
+	client typedappsv1.StatefulSetInterface
+	sts    *apiappsv1.StatefulSet
+}
+
+// get takes the name of the statefulset,
+// returns and saves the corresponding statefulset object from the server
+func (sts *statefulSet) get(name string) (*k8sControllerSpec, error) {
+	var err error
+	sts.sts, err = sts.client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(sts.sts.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	replacedPods := statefulSetReplacedPods(sts.sts)
+	return &k8sControllerSpec{
+		replicas:     sts.sts.Spec.Replicas,
+		podSpec:      &sts.sts.Spec.Template.Spec,
+		replacesPods: replacedPods > 0,
+		replacedPods: replacedPods,
+		selector:     selector,
+	}, nil
+}
+
+// statefulSetReplacedPods returns the number of the existing pods that the StatefulSet replaces when its pod
+// template is updated. With the RollingUpdate strategy, only the pods with an ordinal at or above the partition
+// are replaced. With the OnDelete strategy, the pods are only replaced when deleted.
+func statefulSetReplacedPods(sts *apiappsv1.StatefulSet) int32 {
+	strategy := sts.Spec.UpdateStrategy
+	if strategy.Type != apiappsv1.RollingUpdateStatefulSetStrategyType {
+		return 0
+	}
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	if strategy.RollingUpdate == nil || strategy.RollingUpdate.Partition == nil {
+		return replicas
+	}
+	if partition := *strategy.RollingUpdate.Partition; partition < replicas {
+		return replicas - partition
+	}
+	return 0
+}
+
+// update takes the saved statefulset object and updates it with the server
+func (sts *statefulSet) update() error {
+	_, err := sts.client.Update(sts.sts)
+	return err
+}
+
+func (sts *statefulSet) String() string {
+	return "StatefulSet"
+}
+
+// daemonSet represents the k8s DaemonSet resource
+type daemonSet struct {
+	k8sController
+	client typedappsv1.DaemonSetInterface
+	ds     *apiappsv1.DaemonSet
+}
+
+// get takes the name of the daemonset,
+// returns and saves the corresponding daemonset object from the server
+// A DaemonSet runs a pod on each of its nodes, so it has no replicas
+func (ds *daemonSet) get(name string) (*k8sControllerSpec, error) {
+	var err error
+	ds.ds, err = ds.client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(ds.ds.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	spec := &k8sControllerSpec{
+		podSpec:  &ds.ds.Spec.Template.Spec,
+		selector: selector,
+	}
+	if ds.ds.Spec.UpdateStrategy.Type == apiappsv1.RollingUpdateDaemonSetStrategyType {
+		spec.replacesPods = true
+		spec.replacedPods = ds.ds.Status.DesiredNumberScheduled
+	}
+	return spec, nil
+}
+
+// update takes the saved daemonset object and updates it with the server
+func (ds *daemonSet) update() error {
+	_, err := ds.client.Update(ds.ds)
+	return err
+}
+
+func (ds *daemonSet) String() string {
+	return "DaemonSet"
+}
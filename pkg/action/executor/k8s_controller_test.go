@@ -0,0 +1,64 @@
+package executor
+
+import (
+	"testing"
+
+	apiappsv1 "k8s.io/api/apps/v1"
+)
+
+func TestStatefulSetReplacedPods(t *testing.T) {
+	newStatefulSet := func(strategy apiappsv1.StatefulSetUpdateStrategy) *apiappsv1.StatefulSet {
+		replicas := int32(3)
+		return &apiappsv1.StatefulSet{
+			Spec: apiappsv1.StatefulSetSpec{
+				Replicas:       &replicas,
+				UpdateStrategy: strategy,
+			},
+		}
+	}
+	partition := func(partition int32) *apiappsv1.RollingUpdateStatefulSetStrategy {
+		return &apiappsv1.RollingUpdateStatefulSetStrategy{Partition: &partition}
+	}
+
+	table := []struct {
+		name     string
+		strategy apiappsv1.StatefulSetUpdateStrategy
+		expected int32
+	}{
+		{
+			name:     "no strategy",
+			expected: 0,
+		},
+		{
+			name:     "on delete",
+			strategy: apiappsv1.StatefulSetUpdateStrategy{Type: apiappsv1.OnDeleteStatefulSetStrategyType},
+			expected: 0,
+		},
+		{
+			name:     "rolling update",
+			strategy: apiappsv1.StatefulSetUpdateStrategy{Type: apiappsv1.RollingUpdateStatefulSetStrategyType},
+			expected: 3,
+		},
+		{
+			name: "rolling update from a partition",
+			strategy: apiappsv1.StatefulSetUpdateStrategy{
+				Type:          apiappsv1.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: partition(2),
+			},
+			expected: 1,
+		},
+		{
+			name: "rolling update with the partition at the replicas",
+			strategy: apiappsv1.StatefulSetUpdateStrategy{
+				Type:          apiappsv1.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: partition(3),
+			},
+			expected: 0,
+		},
+	}
+	for _, item := range table {
+		if actual := statefulSetReplacedPods(newStatefulSet(item.strategy)); actual != item.expected {
+			t.Errorf("%s: expected %v but got %v", item.name, item.expected, actual)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kclient "k8s.io/client-go/kubernetes"
+	"reflect"
 	"time"
 )
 
@@ -16,6 +17,7 @@ import (
 type k8sControllerUpdater struct {
 	controller k8sController
 	client     *kclient.Clientset
+	kind       string
 	name       string
 	namespace  string
 	podName    string
@@ -24,9 +26,19 @@ type k8sControllerUpdater struct {
 // controllerSpec defines the portion of a controller specification that we are interested in
 // - replicasDiff: 1 for provision, -1 for suspend
 // - resizeSpec: the index and new resource requirement of a container
+// - restoreSpec: the index and previous resource requirements of a container to roll back to
+// - deletionCostSet: the target pod of a suspension has the lowest deletion cost, and is not evicted
 type controllerSpec struct {
-	replicasDiff int32
-	resizeSpec   *containerResizeSpec
+	replicasDiff    int32
+	resizeSpec      *containerResizeSpec
+	restoreSpec     *containerResources
+	deletionCostSet bool
+}
+
+// containerResources defines the resource requirements of the container of the given index
+type containerResources struct {
+	index     int
+	resources api.ResourceRequirements
 }
 
 // newK8sControllerUpdater returns a k8sControllerUpdater based on the parent kind of a pod
@@ -36,6 +48,7 @@ func newK8sControllerUpdater(client *kclient.Clientset, pod *api.Pod) (*k8sContr
 	if err != nil {
 		return nil, fmt.Errorf("failed to get parent info of pod %s/%s: %v", pod.Namespace, pod.Name, err)
 	}
+	appsClient := util.NewAppsClient(client)
 	var controller k8sController
 	switch kind {
 	case util.KindReplicationController:
@@ -44,11 +57,19 @@ func newK8sControllerUpdater(client *kclient.Clientset, pod *api.Pod) (*k8sContr
 		}
 	case util.KindReplicaSet:
 		controller = &replicaSet{
-			client: client.ExtensionsV1beta1().ReplicaSets(pod.Namespace),
+			client: appsClient.ReplicaSets(pod.Namespace),
 		}
 	case util.KindDeployment:
 		controller = &deployment{
-			client: client.AppsV1beta1().Deployments(pod.Namespace),
+			client: appsClient.Deployments(pod.Namespace),
+		}
+	case util.KindStatefulSet:
+		controller = &statefulSet{
+			client: appsClient.StatefulSets(pod.Namespace),
+		}
+	case util.KindDaemonSet:
+		controller = &daemonSet{
+			client: appsClient.DaemonSets(pod.Namespace),
 		}
 	default:
 		err := fmt.Errorf("unsupport controller type %s for pod %s/%s", kind, pod.Namespace, pod.Name)
@@ -57,6 +78,7 @@ func newK8sControllerUpdater(client *kclient.Clientset, pod *api.Pod) (*k8sContr
 	return &k8sControllerUpdater{
 		controller: controller,
 		client:     client,
+		kind:       kind,
 		name:       name,
 		namespace:  pod.Namespace,
 		podName:    pod.Name,
@@ -109,8 +131,22 @@ func (c *k8sControllerUpdater) update(desired *controllerSpec) error {
 func (c *k8sControllerUpdater) reconcile(current *k8sControllerSpec, desired *controllerSpec) (bool, error) {
 	if desired.replicasDiff != 0 {
 		// This is a horizontal scale
+		if current.replicas == nil {
+			return false, fmt.Errorf("%v has no replicas to scale", c.controller)
+		}
+		if _, isStatefulSet := c.controller.(*statefulSet); isStatefulSet {
+			// The StatefulSet adds or removes the pod of the highest ordinal itself
+			num, err := scaleStatefulSetReplicas(c.name, c.podName, *current.replicas, desired.replicasDiff)
+			if err != nil {
+				return false, err
+			}
+			glog.V(2).Infof("Try to update replicas of %v from %d to %d",
+				c.controller, *current.replicas, num)
+			*current.replicas = num
+			return true, nil
+		}
 		// We want to suspend the target pod or provision a new pod first
-		num, err := c.suspendOrProvision(*current.replicas, desired.replicasDiff)
+		num, err := c.suspendOrProvision(*current.replicas, desired.replicasDiff, !desired.deletionCostSet)
 		if err != nil {
 			return false, err
 		}
@@ -120,6 +156,10 @@ func (c *k8sControllerUpdater) reconcile(current *k8sControllerSpec, desired *co
 		*current.replicas = num
 		return true, nil
 	}
+	if desired.restoreSpec != nil {
+		// This is the rollback of a vertical scale
+		return restoreResourceRequirements(current.podSpec, desired.restoreSpec)
+	}
 	// This may be a vertical scale
 	// Check and update resource limits/requests of the container in the pod specification
 	glog.V(4).Infof("Update container %v/%v-%v resources in the pod specification.",
@@ -131,11 +171,26 @@ func (c *k8sControllerUpdater) reconcile(current *k8sControllerSpec, desired *co
 	return updated, nil
 }
 
+// restoreResourceRequirements sets the resource requirements of the container in the pod specification
+// to the given ones, and returns whether they are changed
+func restoreResourceRequirements(podSpec *api.PodSpec, spec *containerResources) (bool, error) {
+	if spec.index >= len(podSpec.Containers) {
+		return false, fmt.Errorf("failed to find container[%d] in pod", spec.index)
+	}
+	container := &podSpec.Containers[spec.index]
+	if reflect.DeepEqual(container.Resources, spec.resources) {
+		return false, nil
+	}
+	glog.V(2).Infof("Restore the resources of container %s to %+v", container.Name, spec.resources)
+	container.Resources = *spec.resources.DeepCopy()
+	return true, nil
+}
+
 // suspendOrProvision suspends or provisions the target pod and
 // returns the desired replica number after suspension or provision
-// Note: For now we only suspend the target pod
-// TODO: Provision a new pod on the target host
-func (c *k8sControllerUpdater) suspendOrProvision(current, diff int32) (int32, error) {
+// Note: We only suspend the target pod here, by evicting it if required. The new pod of a provision
+// is steered to its destination by the HorizontalScaler
+func (c *k8sControllerUpdater) suspendOrProvision(current, diff int32, evict bool) (int32, error) {
 	//1. validate replica number
 	result := current + diff
 	glog.V(4).Infof("Current replica %d, diff %d, result %d.", current, diff, result)
@@ -143,7 +198,7 @@ func (c *k8sControllerUpdater) suspendOrProvision(current, diff int32) (int32, e
 		return 0, fmt.Errorf("resulting replica is less than 1 after suspension")
 	}
 	//2. suspend the target
-	if diff < 0 {
+	if diff < 0 && evict {
 		if err := c.suspendPod(); err != nil {
 			return 0, err
 		}
@@ -151,16 +206,18 @@ func (c *k8sControllerUpdater) suspendOrProvision(current, diff int32) (int32, e
 	return result, nil
 }
 
-// suspendPod takes the name of a pod and deletes it
+// suspendPod takes the name of a pod and evicts it, which fails if it would violate
+// a PodDisruptionBudget of the pod
 // The call does not block
 func (c *k8sControllerUpdater) suspendPod() error {
 	podClient := c.client.CoreV1().Pods(c.namespace)
-	if _, err := podClient.Get(c.podName, metav1.GetOptions{}); err != nil {
+	pod, err := podClient.Get(c.podName, metav1.GetOptions{})
+	if err != nil {
 		return fmt.Errorf("failed to get latest pod %s/%s: %v", c.namespace, c.podName, err)
 	}
 	// This function does not block
-	if err := podClient.Delete(c.podName, &metav1.DeleteOptions{}); err != nil {
-		return fmt.Errorf("failed to delete pod %s/%s: %v", c.namespace, c.podName, err)
+	if err := evictPod(c.client, pod); err != nil {
+		return err
 	}
 	glog.V(2).Infof("Successfully suspended pod %s/%s", c.namespace, c.podName)
 	return nil
@@ -11,6 +11,9 @@ type MachineActionExecutor struct {
 	executor      TurboK8sActionExecutor
 	cache         *turbostore.Cache
 	cAPINamespace string
+	// Suspend the nodes by cordoning and draining them, and provision nodes by uncordoning the drained nodes,
+	// instead of scaling the MachineDeployments of the Cluster API
+	drainNodes bool
 }
 
 func NewMachineActionExecutor(namespace string, ae TurboK8sActionExecutor) *MachineActionExecutor {
@@ -21,6 +24,16 @@ func NewMachineActionExecutor(namespace string, ae TurboK8sActionExecutor) *Mach
 	}
 }
 
+// NewNodeDrainActionExecutor returns the MachineActionExecutor for the clusters without the Cluster API,
+// which drains the nodes to suspend, and uncordons the drained nodes to provision.
+func NewNodeDrainActionExecutor(ae TurboK8sActionExecutor) *MachineActionExecutor {
+	return &MachineActionExecutor{
+		executor:   ae,
+		cache:      turbostore.NewCache(),
+		drainNodes: true,
+	}
+}
+
 func (s *MachineActionExecutor) unlock(key string) {
 	err := s.cache.Delete(key)
 	if err != nil {
@@ -46,8 +59,16 @@ func (s *MachineActionExecutor) Execute(vmDTO *TurboActionExecutorInput) (*Turbo
 		return nil, fmt.Errorf("unsupported action type %v", vmDTO.ActionItem.GetActionType())
 	}
 	// Get on with it.
-	controller, key, err := newController(s.cAPINamespace, machineName, diff, actionType,
-		s.executor.cApiClient, s.executor.kubeClient)
+	var controller Controller
+	var key *string
+	var err error
+	if s.drainNodes {
+		controller, key, err = newNodeDrainController(machineName, actionType, s.executor.kubeClient,
+			s.executor.clusterScraper)
+	} else {
+		controller, key, err = newController(s.cAPINamespace, machineName, diff, actionType,
+			s.executor.cApiClient, s.executor.kubeClient)
+	}
 	if err != nil {
 		return nil, err
 	} else if key == nil {
@@ -0,0 +1,475 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	podutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+	apiappsv1 "k8s.io/api/apps/v1"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	kclient "k8s.io/client-go/kubernetes"
+)
+
+const (
+	// The well-known label of the nodes with their hostname, by which the pod template is constrained to a node
+	nodeHostnameLabel = "kubernetes.io/hostname"
+	// The highest weight of a preferred scheduling term
+	maxPreferredSchedulingWeight = 100
+)
+
+// templateConstraint constrains the pod template of the controller of a pod to a node while the controller
+// creates a new pod, which is the replacement of the evicted pod for a move by eviction, or a new replica
+// for a provision.
+type templateConstraint struct {
+	client *kclient.Clientset
+	// The clients of the workload controllers in the group versions served by the API server
+	appsClient *goutil.AppsClient
+	pod        *api.Pod
+
+	// The node to constrain the pod template to, nil if it is not constrained
+	node *api.Node
+
+	// The controller creating the pods, which is the ReplicaSet and not the Deployment of a pod
+	controller     k8sController
+	controllerKind string
+	controllerName string
+	controllerUID  types.UID
+	// The selector of the pods of the controller, got with the controller when the pods are first listed
+	podSelector labels.Selector
+
+	// The Deployment of the ReplicaSet paused during the constraint, so that it does not roll out the changed template
+	pausedDeployment string
+	// The pod affinity of the template before the constraint
+	originalAffinity *api.Affinity
+	// The update strategy and the revision of a StatefulSet before the constraint
+	originalUpdateStrategy *apiappsv1.StatefulSetUpdateStrategy
+	originalRevision       string
+	constrained            bool
+}
+
+// supportedEvictionParent checks whether the pods of the controller of the given kind can be moved by eviction
+func supportedEvictionParent(parentKind string) bool {
+	switch parentKind {
+	case goutil.KindReplicationController, goutil.KindReplicaSet, goutil.KindStatefulSet:
+		return true
+	}
+	return false
+}
+
+// moveByEviction moves the pod to the node in four steps:
+//  step1: constrain the pod template of the controller of the pod to the node
+//  step2: evict the pod, which fails if it would violate a PodDisruptionBudget
+//  step3: wait until the replacement of the pod created by the controller is ready on the node
+//  step4: restore the pod template of the controller, whether the move succeeded or not
+// Unlike the clone of movePod, the replacement is created and owned by the controller from the start,
+// and the pods of a StatefulSet can be moved too.
+func moveByEviction(client *kclient.Clientset, pod *api.Pod, node *api.Node, retryNum int) (*api.Pod, error) {
+	hostname, exists := node.Labels[nodeHostnameLabel]
+	if !exists {
+		return nil, fmt.Errorf("node %s has no %s label to constrain the pod template to", node.Name,
+			nodeHostnameLabel)
+	}
+	m, err := newTemplateConstraint(client, pod, node)
+	if err != nil {
+		return nil, err
+	}
+
+	//1. constrain the pod template of the controller to the node
+	if err := m.constrain(hostname, constrainAffinityToNode); err != nil {
+		m.restore(nil)
+		return nil, err
+	}
+
+	//2. evict the pod, after recording the existing pods of the controller
+	existing, err := m.listControllerPods()
+	if err != nil {
+		m.restore(nil)
+		return nil, err
+	}
+	if err := evictPod(client, pod); err != nil {
+		m.restore(nil)
+		return nil, err
+	}
+
+	//3. wait for the replacement of the pod
+	npod, err := m.waitForReplacement(existing, retryNum)
+
+	//4. restore the pod template
+	if restoreErr := m.restore(npod); restoreErr != nil && err == nil {
+		err = restoreErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	glog.V(2).Infof("Pod %s/%s is replaced by pod %s on node %s", pod.Namespace, pod.Name, npod.Name, node.Name)
+	return npod, nil
+}
+
+func newTemplateConstraint(client *kclient.Clientset, pod *api.Pod, node *api.Node) (*templateConstraint, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return nil, fmt.Errorf("pod %s/%s has no controller to replace it", pod.Namespace, pod.Name)
+	}
+	appsClient := goutil.NewAppsClient(client)
+	controller := newEvictionParent(client, appsClient, owner.Kind, pod.Namespace)
+	if controller == nil {
+		return nil, fmt.Errorf("unsupport controller type %s for moving pod %s/%s by eviction",
+			owner.Kind, pod.Namespace, pod.Name)
+	}
+	return &templateConstraint{
+		client:         client,
+		appsClient:     appsClient,
+		pod:            pod,
+		node:           node,
+		controller:     controller,
+		controllerKind: owner.Kind,
+		controllerName: owner.Name,
+		controllerUID:  owner.UID,
+	}, nil
+}
+
+// newEvictionParent returns the controller of the given kind and namespace which recreates the evicted pods,
+// or nil if the pods of the controllers of this kind cannot be moved by eviction
+func newEvictionParent(client *kclient.Clientset, appsClient *goutil.AppsClient, kind,
+	namespace string) k8sController {
+	switch kind {
+	case goutil.KindReplicationController:
+		return &replicationController{
+			client: client.CoreV1().ReplicationControllers(namespace),
+		}
+	case goutil.KindReplicaSet:
+		return &replicaSet{
+			client: appsClient.ReplicaSets(namespace),
+		}
+	case goutil.KindStatefulSet:
+		return &statefulSet{
+			client: appsClient.StatefulSets(namespace),
+		}
+	}
+	return nil
+}
+
+// constrain applies the given constraint on the node of the given hostname to the affinity of the pod template
+// of the controller. The Deployment of a ReplicaSet is paused first, as it would otherwise roll out a new ReplicaSet
+// for the changed template. The RollingUpdate of a StatefulSet is replaced by OnDelete for the same reason.
+func (m *templateConstraint) constrain(hostname string,
+	constraint func(affinity *api.Affinity, hostname string) *api.Affinity) error {
+	if m.controllerKind == goutil.KindReplicaSet {
+		if err := m.pauseDeployment(); err != nil {
+			return err
+		}
+	}
+	err := m.updateController(func(spec *k8sControllerSpec) {
+		m.originalAffinity = spec.podSpec.Affinity
+		spec.podSpec.Affinity = constraint(spec.podSpec.Affinity, hostname)
+		if sts, ok := m.controller.(*statefulSet); ok {
+			m.originalUpdateStrategy = sts.sts.Spec.UpdateStrategy.DeepCopy()
+			m.originalRevision = sts.sts.Status.UpdateRevision
+			sts.sts.Spec.UpdateStrategy = apiappsv1.StatefulSetUpdateStrategy{
+				Type: apiappsv1.OnDeleteStatefulSetStrategyType,
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to constrain the pod template of %v %s/%s to node %s: %v",
+			m.controller, m.pod.Namespace, m.controllerName, m.node.Name, err)
+	}
+	m.constrained = true
+	glog.V(2).Infof("Constrained the pod template of %v %s/%s to node %s", m.controller, m.pod.Namespace,
+		m.controllerName, m.node.Name)
+	return nil
+}
+
+// restore reverts the changes of constrain. The new pod of a StatefulSet, if any, is labelled with the original
+// revision of the StatefulSet first, so that the restored RollingUpdate does not replace it again.
+func (m *templateConstraint) restore(npod *api.Pod) error {
+	var errs []string
+	if m.constrained {
+		if npod != nil && m.originalRevision != "" {
+			if err := m.labelRevision(npod); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		err := m.updateController(func(spec *k8sControllerSpec) {
+			spec.podSpec.Affinity = m.originalAffinity
+			if sts, ok := m.controller.(*statefulSet); ok && m.originalUpdateStrategy != nil {
+				sts.sts.Spec.UpdateStrategy = *m.originalUpdateStrategy
+			}
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to restore the pod template of %v %s/%s: %v",
+				m.controller, m.pod.Namespace, m.controllerName, err))
+		} else {
+			glog.V(2).Infof("Restored the pod template of %v %s/%s", m.controller, m.pod.Namespace,
+				m.controllerName)
+		}
+	}
+	if m.pausedDeployment != "" {
+		if err := setDeploymentPaused(m.appsClient, m.pod.Namespace, m.pausedDeployment, false); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to resume Deployment %s/%s: %v",
+				m.pod.Namespace, m.pausedDeployment, err))
+		}
+	}
+	if len(errs) > 0 {
+		err := fmt.Errorf("%v", errs)
+		glog.Errorf("Failed to restore the controller of pod %s/%s: %v", m.pod.Namespace,
+			m.pod.Name, err)
+		return err
+	}
+	return nil
+}
+
+// updateController updates the controller with the given change, with retry and timeout
+func (m *templateConstraint) updateController(change func(spec *k8sControllerSpec)) error {
+	retryNum := defaultRetryLess
+	interval := defaultUpdateReplicaSleep
+	timeout := time.Duration(retryNum+1) * interval
+	return goutil.RetryDuring(retryNum, timeout, interval, func() error {
+		spec, err := m.controller.get(m.controllerName)
+		if err != nil {
+			return err
+		}
+		change(spec)
+		return m.controller.update()
+	})
+}
+
+// pauseDeployment pauses the Deployment of the ReplicaSet of the pod, if any and not paused already
+func (m *templateConstraint) pauseDeployment() error {
+	rs, err := m.appsClient.ReplicaSets(m.pod.Namespace).Get(m.controllerName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get ReplicaSet %s/%s: %v", m.pod.Namespace, m.controllerName, err)
+	}
+	owner := metav1.GetControllerOf(rs)
+	if owner == nil || owner.Kind != goutil.KindDeployment {
+		return nil
+	}
+	dep, err := m.appsClient.Deployments(m.pod.Namespace).Get(owner.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get Deployment %s/%s: %v", m.pod.Namespace, owner.Name, err)
+	}
+	if dep.Spec.Paused {
+		glog.V(3).Infof("Deployment %s/%s is already paused", m.pod.Namespace, owner.Name)
+		return nil
+	}
+	if err := setDeploymentPaused(m.appsClient, m.pod.Namespace, owner.Name, true); err != nil {
+		return fmt.Errorf("failed to pause Deployment %s/%s: %v", m.pod.Namespace, owner.Name, err)
+	}
+	m.pausedDeployment = owner.Name
+	return nil
+}
+
+// setDeploymentPaused pauses or resumes the Deployment, with retry and timeout
+func setDeploymentPaused(appsClient *goutil.AppsClient, namespace, name string, paused bool) error {
+	retryNum := defaultRetryLess
+	interval := defaultUpdateReplicaSleep
+	timeout := time.Duration(retryNum+1) * interval
+	return goutil.RetryDuring(retryNum, timeout, interval, func() error {
+		depClient := appsClient.Deployments(namespace)
+		dep, err := depClient.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		dep.Spec.Paused = paused
+		if _, err := depClient.Update(dep); err != nil {
+			return err
+		}
+		glog.V(2).Infof("Set paused of Deployment %s/%s to %v", namespace, name, paused)
+		return nil
+	})
+}
+
+// labelRevision sets the revision label of the new pod of a StatefulSet to the revision before the constraint
+func (m *templateConstraint) labelRevision(npod *api.Pod) error {
+	podClient := m.client.CoreV1().Pods(npod.Namespace)
+	xpod, err := podClient.Get(npod.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get pod %s/%s: %v", npod.Namespace, npod.Name, err)
+	}
+	if xpod.Labels == nil {
+		xpod.Labels = make(map[string]string)
+	}
+	xpod.Labels[apiappsv1.StatefulSetRevisionLabel] = m.originalRevision
+	if _, err := podClient.Update(xpod); err != nil {
+		return fmt.Errorf("failed to label pod %s/%s with revision %s: %v", npod.Namespace, npod.Name,
+			m.originalRevision, err)
+	}
+	return nil
+}
+
+// listControllerPods returns the UIDs of the existing pods of the controller
+func (m *templateConstraint) listControllerPods() (map[types.UID]bool, error) {
+	pods, err := m.getControllerPods()
+	if err != nil {
+		return nil, err
+	}
+	uids := make(map[types.UID]bool, len(pods))
+	for _, pod := range pods {
+		uids[pod.UID] = true
+	}
+	return uids, nil
+}
+
+// getControllerPods lists the pods of the controller by its selector, as the labels of the pod of a StatefulSet
+// do not match the labels of its other pods, nor of its replacement of another revision
+func (m *templateConstraint) getControllerPods() ([]*api.Pod, error) {
+	if m.podSelector == nil {
+		spec, err := m.controller.get(m.controllerName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the pod selector of %v %s/%s: %v", m.controller,
+				m.pod.Namespace, m.controllerName, err)
+		}
+		m.podSelector = spec.selector
+	}
+	podList, err := m.client.CoreV1().Pods(m.pod.Namespace).List(metav1.ListOptions{
+		LabelSelector: m.podSelector.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods of %v %s/%s: %v", m.controller, m.pod.Namespace,
+			m.controllerName, err)
+	}
+	pods := make([]*api.Pod, len(podList.Items))
+	for i := range podList.Items {
+		pods[i] = &podList.Items[i]
+	}
+	return selectControllerPods(pods, m.podSelector, m.controllerUID), nil
+}
+
+// selectControllerPods returns the pods matching the selector and controlled by the controller of the given UID
+func selectControllerPods(pods []*api.Pod, selector labels.Selector, controllerUID types.UID) []*api.Pod {
+	var result []*api.Pod
+	for _, pod := range pods {
+		if !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if owner := metav1.GetControllerOf(pod); owner != nil && owner.UID == controllerUID {
+			result = append(result, pod)
+		}
+	}
+	return result
+}
+
+// waitForNewPod waits until the controller creates a pod which is not one of the existing pods, and until
+// the pod is scheduled. The name of the new pod is given if it is known, as for a StatefulSet, whose replacement
+// of a pod has the same name, and whose new replica has the next ordinal.
+func (m *templateConstraint) waitForNewPod(existing map[types.UID]bool, name string,
+	retryNum int) (*api.Pod, error) {
+	var npod *api.Pod
+	timeout := time.Duration(retryNum+1) * defaultPodCheckSleep
+	err := goutil.RetrySimple(retryNum, timeout, defaultPodCheckSleep, func() (bool, error) {
+		pods, err := m.getControllerPods()
+		if err != nil {
+			return true, err
+		}
+		if npod = findNewPod(pods, existing, name); npod != nil {
+			return false, nil
+		}
+		return true, fmt.Errorf("%v %s/%s has not created and scheduled a new pod yet", m.controller,
+			m.pod.Namespace, m.controllerName)
+	})
+	if err == nil && npod == nil {
+		err = fmt.Errorf("timeout waiting for %v %s/%s to create a new pod", m.controller, m.pod.Namespace,
+			m.controllerName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	glog.V(3).Infof("%v %s/%s created pod %s on node %s", m.controller, m.pod.Namespace, m.controllerName,
+		npod.Name, npod.Spec.NodeName)
+	return npod, nil
+}
+
+// findNewPod returns the scheduled pod which is not one of the existing pods, and has the given name if any
+func findNewPod(pods []*api.Pod, existing map[types.UID]bool, name string) *api.Pod {
+	for _, pod := range pods {
+		if name != "" && pod.Name != name {
+			continue
+		}
+		if !existing[pod.UID] && pod.DeletionTimestamp == nil && pod.Spec.NodeName != "" {
+			return pod
+		}
+	}
+	return nil
+}
+
+// waitForReplacement waits until the controller creates a pod which is not one of the existing pods,
+// and until the pod is ready on the node.
+func (m *templateConstraint) waitForReplacement(existing map[types.UID]bool, retryNum int) (*api.Pod, error) {
+	name := ""
+	if m.controllerKind == goutil.KindStatefulSet {
+		name = m.pod.Name
+	}
+	npod, err := m.waitForNewPod(existing, name, retryNum)
+	if err != nil {
+		glog.Errorf("Move pod failed: %v", err)
+		return nil, err
+	}
+	glog.V(3).Infof("Pod %s is replaced by pod %s", util.BuildIdentifier(m.pod.Namespace, m.pod.Name), npod.Name)
+	err = podutil.WaitForPodReady(m.client, npod.Namespace, npod.Name, m.node.Name, retryNum, defaultPodCreateSleep)
+	if err != nil {
+		glog.Errorf("Wait for the replacement pod ready timeout: %v", err)
+		return npod, err
+	}
+	return npod, nil
+}
+
+// constrainAffinityToNode returns a copy of the affinity which also requires the node of the given hostname.
+// The requirement is added to each of the required node selector terms, as they are ORed.
+func constrainAffinityToNode(affinity *api.Affinity, hostname string) *api.Affinity {
+	result := &api.Affinity{}
+	if affinity != nil {
+		result = affinity.DeepCopy()
+	}
+	if result.NodeAffinity == nil {
+		result.NodeAffinity = &api.NodeAffinity{}
+	}
+	requirement := api.NodeSelectorRequirement{
+		Key:      nodeHostnameLabel,
+		Operator: api.NodeSelectorOpIn,
+		Values:   []string{hostname},
+	}
+	required := result.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil || len(required.NodeSelectorTerms) == 0 {
+		result.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &api.NodeSelector{
+			NodeSelectorTerms: []api.NodeSelectorTerm{
+				{MatchExpressions: []api.NodeSelectorRequirement{requirement}},
+			},
+		}
+		return result
+	}
+	for i := range required.NodeSelectorTerms {
+		term := &required.NodeSelectorTerms[i]
+		term.MatchExpressions = append(term.MatchExpressions, requirement)
+	}
+	return result
+}
+
+// preferAffinityToNode returns a copy of the affinity which also prefers the node of the given hostname,
+// with the highest weight.
+func preferAffinityToNode(affinity *api.Affinity, hostname string) *api.Affinity {
+	result := &api.Affinity{}
+	if affinity != nil {
+		result = affinity.DeepCopy()
+	}
+	if result.NodeAffinity == nil {
+		result.NodeAffinity = &api.NodeAffinity{}
+	}
+	result.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+		result.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, api.PreferredSchedulingTerm{
+			Weight: maxPreferredSchedulingWeight,
+			Preference: api.NodeSelectorTerm{
+				MatchExpressions: []api.NodeSelectorRequirement{{
+					Key:      nodeHostnameLabel,
+					Operator: api.NodeSelectorOpIn,
+					Values:   []string{hostname},
+				}},
+			},
+		})
+	return result
+}
@@ -0,0 +1,239 @@
+package executor
+
+import (
+	"reflect"
+	"testing"
+
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+	apiappsv1 "k8s.io/api/apps/v1"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestConstrainAffinityToNode(t *testing.T) {
+	requirement := api.NodeSelectorRequirement{
+		Key: nodeHostnameLabel, Operator: api.NodeSelectorOpIn, Values: []string{"node2"},
+	}
+	zone := api.NodeSelectorRequirement{
+		Key: "failure-domain.beta.kubernetes.io/zone", Operator: api.NodeSelectorOpIn, Values: []string{"us-east-1a"},
+	}
+	ssd := api.NodeSelectorRequirement{
+		Key: "disktype", Operator: api.NodeSelectorOpIn, Values: []string{"ssd"},
+	}
+	podAffinity := &api.PodAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []api.PodAffinityTerm{{TopologyKey: nodeHostnameLabel}},
+	}
+
+	table := []struct {
+		name     string
+		affinity *api.Affinity
+		expected *api.Affinity
+	}{
+		{
+			name: "no affinity",
+			expected: &api.Affinity{NodeAffinity: &api.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &api.NodeSelector{
+					NodeSelectorTerms: []api.NodeSelectorTerm{{MatchExpressions: []api.NodeSelectorRequirement{requirement}}},
+				},
+			}},
+		},
+		{
+			name:     "pod affinity only",
+			affinity: &api.Affinity{PodAffinity: podAffinity},
+			expected: &api.Affinity{
+				PodAffinity: podAffinity,
+				NodeAffinity: &api.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &api.NodeSelector{
+						NodeSelectorTerms: []api.NodeSelectorTerm{{MatchExpressions: []api.NodeSelectorRequirement{requirement}}},
+					},
+				},
+			},
+		},
+		{
+			name: "required node selector terms",
+			affinity: &api.Affinity{NodeAffinity: &api.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &api.NodeSelector{
+					NodeSelectorTerms: []api.NodeSelectorTerm{
+						{MatchExpressions: []api.NodeSelectorRequirement{zone}},
+						{MatchExpressions: []api.NodeSelectorRequirement{ssd}},
+					},
+				},
+			}},
+			expected: &api.Affinity{NodeAffinity: &api.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &api.NodeSelector{
+					NodeSelectorTerms: []api.NodeSelectorTerm{
+						{MatchExpressions: []api.NodeSelectorRequirement{zone, requirement}},
+						{MatchExpressions: []api.NodeSelectorRequirement{ssd, requirement}},
+					},
+				},
+			}},
+		},
+	}
+
+	for _, item := range table {
+		var original *api.Affinity
+		if item.affinity != nil {
+			original = item.affinity.DeepCopy()
+		}
+		actual := constrainAffinityToNode(item.affinity, "node2")
+		if !reflect.DeepEqual(actual, item.expected) {
+			t.Errorf("%s: expected affinity %+v but got %+v", item.name, item.expected, actual)
+		}
+		// The affinity of the template is restored from the original after the move
+		if !reflect.DeepEqual(item.affinity, original) {
+			t.Errorf("%s: the original affinity is modified", item.name)
+		}
+	}
+}
+
+func TestPreferAffinityToNode(t *testing.T) {
+	preferred := api.PreferredSchedulingTerm{
+		Weight: maxPreferredSchedulingWeight,
+		Preference: api.NodeSelectorTerm{MatchExpressions: []api.NodeSelectorRequirement{{
+			Key: nodeHostnameLabel, Operator: api.NodeSelectorOpIn, Values: []string{"node2"},
+		}}},
+	}
+	ssd := api.PreferredSchedulingTerm{
+		Weight: 10,
+		Preference: api.NodeSelectorTerm{MatchExpressions: []api.NodeSelectorRequirement{{
+			Key: "disktype", Operator: api.NodeSelectorOpIn, Values: []string{"ssd"},
+		}}},
+	}
+	required := &api.NodeSelector{
+		NodeSelectorTerms: []api.NodeSelectorTerm{{MatchExpressions: []api.NodeSelectorRequirement{{
+			Key: "failure-domain.beta.kubernetes.io/zone", Operator: api.NodeSelectorOpIn, Values: []string{"us-east-1a"},
+		}}}},
+	}
+
+	table := []struct {
+		name     string
+		affinity *api.Affinity
+		expected *api.Affinity
+	}{
+		{
+			name: "no affinity",
+			expected: &api.Affinity{NodeAffinity: &api.NodeAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []api.PreferredSchedulingTerm{preferred},
+			}},
+		},
+		{
+			name: "preferred and required node affinity",
+			affinity: &api.Affinity{NodeAffinity: &api.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution:  required,
+				PreferredDuringSchedulingIgnoredDuringExecution: []api.PreferredSchedulingTerm{ssd},
+			}},
+			expected: &api.Affinity{NodeAffinity: &api.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution:  required,
+				PreferredDuringSchedulingIgnoredDuringExecution: []api.PreferredSchedulingTerm{ssd, preferred},
+			}},
+		},
+	}
+
+	for _, item := range table {
+		var original *api.Affinity
+		if item.affinity != nil {
+			original = item.affinity.DeepCopy()
+		}
+		actual := preferAffinityToNode(item.affinity, "node2")
+		if !reflect.DeepEqual(actual, item.expected) {
+			t.Errorf("%s: expected affinity %+v but got %+v", item.name, item.expected, actual)
+		}
+		if !reflect.DeepEqual(item.affinity, original) {
+			t.Errorf("%s: the original affinity is modified", item.name)
+		}
+	}
+}
+
+func TestSupportedEvictionParent(t *testing.T) {
+	for _, kind := range []string{goutil.KindReplicationController, goutil.KindReplicaSet, goutil.KindStatefulSet} {
+		if !supportedEvictionParent(kind) {
+			t.Errorf("expected the pods of %s to be moved by eviction", kind)
+		}
+	}
+	for _, kind := range []string{"", goutil.KindDeployment, "DaemonSet", "Job"} {
+		if supportedEvictionParent(kind) {
+			t.Errorf("expected the pods of %q not to be moved by eviction", kind)
+		}
+	}
+}
+
+func TestSelectControllerPodsOfStatefulSet(t *testing.T) {
+	isController := true
+	newPod := func(name string, uid, ownerUID types.UID, podLabels map[string]string) *api.Pod {
+		return &api.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      name,
+			UID:       uid,
+			Labels:    podLabels,
+			OwnerReferences: []metav1.OwnerReference{{
+				Kind: goutil.KindStatefulSet, Name: "db", UID: ownerUID, Controller: &isController,
+			}},
+		}}
+	}
+	newLabels := func(name, revision string) map[string]string {
+		return map[string]string{
+			"app":                                "db",
+			apiappsv1.StatefulSetRevisionLabel:   revision,
+			"statefulset.kubernetes.io/pod-name": name,
+		}
+	}
+	evicted := newPod("db-1", "uid-1", "sts-uid", newLabels("db-1", "db-r1"))
+	sibling := newPod("db-0", "uid-0", "sts-uid", newLabels("db-0", "db-r1"))
+	// The replacement of the evicted pod is of the revision of the constrained template
+	replacement := newPod("db-1", "uid-2", "sts-uid", newLabels("db-1", "db-r2"))
+	// A pod matching the selector, of a former StatefulSet of the same name
+	orphan := newPod("db-2", "uid-3", "old-sts-uid", newLabels("db-2", "db-r1"))
+	other := newPod("web-0", "uid-4", "sts-uid", map[string]string{"app": "web"})
+
+	sts := &apiappsv1.StatefulSet{
+		Spec: apiappsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+		},
+	}
+	selector, err := metav1.LabelSelectorAsSelector(sts.Spec.Selector)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if labels.SelectorFromSet(evicted.Labels).Matches(labels.Set(replacement.Labels)) {
+		t.Errorf("the labels of the evicted pod should not match its replacement")
+	}
+
+	pods := selectControllerPods([]*api.Pod{evicted, sibling, replacement, orphan, other}, selector, "sts-uid")
+	var uids []types.UID
+	for _, pod := range pods {
+		uids = append(uids, pod.UID)
+	}
+	expected := []types.UID{"uid-1", "uid-0", "uid-2"}
+	if !reflect.DeepEqual(uids, expected) {
+		t.Errorf("expected the pods %v but got %v", expected, uids)
+	}
+}
+
+func TestFindNewPodOfStatefulSetProvision(t *testing.T) {
+	newPod := func(name string, uid types.UID, nodeName string) *api.Pod {
+		return &api.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: name, UID: uid},
+			Spec:       api.PodSpec{NodeName: nodeName},
+		}
+	}
+	existing := map[types.UID]bool{"uid-0": true, "uid-1": true}
+	// Pod db-0 is recreated by the StatefulSet meanwhile, before the pod of the next ordinal is scheduled
+	recreated := newPod("db-0", "uid-2", "node1")
+	pending := newPod("db-2", "uid-3", "")
+	scheduled := newPod("db-2", "uid-3", "node2")
+
+	pods := []*api.Pod{recreated, newPod("db-1", "uid-1", "node1"), pending}
+	if npod := findNewPod(pods, existing, "db-2"); npod != nil {
+		t.Errorf("expected no new pod but got %s", npod.Name)
+	}
+	if npod := findNewPod(pods, existing, ""); npod != recreated {
+		t.Errorf("expected the recreated pod without a name but got %v", npod)
+	}
+
+	pods = []*api.Pod{recreated, newPod("db-1", "uid-1", "node1"), scheduled}
+	if npod := findNewPod(pods, existing, statefulSetPodName("db", 2)); npod != scheduled {
+		t.Errorf("expected the pod of the next ordinal but got %v", npod)
+	}
+}
@@ -0,0 +1,280 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	podutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/worker/compliance"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// The labels of the zone and region of the nodes and the persistent volumes
+	labelZoneFailureDomain = "failure-domain.beta.kubernetes.io/zone"
+	labelZoneRegion        = "failure-domain.beta.kubernetes.io/region"
+	// The separator of the zones of a volume available in several zones
+	labelMultiZoneDelimiter = "__"
+)
+
+// A predicate checking whether a pod can run on a node, returning the reason why it cannot.
+type movePredicate func(pod *api.Pod, node *api.Node, nodePods []*api.Pod, pvs []*api.PersistentVolume) error
+
+// The clone pod of a move is bound to the destination node directly, bypassing the scheduler.
+// These predicates, mostly the ones of the scheduler, are checked before creating it.
+var movePredicates = []struct {
+	name      string
+	predicate movePredicate
+}{
+	{"NodeReady", checkNodeReady},
+	{"NodeSchedulable", checkNodeSchedulable},
+	{"PodFitsResources", checkPodFitsResources},
+	{"PodToleratesNodeTaints", checkPodToleratesNodeTaints},
+	{"MatchNodeSelector", checkMatchNodeSelector},
+	{"PodFitsHostPorts", checkPodFitsHostPorts},
+	{"VolumeNodeAffinity", checkVolumeNodeAffinity},
+	{"VolumeZone", checkVolumeZone},
+}
+
+// checkMoveFeasibility checks whether the pod can run on the destination node of the move.
+// The error names the predicate which fails.
+func (r *ReScheduler) checkMoveFeasibility(pod *api.Pod, node *api.Node) error {
+	nodePods, err := r.clusterScraper.GetPodsOnNode(node.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get the pods on node %s: %v", node.Name, err)
+	}
+	pvs, err := r.getPersistentVolumes(pod)
+	if err != nil {
+		return err
+	}
+	return podFitsNode(pod, node, nodePods, pvs)
+}
+
+// getPersistentVolumes returns the persistent volumes bound to the claims of the pod.
+// Like the pods of the node, they are read from the cluster cache when it has synced them.
+func (r *ReScheduler) getPersistentVolumes(pod *api.Pod) ([]*api.PersistentVolume, error) {
+	var pvs []*api.PersistentVolume
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		claimName := volume.PersistentVolumeClaim.ClaimName
+		pvc, err := r.clusterScraper.GetPVC(pod.Namespace, claimName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get persistent volume claim %s/%s: %v", pod.Namespace, claimName, err)
+		}
+		if pvc.Spec.VolumeName == "" {
+			glog.V(3).Infof("Persistent volume claim %s/%s is not bound.", pod.Namespace, claimName)
+			continue
+		}
+		pv, err := r.clusterScraper.GetPV(pvc.Spec.VolumeName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get persistent volume %s: %v", pvc.Spec.VolumeName, err)
+		}
+		pvs = append(pvs, pv)
+	}
+	return pvs, nil
+}
+
+// podFitsNode checks all the move predicates, and returns the error of the first one which fails.
+func podFitsNode(pod *api.Pod, node *api.Node, nodePods []*api.Pod, pvs []*api.PersistentVolume) error {
+	// The pods terminated or being moved out of the node do not take its resources anymore
+	var activePods []*api.Pod
+	for _, nodePod := range nodePods {
+		if nodePod.UID == pod.UID || nodePod.Status.Phase == api.PodSucceeded || nodePod.Status.Phase == api.PodFailed {
+			continue
+		}
+		activePods = append(activePods, nodePod)
+	}
+	for _, p := range movePredicates {
+		if err := p.predicate(pod, node, activePods, pvs); err != nil {
+			return fmt.Errorf("pod %s/%s cannot be moved to node %s, predicate %s failed: %v",
+				pod.Namespace, pod.Name, node.Name, p.name, err)
+		}
+	}
+	return nil
+}
+
+func checkNodeReady(pod *api.Pod, node *api.Node, nodePods []*api.Pod, pvs []*api.PersistentVolume) error {
+	if !podutil.NodeIsReady(node) {
+		return fmt.Errorf("node is not ready")
+	}
+	return nil
+}
+
+func checkNodeSchedulable(pod *api.Pod, node *api.Node, nodePods []*api.Pod, pvs []*api.PersistentVolume) error {
+	if !podutil.NodeIsSchedulable(node) {
+		return fmt.Errorf("node is cordoned")
+	}
+	return nil
+}
+
+// podRequests returns the resources requested by a pod: the sum of the requests of its containers,
+// or the request of its largest init container if it is higher, as the init containers run one by one.
+func podRequests(pod *api.Pod) api.ResourceList {
+	return podResources(pod, func(resources api.ResourceRequirements) api.ResourceList { return resources.Requests })
+}
+
+// podLimits returns the resource limits of a pod, computed the same way as its requests.
+func podLimits(pod *api.Pod) api.ResourceList {
+	return podResources(pod, func(resources api.ResourceRequirements) api.ResourceList { return resources.Limits })
+}
+
+func podResources(pod *api.Pod, getResources func(resources api.ResourceRequirements) api.ResourceList) api.ResourceList {
+	result := make(api.ResourceList)
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range getResources(container.Resources) {
+			total := result[name]
+			total.Add(quantity)
+			result[name] = total
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range getResources(container.Resources) {
+			if total, exists := result[name]; !exists || quantity.Cmp(total) > 0 {
+				result[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return result
+}
+
+func checkPodFitsResources(pod *api.Pod, node *api.Node, nodePods []*api.Pod, pvs []*api.PersistentVolume) error {
+	allocatable := node.Status.Allocatable
+	if maxPods, exists := allocatable[api.ResourcePods]; exists && int64(len(nodePods)+1) > maxPods.Value() {
+		return fmt.Errorf("too many pods: %d pods already on the node, %d allocatable",
+			len(nodePods), maxPods.Value())
+	}
+
+	requested := make(api.ResourceList)
+	for _, nodePod := range nodePods {
+		for name, quantity := range podRequests(nodePod) {
+			total := requested[name]
+			total.Add(quantity)
+			requested[name] = total
+		}
+	}
+	for name, quantity := range podRequests(pod) {
+		if quantity.IsZero() {
+			continue
+		}
+		capacity, exists := allocatable[name]
+		if !exists {
+			return fmt.Errorf("insufficient %s: %s requested, none allocatable", name, quantity.String())
+		}
+		available := capacity.DeepCopy()
+		used := requested[name]
+		available.Sub(used)
+		if quantity.Cmp(available) > 0 {
+			return fmt.Errorf("insufficient %s: %s requested, %s available of %s allocatable",
+				name, quantity.String(), quantityString(available), capacity.String())
+		}
+	}
+	return nil
+}
+
+// Print a quantity which may be negative as a result of a subtraction.
+func quantityString(quantity resource.Quantity) string {
+	if quantity.Sign() < 0 {
+		return "0"
+	}
+	return quantity.String()
+}
+
+func checkPodToleratesNodeTaints(pod *api.Pod, node *api.Node, nodePods []*api.Pod, pvs []*api.PersistentVolume) error {
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect != api.TaintEffectNoSchedule && taint.Effect != api.TaintEffectNoExecute {
+			continue
+		}
+		if !compliance.TolerationsTolerateTaint(pod.Spec.Tolerations, taint) {
+			return fmt.Errorf("taint %s=%s:%s is not tolerated", taint.Key, taint.Value, taint.Effect)
+		}
+	}
+	return nil
+}
+
+func checkMatchNodeSelector(pod *api.Pod, node *api.Node, nodePods []*api.Pod, pvs []*api.PersistentVolume) error {
+	if !compliance.MatchesNodeSelector(pod, node) {
+		return fmt.Errorf("node labels do not match the node selector %v", pod.Spec.NodeSelector)
+	}
+	if !compliance.MatchesNodeAffinity(pod, node) {
+		return fmt.Errorf("node labels do not match the required node affinity")
+	}
+	return nil
+}
+
+// The host ports of a pod, keyed by protocol and port, with the host IPs they are bound to.
+func podHostPorts(pod *api.Pod) map[string][]string {
+	hostPorts := make(map[string][]string)
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.HostPort <= 0 {
+				continue
+			}
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = api.ProtocolTCP
+			}
+			hostIP := port.HostIP
+			if hostIP == "" {
+				hostIP = "0.0.0.0"
+			}
+			key := fmt.Sprintf("%s/%d", protocol, port.HostPort)
+			hostPorts[key] = append(hostPorts[key], hostIP)
+		}
+	}
+	return hostPorts
+}
+
+func checkPodFitsHostPorts(pod *api.Pod, node *api.Node, nodePods []*api.Pod, pvs []*api.PersistentVolume) error {
+	wanted := podHostPorts(pod)
+	if len(wanted) == 0 {
+		return nil
+	}
+	for _, nodePod := range nodePods {
+		for key, usedIPs := range podHostPorts(nodePod) {
+			for _, wantedIP := range wanted[key] {
+				for _, usedIP := range usedIPs {
+					// A port bound to all the addresses conflicts with the port bound to any address
+					if wantedIP == usedIP || wantedIP == "0.0.0.0" || usedIP == "0.0.0.0" {
+						return fmt.Errorf("host port %s is used by pod %s/%s", key, nodePod.Namespace, nodePod.Name)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func checkVolumeNodeAffinity(pod *api.Pod, node *api.Node, nodePods []*api.Pod, pvs []*api.PersistentVolume) error {
+	for _, pv := range pvs {
+		if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+			continue
+		}
+		if !compliance.NodeMatchesNodeSelectorTerms(node, pv.Spec.NodeAffinity.Required.NodeSelectorTerms) {
+			return fmt.Errorf("node does not match the node affinity of persistent volume %s", pv.Name)
+		}
+	}
+	return nil
+}
+
+func checkVolumeZone(pod *api.Pod, node *api.Node, nodePods []*api.Pod, pvs []*api.PersistentVolume) error {
+	for _, pv := range pvs {
+		for _, label := range []string{labelZoneFailureDomain, labelZoneRegion} {
+			pvValue, exists := pv.Labels[label]
+			if !exists {
+				continue
+			}
+			nodeValue, exists := node.Labels[label]
+			if !exists || !sets.NewString(strings.Split(pvValue, labelMultiZoneDelimiter)...).Has(nodeValue) {
+				return fmt.Errorf("persistent volume %s is in %s %s, the node is in %q",
+					pv.Name, label, pvValue, nodeValue)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newFeasibilityNode() *api.Node {
+	return &api.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			Labels: map[string]string{
+				"disk":                 "ssd",
+				labelZoneFailureDomain: "us-east-1a",
+			},
+		},
+		Status: api.NodeStatus{
+			Allocatable: api.ResourceList{
+				api.ResourceCPU:    resource.MustParse("2"),
+				api.ResourceMemory: resource.MustParse("4Gi"),
+				api.ResourcePods:   resource.MustParse("3"),
+			},
+			Conditions: []api.NodeCondition{{Type: api.NodeReady, Status: api.ConditionTrue}},
+		},
+	}
+}
+
+func newFeasibilityPod(name, cpu, memory string, hostPort int32) *api.Pod {
+	container := api.Container{
+		Name: "c1",
+		Resources: api.ResourceRequirements{
+			Requests: api.ResourceList{
+				api.ResourceCPU:    resource.MustParse(cpu),
+				api.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+	if hostPort > 0 {
+		container.Ports = []api.ContainerPort{{ContainerPort: 8080, HostPort: hostPort}}
+	}
+	return &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: name, UID: types.UID("uid-" + name)},
+		Spec:       api.PodSpec{Containers: []api.Container{container}},
+		Status:     api.PodStatus{Phase: api.PodRunning},
+	}
+}
+
+func TestPodFitsNode(t *testing.T) {
+	nodePods := []*api.Pod{
+		newFeasibilityPod("running", "1", "1Gi", 80),
+		newFeasibilityPod("completed", "1", "2Gi", 0),
+	}
+	nodePods[1].Status.Phase = api.PodSucceeded
+
+	table := []struct {
+		name      string
+		update    func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume)
+		predicate string
+	}{
+		{
+			name:   "fits",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {},
+		},
+		{
+			name: "not ready",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				node.Status.Conditions[0].Status = api.ConditionFalse
+			},
+			predicate: "NodeReady",
+		},
+		{
+			name: "cordoned",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				node.Spec.Unschedulable = true
+			},
+			predicate: "NodeSchedulable",
+		},
+		{
+			name: "insufficient cpu",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				pod.Spec.Containers[0].Resources.Requests[api.ResourceCPU] = resource.MustParse("1500m")
+			},
+			predicate: "PodFitsResources",
+		},
+		{
+			name: "too many pods",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				node.Status.Allocatable[api.ResourcePods] = resource.MustParse("1")
+			},
+			predicate: "PodFitsResources",
+		},
+		{
+			name: "larger init container",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				pod.Spec.InitContainers = []api.Container{{
+					Name: "init",
+					Resources: api.ResourceRequirements{
+						Requests: api.ResourceList{api.ResourceMemory: resource.MustParse("3500Mi")},
+					},
+				}}
+			},
+			predicate: "PodFitsResources",
+		},
+		{
+			name: "untolerated taint",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				node.Spec.Taints = []api.Taint{{Key: "dedicated", Value: "db", Effect: api.TaintEffectNoSchedule}}
+			},
+			predicate: "PodToleratesNodeTaints",
+		},
+		{
+			name: "tolerated taint",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				node.Spec.Taints = []api.Taint{{Key: "dedicated", Value: "db", Effect: api.TaintEffectNoSchedule}}
+				pod.Spec.Tolerations = []api.Toleration{{Key: "dedicated", Operator: api.TolerationOpExists}}
+			},
+		},
+		{
+			name: "node selector",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				pod.Spec.NodeSelector = map[string]string{"disk": "hdd"}
+			},
+			predicate: "MatchNodeSelector",
+		},
+		{
+			name: "required node affinity",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				pod.Spec.Affinity = &api.Affinity{NodeAffinity: &api.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &api.NodeSelector{
+						NodeSelectorTerms: []api.NodeSelectorTerm{{
+							MatchExpressions: []api.NodeSelectorRequirement{{
+								Key: "disk", Operator: api.NodeSelectorOpNotIn, Values: []string{"ssd"},
+							}},
+						}},
+					},
+				}}
+			},
+			predicate: "MatchNodeSelector",
+		},
+		{
+			name: "host port conflict",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				pod.Spec.Containers[0].Ports = []api.ContainerPort{{ContainerPort: 80, HostPort: 80, HostIP: "10.0.0.1"}}
+			},
+			predicate: "PodFitsHostPorts",
+		},
+		{
+			name: "host port of another protocol",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				pod.Spec.Containers[0].Ports = []api.ContainerPort{{ContainerPort: 80, HostPort: 80,
+					Protocol: api.ProtocolUDP}}
+			},
+		},
+		{
+			name: "volume node affinity",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				*pvs = []*api.PersistentVolume{{
+					ObjectMeta: metav1.ObjectMeta{Name: "local-pv"},
+					Spec: api.PersistentVolumeSpec{NodeAffinity: &api.VolumeNodeAffinity{
+						Required: &api.NodeSelector{NodeSelectorTerms: []api.NodeSelectorTerm{{
+							MatchExpressions: []api.NodeSelectorRequirement{{
+								Key: "kubernetes.io/hostname", Operator: api.NodeSelectorOpIn, Values: []string{"node2"},
+							}},
+						}}},
+					}},
+				}}
+			},
+			predicate: "VolumeNodeAffinity",
+		},
+		{
+			name: "volume zone",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				*pvs = []*api.PersistentVolume{{ObjectMeta: metav1.ObjectMeta{
+					Name:   "ebs-pv",
+					Labels: map[string]string{labelZoneFailureDomain: "us-east-1b__us-east-1c"},
+				}}}
+			},
+			predicate: "VolumeZone",
+		},
+		{
+			name: "volume in one of the zones",
+			update: func(pod *api.Pod, node *api.Node, pvs *[]*api.PersistentVolume) {
+				*pvs = []*api.PersistentVolume{{ObjectMeta: metav1.ObjectMeta{
+					Name:   "ebs-pv",
+					Labels: map[string]string{labelZoneFailureDomain: "us-east-1a__us-east-1c"},
+				}}}
+			},
+		},
+	}
+
+	for _, item := range table {
+		pod := newFeasibilityPod("moved", "1", "1Gi", 0)
+		node := newFeasibilityNode()
+		var pvs []*api.PersistentVolume
+		item.update(pod, node, &pvs)
+
+		err := podFitsNode(pod, node, nodePods, pvs)
+		if item.predicate == "" {
+			if err != nil {
+				t.Errorf("%s: expected the pod to fit the node but got %v", item.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: expected predicate %s to fail", item.name, item.predicate)
+		} else if !strings.Contains(err.Error(), "predicate "+item.predicate+" failed") {
+			t.Errorf("%s: expected predicate %s to fail but got %v", item.name, item.predicate, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MoveStrategy is the way a pod is moved to another node
+type MoveStrategy string
+
+const (
+	// MoveByClone creates a clone of the pod bound to the destination node, and evicts the pod once the clone
+	// is ready. The clone is adopted by the controller of the pod when it gets the labels of the pod.
+	MoveByClone MoveStrategy = "clone"
+	// MoveByEviction makes the controller of the pod create its replacement on the destination node, by
+	// constraining the pod template of the controller to the node while the pod is evicted.
+	MoveByEviction MoveStrategy = "evict"
+)
+
+func parseMoveStrategy(value string) (MoveStrategy, error) {
+	switch strategy := MoveStrategy(strings.ToLower(strings.TrimSpace(value))); strategy {
+	case MoveByClone, MoveByEviction:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown move strategy %q, expected %q or %q", value, MoveByClone, MoveByEviction)
+	}
+}
+
+// MoveStrategySelector selects the move strategy of a pod from its namespace and the kind of its controller,
+// in this order, and falls back to the default strategy.
+type MoveStrategySelector struct {
+	defaultStrategy MoveStrategy
+	byNamespace     map[string]MoveStrategy
+	// By the lower case kind of the controller, e.g., deployment
+	byKind map[string]MoveStrategy
+}
+
+// NewMoveStrategySelector creates the selector of the default strategy and the strategies of the given
+// namespaces and controller kinds, each in the form of <namespace>:<strategy> or <kind>:<strategy>.
+func NewMoveStrategySelector(defaultStrategy string, namespaces, kinds []string) (*MoveStrategySelector, error) {
+	strategy, err := parseMoveStrategy(defaultStrategy)
+	if err != nil {
+		return nil, err
+	}
+	byNamespace, err := parseMoveStrategies(namespaces, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid move strategy of namespace: %v", err)
+	}
+	byKind, err := parseMoveStrategies(kinds, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid move strategy of controller kind: %v", err)
+	}
+	return &MoveStrategySelector{
+		defaultStrategy: strategy,
+		byNamespace:     byNamespace,
+		byKind:          byKind,
+	}, nil
+}
+
+func parseMoveStrategies(entries []string, lowerCaseKey bool) (map[string]MoveStrategy, error) {
+	strategies := make(map[string]MoveStrategy)
+	for _, entry := range entries {
+		idx := strings.LastIndex(entry, ":")
+		if idx <= 0 {
+			return nil, fmt.Errorf("%q is not in the form of <name>:<strategy>", entry)
+		}
+		strategy, err := parseMoveStrategy(entry[idx+1:])
+		if err != nil {
+			return nil, err
+		}
+		key := strings.TrimSpace(entry[:idx])
+		if lowerCaseKey {
+			key = strings.ToLower(key)
+		}
+		strategies[key] = strategy
+	}
+	return strategies, nil
+}
+
+// strategyFor returns the move strategy of a pod of the given namespace, controlled by a controller of the
+// given kind, which is empty for a bare pod.
+func (s *MoveStrategySelector) strategyFor(namespace, kind string) MoveStrategy {
+	if s == nil {
+		return MoveByClone
+	}
+	if strategy, exists := s.byNamespace[namespace]; exists {
+		return strategy
+	}
+	if strategy, exists := s.byKind[strings.ToLower(kind)]; exists {
+		return strategy
+	}
+	return s.defaultStrategy
+}
+
+func (s *MoveStrategySelector) String() string {
+	if s == nil {
+		return string(MoveByClone)
+	}
+	return fmt.Sprintf("default: %s, namespaces: %v, controller kinds: %v", s.defaultStrategy, s.byNamespace,
+		s.byKind)
+}
@@ -0,0 +1,55 @@
+package executor
+
+import (
+	"testing"
+)
+
+func TestMoveStrategySelector(t *testing.T) {
+	selector, err := NewMoveStrategySelector("clone", []string{"prod:evict", "dev: clone"},
+		[]string{"StatefulSet:evict", "deployment:EVICT"})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	table := []struct {
+		namespace string
+		kind      string
+		expected  MoveStrategy
+	}{
+		{namespace: "default", kind: "ReplicaSet", expected: MoveByClone},
+		{namespace: "default", kind: "StatefulSet", expected: MoveByEviction},
+		{namespace: "default", kind: "Deployment", expected: MoveByEviction},
+		{namespace: "prod", kind: "ReplicationController", expected: MoveByEviction},
+		// The strategy of the namespace overrides the one of the controller kind
+		{namespace: "dev", kind: "StatefulSet", expected: MoveByClone},
+		{namespace: "default", kind: "", expected: MoveByClone},
+	}
+	for _, item := range table {
+		if actual := selector.strategyFor(item.namespace, item.kind); actual != item.expected {
+			t.Errorf("%s/%s: expected strategy %s but got %s", item.namespace, item.kind, item.expected, actual)
+		}
+	}
+
+	var nilSelector *MoveStrategySelector
+	if actual := nilSelector.strategyFor("prod", "StatefulSet"); actual != MoveByClone {
+		t.Errorf("expected strategy %s without selector but got %s", MoveByClone, actual)
+	}
+}
+
+func TestNewMoveStrategySelectorErrors(t *testing.T) {
+	table := []struct {
+		name            string
+		defaultStrategy string
+		namespaces      []string
+		kinds           []string
+	}{
+		{name: "unknown default strategy", defaultStrategy: "drain"},
+		{name: "missing strategy", defaultStrategy: "clone", namespaces: []string{"prod"}},
+		{name: "missing name", defaultStrategy: "clone", kinds: []string{":evict"}},
+		{name: "unknown strategy", defaultStrategy: "clone", kinds: []string{"StatefulSet:recreate"}},
+	}
+	for _, item := range table {
+		if _, err := NewMoveStrategySelector(item.defaultStrategy, item.namespaces, item.kinds); err == nil {
+			t.Errorf("%s: expected error but got nil", item.name)
+		}
+	}
+}
@@ -73,7 +73,7 @@ func genNewPodName(oldPod *api.Pod) string {
 // Move pod to node nodeName in four steps:
 //  step1: create a clone pod of the original pod (without labels)
 //  step2: wait until the cloned pod is ready
-//  step3: delete the original pod
+//  step3: evict the original pod, which fails if it would violate a PodDisruptionBudget
 //  step4: add the labels to the cloned pod
 func movePod(client *kclient.Clientset, pod *api.Pod, nodeName string, retryNum int) (*api.Pod, error) {
 	podClient := client.CoreV1().Pods(pod.Namespace)
@@ -86,6 +86,7 @@ func movePod(client *kclient.Clientset, pod *api.Pod, nodeName string, retryNum
 		glog.Errorf("Move pod failed: failed to create a clone pod: %v", err)
 		return nil, err
 	}
+	defer clonePodsInFlight.remove(npod)
 
 	//delete the clone pod if this action fails
 	flag := false
@@ -105,10 +106,9 @@ func movePod(client *kclient.Clientset, pod *api.Pod, nodeName string, retryNum
 		return nil, err
 	}
 
-	//3. delete the original pod--podA
-	delOpt := &metav1.DeleteOptions{}
-	if err := podClient.Delete(pod.Name, delOpt); err != nil {
-		glog.Errorf("Move pod warning: failed to delete original pod: %v", err)
+	//3. evict the original pod--podA
+	if err := evictPod(client, pod); err != nil {
+		glog.Errorf("Move pod failed: failed to evict original pod: %v", err)
 		return nil, err
 	}
 
@@ -132,17 +132,38 @@ func movePod(client *kclient.Clientset, pod *api.Pod, nodeName string, retryNum
 	return xpod, nil
 }
 
+// annotateClonePod sets the annotations of the clone pod of an action, which tell the ClonePodCollector
+// whether it is left behind if the action is interrupted.
+func annotateClonePod(pod, npod *api.Pod, action string) {
+	// The annotations are shared with the original pod by the copy
+	annotations := make(map[string]string, len(pod.Annotations)+4)
+	for key, value := range pod.Annotations {
+		annotations[key] = value
+	}
+	npod.Annotations = annotations
+	controller := ""
+	if owner := metav1.GetControllerOf(pod); owner != nil {
+		controller = owner.Kind + "/" + owner.Name
+	}
+	util.AddAnnotation(npod, TurboActionAnnotationKey, action)
+	util.AddAnnotation(npod, TurboActionSourcePodAnnotationKey, pod.Name)
+	util.AddAnnotation(npod, TurboActionSourcePodUIDAnnotationKey, string(pod.UID))
+	util.AddAnnotation(npod, TurboActionSourceControllerAnnotationKey, controller)
+}
+
 func createClonePod(client *kclient.Clientset, pod *api.Pod, nodeName string) (*api.Pod, error) {
 	npod := &api.Pod{}
 	copyPodWithoutLabel(pod, npod)
 	npod.Spec.NodeName = nodeName
 	npod.Name = genNewPodName(pod)
-	// this annotation can be used for future garbage collection if action is interrupted
-	util.AddAnnotation(npod, TurboActionAnnotationKey, TurboMoveAnnotationValue)
+	// these annotations are used for the garbage collection if the action is interrupted
+	annotateClonePod(pod, npod, TurboMoveAnnotationValue)
 
 	podClient := client.CoreV1().Pods(pod.Namespace)
+	clonePodsInFlight.add(npod)
 	rpod, err := podClient.Create(npod)
 	if err != nil {
+		clonePodsInFlight.remove(npod)
 		glog.Errorf("Failed to create a new pod: %s/%s, %v", npod.Namespace, npod.Name, err)
 		return nil, err
 	}
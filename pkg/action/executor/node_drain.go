@@ -0,0 +1,342 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	podutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	kclient "k8s.io/client-go/kubernetes"
+)
+
+// nodeDrainController executes a suspend action on a node which cannot be scaled through the Cluster API,
+// by cordoning the node and evicting its pods. The drained node is left cordoned and labelled for an external
+// tool or an operator to terminate it.
+type nodeDrainController struct {
+	client         *kclient.Clientset
+	clusterScraper *cluster.ClusterScraper
+	nodeName       string
+	// The pods to evict from the node, with the numbers of the ready pods of their controllers before the drain
+	pods []*drainedPod
+}
+
+// drainedPod is a pod evicted from the drained node, which is rescheduled once its controller has as many ready
+// pods on the other nodes as it had before the drain. The pods of its controller are the pods matching the selector
+// of the controller, and controlled by it.
+type drainedPod struct {
+	pod      *api.Pod
+	ownerUID types.UID
+	selector labels.Selector
+	ready    int
+}
+
+// nodeUncordonController executes a provision action when the nodes cannot be scaled through the Cluster API,
+// by reverting the drain of a node which is not terminated yet.
+type nodeUncordonController struct {
+	client   *kclient.Clientset
+	nodeName string
+}
+
+// newNodeDrainController returns the controller draining the node for a suspend action, or uncordoning a drained
+// node for a provision action, which is the node of the action if drained, or any other drained node.
+// It also returns the name of the node as the key of the action.
+func newNodeDrainController(nodeName string, actionType ActionType, kubeClient *kclient.Clientset,
+	clusterScraper *cluster.ClusterScraper) (Controller, *string, error) {
+	if actionType == ProvisionAction {
+		node, err := findDrainedNode(kubeClient, nodeName)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &nodeUncordonController{client: kubeClient, nodeName: node.Name}, &node.Name, nil
+	}
+	return &nodeDrainController{client: kubeClient, clusterScraper: clusterScraper, nodeName: nodeName}, &nodeName, nil
+}
+
+// checkPreconditions checks that the node is not drained yet, and that its pods can be evicted without violating
+// their PodDisruptionBudgets.
+func (c *nodeDrainController) checkPreconditions() error {
+	node, err := c.client.CoreV1().Nodes().Get(c.nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %v", c.nodeName, err)
+	}
+	if isNodeDrained(node) {
+		return fmt.Errorf("node %s is already drained", c.nodeName)
+	}
+	nodePods, err := c.clusterScraper.GetPodsOnNode(c.nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to list the pods of node %s: %v", c.nodeName, err)
+	}
+	pods, err := podsToDrain(nodePods)
+	if err != nil {
+		return fmt.Errorf("cannot drain node %s: %v", c.nodeName, err)
+	}
+	appsClient := goutil.NewAppsClient(c.client)
+	c.pods = nil
+	for _, pod := range pods {
+		if err := checkPodDisruptionBudgets(c.clusterScraper, pod); err != nil {
+			return fmt.Errorf("cannot drain node %s: %v", c.nodeName, err)
+		}
+		owner := metav1.GetControllerOf(pod)
+		selector, err := c.getControllerSelector(appsClient, pod, owner)
+		if err != nil {
+			return err
+		}
+		p := &drainedPod{
+			pod:      pod,
+			ownerUID: owner.UID,
+			selector: selector,
+		}
+		siblings, err := c.listControllerPods(p)
+		if err != nil {
+			return err
+		}
+		p.ready = countReadyPods(siblings, "")
+		c.pods = append(c.pods, p)
+	}
+	glog.V(2).Infof("Draining node %s evicts %d pods", c.nodeName, len(c.pods))
+	return nil
+}
+
+// executeAction cordons and labels the node, and evicts its pods. The evictions refused by the PodDisruptionBudgets
+// are retried, as the budgets allow them once the pods evicted before are rescheduled.
+// The node is uncordoned if a pod cannot be evicted.
+func (c *nodeDrainController) executeAction() error {
+	//1. cordon and label the node
+	if err := setNodeDrained(c.client, c.nodeName, true); err != nil {
+		return fmt.Errorf("failed to cordon node %s: %v", c.nodeName, err)
+	}
+	glog.V(2).Infof("Cordoned node %s", c.nodeName)
+
+	//2. evict the pods
+	retryNum := defaultRetryMore
+	timeout := time.Duration(retryNum+1) * defaultPodCheckSleep
+	for _, p := range c.pods {
+		pod := p.pod
+		err := goutil.RetryDuring(retryNum, timeout, defaultPodCheckSleep, func() error {
+			xpod, err := c.client.CoreV1().Pods(pod.Namespace).Get(pod.Name, metav1.GetOptions{})
+			if errors.IsNotFound(err) || (err == nil && xpod.UID != pod.UID) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return evictPod(c.client, xpod)
+		})
+		if err != nil {
+			c.revert()
+			return fmt.Errorf("failed to drain node %s: %v", c.nodeName, err)
+		}
+	}
+	return nil
+}
+
+// checkSuccess waits until the evicted pods are deleted, and until their controllers have as many ready pods
+// on the other nodes as before the drain. The node is uncordoned if the pods are not rescheduled.
+func (c *nodeDrainController) checkSuccess() error {
+	for _, p := range c.pods {
+		err := waitForPodDeleted(c.client, p.pod.Namespace, p.pod.Name, p.pod.UID, defaultRetryMore,
+			defaultPodCheckSleep)
+		if err != nil {
+			c.revert()
+			return fmt.Errorf("failed to drain node %s: %v", c.nodeName, err)
+		}
+	}
+	for _, p := range c.pods {
+		if err := c.waitForRescheduled(p); err != nil {
+			c.revert()
+			return fmt.Errorf("failed to drain node %s: %v", c.nodeName, err)
+		}
+	}
+	glog.V(2).Infof("Node %s is drained, and left cordoned with label %s=%s to be terminated", c.nodeName,
+		TurboDrainedNodeLabelKey, TurboDrainedNodeLabelValue)
+	return nil
+}
+
+// waitForRescheduled waits until the controller of the evicted pod has as many ready pods on the other nodes
+// as before the drain
+func (c *nodeDrainController) waitForRescheduled(p *drainedPod) error {
+	timeout := time.Duration(defaultRetryMore+1) * defaultPodCheckSleep
+	return goutil.RetrySimple(defaultRetryMore, timeout, defaultPodCheckSleep, func() (bool, error) {
+		siblings, err := c.listControllerPods(p)
+		if err != nil {
+			return true, err
+		}
+		ready := countReadyPods(siblings, c.nodeName)
+		if ready < p.ready {
+			return true, fmt.Errorf("pod %s/%s is not rescheduled yet: %d of %d pods of its controller are ready",
+				p.pod.Namespace, p.pod.Name, ready, p.ready)
+		}
+		return false, nil
+	})
+}
+
+// getControllerSelector returns the pod selector of the given controller of the pod. The pods of the controllers
+// whose pods cannot be moved by eviction, e.g., the Jobs, are selected by their controller only.
+func (c *nodeDrainController) getControllerSelector(appsClient *goutil.AppsClient, pod *api.Pod,
+	owner *metav1.OwnerReference) (labels.Selector, error) {
+	controller := newEvictionParent(c.client, appsClient, owner.Kind, pod.Namespace)
+	if controller == nil {
+		return labels.Everything(), nil
+	}
+	spec, err := controller.get(owner.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the pod selector of %v %s/%s: %v", controller, pod.Namespace,
+			owner.Name, err)
+	}
+	return spec.selector, nil
+}
+
+// listControllerPods lists the pods of the controller of the drained pod, by its selector and owner as for a move
+// by eviction, since the labels of the pods of a StatefulSet differ from each other
+func (c *nodeDrainController) listControllerPods(p *drainedPod) ([]*api.Pod, error) {
+	pods, err := c.clusterScraper.GetPodsBySelector(p.pod.Namespace, p.selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods of the controller of pod %s/%s: %v", p.pod.Namespace,
+			p.pod.Name, err)
+	}
+	return selectControllerPods(pods, p.selector, p.ownerUID), nil
+}
+
+// revert uncordons the node after a failed drain
+func (c *nodeDrainController) revert() {
+	if err := setNodeDrained(c.client, c.nodeName, false); err != nil {
+		glog.Errorf("Failed to uncordon node %s after the failed drain: %v", c.nodeName, err)
+		return
+	}
+	glog.V(2).Infof("Uncordoned node %s after the failed drain", c.nodeName)
+}
+
+// checkPreconditions checks that the node is still drained
+func (c *nodeUncordonController) checkPreconditions() error {
+	node, err := c.client.CoreV1().Nodes().Get(c.nodeName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %v", c.nodeName, err)
+	}
+	if !isNodeDrained(node) {
+		return fmt.Errorf("node %s is not drained anymore", c.nodeName)
+	}
+	return nil
+}
+
+// executeAction uncordons the node and removes its label
+func (c *nodeUncordonController) executeAction() error {
+	if err := setNodeDrained(c.client, c.nodeName, false); err != nil {
+		return fmt.Errorf("failed to uncordon node %s: %v", c.nodeName, err)
+	}
+	glog.V(2).Infof("Uncordoned node %s", c.nodeName)
+	return nil
+}
+
+// checkSuccess waits until the node is ready to schedule pods
+func (c *nodeUncordonController) checkSuccess() error {
+	timeout := time.Duration(defaultRetryMore+1) * defaultPodCheckSleep
+	return goutil.RetrySimple(defaultRetryMore, timeout, defaultPodCheckSleep, func() (bool, error) {
+		node, err := c.client.CoreV1().Nodes().Get(c.nodeName, metav1.GetOptions{})
+		if err != nil {
+			return true, fmt.Errorf("failed to get node %s: %v", c.nodeName, err)
+		}
+		if node.Spec.Unschedulable || !podutil.NodeIsReady(node) {
+			return true, fmt.Errorf("node %s is not ready to schedule pods yet", c.nodeName)
+		}
+		return false, nil
+	})
+}
+
+// findDrainedNode returns the node of the given name if it is drained, otherwise any other drained node
+func findDrainedNode(client *kclient.Clientset, nodeName string) (*api.Node, error) {
+	node, err := client.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+	if err == nil && isNodeDrained(node) {
+		return node, nil
+	}
+	nodeList, err := client.CoreV1().Nodes().List(metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{TurboDrainedNodeLabelKey: TurboDrainedNodeLabelValue}).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the drained nodes: %v", err)
+	}
+	for i := range nodeList.Items {
+		if isNodeDrained(&nodeList.Items[i]) {
+			return &nodeList.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("there is no drained node to uncordon for a node like %s, and nodes can only be "+
+		"provisioned through the Cluster API", nodeName)
+}
+
+// isNodeDrained returns whether the node is cordoned and labelled by a drain
+func isNodeDrained(node *api.Node) bool {
+	return node.Spec.Unschedulable && node.Labels[TurboDrainedNodeLabelKey] == TurboDrainedNodeLabelValue
+}
+
+// setNodeDrained cordons and labels the node, or uncordons it and removes the label, with retry and timeout
+func setNodeDrained(client *kclient.Clientset, nodeName string, drained bool) error {
+	retryNum := defaultRetryLess
+	interval := defaultUpdateReplicaSleep
+	timeout := time.Duration(retryNum+1) * interval
+	return goutil.RetryDuring(retryNum, timeout, interval, func() error {
+		nodeClient := client.CoreV1().Nodes()
+		node, err := nodeClient.Get(nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		node.Spec.Unschedulable = drained
+		if drained {
+			if node.Labels == nil {
+				node.Labels = make(map[string]string)
+			}
+			node.Labels[TurboDrainedNodeLabelKey] = TurboDrainedNodeLabelValue
+		} else {
+			delete(node.Labels, TurboDrainedNodeLabelKey)
+		}
+		_, err = nodeClient.Update(node)
+		return err
+	})
+}
+
+// podsToDrain returns the pods to evict from a node, skipping the finished, terminating, mirror and daemon pods.
+// It returns an error if a pod has no controller to recreate it on another node.
+func podsToDrain(pods []*api.Pod) ([]*api.Pod, error) {
+	var result []*api.Pod
+	for _, pod := range pods {
+		if pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if _, isMirror := pod.Annotations[api.MirrorPodAnnotationKey]; isMirror {
+			continue
+		}
+		if podutil.Daemon(pod) {
+			continue
+		}
+		if metav1.GetControllerOf(pod) == nil {
+			return nil, fmt.Errorf("pod %s/%s has no controller to recreate it on another node", pod.Namespace,
+				pod.Name)
+		}
+		result = append(result, pod)
+	}
+	return result, nil
+}
+
+// countReadyPods returns the number of the ready pods of a controller, which are not terminating nor on
+// the excluded node, if any
+func countReadyPods(pods []*api.Pod, excludedNode string) int {
+	count := 0
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if excludedNode != "" && pod.Spec.NodeName == excludedNode {
+			continue
+		}
+		if podutil.PodIsReady(pod) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newDrainPod(name, ownerKind string, ownerUID types.UID, nodeName string, ready bool) *api.Pod {
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: name, UID: types.UID(name)},
+		Spec:       api.PodSpec{NodeName: nodeName},
+		Status:     api.PodStatus{Phase: api.PodRunning},
+	}
+	if ownerKind != "" {
+		isController := true
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: ownerKind, Name: "owner", UID: ownerUID,
+			Controller: &isController}}
+	}
+	if ready {
+		pod.Status.Conditions = []api.PodCondition{{Type: api.PodReady, Status: api.ConditionTrue}}
+	}
+	return pod
+}
+
+func TestPodsToDrain(t *testing.T) {
+	web := newDrainPod("web-1", goutil.KindReplicaSet, "rs1", "node1", true)
+	db := newDrainPod("db-0", goutil.KindStatefulSet, "sts1", "node1", true)
+	daemon := newDrainPod("agent-x2k", goutil.KindDaemonSet, "ds1", "node1", true)
+	mirror := newDrainPod("kube-proxy-node1", "", "", "node1", true)
+	mirror.Annotations = map[string]string{api.MirrorPodAnnotationKey: "mirror"}
+	finished := newDrainPod("job-x2k", "Job", "job1", "node1", false)
+	finished.Status.Phase = api.PodSucceeded
+
+	pods, err := podsToDrain([]*api.Pod{web, daemon, mirror, db, finished})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(pods) != 2 || pods[0].Name != web.Name || pods[1].Name != db.Name {
+		t.Errorf("expected pods %s and %s but got %v", web.Name, db.Name, pods)
+	}
+
+	bare := newDrainPod("bare", "", "", "node1", true)
+	if _, err := podsToDrain([]*api.Pod{web, bare}); err == nil {
+		t.Errorf("expected an error for the bare pod")
+	}
+}
+
+func TestCountReadyPods(t *testing.T) {
+	terminating := newDrainPod("web-4", goutil.KindReplicaSet, "rs1", "node2", true)
+	now := metav1.Now()
+	terminating.DeletionTimestamp = &now
+	pods := []*api.Pod{
+		newDrainPod("web-1", goutil.KindReplicaSet, "rs1", "node1", true),
+		newDrainPod("web-2", goutil.KindReplicaSet, "rs1", "node2", true),
+		newDrainPod("web-3", goutil.KindReplicaSet, "rs1", "node3", false),
+		terminating,
+	}
+	if count := countReadyPods(pods, ""); count != 2 {
+		t.Errorf("expected 2 ready pods but got %d", count)
+	}
+	if count := countReadyPods(pods, "node1"); count != 1 {
+		t.Errorf("expected 1 ready pod off node1 but got %d", count)
+	}
+}
+
+func TestDrainListControllerPods(t *testing.T) {
+	newLabels := func(name string) map[string]string {
+		return map[string]string{"app": "db", "statefulset.kubernetes.io/pod-name": name}
+	}
+	drained := newDrainPod("db-0", goutil.KindStatefulSet, "sts1", "node1", true)
+	drained.Labels = newLabels("db-0")
+	// The labels of the pods of a StatefulSet differ from each other
+	sibling := newDrainPod("db-1", goutil.KindStatefulSet, "sts1", "node2", true)
+	sibling.Labels = newLabels("db-1")
+	// A pod matching the selector, of another controller
+	orphan := newDrainPod("db-2", goutil.KindStatefulSet, "old-sts1", "node2", true)
+	orphan.Labels = newLabels("db-2")
+	other := newDrainPod("web-0", goutil.KindStatefulSet, "sts1", "node2", true)
+	other.Labels = map[string]string{"app": "web"}
+
+	cache, err := cluster.NewClusterCacheFromObjects(&cluster.ClusterObjects{
+		Pods: []*api.Pod{drained, sibling, orphan, other},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	c := &nodeDrainController{
+		clusterScraper: cluster.NewClusterScraper(nil).WithClusterCache(cache),
+		nodeName:       "node1",
+	}
+	pods, err := c.listControllerPods(&drainedPod{
+		pod:      drained,
+		ownerUID: "sts1",
+		selector: labels.SelectorFromSet(labels.Set{"app": "db"}),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var names []string
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	sort.Strings(names)
+	expected := []string{"db-0", "db-1"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected the pods %v but got %v", expected, names)
+	}
+}
@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/glog"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	kclient "k8s.io/client-go/kubernetes"
+)
+
+const (
+	// The annotation ranking the pods of a ReplicaSet to remove when it is scaled down, the lowest cost first
+	podDeletionCostAnnotation = "controller.kubernetes.io/pod-deletion-cost"
+	// The lowest deletion cost, for the pod to be removed before any other pod of its ReplicaSet
+	podDeletionCostLowest = "-2147483648"
+
+	// The pod deletion cost is enabled by default since Kubernetes 1.22
+	podDeletionCostMajorVersion = 1
+	podDeletionCostMinorVersion = 22
+)
+
+// supportsPodDeletionCost returns whether the ReplicaSet controller of the cluster honors the pod deletion cost,
+// based on the version of the API server
+func supportsPodDeletionCost(client *kclient.Clientset) bool {
+	supported, err := goutil.ServerVersionAtLeast(client.Discovery(), podDeletionCostMajorVersion,
+		podDeletionCostMinorVersion)
+	if err != nil {
+		glog.Warningf("Assuming no support of the pod deletion cost: %v", err)
+		return false
+	}
+	return supported
+}
+
+// setPodDeletionCost gives the pod the lowest deletion cost, so that its ReplicaSet removes it first when scaled down
+func setPodDeletionCost(client *kclient.Clientset, pod *api.Pod) error {
+	if err := patchPodDeletionCost(client, pod, podDeletionCostLowest); err != nil {
+		return fmt.Errorf("failed to set the deletion cost of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+	glog.V(3).Infof("Set the deletion cost of pod %s/%s to %s", pod.Namespace, pod.Name, podDeletionCostLowest)
+	return nil
+}
+
+// removePodDeletionCost removes the deletion cost from the pod, unless the pod is already gone
+func removePodDeletionCost(client *kclient.Clientset, pod *api.Pod) {
+	err := patchPodDeletionCost(client, pod, nil)
+	if err == nil {
+		glog.V(3).Infof("Removed the deletion cost of pod %s/%s", pod.Namespace, pod.Name)
+		return
+	}
+	// The pod is deleted, or replaced by another pod of the same name which fails the UID precondition
+	if errors.IsNotFound(err) || errors.IsConflict(err) {
+		return
+	}
+	glog.Errorf("Failed to remove the deletion cost of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+}
+
+// patchPodDeletionCost sets the deletion cost annotation of the pod to the given value, or removes it if nil.
+// The patch only applies to the pod of the same UID.
+func patchPodDeletionCost(client *kclient.Clientset, pod *api.Pod, cost interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"uid":         pod.UID,
+			"annotations": map[string]interface{}{podDeletionCostAnnotation: cost},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.CoreV1().Pods(pod.Namespace).Patch(pod.Name, types.MergePatchType, patch)
+	return err
+}
@@ -9,18 +9,23 @@ import (
 	api "k8s.io/api/core/v1"
 
 	podutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 )
 
 type ReScheduler struct {
 	TurboK8sActionExecutor
 	sccAllowedSet map[string]struct{}
+	// Selects whether a pod is moved by clone or by eviction, the pods are moved by clone if nil
+	moveStrategies *MoveStrategySelector
 }
 
-func NewReScheduler(ae TurboK8sActionExecutor, sccAllowedSet map[string]struct{}) *ReScheduler {
+func NewReScheduler(ae TurboK8sActionExecutor, sccAllowedSet map[string]struct{},
+	moveStrategies *MoveStrategySelector) *ReScheduler {
 	return &ReScheduler{
 		TurboK8sActionExecutor: ae,
 		sccAllowedSet:          sccAllowedSet,
+		moveStrategies:         moveStrategies,
 	}
 }
 
@@ -50,63 +55,6 @@ func (r *ReScheduler) Execute(input *TurboActionExecutorInput) (*TurboActionExec
 	}, nil
 }
 
-// get k8s.node of the new hosting node
-func (r *ReScheduler) getNode(action *proto.ActionItemDTO) (*api.Node, error) {
-	//1. check host entity
-	hostSE := action.GetNewSE()
-	if hostSE == nil {
-		err := fmt.Errorf("New host entity is empty")
-		glog.Errorf("%v.", err)
-		return nil, err
-	}
-
-	//2. check entity type
-	etype := hostSE.GetEntityType()
-	if etype != proto.EntityDTO_VIRTUAL_MACHINE && etype != proto.EntityDTO_PHYSICAL_MACHINE {
-		err := fmt.Errorf("The move destination [%v] is neither a VM nor a PM", etype)
-		glog.Errorf("%v.", err)
-		return nil, err
-	}
-
-	//3. get node from properties
-	node, err := util.GetNodeFromProperties(r.kubeClient, hostSE.GetEntityProperties())
-	if err == nil {
-		glog.V(2).Infof("Get node(%v) from properties.", node.Name)
-		return node, nil
-	}
-
-	//4. get node by displayName
-	node, err = util.GetNodebyName(r.kubeClient, hostSE.GetDisplayName())
-	if err == nil {
-		glog.V(2).Infof("Get node(%v) by displayName.", node.Name)
-		return node, nil
-	}
-
-	//5. get node by UUID
-	node, err = util.GetNodebyUUID(r.kubeClient, hostSE.GetId())
-	if err == nil {
-		glog.V(2).Infof("Get node(%v) by UUID(%v).", node.Name, hostSE.GetId())
-		return node, nil
-	}
-
-	//6. get node by IP
-	vmIPs := getVMIps(hostSE)
-	if len(vmIPs) > 0 {
-		node, err = util.GetNodebyIP(r.kubeClient, vmIPs)
-		if err == nil {
-			glog.V(2).Infof("Get node(%v) by IP.", hostSE.GetDisplayName())
-			return node, nil
-		}
-		err = fmt.Errorf("Failed to get node %s by IP %+v: %v",
-			hostSE.GetDisplayName(), vmIPs, err)
-	} else {
-		err = fmt.Errorf("Failed to get node %s: IPs are empty",
-			hostSE.GetDisplayName())
-	}
-	glog.Errorf("%v.", err)
-	return nil, err
-}
-
 // get kubernetes pod, and the new hosting kubernetes node
 func (r *ReScheduler) getPodNode(action *proto.ActionItemDTO) (*api.Node, error) {
 	glog.V(4).Infof("MoveActionItem: %++v", action)
@@ -115,7 +63,6 @@ func (r *ReScheduler) getPodNode(action *proto.ActionItemDTO) (*api.Node, error)
 }
 
 // Check whether the action should be executed.
-// TODO: find a reliable way to check node's status; current checking has no actual effect.
 func (r *ReScheduler) preActionCheck(pod *api.Pod, node *api.Node) error {
 	fullName := fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
 
@@ -132,17 +79,10 @@ func (r *ReScheduler) preActionCheck(pod *api.Pod, node *api.Node) error {
 		glog.Errorf("Move action should be aborted: original pod termiated:%v phase:%v", fullName, pod.Status.Phase)
 	}
 
-	//2. if Node is out of condition
-	conditions := node.Status.Conditions
-	if conditions == nil || len(conditions) < 1 {
-		glog.Warningf("Move action: pod[%v]'s new host(%v) condition is unknown", fullName, node.Name)
-		return nil
-	}
-
-	for _, cond := range conditions {
-		if cond.Status != api.ConditionTrue {
-			glog.Warningf("Move action: pod[%v]'s new host(%v) in bad condition: %v", fullName, node.Name, cond.Type)
-		}
+	//2. if the pod cannot run on the new host, as the clone pod is not checked by the scheduler
+	if err := r.checkMoveFeasibility(pod, node); err != nil {
+		glog.Errorf("%v.", err)
+		return err
 	}
 
 	return nil
@@ -171,16 +111,50 @@ func (r *ReScheduler) reSchedule(pod *api.Pod, node *api.Node) (*api.Pod, error)
 		return nil, err
 	}
 
-	if !util.SupportedParent(parentKind) {
+	strategy, err := r.getMoveStrategy(pod, parentKind)
+	if err != nil {
+		glog.Errorf("Move action aborted: %v.", err)
+		return nil, err
+	}
+
+	if strategy == MoveByEviction && !supportedEvictionParent(parentKind) ||
+		strategy == MoveByClone && !util.SupportedParent(parentKind) {
 		err = fmt.Errorf("The object kind [%v] of [%s] is not supported", parentKind, parentName)
 		glog.Errorf("Move action aborted: %v.", err)
 		return nil, err
 	}
 
+	if err := checkPodDisruptionBudgets(r.clusterScraper, pod); err != nil {
+		glog.Errorf("Move action aborted: %v.", err)
+		return nil, err
+	}
+
 	//2. move
+	glog.V(2).Infof("Move pod %s to node %s by %s.", fullName, nodeName, strategy)
+	if strategy == MoveByEviction {
+		return moveByEviction(r.kubeClient, pod, node, defaultRetryMore)
+	}
 	return movePod(r.kubeClient, pod, nodeName, defaultRetryMore)
 }
 
+// getMoveStrategy selects the move strategy of the pod from its namespace and the kind of its controller,
+// which is the Deployment of a ReplicaSet, read through the cluster cache. A bare pod is always moved by clone,
+// as nothing would replace it once evicted.
+func (r *ReScheduler) getMoveStrategy(pod *api.Pod, parentKind string) (MoveStrategy, error) {
+	if parentKind == "" {
+		return MoveByClone, nil
+	}
+	kind := parentKind
+	if parentKind == goutil.KindReplicaSet {
+		grandKind, _, err := r.clusterScraper.GetPodGrandInfo(pod)
+		if err != nil {
+			return "", err
+		}
+		kind = grandKind
+	}
+	return r.moveStrategies.strategyFor(pod.Namespace, kind), nil
+}
+
 func getVMIps(entity *proto.EntityDTO) []string {
 	result := []string{}
 
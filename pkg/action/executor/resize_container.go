@@ -3,6 +3,7 @@ package executor
 import (
 	"fmt"
 	"math"
+	"time"
 
 	"github.com/golang/glog"
 
@@ -38,6 +39,12 @@ type ContainerResizer struct {
 	enableNonDisruptiveSupport bool
 	sccAllowedSet              map[string]struct{}
 	spec                       *containerResizeSpec
+	// Clamp the resized resources to the LimitRanges of the namespace instead of failing the action
+	clampToLimitRange bool
+	// The time to verify the health of the pods after the rollout of a consistent resize, and the number of
+	// restarts of a container within that time which fails the resize
+	rolloutVerificationWindow time.Duration
+	rolloutRestartThreshold   int32
 }
 
 func NewContainerResizeSpec(idx int) *containerResizeSpec {
@@ -49,11 +56,15 @@ func NewContainerResizeSpec(idx int) *containerResizeSpec {
 }
 
 func NewContainerResizer(ae TurboK8sActionExecutor, kubeletClient *kubeclient.KubeletClient,
-	sccAllowedSet map[string]struct{}) *ContainerResizer {
+	sccAllowedSet map[string]struct{}, clampToLimitRange bool, rolloutVerificationWindow time.Duration,
+	rolloutRestartThreshold int32) *ContainerResizer {
 	return &ContainerResizer{
-		TurboK8sActionExecutor: ae,
-		kubeletClient:          kubeletClient,
-		sccAllowedSet:          sccAllowedSet,
+		TurboK8sActionExecutor:    ae,
+		kubeletClient:             kubeletClient,
+		sccAllowedSet:             sccAllowedSet,
+		clampToLimitRange:         clampToLimitRange,
+		rolloutVerificationWindow: rolloutVerificationWindow,
+		rolloutRestartThreshold:   rolloutRestartThreshold,
 	}
 }
 
@@ -241,12 +252,23 @@ func (r *ContainerResizer) Execute(input *TurboActionExecutorInput) (*TurboActio
 		return &TurboActionExecutorOutput{}, err
 	}
 
-	// execute the Action
+	// validate the new resources against the LimitRanges and ResourceQuotas of the namespace
+	consistentResize := actionItem.GetConsistentScalingCompliance()
+	if err := validateResize(r.kubeClient, pod, spec, consistentResize, r.clampToLimitRange); err != nil {
+		glog.Errorf("Failed to execute resize action: %v", err)
+		return &TurboActionExecutorOutput{}, err
+	}
+
+	// execute the Action, following the rollout of a consistent resize
+	rollout := newResizeRollout(r.kubeClient, input.Progress, r.rolloutVerificationWindow,
+		r.rolloutRestartThreshold)
 	npod, err := resizeContainer(
 		r.kubeClient,
+		r.clusterScraper,
 		pod,
 		spec,
-		actionItem.GetConsistentScalingCompliance(),
+		consistentResize,
+		rollout,
 	)
 	if err != nil {
 		glog.Errorf("Failed to execute resize action: %v", err)
@@ -3,10 +3,12 @@ package executor
 import (
 	"fmt"
 	"math"
+	"strings"
 
 	"github.com/golang/glog"
 
 	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	podutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	k8sapi "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -150,12 +152,12 @@ func genMemoryQuantity(newValue float64) (resource.Quantity, error) {
 	return resource.ParseQuantity(fmt.Sprintf("%dKi", tmp))
 }
 
-func resizeContainer(client *kclient.Clientset, pod *k8sapi.Pod, spec *containerResizeSpec,
-	consistentResize bool) (*k8sapi.Pod, error) {
+func resizeContainer(client *kclient.Clientset, clusterScraper *cluster.ClusterScraper, pod *k8sapi.Pod,
+	spec *containerResizeSpec, consistentResize bool, rollout *resizeRollout) (*k8sapi.Pod, error) {
 	if consistentResize {
-		return nil, resizeControllerContainer(client, pod, spec)
+		return nil, resizeControllerContainer(client, clusterScraper, pod, spec, rollout)
 	}
-	return resizeSingleContainer(client, pod, spec)
+	return resizeSingleContainer(client, clusterScraper, pod, spec)
 }
 
 // resizeControllerContainer updates the pod template of the controller that this container pod
@@ -163,37 +165,98 @@ func resizeContainer(client *kclient.Clientset, pod *k8sapi.Pod, spec *container
 // - For Deployment, after pod template is successfully updated, a new ReplicaSet will be created
 //   and associated with this Deployment. After the new ReplicaSet scales to the desired number of
 //   pods, the original ReplicaSet associated with this Deployment is then scaled to 0, effectively
-//   terminating all original pods
+//   terminating all original pods. If a rollout is given, the resize succeeds only once the rollout
+//   is complete and the new pods are healthy, otherwise the resources of the container are rolled back
 // - For ReplicaSet and ReplicationController, only pod template will be updated with the new
 //   resource, all existing pods that belong to the original ReplicaSet and ReplicationController
 //   are not affected. Only newly created pods (through scaling action) will use the updated
 //   resource
-func resizeControllerContainer(client *kclient.Clientset, pod *k8sapi.Pod, spec *containerResizeSpec) error {
+// - For StatefulSet and DaemonSet, the existing pods are replaced according to the update strategy:
+//   with RollingUpdate, the pods are replaced one by one, only from the partition of a StatefulSet;
+//   with OnDelete, the existing pods keep their resources until they are deleted
+func resizeControllerContainer(client *kclient.Clientset, clusterScraper *cluster.ClusterScraper, pod *k8sapi.Pod,
+	spec *containerResizeSpec, rollout *resizeRollout) error {
 	// prepare controllerUpdater
 	controllerUpdater, err := newK8sControllerUpdater(client, pod)
 	if err != nil {
 		glog.Errorf("Failed to create controllerUpdater: %v", err)
 		return err
 	}
+	// keep the resources of the container to roll back to
+	current, err := controllerUpdater.controller.get(controllerUpdater.name)
+	if err != nil {
+		glog.Errorf("Failed to get %v of pod %s/%s: %v", controllerUpdater.controller, pod.Namespace, pod.Name, err)
+		return err
+	}
+	if spec.Index >= len(current.podSpec.Containers) {
+		return fmt.Errorf("failed to find container[%d] in the pod template of %v %s/%s", spec.Index,
+			controllerUpdater.controller, pod.Namespace, controllerUpdater.name)
+	}
+	// Only check the disruption of the pods if the controller replaces the existing pods with the updated template
+	if current.replacesPods {
+		if err := checkPodDisruptionBudgets(clusterScraper, pod); err != nil {
+			glog.Errorf("Consistent resize of %v of pod %s/%s aborted: %v",
+				controllerUpdater.controller, pod.Namespace, pod.Name, err)
+			return err
+		}
+	} else {
+		glog.V(2).Infof("The existing pods of %v %s/%s keep their resources until they are recreated, "+
+			"as its update strategy does not replace them.", controllerUpdater.controller, pod.Namespace,
+			controllerUpdater.name)
+	}
+	previous := &containerResources{
+		index:     spec.Index,
+		resources: *current.podSpec.Containers[spec.Index].Resources.DeepCopy(),
+	}
 	glog.V(2).Infof("Begin to consistently resize %v of pod %s/%s.",
 		controllerUpdater.controller, pod.Namespace, pod.Name)
 	// execute the action to update resource requirements of the container of interest
-	err = controllerUpdater.updateWithRetry(&controllerSpec{0, spec})
+	err = controllerUpdater.updateWithRetry(&controllerSpec{resizeSpec: spec})
 	if err != nil {
 		glog.Errorf("Failed to consistently resize %v of pod %s/%s: %v",
 			controllerUpdater.controller, pod.Namespace, pod.Name, err)
 		return err
 	}
+	if _, isDeployment := controllerUpdater.controller.(*deployment); !isDeployment || rollout == nil {
+		return nil
+	}
+	// follow the rollout of the resized pods, and roll back if it fails
+	if err := rollout.verifyDeployment(pod.Namespace, controllerUpdater.name); err != nil {
+		glog.Errorf("Failed to consistently resize %v of pod %s/%s: %v, rolling back.",
+			controllerUpdater.controller, pod.Namespace, pod.Name, err)
+		if rollbackErr := controllerUpdater.updateWithRetry(&controllerSpec{restoreSpec: previous}); rollbackErr != nil {
+			glog.Errorf("Failed to roll back the resize of %v of pod %s/%s: %v",
+				controllerUpdater.controller, pod.Namespace, pod.Name, rollbackErr)
+			return fmt.Errorf("%v; failed to roll back the resources of the container: %v", err, rollbackErr)
+		}
+		return fmt.Errorf("%v; rolled back the resources of the container to %s", err,
+			describeResources(previous.resources))
+	}
 	return nil
 }
 
+// describeResources returns a short description of the limits and requests of a container
+func describeResources(resources k8sapi.ResourceRequirements) string {
+	describe := func(list k8sapi.ResourceList) string {
+		var values []string
+		for _, name := range []k8sapi.ResourceName{k8sapi.ResourceCPU, k8sapi.ResourceMemory} {
+			if quantity, exists := list[name]; exists {
+				values = append(values, fmt.Sprintf("%s=%s", name, quantity.String()))
+			}
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	}
+	return fmt.Sprintf("limits %s and requests %s", describe(resources.Limits), describe(resources.Requests))
+}
+
 // resizeSingleContainer resizes a single container pod in the following steps:
 // - create a clone pod of the original pod (without labels), with new resource limits/requests;
 // - wait until the cloned pod is ready
-// - delete the original pod
+// - evict the original pod, which fails if it would violate a PodDisruptionBudget
 // - add the labels to the cloned pod
 // If the action fails, the cloned pod will be deleted
-func resizeSingleContainer(client *kclient.Clientset, originalPod *k8sapi.Pod, spec *containerResizeSpec) (*k8sapi.Pod, error) {
+func resizeSingleContainer(client *kclient.Clientset, clusterScraper *cluster.ClusterScraper, originalPod *k8sapi.Pod,
+	spec *containerResizeSpec) (*k8sapi.Pod, error) {
 	// check parent controller of the original pod
 	fullName := util.BuildIdentifier(originalPod.Namespace, originalPod.Name)
 	parentKind, parentName, err := podutil.GetPodParentInfo(originalPod)
@@ -223,6 +286,11 @@ func resizeSingleContainer(client *kclient.Clientset, originalPod *k8sapi.Pod, s
 		return nil, err
 	}
 
+	if err := checkPodDisruptionBudgets(clusterScraper, originalPod); err != nil {
+		glog.Errorf("Resize action aborted: %v.", err)
+		return nil, err
+	}
+
 	// create a clone pod with new size
 	clonePod, changed, err := clonePodWithNewSize(client, originalPod, spec)
 	if err != nil {
@@ -234,6 +302,7 @@ func resizeSingleContainer(client *kclient.Clientset, originalPod *k8sapi.Pod, s
 		glog.Warningf("No need to resize container %s. Not enough change.", id)
 		return nil, fmt.Errorf("resize aborted due to not enough change")
 	}
+	defer clonePodsInFlight.remove(clonePod)
 
 	// delete the clone pod if this action fails
 	success := false
@@ -255,10 +324,10 @@ func resizeSingleContainer(client *kclient.Clientset, originalPod *k8sapi.Pod, s
 		return nil, err
 	}
 
-	// delete the original pod after the clone pod is ready
-	delOpt := &metav1.DeleteOptions{}
-	if err := podClient.Delete(originalPod.Name, delOpt); err != nil {
-		glog.Warningf("Resize podContainer warning: failed to delete original pod: %v", err)
+	// evict the original pod after the clone pod is ready
+	if err := evictPod(client, originalPod); err != nil {
+		glog.Errorf("Resize podContainer failed: failed to evict original pod: %v", err)
+		return nil, err
 	}
 
 	// add labels to the clone pod so it can be attached to the controller if any
@@ -290,8 +359,8 @@ func clonePodWithNewSize(client *kclient.Clientset, pod *k8sapi.Pod, spec *conta
 	copyPodWithoutLabel(pod, npod)
 	npod.Spec.NodeName = pod.Spec.NodeName
 	npod.Name = genNewPodName(pod)
-	// this annotation can be used for future garbage collection if action is interrupted
-	util.AddAnnotation(npod, TurboActionAnnotationKey, TurboResizeAnnotationValue)
+	// these annotations are used for the garbage collection if the action is interrupted
+	annotateClonePod(pod, npod, TurboResizeAnnotationValue)
 
 	//2. resize resource limits/requests
 	glog.V(4).Infof("Update container %v resources in the pod specification.", id)
@@ -305,8 +374,10 @@ func clonePodWithNewSize(client *kclient.Clientset, pod *k8sapi.Pod, spec *conta
 
 	//3. create pod
 	podClient := client.CoreV1().Pods(pod.Namespace)
+	clonePodsInFlight.add(npod)
 	rpod, err := podClient.Create(npod)
 	if err != nil {
+		clonePodsInFlight.remove(npod)
 		return nil, true, err
 	}
 
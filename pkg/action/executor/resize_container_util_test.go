@@ -150,3 +150,35 @@ func TestUpdateCapacity(t *testing.T) {
 	//printResourceList(container.Resources.Limits)
 	//printResourceList(container.Resources.Requests)
 }
+
+func TestRestoreResourceRequirements(t *testing.T) {
+	podSpec := &k8sapi.PodSpec{Containers: []k8sapi.Container{{
+		Name: "web",
+		Resources: k8sapi.ResourceRequirements{
+			Limits:   k8sapi.ResourceList{k8sapi.ResourceMemory: resource.MustParse("256Mi")},
+			Requests: k8sapi.ResourceList{k8sapi.ResourceMemory: resource.MustParse("0")},
+		},
+	}}}
+	previous := k8sapi.ResourceRequirements{
+		Limits: k8sapi.ResourceList{k8sapi.ResourceMemory: resource.MustParse("512Mi")},
+	}
+
+	changed, err := restoreResourceRequirements(podSpec, &containerResources{index: 0, resources: previous})
+	if err != nil || !changed {
+		t.Errorf("expected the resources to be restored but got changed %v and error %v", changed, err)
+	}
+	// The requests set by the resize are removed too
+	resources := podSpec.Containers[0].Resources
+	if limit := resources.Limits[k8sapi.ResourceMemory]; limit.String() != "512Mi" || len(resources.Requests) > 0 {
+		t.Errorf("expected the previous resources %+v but got %+v", previous, resources)
+	}
+
+	changed, err = restoreResourceRequirements(podSpec, &containerResources{index: 0, resources: previous})
+	if err != nil || changed {
+		t.Errorf("expected the restored resources to be unchanged but got changed %v and error %v", changed, err)
+	}
+
+	if _, err := restoreResourceRequirements(podSpec, &containerResources{index: 1}); err == nil {
+		t.Errorf("expected error for a missing container")
+	}
+}
@@ -0,0 +1,227 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	podutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+	sdkprobe "github.com/turbonomic/turbo-go-sdk/pkg/probe"
+	apiappsv1 "k8s.io/api/apps/v1"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	kclient "k8s.io/client-go/kubernetes"
+)
+
+const (
+	// The longest time to wait for the rollout of a consistent resize, which also fails when the Deployment
+	// exceeds its own progress deadline
+	defaultRolloutTimeout = time.Minute * 30
+
+	// The revision annotation of a Deployment and of its ReplicaSets
+	deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+	// The reason of the Progressing condition of a Deployment which exceeded its progress deadline
+	deploymentProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+
+	crashLoopBackOffReason = "CrashLoopBackOff"
+	oomKilledReason        = "OOMKilled"
+)
+
+// resizeRollout follows the rollout of the pods of a Deployment with the resized pod template, and verifies
+// the health of the new pods during the rollout and a window after it. The new pods are unhealthy if a container
+// is crash looping, was killed for running out of memory, restarted too many times, or if a pod is not ready
+// at the end of the window.
+type resizeRollout struct {
+	client           *kclient.Clientset
+	appsClient       *goutil.AppsClient
+	progress         sdkprobe.ActionProgressTracker
+	window           time.Duration
+	restartThreshold int32
+	interval         time.Duration
+	timeout          time.Duration
+}
+
+func newResizeRollout(client *kclient.Clientset, progress sdkprobe.ActionProgressTracker, window time.Duration,
+	restartThreshold int32) *resizeRollout {
+	return &resizeRollout{
+		client:           client,
+		appsClient:       goutil.NewAppsClient(client),
+		progress:         progress,
+		window:           window,
+		restartThreshold: restartThreshold,
+		interval:         defaultPodCheckSleep,
+		timeout:          defaultRolloutTimeout,
+	}
+}
+
+// verifyDeployment waits until the rollout of the Deployment is complete, and then for the verification window.
+// It returns an error as soon as the rollout fails or a new pod is unhealthy.
+func (r *resizeRollout) verifyDeployment(namespace, name string) error {
+	fullName := util.BuildIdentifier(namespace, name)
+	depClient := r.appsClient.Deployments(namespace)
+
+	//1. wait until the rollout is complete
+	deadline := time.Now().Add(r.timeout)
+	for {
+		dep, err := depClient.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get Deployment %s: %v", fullName, err)
+		}
+		done, updated, replicas, err := deploymentRolloutStatus(dep)
+		if err != nil {
+			return fmt.Errorf("rollout of Deployment %s failed: %v", fullName, err)
+		}
+		// The current revision is only known once the Deployment controller observed the resize
+		if dep.Status.ObservedGeneration >= dep.Generation {
+			if err := r.checkNewPods(dep, false); err != nil {
+				return fmt.Errorf("rollout of Deployment %s failed: %v", fullName, err)
+			}
+		}
+		if done {
+			glog.V(2).Infof("Rollout of Deployment %s is complete.", fullName)
+			break
+		}
+		reportProgress(r.progress, fmt.Sprintf("Rolling out Deployment %s: %d of %d pods updated",
+			fullName, updated, replicas), 10+70*updated/replicas)
+		if time.Now().After(deadline) {
+			return fmt.Errorf("rollout of Deployment %s is not complete after %v: %d of %d pods updated",
+				fullName, r.timeout, updated, replicas)
+		}
+		time.Sleep(r.interval)
+	}
+
+	//2. verify the health of the new pods during the window
+	start := time.Now()
+	for {
+		elapsed := time.Since(start)
+		final := elapsed >= r.window
+		dep, err := depClient.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get Deployment %s: %v", fullName, err)
+		}
+		if err := r.checkNewPods(dep, final); err != nil {
+			return fmt.Errorf("the pods of Deployment %s are unhealthy after the rollout: %v", fullName, err)
+		}
+		if final {
+			glog.V(2).Infof("The pods of Deployment %s are healthy %v after the rollout.", fullName, r.window)
+			return nil
+		}
+		reportProgress(r.progress, fmt.Sprintf("Verifying the health of the pods of Deployment %s", fullName),
+			80+int32(19*elapsed/r.window))
+		sleep := r.interval
+		if remaining := r.window - elapsed; remaining < sleep {
+			sleep = remaining
+		}
+		time.Sleep(sleep)
+	}
+}
+
+// checkNewPods checks the health of the pods of the ReplicaSet of the current revision of the Deployment,
+// and their readiness if required.
+func (r *resizeRollout) checkNewPods(dep *apiappsv1.Deployment, requireReady bool) error {
+	pods, err := r.getNewPods(dep)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		if err := checkPodHealth(pod, r.restartThreshold); err != nil {
+			return err
+		}
+		if requireReady && !podutil.PodIsReady(pod) {
+			return fmt.Errorf("pod %s is not ready", pod.Name)
+		}
+	}
+	return nil
+}
+
+// getNewPods returns the pods of the ReplicaSet of the current revision of the Deployment, which may not
+// be created yet.
+func (r *resizeRollout) getNewPods(dep *apiappsv1.Deployment) ([]*api.Pod, error) {
+	revision := dep.Annotations[deploymentRevisionAnnotation]
+	rsList, err := r.appsClient.ReplicaSets(dep.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the ReplicaSets of namespace %s: %v", dep.Namespace, err)
+	}
+	var newRS *apiappsv1.ReplicaSet
+	for i := range rsList.Items {
+		rs := &rsList.Items[i]
+		owner := metav1.GetControllerOf(rs)
+		if owner != nil && owner.UID == dep.UID && rs.Annotations[deploymentRevisionAnnotation] == revision {
+			newRS = rs
+			break
+		}
+	}
+	if newRS == nil {
+		return nil, nil
+	}
+	podList, err := r.client.CoreV1().Pods(dep.Namespace).List(metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(newRS.Spec.Template.Labels).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods of ReplicaSet %s/%s: %v", newRS.Namespace, newRS.Name, err)
+	}
+	var pods []*api.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if owner := metav1.GetControllerOf(pod); owner != nil && owner.UID == newRS.UID && pod.DeletionTimestamp == nil {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// deploymentRolloutStatus returns whether the rollout of the Deployment is complete, with the numbers of the
+// updated and desired pods, in the same way as kubectl rollout status. It returns an error if the Deployment
+// exceeded its progress deadline.
+func deploymentRolloutStatus(dep *apiappsv1.Deployment) (bool, int32, int32, error) {
+	replicas := int32(1)
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+	if replicas < 1 {
+		// Nothing to roll out, but avoid the division of the progress
+		return true, 0, 1, nil
+	}
+	if dep.Generation > dep.Status.ObservedGeneration {
+		return false, 0, replicas, nil
+	}
+	for _, condition := range dep.Status.Conditions {
+		if condition.Type == apiappsv1.DeploymentProgressing &&
+			condition.Reason == deploymentProgressDeadlineExceeded {
+			return false, dep.Status.UpdatedReplicas, replicas,
+				fmt.Errorf("progress deadline exceeded: %s", condition.Message)
+		}
+	}
+	updated := dep.Status.UpdatedReplicas
+	if updated > replicas {
+		updated = replicas
+	}
+	done := dep.Status.UpdatedReplicas >= replicas &&
+		dep.Status.Replicas <= dep.Status.UpdatedReplicas &&
+		dep.Status.AvailableReplicas >= dep.Status.UpdatedReplicas
+	return done, updated, replicas, nil
+}
+
+// checkPodHealth returns an error if a container of the pod is crash looping, was killed for running out of
+// memory, or restarted at least the threshold times, if positive.
+func checkPodHealth(pod *api.Pod, restartThreshold int32) error {
+	for _, status := range pod.Status.ContainerStatuses {
+		if waiting := status.State.Waiting; waiting != nil && waiting.Reason == crashLoopBackOffReason {
+			return fmt.Errorf("container %s of pod %s is crash looping: %s", status.Name, pod.Name,
+				waiting.Message)
+		}
+		if terminated := status.LastTerminationState.Terminated; terminated != nil &&
+			terminated.Reason == oomKilledReason {
+			return fmt.Errorf("container %s of pod %s was killed for running out of memory", status.Name,
+				pod.Name)
+		}
+		if restartThreshold > 0 && status.RestartCount >= restartThreshold {
+			return fmt.Errorf("container %s of pod %s restarted %d times", status.Name, pod.Name,
+				status.RestartCount)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	apiappsv1 "k8s.io/api/apps/v1"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newRolloutDeployment(replicas, observed, updated, total, available int32) *apiappsv1.Deployment {
+	return &apiappsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "web", Generation: 2},
+		Spec:       apiappsv1.DeploymentSpec{Replicas: &replicas},
+		Status: apiappsv1.DeploymentStatus{
+			ObservedGeneration: int64(observed),
+			UpdatedReplicas:    updated,
+			Replicas:           total,
+			AvailableReplicas:  available,
+		},
+	}
+}
+
+func TestDeploymentRolloutStatus(t *testing.T) {
+	stalled := newRolloutDeployment(3, 2, 1, 4, 3)
+	stalled.Status.Conditions = []apiappsv1.DeploymentCondition{{
+		Type:    apiappsv1.DeploymentProgressing,
+		Status:  api.ConditionFalse,
+		Reason:  deploymentProgressDeadlineExceeded,
+		Message: `ReplicaSet "web-5d4f" has timed out progressing.`,
+	}}
+
+	table := []struct {
+		name       string
+		deployment *apiappsv1.Deployment
+		done       bool
+		updated    int32
+		err        string
+	}{
+		{
+			name:       "resize not observed",
+			deployment: newRolloutDeployment(3, 1, 3, 3, 3),
+		},
+		{
+			name:       "pods being updated",
+			deployment: newRolloutDeployment(3, 2, 1, 4, 3),
+			updated:    1,
+		},
+		{
+			name:       "old pods being terminated",
+			deployment: newRolloutDeployment(3, 2, 3, 4, 3),
+			updated:    3,
+		},
+		{
+			name:       "new pods not available",
+			deployment: newRolloutDeployment(3, 2, 3, 3, 2),
+			updated:    3,
+		},
+		{
+			name:       "rollout complete",
+			deployment: newRolloutDeployment(3, 2, 3, 3, 3),
+			done:       true,
+			updated:    3,
+		},
+		{
+			name:       "progress deadline exceeded",
+			deployment: stalled,
+			updated:    1,
+			err:        "has timed out progressing",
+		},
+	}
+	for _, item := range table {
+		done, updated, replicas, err := deploymentRolloutStatus(item.deployment)
+		if item.err != "" {
+			if err == nil || !strings.Contains(err.Error(), item.err) {
+				t.Errorf("%s: expected error %q but got %v", item.name, item.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", item.name, err)
+		}
+		if done != item.done || updated != item.updated || replicas != 3 {
+			t.Errorf("%s: expected done %v with %d of 3 pods updated but got %v with %d of %d", item.name,
+				item.done, item.updated, done, updated, replicas)
+		}
+	}
+}
+
+func TestCheckPodHealth(t *testing.T) {
+	newPod := func(status api.ContainerStatus) *api.Pod {
+		status.Name = "web"
+		return &api.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "web-5d4f-x2k"},
+			Status:     api.PodStatus{ContainerStatuses: []api.ContainerStatus{status}},
+		}
+	}
+	table := []struct {
+		name             string
+		status           api.ContainerStatus
+		restartThreshold int32
+		err              string
+	}{
+		{
+			name: "running",
+			status: api.ContainerStatus{
+				State: api.ContainerState{Running: &api.ContainerStateRunning{}},
+			},
+			restartThreshold: 3,
+		},
+		{
+			name: "crash looping",
+			status: api.ContainerStatus{
+				State: api.ContainerState{Waiting: &api.ContainerStateWaiting{Reason: crashLoopBackOffReason}},
+			},
+			err: "is crash looping",
+		},
+		{
+			name: "out of memory",
+			status: api.ContainerStatus{
+				State:                api.ContainerState{Running: &api.ContainerStateRunning{}},
+				LastTerminationState: api.ContainerState{Terminated: &api.ContainerStateTerminated{Reason: oomKilledReason}},
+				RestartCount:         1,
+			},
+			restartThreshold: 3,
+			err:              "killed for running out of memory",
+		},
+		{
+			name: "restarts below the threshold",
+			status: api.ContainerStatus{
+				State:        api.ContainerState{Running: &api.ContainerStateRunning{}},
+				RestartCount: 2,
+			},
+			restartThreshold: 3,
+		},
+		{
+			name: "restarts at the threshold",
+			status: api.ContainerStatus{
+				State:        api.ContainerState{Running: &api.ContainerStateRunning{}},
+				RestartCount: 3,
+			},
+			restartThreshold: 3,
+			err:              "restarted 3 times",
+		},
+		{
+			name: "restarts ignored",
+			status: api.ContainerStatus{
+				State:        api.ContainerState{Running: &api.ContainerStateRunning{}},
+				RestartCount: 5,
+			},
+		},
+	}
+	for _, item := range table {
+		err := checkPodHealth(newPod(item.status), item.restartThreshold)
+		if item.err == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", item.name, err)
+		}
+		if item.err != "" && (err == nil || !strings.Contains(err.Error(), item.err)) {
+			t.Errorf("%s: expected error %q but got %v", item.name, item.err, err)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package executor
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kclient "k8s.io/client-go/kubernetes"
+)
+
+var (
+	// The quota resources of the requests and the limits of the compute resources
+	quotaRequestResources = map[api.ResourceName]api.ResourceName{
+		api.ResourceCPU:            api.ResourceCPU,
+		api.ResourceMemory:         api.ResourceMemory,
+		api.ResourceRequestsCPU:    api.ResourceCPU,
+		api.ResourceRequestsMemory: api.ResourceMemory,
+	}
+	quotaLimitResources = map[api.ResourceName]api.ResourceName{
+		api.ResourceLimitsCPU:    api.ResourceCPU,
+		api.ResourceLimitsMemory: api.ResourceMemory,
+	}
+	// The quota resources counting the pods, either as a compute resource or as an object count
+	quotaPodCountResources = []api.ResourceName{api.ResourcePods, "count/pods"}
+)
+
+// validateResize checks the resized container against the LimitRanges and the ResourceQuotas of the namespace
+// of the pod, before anything is written: otherwise the admission of the new pods fails and the workload is stuck.
+// When clamping is enabled, the resources out of the minimum and maximum of a container LimitRange, or out of
+// its maximum limit to request ratio, are clamped in the resize spec instead of failing the validation.
+func validateResize(client *kclient.Clientset, pod *api.Pod, spec *containerResizeSpec,
+	consistentResize, clamp bool) error {
+	limitRangeList, err := client.CoreV1().LimitRanges(pod.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list the LimitRanges of namespace %s: %v", pod.Namespace, err)
+	}
+	newPod, err := validateResizeLimitRanges(pod, spec, limitRangeList.Items, clamp)
+	if err != nil {
+		return err
+	}
+
+	// The number of the existing pods replaced with the new resources
+	var replicas int32
+	if consistentResize {
+		replicas, err = getReplacedPods(client, pod)
+		if err != nil {
+			return err
+		}
+	}
+	quotaList, err := client.CoreV1().ResourceQuotas(pod.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list the ResourceQuotas of namespace %s: %v", pod.Namespace, err)
+	}
+	return validateResizeQuotas(pod, newPod, consistentResize, replicas, quotaList.Items)
+}
+
+// getReplacedPods returns the number of the pods of the controller of the pod which are replaced by a consistent
+// resize: the replicas of a Deployment, and the pods of a StatefulSet or a DaemonSet replaced by their rolling
+// update. It is 0 for the other controllers, which only use the resized template for the new pods.
+func getReplacedPods(client *kclient.Clientset, pod *api.Pod) (int32, error) {
+	controllerUpdater, err := newK8sControllerUpdater(client, pod)
+	if err != nil {
+		return 0, err
+	}
+	current, err := controllerUpdater.controller.get(controllerUpdater.name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %v %s/%s: %v", controllerUpdater.controller,
+			pod.Namespace, controllerUpdater.name, err)
+	}
+	if !current.replacesPods {
+		return 0, nil
+	}
+	return current.replacedPods, nil
+}
+
+// validateResizeLimitRanges applies the resize spec to a copy of the pod and checks the result against the
+// LimitRanges. It returns the resized pod, with the clamped resources if clamping is enabled.
+func validateResizeLimitRanges(pod *api.Pod, spec *containerResizeSpec, limitRanges []api.LimitRange,
+	clamp bool) (*api.Pod, error) {
+	newPod := pod.DeepCopy()
+	if _, err := updateResourceAmount(&newPod.Spec, spec); err != nil {
+		return nil, err
+	}
+	container := &newPod.Spec.Containers[spec.Index]
+	for i := range limitRanges {
+		limitRange := &limitRanges[i]
+		for _, item := range limitRange.Spec.Limits {
+			var err error
+			switch item.Type {
+			case api.LimitTypeContainer:
+				err = checkContainerLimitRangeItem(container, spec, item, clamp)
+			case api.LimitTypePod:
+				err = checkPodLimitRangeItem(newPod, item)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("resize of container %s of pod %s/%s violates LimitRange %s/%s: %v",
+					container.Name, pod.Namespace, pod.Name, limitRange.Namespace, limitRange.Name, err)
+			}
+		}
+	}
+	// The clamping must not set a request higher than the limit
+	if err := checkLimitsRequests(container); err != nil {
+		return nil, err
+	}
+	return newPod, nil
+}
+
+// checkContainerLimitRangeItem checks the limits and requests of a container against the minimum, the maximum
+// and the maximum limit to request ratio of a LimitRange, clamping them in the container and the spec if allowed.
+func checkContainerLimitRangeItem(container *api.Container, spec *containerResizeSpec,
+	item api.LimitRangeItem, clamp bool) error {
+	limits := container.Resources.Limits
+	requests := container.Resources.Requests
+	for name, max := range item.Max {
+		if limit, exists := limits[name]; exists && limit.Cmp(max) > 0 {
+			if !clamp {
+				return fmt.Errorf("%s limit %s is above the maximum %s", name, limit.String(), max.String())
+			}
+			glog.Warningf("Clamp the %s limit of container %s from %s to the maximum %s",
+				name, container.Name, limit.String(), max.String())
+			setResource(limits, spec.NewCapacity, name, max)
+		}
+		if request, exists := requests[name]; exists && request.Cmp(max) > 0 {
+			if !clamp {
+				return fmt.Errorf("%s request %s is above the maximum %s", name, request.String(), max.String())
+			}
+			glog.Warningf("Clamp the %s request of container %s from %s to the maximum %s",
+				name, container.Name, request.String(), max.String())
+			setResource(requests, spec.NewRequest, name, max)
+		}
+	}
+	for name, min := range item.Min {
+		request, exists := requests[name]
+		if !exists || request.Cmp(min) < 0 {
+			if !clamp {
+				return fmt.Errorf("%s request %s is below the minimum %s", name, request.String(), min.String())
+			}
+			glog.Warningf("Clamp the %s request of container %s from %s to the minimum %s",
+				name, container.Name, request.String(), min.String())
+			requests = setResource(requests, spec.NewRequest, name, min)
+			container.Resources.Requests = requests
+		}
+		if limit, exists := limits[name]; exists && limit.Cmp(min) < 0 {
+			if !clamp {
+				return fmt.Errorf("%s limit %s is below the minimum %s", name, limit.String(), min.String())
+			}
+			glog.Warningf("Clamp the %s limit of container %s from %s to the minimum %s",
+				name, container.Name, limit.String(), min.String())
+			setResource(limits, spec.NewCapacity, name, min)
+		}
+	}
+	for name, maxRatio := range item.MaxLimitRequestRatio {
+		limit, exists := limits[name]
+		if !exists {
+			continue
+		}
+		request := requests[name]
+		ratio := math.Inf(1)
+		if !request.IsZero() {
+			ratio = float64(limit.MilliValue()) / float64(request.MilliValue())
+		}
+		if ratio <= float64(maxRatio.MilliValue())/1000 {
+			continue
+		}
+		if !clamp {
+			return fmt.Errorf("%s limit to request ratio %.2f is above the maximum %s",
+				name, ratio, maxRatio.String())
+		}
+		// Raise the request to the lowest value allowed by the ratio
+		minRequest := int64(math.Ceil(float64(limit.MilliValue()) * 1000 / float64(maxRatio.MilliValue())))
+		clamped := *resource.NewMilliQuantity(minRequest, limit.Format)
+		glog.Warningf("Clamp the %s request of container %s from %s to %s for the maximum limit to request ratio %s",
+			name, container.Name, request.String(), clamped.String(), maxRatio.String())
+		requests = setResource(requests, spec.NewRequest, name, clamped)
+		container.Resources.Requests = requests
+	}
+	return nil
+}
+
+// setResource sets the quantity of a resource both in the resource list of the container and in the resize spec.
+func setResource(resources, specResources api.ResourceList, name api.ResourceName,
+	quantity resource.Quantity) api.ResourceList {
+	if resources == nil {
+		resources = make(api.ResourceList)
+	}
+	resources[name] = quantity.DeepCopy()
+	specResources[name] = quantity.DeepCopy()
+	return resources
+}
+
+// checkPodLimitRangeItem checks the total limits and requests of a pod against the minimum and the maximum of a
+// LimitRange. They are not clamped, as it is not known which containers should give up their resources.
+func checkPodLimitRangeItem(pod *api.Pod, item api.LimitRangeItem) error {
+	limits := podLimits(pod)
+	requests := podRequests(pod)
+	for name, max := range item.Max {
+		if limit, exists := limits[name]; exists && limit.Cmp(max) > 0 {
+			return fmt.Errorf("total %s limit %s of the pod is above the maximum %s", name, limit.String(), max.String())
+		}
+		if request, exists := requests[name]; exists && request.Cmp(max) > 0 {
+			return fmt.Errorf("total %s request %s of the pod is above the maximum %s", name, request.String(), max.String())
+		}
+	}
+	for name, min := range item.Min {
+		if request := requests[name]; request.Cmp(min) < 0 {
+			return fmt.Errorf("total %s request %s of the pod is below the minimum %s", name, request.String(), min.String())
+		}
+	}
+	return nil
+}
+
+// validateResizeQuotas checks that the resize does not push the usage of a ResourceQuota over its hard limits.
+// A single pod is resized by a clone running beside the original pod, so the whole new pod is added to the usage,
+// and to the pod count.
+// A consistent resize replaces the pods of a Deployment, or of a StatefulSet or a DaemonSet with a rolling update,
+// so the increase of resources of the replaced pods is added instead.
+// The quotas with scopes are not checked, as their scopes may not select the pod.
+func validateResizeQuotas(pod, newPod *api.Pod, consistentResize bool, replicas int32,
+	quotas []api.ResourceQuota) error {
+	increase := make(map[api.ResourceName]int64)
+	for _, item := range []struct {
+		quotaResources map[api.ResourceName]api.ResourceName
+		getResources   func(pod *api.Pod) api.ResourceList
+	}{
+		{quotaRequestResources, podRequests},
+		{quotaLimitResources, podLimits},
+	} {
+		oldResources := item.getResources(pod)
+		newResources := item.getResources(newPod)
+		for quotaResource, name := range item.quotaResources {
+			newQuantity := newResources[name]
+			if !consistentResize {
+				increase[quotaResource] = newQuantity.MilliValue()
+				continue
+			}
+			oldQuantity := oldResources[name]
+			increase[quotaResource] = (newQuantity.MilliValue() - oldQuantity.MilliValue()) * int64(replicas)
+		}
+	}
+	if !consistentResize {
+		// The clone is one more pod, in milli units as the other increases
+		for _, quotaResource := range quotaPodCountResources {
+			increase[quotaResource] = 1000
+		}
+	}
+
+	for i := range quotas {
+		quota := &quotas[i]
+		if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			glog.V(3).Infof("Skip the check of ResourceQuota %s/%s with scopes", quota.Namespace, quota.Name)
+			continue
+		}
+		for quotaResource, hard := range quota.Spec.Hard {
+			delta, exists := increase[quotaResource]
+			if !exists || delta <= 0 {
+				continue
+			}
+			used := quota.Status.Used[quotaResource]
+			newUsed := used.MilliValue() + delta
+			if newUsed > hard.MilliValue() {
+				return fmt.Errorf("resize of pod %s/%s would raise the %s usage of ResourceQuota %s/%s to %s, "+
+					"above its hard limit %s", pod.Namespace, pod.Name, quotaResource, quota.Namespace, quota.Name,
+					resource.NewMilliQuantity(newUsed, hard.Format).String(), hard.String())
+			}
+		}
+	}
+	return nil
+}
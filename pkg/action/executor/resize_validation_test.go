@@ -0,0 +1,294 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newResizedPod() *api.Pod {
+	return &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "web-1"},
+		Spec: api.PodSpec{Containers: []api.Container{
+			{
+				Name: "web",
+				Resources: api.ResourceRequirements{
+					Limits: api.ResourceList{
+						api.ResourceCPU:    resource.MustParse("500m"),
+						api.ResourceMemory: resource.MustParse("512Mi"),
+					},
+					Requests: api.ResourceList{
+						api.ResourceCPU:    resource.MustParse("250m"),
+						api.ResourceMemory: resource.MustParse("256Mi"),
+					},
+				},
+			},
+			{
+				Name: "sidecar",
+				Resources: api.ResourceRequirements{
+					Limits:   api.ResourceList{api.ResourceMemory: resource.MustParse("128Mi")},
+					Requests: api.ResourceList{api.ResourceMemory: resource.MustParse("128Mi")},
+				},
+			},
+		}},
+	}
+}
+
+func newContainerLimitRange(item api.LimitRangeItem) api.LimitRange {
+	item.Type = api.LimitTypeContainer
+	return api.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "limits"},
+		Spec:       api.LimitRangeSpec{Limits: []api.LimitRangeItem{item}},
+	}
+}
+
+func TestValidateResizeLimitRanges(t *testing.T) {
+	table := []struct {
+		name        string
+		newCapacity api.ResourceList
+		newRequest  api.ResourceList
+		limitRange  api.LimitRange
+		clamp       bool
+		// The expected error, or the expected resources of the resized container if empty
+		err      string
+		limits   api.ResourceList
+		requests api.ResourceList
+	}{
+		{
+			name:        "within the limits",
+			newCapacity: api.ResourceList{api.ResourceMemory: resource.MustParse("1Gi")},
+			limitRange: newContainerLimitRange(api.LimitRangeItem{
+				Max: api.ResourceList{api.ResourceMemory: resource.MustParse("2Gi")},
+			}),
+			limits: api.ResourceList{api.ResourceMemory: resource.MustParse("1Gi")},
+		},
+		{
+			name:        "limit above the maximum",
+			newCapacity: api.ResourceList{api.ResourceMemory: resource.MustParse("4Gi")},
+			limitRange: newContainerLimitRange(api.LimitRangeItem{
+				Max: api.ResourceList{api.ResourceMemory: resource.MustParse("2Gi")},
+			}),
+			err: "memory limit 4Gi is above the maximum 2Gi",
+		},
+		{
+			name:        "limit clamped to the maximum",
+			newCapacity: api.ResourceList{api.ResourceMemory: resource.MustParse("4Gi")},
+			limitRange: newContainerLimitRange(api.LimitRangeItem{
+				Max: api.ResourceList{api.ResourceMemory: resource.MustParse("2Gi")},
+			}),
+			clamp:  true,
+			limits: api.ResourceList{api.ResourceMemory: resource.MustParse("2Gi")},
+		},
+		{
+			name:       "request below the minimum",
+			newRequest: api.ResourceList{api.ResourceCPU: resource.MustParse("50m")},
+			limitRange: newContainerLimitRange(api.LimitRangeItem{
+				Min: api.ResourceList{api.ResourceCPU: resource.MustParse("100m")},
+			}),
+			err: "cpu request 50m is below the minimum 100m",
+		},
+		{
+			name:       "request clamped to the minimum",
+			newRequest: api.ResourceList{api.ResourceCPU: resource.MustParse("50m")},
+			limitRange: newContainerLimitRange(api.LimitRangeItem{
+				Min: api.ResourceList{api.ResourceCPU: resource.MustParse("100m")},
+			}),
+			clamp:    true,
+			requests: api.ResourceList{api.ResourceCPU: resource.MustParse("100m")},
+		},
+		{
+			name:        "limit to request ratio above the maximum",
+			newCapacity: api.ResourceList{api.ResourceCPU: resource.MustParse("2")},
+			limitRange: newContainerLimitRange(api.LimitRangeItem{
+				MaxLimitRequestRatio: api.ResourceList{api.ResourceCPU: resource.MustParse("4")},
+			}),
+			err: "cpu limit to request ratio 8.00 is above the maximum 4",
+		},
+		{
+			name:        "request raised for the limit to request ratio",
+			newCapacity: api.ResourceList{api.ResourceCPU: resource.MustParse("2")},
+			limitRange: newContainerLimitRange(api.LimitRangeItem{
+				MaxLimitRequestRatio: api.ResourceList{api.ResourceCPU: resource.MustParse("4")},
+			}),
+			clamp:    true,
+			limits:   api.ResourceList{api.ResourceCPU: resource.MustParse("2")},
+			requests: api.ResourceList{api.ResourceCPU: resource.MustParse("500m")},
+		},
+		{
+			name:       "limit and request clamped to the minimum",
+			newRequest: api.ResourceList{api.ResourceCPU: resource.MustParse("100m")},
+			limitRange: newContainerLimitRange(api.LimitRangeItem{
+				Min: api.ResourceList{api.ResourceCPU: resource.MustParse("1")},
+			}),
+			clamp:    true,
+			limits:   api.ResourceList{api.ResourceCPU: resource.MustParse("1")},
+			requests: api.ResourceList{api.ResourceCPU: resource.MustParse("1")},
+		},
+		{
+			name:        "request above the limit",
+			newCapacity: api.ResourceList{api.ResourceCPU: resource.MustParse("100m")},
+			clamp:       true,
+			err:         "resource request is larger than limits",
+		},
+		{
+			name:        "pod limit above the maximum",
+			newCapacity: api.ResourceList{api.ResourceMemory: resource.MustParse("1Gi")},
+			limitRange: api.LimitRange{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "pod-limits"},
+				Spec: api.LimitRangeSpec{Limits: []api.LimitRangeItem{{
+					Type: api.LimitTypePod,
+					Max:  api.ResourceList{api.ResourceMemory: resource.MustParse("1Gi")},
+				}}},
+			},
+			clamp: true,
+			err:   "violates LimitRange ns1/pod-limits: total memory limit 1152Mi of the pod is above the maximum 1Gi",
+		},
+	}
+
+	for _, item := range table {
+		pod := newResizedPod()
+		spec := NewContainerResizeSpec(0)
+		for name, quantity := range item.newCapacity {
+			spec.NewCapacity[name] = quantity
+		}
+		for name, quantity := range item.newRequest {
+			spec.NewRequest[name] = quantity
+		}
+
+		newPod, err := validateResizeLimitRanges(pod, spec, []api.LimitRange{item.limitRange}, item.clamp)
+		if item.err != "" {
+			if err == nil || !strings.Contains(err.Error(), item.err) {
+				t.Errorf("%s: expected error %q but got %v", item.name, item.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", item.name, err)
+			continue
+		}
+		resources := newPod.Spec.Containers[0].Resources
+		for name, expected := range item.limits {
+			if actual := resources.Limits[name]; actual.Cmp(expected) != 0 {
+				t.Errorf("%s: expected %s limit %s but got %s", item.name, name, expected.String(), actual.String())
+			}
+			// The clamped values are also applied to the resize spec
+			if actual := spec.NewCapacity[name]; actual.Cmp(expected) != 0 {
+				t.Errorf("%s: expected %s capacity %s in the spec but got %s", item.name, name, expected.String(),
+					actual.String())
+			}
+		}
+		for name, expected := range item.requests {
+			if actual := resources.Requests[name]; actual.Cmp(expected) != 0 {
+				t.Errorf("%s: expected %s request %s but got %s", item.name, name, expected.String(), actual.String())
+			}
+			if actual := spec.NewRequest[name]; actual.Cmp(expected) != 0 {
+				t.Errorf("%s: expected %s request %s in the spec but got %s", item.name, name, expected.String(),
+					actual.String())
+			}
+		}
+		// The original pod is never modified
+		if limit := pod.Spec.Containers[0].Resources.Limits[api.ResourceMemory]; limit.String() != "512Mi" {
+			t.Errorf("%s: the original pod is modified", item.name)
+		}
+	}
+}
+
+func TestValidateResizeQuotas(t *testing.T) {
+	pod := newResizedPod()
+	newPod := newResizedPod()
+	newPod.Spec.Containers[0].Resources.Limits[api.ResourceMemory] = resource.MustParse("1Gi")
+	newPodCountQuota := func(quotaResource api.ResourceName, hard, used string) api.ResourceQuota {
+		return api.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "quota"},
+			Spec:       api.ResourceQuotaSpec{Hard: api.ResourceList{quotaResource: resource.MustParse(hard)}},
+			Status:     api.ResourceQuotaStatus{Used: api.ResourceList{quotaResource: resource.MustParse(used)}},
+		}
+	}
+	newQuota := func(hard, used string, scopes ...api.ResourceQuotaScope) api.ResourceQuota {
+		return api.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "quota"},
+			Spec: api.ResourceQuotaSpec{
+				Hard:   api.ResourceList{api.ResourceLimitsMemory: resource.MustParse(hard)},
+				Scopes: scopes,
+			},
+			Status: api.ResourceQuotaStatus{
+				Used: api.ResourceList{api.ResourceLimitsMemory: resource.MustParse(used)},
+			},
+		}
+	}
+
+	table := []struct {
+		name             string
+		quota            api.ResourceQuota
+		consistentResize bool
+		replicas         int32
+		err              string
+	}{
+		{
+			// The clone pod with 1152Mi of limits runs beside the original pod
+			name:  "clone within the quota",
+			quota: newQuota("4Gi", "2Gi"),
+		},
+		{
+			name:  "clone over the quota",
+			quota: newQuota("4Gi", "3Gi"),
+			err:   "would raise the limits.memory usage of ResourceQuota ns1/quota to 4224Mi, above its hard limit 4Gi",
+		},
+		{
+			name:  "clone within the pod count",
+			quota: newPodCountQuota(api.ResourcePods, "10", "9"),
+		},
+		{
+			name:  "clone over the pod count",
+			quota: newPodCountQuota(api.ResourcePods, "10", "10"),
+			err:   "would raise the pods usage of ResourceQuota ns1/quota to 11, above its hard limit 10",
+		},
+		{
+			name:  "clone over the pod object count",
+			quota: newPodCountQuota("count/pods", "10", "10"),
+			err:   "would raise the count/pods usage of ResourceQuota ns1/quota to 11",
+		},
+		{
+			name:             "deployment with the pod count reached",
+			quota:            newPodCountQuota(api.ResourcePods, "10", "10"),
+			consistentResize: true,
+			replicas:         3,
+		},
+		{
+			// 3 replicas with 512Mi more of limits
+			name:             "deployment within the quota",
+			quota:            newQuota("4Gi", "2Gi"),
+			consistentResize: true,
+			replicas:         3,
+		},
+		{
+			name:             "deployment over the quota",
+			quota:            newQuota("4Gi", "3Gi"),
+			consistentResize: true,
+			replicas:         3,
+			err:              "to 4608Mi, above its hard limit 4Gi",
+		},
+		{
+			name:             "template only resize",
+			quota:            newQuota("4Gi", "4Gi"),
+			consistentResize: true,
+		},
+		{
+			name:  "quota with scopes",
+			quota: newQuota("4Gi", "4Gi", api.ResourceQuotaScopeBestEffort),
+		},
+	}
+	for _, item := range table {
+		err := validateResizeQuotas(pod, newPod, item.consistentResize, item.replicas,
+			[]api.ResourceQuota{item.quota})
+		if item.err == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", item.name, err)
+		}
+		if item.err != "" && (err == nil || !strings.Contains(err.Error(), item.err)) {
+			t.Errorf("%s: expected error %q but got %v", item.name, item.err, err)
+		}
+	}
+}
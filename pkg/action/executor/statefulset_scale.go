@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kclient "k8s.io/client-go/kubernetes"
+)
+
+// A StatefulSet names its pods by their ordinal, from 0 to replicas-1. It always adds the pod of the next ordinal
+// when scaled out, and removes the pod of the highest ordinal when scaled in: deleting another pod would only
+// make the StatefulSet recreate it.
+
+// statefulSetPodName returns the name of the pod of the given ordinal of the StatefulSet
+func statefulSetPodName(stsName string, ordinal int32) string {
+	return fmt.Sprintf("%s-%d", stsName, ordinal)
+}
+
+// getStatefulSetPodOrdinal returns the ordinal of the pod of the StatefulSet
+func getStatefulSetPodOrdinal(stsName, podName string) (int32, error) {
+	prefix := stsName + "-"
+	if !strings.HasPrefix(podName, prefix) {
+		return 0, fmt.Errorf("pod %s is not named after StatefulSet %s", podName, stsName)
+	}
+	ordinal, err := strconv.ParseInt(podName[len(prefix):], 10, 32)
+	if err != nil || ordinal < 0 {
+		return 0, fmt.Errorf("pod %s has no ordinal of StatefulSet %s", podName, stsName)
+	}
+	return int32(ordinal), nil
+}
+
+// scaleStatefulSetReplicas validates the scaling of the StatefulSet of the given replicas through the given pod,
+// and returns the resulting replicas. A suspension is refused unless the pod is the one of the highest ordinal,
+// which is the only pod the StatefulSet removes.
+func scaleStatefulSetReplicas(stsName, podName string, current, diff int32) (int32, error) {
+	result := current + diff
+	glog.V(4).Infof("Current replica %d, diff %d, result %d.", current, diff, result)
+	if result < 1 {
+		return 0, fmt.Errorf("resulting replica is less than 1 after suspension")
+	}
+	if diff >= 0 {
+		return result, nil
+	}
+	ordinal, err := getStatefulSetPodOrdinal(stsName, podName)
+	if err != nil {
+		return 0, err
+	}
+	if ordinal != current-1 {
+		return 0, fmt.Errorf("StatefulSet %s can only remove its pod of the highest ordinal %s, not pod %s",
+			stsName, statefulSetPodName(stsName, current-1), podName)
+	}
+	return result, nil
+}
+
+// waitForPodDeleted waits until the pod of the given UID is deleted, with a retry limit and a timeout,
+// whichever comes first.
+func waitForPodDeleted(client *kclient.Clientset, namespace, podName string, uid types.UID,
+	retry int, interval time.Duration) error {
+	timeout := time.Duration(retry+1) * interval
+	return goutil.RetrySimple(retry, timeout, interval, func() (bool, error) {
+		pod, err := client.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return true, err
+		}
+		if pod.UID != uid {
+			return false, nil
+		}
+		return true, fmt.Errorf("pod %s/%s is not deleted yet, phase: %v", namespace, podName, pod.Status.Phase)
+	})
+}
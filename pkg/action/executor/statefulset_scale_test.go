@@ -0,0 +1,84 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetStatefulSetPodOrdinal(t *testing.T) {
+	table := []struct {
+		podName  string
+		expected int32
+		err      bool
+	}{
+		{podName: "web-0", expected: 0},
+		{podName: "web-12", expected: 12},
+		{podName: "web-db-1", err: true},
+		{podName: "web-", err: true},
+		{podName: "db-1", err: true},
+	}
+	for _, item := range table {
+		ordinal, err := getStatefulSetPodOrdinal("web", item.podName)
+		if item.err {
+			if err == nil {
+				t.Errorf("%s: expected an error but got ordinal %d", item.podName, ordinal)
+			}
+			continue
+		}
+		if err != nil || ordinal != item.expected {
+			t.Errorf("%s: expected ordinal %d but got %d, %v", item.podName, item.expected, ordinal, err)
+		}
+	}
+}
+
+func TestScaleStatefulSetReplicas(t *testing.T) {
+	table := []struct {
+		name     string
+		podName  string
+		current  int32
+		diff     int32
+		expected int32
+		err      string
+	}{
+		{
+			name:     "provision",
+			podName:  "web-0",
+			current:  3,
+			diff:     1,
+			expected: 4,
+		},
+		{
+			name:     "suspend the highest ordinal",
+			podName:  "web-2",
+			current:  3,
+			diff:     -1,
+			expected: 2,
+		},
+		{
+			name:    "suspend a middle ordinal",
+			podName: "web-1",
+			current: 3,
+			diff:    -1,
+			err:     "only remove its pod of the highest ordinal web-2",
+		},
+		{
+			name:    "suspend the last pod",
+			podName: "web-0",
+			current: 1,
+			diff:    -1,
+			err:     "less than 1",
+		},
+	}
+	for _, item := range table {
+		result, err := scaleStatefulSetReplicas("web", item.podName, item.current, item.diff)
+		if item.err != "" {
+			if err == nil || !strings.Contains(err.Error(), item.err) {
+				t.Errorf("%s: expected error %q but got %v", item.name, item.err, err)
+			}
+			continue
+		}
+		if err != nil || result != item.expected {
+			t.Errorf("%s: expected %d replicas but got %d, %v", item.name, item.expected, result, err)
+		}
+	}
+}
@@ -26,6 +26,11 @@ var (
 	listOption = metav1.ListOptions{}
 )
 
+// NodesGetter gets all the nodes of the cluster, e.g., from the shared cluster cache.
+type NodesGetter interface {
+	GetAllNodes() ([]*api.Node, error)
+}
+
 // Get all nodes currently in K8s.
 func GetAllNodes(kubeClient *client.Clientset) ([]api.Node, error) {
 	nodeList, err := kubeClient.CoreV1().Nodes().List(listOption)
@@ -37,14 +42,13 @@ func GetAllNodes(kubeClient *client.Clientset) ([]api.Node, error) {
 
 // Iterate all nodes to find the name of the node which has the provided IP address.
 // TODO. We can also create a IP->NodeName map to save time. But it consumes space.
-func GetNodebyIP(kubeClient *client.Clientset, machineIPs []string) (*api.Node, error) {
+func GetNodebyIP(nodesGetter NodesGetter, machineIPs []string) (*api.Node, error) {
 	ipAddresses := machineIPs
-	allNodes, err := GetAllNodes(kubeClient)
+	allNodes, err := nodesGetter.GetAllNodes()
 	if err != nil {
 		return nil, err
 	}
-	for i := range allNodes {
-		node := &allNodes[i]
+	for _, node := range allNodes {
 		nodeAddresses := node.Status.Addresses
 		for _, nodeAddress := range nodeAddresses {
 			for _, machineIP := range ipAddresses {
@@ -69,15 +73,14 @@ func GetNodebyName(kubeClient *client.Clientset, nodeName string) (*api.Node, er
 }
 
 // Iterate all nodes to find the name of the node which has the provided IP address.
-func GetNodebyUUID(kubeClient *client.Clientset, uuid string) (*api.Node, error) {
-	allNodes, err := GetAllNodes(kubeClient)
+func GetNodebyUUID(nodesGetter NodesGetter, uuid string) (*api.Node, error) {
+	allNodes, err := nodesGetter.GetAllNodes()
 	if err != nil {
 		glog.Errorf("Failed to list nodes: %v", err)
 		return nil, err
 	}
 
-	for i := range allNodes {
-		node := &allNodes[i]
+	for _, node := range allNodes {
 		if strings.EqualFold(uuid, node.Status.NodeInfo.SystemUUID) {
 			return node, nil
 		}
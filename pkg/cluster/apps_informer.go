@@ -0,0 +1,53 @@
+package cluster
+
+import (
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+)
+
+// The ReplicaSets and Deployments are watched through the group versions served by the API server, the same as
+// the executors update them through, i.e., apps/v1, or extensions/v1beta1 on the API servers older than 1.9.
+
+// Create the informer of the ReplicaSets of all the namespaces.
+func newReplicaSetInformer(appsClient *goutil.AppsClient, resyncPeriod time.Duration,
+	indexers cache.Indexers) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return appsClient.ReplicaSets(api.NamespaceAll).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return appsClient.ReplicaSets(api.NamespaceAll).Watch(options)
+			},
+		},
+		&apps.ReplicaSet{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+// Create the informer of the Deployments of all the namespaces.
+func newDeploymentInformer(appsClient *goutil.AppsClient, resyncPeriod time.Duration,
+	indexers cache.Indexers) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return appsClient.Deployments(api.NamespaceAll).List(options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return appsClient.Deployments(api.NamespaceAll).Watch(options)
+			},
+		},
+		&apps.Deployment{},
+		resyncPeriod,
+		indexers,
+	)
+}
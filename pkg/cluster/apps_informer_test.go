@@ -0,0 +1,64 @@
+package cluster
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestReplicaSetInformerOfLegacyServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/version":
+			fmt.Fprint(w, `{"major":"1","minor":"8","gitVersion":"v1.8.15"}`)
+		case r.URL.Path == "/apis/extensions/v1beta1/replicasets" && r.URL.Query().Get("watch") == "true":
+			fmt.Fprint(w, `{"type":"ADDED","object":{"apiVersion":"extensions/v1beta1","kind":"ReplicaSet",`+
+				`"metadata":{"name":"db-7c9b","namespace":"space1","resourceVersion":"2"},"spec":{"replicas":1}}}`)
+			// The watch is closed by the server, and started again by the informer
+			time.Sleep(100 * time.Millisecond)
+		case r.URL.Path == "/apis/extensions/v1beta1/replicasets":
+			fmt.Fprint(w, `{"apiVersion":"extensions/v1beta1","kind":"ReplicaSetList","metadata":{"resourceVersion":"1"},`+
+				`"items":[{"metadata":{"name":"web-5d8f","namespace":"space1","resourceVersion":"1"},`+
+				`"spec":{"replicas":2}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	kubeClient, err := client.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create the client: %v", err)
+	}
+	c := NewClusterCache(kubeClient, 0)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go c.informersByType[reflect.TypeOf(&apps.ReplicaSet{})].Run(stopCh)
+
+	var names []string
+	for i := 0; i < 50; i++ {
+		rsList, err := c.ReplicaSetLister().ReplicaSets("space1").List(labels.Everything())
+		if err != nil {
+			t.Fatalf("Failed to list the ReplicaSets: %v", err)
+		}
+		if len(rsList) == 2 {
+			for _, rs := range rsList {
+				names = append(names, rs.Name)
+			}
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if len(names) != 2 {
+		t.Errorf("Expected the listed and watched ReplicaSets in the cache, got %v", names)
+	}
+}
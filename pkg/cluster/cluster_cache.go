@@ -0,0 +1,448 @@
+package cluster
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	api "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	storage "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	autoscalinginformers "k8s.io/client-go/informers/autoscaling/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	policyinformers "k8s.io/client-go/informers/policy/v1beta1"
+	storageinformers "k8s.io/client-go/informers/storage/v1"
+	client "k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	autoscalinglisters "k8s.io/client-go/listers/autoscaling/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	policylisters "k8s.io/client-go/listers/policy/v1beta1"
+	storagelisters "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/golang/glog"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+)
+
+const (
+	// Index of the pods by the name of their hosting node
+	podNodeNameIndex = "nodeName"
+)
+
+// ClusterObjects are the objects kept by the ClusterCache.
+type ClusterObjects struct {
+	Nodes                    []*api.Node                            `json:"nodes,omitempty"`
+	Pods                     []*api.Pod                             `json:"pods,omitempty"`
+	Services                 []*api.Service                         `json:"services,omitempty"`
+	Endpoints                []*api.Endpoints                       `json:"endpoints,omitempty"`
+	ResourceQuotas           []*api.ResourceQuota                   `json:"resourceQuotas,omitempty"`
+	Namespaces               []*api.Namespace                       `json:"namespaces,omitempty"`
+	PersistentVolumes        []*api.PersistentVolume                `json:"persistentVolumes,omitempty"`
+	PersistentVolumeClaims   []*api.PersistentVolumeClaim           `json:"persistentVolumeClaims,omitempty"`
+	StorageClasses           []*storage.StorageClass                `json:"storageClasses,omitempty"`
+	ReplicationControllers   []*api.ReplicationController           `json:"replicationControllers,omitempty"`
+	ReplicaSets              []*apps.ReplicaSet                     `json:"replicaSets,omitempty"`
+	Deployments              []*apps.Deployment                     `json:"deployments,omitempty"`
+	PodDisruptionBudgets     []*policy.PodDisruptionBudget          `json:"podDisruptionBudgets,omitempty"`
+	HorizontalPodAutoscalers []*autoscaling.HorizontalPodAutoscaler `json:"horizontalPodAutoscalers,omitempty"`
+}
+
+func (o *ClusterObjects) all() []interface{} {
+	var all []interface{}
+	for _, obj := range o.Nodes {
+		all = append(all, obj)
+	}
+	for _, obj := range o.Pods {
+		all = append(all, obj)
+	}
+	for _, obj := range o.Services {
+		all = append(all, obj)
+	}
+	for _, obj := range o.Endpoints {
+		all = append(all, obj)
+	}
+	for _, obj := range o.ResourceQuotas {
+		all = append(all, obj)
+	}
+	for _, obj := range o.Namespaces {
+		all = append(all, obj)
+	}
+	for _, obj := range o.PersistentVolumes {
+		all = append(all, obj)
+	}
+	for _, obj := range o.PersistentVolumeClaims {
+		all = append(all, obj)
+	}
+	for _, obj := range o.StorageClasses {
+		all = append(all, obj)
+	}
+	for _, obj := range o.ReplicationControllers {
+		all = append(all, obj)
+	}
+	for _, obj := range o.ReplicaSets {
+		all = append(all, obj)
+	}
+	for _, obj := range o.Deployments {
+		all = append(all, obj)
+	}
+	for _, obj := range o.PodDisruptionBudgets {
+		all = append(all, obj)
+	}
+	for _, obj := range o.HorizontalPodAutoscalers {
+		all = append(all, obj)
+	}
+	return all
+}
+
+// ClusterCache keeps a local copy of the cluster objects used by the discovery, the compliance
+// processors and the action execution. It is fed by the watches of shared informers, so that these
+// components read from memory instead of listing the objects from the API server every time.
+// It must be started once, and is shared by all the components.
+type ClusterCache struct {
+	informers []cache.SharedIndexInformer
+	// The informers and their indexers by the type of their objects
+	informersByType map[reflect.Type]cache.SharedIndexInformer
+	indexers        map[reflect.Type]cache.Indexer
+	// Whether the cache holds a fixed set of objects instead of being fed by the informers
+	static bool
+	// The informers of the nodes, the pods and the owners of the pods, to notify the changes
+	nodeInformer        cache.SharedIndexInformer
+	podInformer         cache.SharedIndexInformer
+	controllerInformers []cache.SharedIndexInformer
+
+	// The pods indexed by namespace and node name
+	podIndexer cache.Indexer
+
+	nodeLister            corelisters.NodeLister
+	podLister             corelisters.PodLister
+	serviceLister         corelisters.ServiceLister
+	endpointsLister       corelisters.EndpointsLister
+	quotaLister           corelisters.ResourceQuotaLister
+	namespaceLister       corelisters.NamespaceLister
+	pvLister              corelisters.PersistentVolumeLister
+	pvcLister             corelisters.PersistentVolumeClaimLister
+	storageClassLister    storagelisters.StorageClassLister
+	replicationCtrlLister corelisters.ReplicationControllerLister
+	replicaSetLister      appslisters.ReplicaSetLister
+	deploymentLister      appslisters.DeploymentLister
+	pdbLister             policylisters.PodDisruptionBudgetLister
+	hpaLister             autoscalinglisters.HorizontalPodAutoscalerLister
+}
+
+func NewClusterCache(kubeClient client.Interface, resyncPeriod time.Duration) *ClusterCache {
+	c := &ClusterCache{
+		informersByType: make(map[reflect.Type]cache.SharedIndexInformer),
+		indexers:        make(map[reflect.Type]cache.Indexer),
+	}
+
+	c.nodeInformer = c.addInformer(&api.Node{}, coreinformers.NewNodeInformer(kubeClient, resyncPeriod, cache.Indexers{}))
+	c.nodeLister = corelisters.NewNodeLister(c.nodeInformer.GetIndexer())
+
+	c.podInformer = c.addInformer(&api.Pod{}, coreinformers.NewPodInformer(kubeClient, api.NamespaceAll, resyncPeriod,
+		cache.Indexers{
+			cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+			podNodeNameIndex:     podNodeNameIndexFunc,
+		}))
+	c.podIndexer = c.podInformer.GetIndexer()
+	c.podLister = corelisters.NewPodLister(c.podIndexer)
+
+	namespaceIndexers := cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}
+
+	serviceInformer := c.addInformer(&api.Service{}, coreinformers.NewServiceInformer(kubeClient, api.NamespaceAll, resyncPeriod,
+		namespaceIndexers))
+	c.serviceLister = corelisters.NewServiceLister(serviceInformer.GetIndexer())
+
+	endpointsInformer := c.addInformer(&api.Endpoints{}, coreinformers.NewEndpointsInformer(kubeClient, api.NamespaceAll, resyncPeriod,
+		namespaceIndexers))
+	c.endpointsLister = corelisters.NewEndpointsLister(endpointsInformer.GetIndexer())
+
+	quotaInformer := c.addInformer(&api.ResourceQuota{}, coreinformers.NewResourceQuotaInformer(kubeClient, api.NamespaceAll, resyncPeriod,
+		namespaceIndexers))
+	c.quotaLister = corelisters.NewResourceQuotaLister(quotaInformer.GetIndexer())
+
+	namespaceInformer := c.addInformer(&api.Namespace{}, coreinformers.NewNamespaceInformer(kubeClient, resyncPeriod, cache.Indexers{}))
+	c.namespaceLister = corelisters.NewNamespaceLister(namespaceInformer.GetIndexer())
+
+	pvInformer := c.addInformer(&api.PersistentVolume{}, coreinformers.NewPersistentVolumeInformer(kubeClient, resyncPeriod, cache.Indexers{}))
+	c.pvLister = corelisters.NewPersistentVolumeLister(pvInformer.GetIndexer())
+
+	pvcInformer := c.addInformer(&api.PersistentVolumeClaim{}, coreinformers.NewPersistentVolumeClaimInformer(kubeClient, api.NamespaceAll,
+		resyncPeriod, namespaceIndexers))
+	c.pvcLister = corelisters.NewPersistentVolumeClaimLister(pvcInformer.GetIndexer())
+
+	storageClassInformer := c.addInformer(&storage.StorageClass{}, storageinformers.NewStorageClassInformer(kubeClient, resyncPeriod,
+		cache.Indexers{}))
+	c.storageClassLister = storagelisters.NewStorageClassLister(storageClassInformer.GetIndexer())
+
+	// The owners of the pods
+	rcInformer := c.addInformer(&api.ReplicationController{}, coreinformers.NewReplicationControllerInformer(kubeClient, api.NamespaceAll,
+		resyncPeriod, namespaceIndexers))
+	c.replicationCtrlLister = corelisters.NewReplicationControllerLister(rcInformer.GetIndexer())
+
+	// The ReplicaSets and Deployments are watched through apps/v1, or their legacy group versions on older API servers
+	var appsClient *goutil.AppsClient
+	if kubeClient != nil {
+		appsClient = goutil.NewAppsClient(kubeClient)
+	}
+	rsInformer := c.addInformer(&apps.ReplicaSet{}, newReplicaSetInformer(appsClient, resyncPeriod, namespaceIndexers))
+	c.replicaSetLister = appslisters.NewReplicaSetLister(rsInformer.GetIndexer())
+
+	deploymentInformer := c.addInformer(&apps.Deployment{}, newDeploymentInformer(appsClient, resyncPeriod,
+		namespaceIndexers))
+	c.deploymentLister = appslisters.NewDeploymentLister(deploymentInformer.GetIndexer())
+
+	c.controllerInformers = []cache.SharedIndexInformer{rcInformer, rsInformer, deploymentInformer}
+
+	// The PodDisruptionBudgets are watched through policy/v1 if the API server serves it
+	var pdbInformer cache.SharedIndexInformer
+	if kubeClient != nil && goutil.ServesPolicyV1(kubeClient.Discovery()) {
+		pdbInformer = newPodDisruptionBudgetV1Informer(kubeClient, resyncPeriod, namespaceIndexers)
+	} else {
+		pdbInformer = policyinformers.NewPodDisruptionBudgetInformer(kubeClient, api.NamespaceAll, resyncPeriod,
+			namespaceIndexers)
+	}
+	c.addInformer(&policy.PodDisruptionBudget{}, pdbInformer)
+	c.pdbLister = policylisters.NewPodDisruptionBudgetLister(pdbInformer.GetIndexer())
+
+	hpaInformer := c.addInformer(&autoscaling.HorizontalPodAutoscaler{}, autoscalinginformers.NewHorizontalPodAutoscalerInformer(
+		kubeClient, api.NamespaceAll, resyncPeriod, namespaceIndexers))
+	c.hpaLister = autoscalinglisters.NewHorizontalPodAutoscalerLister(hpaInformer.GetIndexer())
+
+	return c
+}
+
+func (c *ClusterCache) addInformer(objType interface{}, informer cache.SharedIndexInformer) cache.SharedIndexInformer {
+	c.informers = append(c.informers, informer)
+	c.informersByType[reflect.TypeOf(objType)] = informer
+	c.indexers[reflect.TypeOf(objType)] = informer.GetIndexer()
+	return informer
+}
+
+// NewClusterCacheFromObjects creates a cache holding the given objects, e.g., from a discovery snapshot.
+// It has no connection to the cluster, and must not be started.
+func NewClusterCacheFromObjects(objects *ClusterObjects) (*ClusterCache, error) {
+	c := NewClusterCache(nil, 0)
+	c.static = true
+	for _, obj := range objects.all() {
+		indexer, exists := c.indexers[reflect.TypeOf(obj)]
+		if !exists {
+			return nil, fmt.Errorf("unexpected object %T in the cluster objects", obj)
+		}
+		if err := indexer.Add(obj); err != nil {
+			return nil, fmt.Errorf("failed to add %T to the cluster cache: %v", obj, err)
+		}
+	}
+	return c, nil
+}
+
+// Start the watches of all the informers. They run until the stop channel is closed.
+func (c *ClusterCache) Start(stopCh <-chan struct{}) {
+	glog.V(2).Infof("Start the cluster cache with %d informers.", len(c.informers))
+	for _, informer := range c.informers {
+		go informer.Run(stopCh)
+	}
+}
+
+// Wait until the initial list of every informer is in the cache, for at most the given timeout.
+// Returns an error naming the objects not synced if the timeout expires or the stop channel is closed before that.
+// The informers which are not synced keep retrying, and their objects are not read from the cache until they sync,
+// e.g., when the API of the objects is not served by the cluster.
+func (c *ClusterCache) WaitForCacheSync(stopCh <-chan struct{}, timeout time.Duration) error {
+	var hasSynced []cache.InformerSynced
+	for _, informer := range c.informers {
+		hasSynced = append(hasSynced, informer.HasSynced)
+	}
+	// Stop waiting when the stop channel is closed or the timeout expires
+	waitCh := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(waitCh)
+		select {
+		case <-stopCh:
+		case <-time.After(timeout):
+		case <-done:
+		}
+	}()
+	if !cache.WaitForCacheSync(waitCh, hasSynced...) {
+		return fmt.Errorf("the cluster cache of %v is not synced within %v", c.unsyncedTypes(), timeout)
+	}
+	glog.V(2).Infof("The cluster cache is synced.")
+	return nil
+}
+
+// HasSynced returns whether the initial list of the objects of the given type is in the cache.
+func (c *ClusterCache) HasSynced(objType interface{}) bool {
+	if c.static {
+		return true
+	}
+	informer, exists := c.informersByType[reflect.TypeOf(objType)]
+	return exists && informer.HasSynced()
+}
+
+func (c *ClusterCache) unsyncedTypes() []string {
+	var types []string
+	for objType, informer := range c.informersByType {
+		if !informer.HasSynced() {
+			types = append(types, objType.Elem().Name())
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Register a handler of the node events, including the initial list of the nodes as added.
+func (c *ClusterCache) AddNodeEventHandler(handler cache.ResourceEventHandler) {
+	c.nodeInformer.AddEventHandler(handler)
+}
+
+// Register a handler of the pod events.
+func (c *ClusterCache) AddPodEventHandler(handler cache.ResourceEventHandler) {
+	c.podInformer.AddEventHandler(handler)
+}
+
+// Register a handler of the events of the pod owners, i.e., the ReplicationControllers, ReplicaSets and Deployments.
+func (c *ClusterCache) AddControllerEventHandler(handler cache.ResourceEventHandler) {
+	for _, informer := range c.controllerInformers {
+		informer.AddEventHandler(handler)
+	}
+}
+
+func (c *ClusterCache) NodeLister() corelisters.NodeLister {
+	return c.nodeLister
+}
+
+func (c *ClusterCache) PodLister() corelisters.PodLister {
+	return c.podLister
+}
+
+func (c *ClusterCache) ServiceLister() corelisters.ServiceLister {
+	return c.serviceLister
+}
+
+func (c *ClusterCache) EndpointsLister() corelisters.EndpointsLister {
+	return c.endpointsLister
+}
+
+func (c *ClusterCache) ResourceQuotaLister() corelisters.ResourceQuotaLister {
+	return c.quotaLister
+}
+
+func (c *ClusterCache) NamespaceLister() corelisters.NamespaceLister {
+	return c.namespaceLister
+}
+
+func (c *ClusterCache) PersistentVolumeLister() corelisters.PersistentVolumeLister {
+	return c.pvLister
+}
+
+func (c *ClusterCache) PersistentVolumeClaimLister() corelisters.PersistentVolumeClaimLister {
+	return c.pvcLister
+}
+
+func (c *ClusterCache) StorageClassLister() storagelisters.StorageClassLister {
+	return c.storageClassLister
+}
+
+func (c *ClusterCache) ReplicationControllerLister() corelisters.ReplicationControllerLister {
+	return c.replicationCtrlLister
+}
+
+func (c *ClusterCache) ReplicaSetLister() appslisters.ReplicaSetLister {
+	return c.replicaSetLister
+}
+
+func (c *ClusterCache) DeploymentLister() appslisters.DeploymentLister {
+	return c.deploymentLister
+}
+
+func (c *ClusterCache) PodDisruptionBudgetLister() policylisters.PodDisruptionBudgetLister {
+	return c.pdbLister
+}
+
+func (c *ClusterCache) HorizontalPodAutoscalerLister() autoscalinglisters.HorizontalPodAutoscalerLister {
+	return c.hpaLister
+}
+
+// GetObjects returns all the objects in the cache. They are shared with the cache and must not be modified.
+func (c *ClusterCache) GetObjects() (*ClusterObjects, error) {
+	var err error
+	objects := &ClusterObjects{}
+	everything := labels.Everything()
+	if objects.Nodes, err = c.nodeLister.List(everything); err != nil {
+		return nil, err
+	}
+	if objects.Pods, err = c.podLister.List(everything); err != nil {
+		return nil, err
+	}
+	if objects.Services, err = c.serviceLister.List(everything); err != nil {
+		return nil, err
+	}
+	if objects.Endpoints, err = c.endpointsLister.List(everything); err != nil {
+		return nil, err
+	}
+	if objects.ResourceQuotas, err = c.quotaLister.List(everything); err != nil {
+		return nil, err
+	}
+	if objects.Namespaces, err = c.namespaceLister.List(everything); err != nil {
+		return nil, err
+	}
+	if objects.PersistentVolumes, err = c.pvLister.List(everything); err != nil {
+		return nil, err
+	}
+	if objects.PersistentVolumeClaims, err = c.pvcLister.List(everything); err != nil {
+		return nil, err
+	}
+	if objects.StorageClasses, err = c.storageClassLister.List(everything); err != nil {
+		return nil, err
+	}
+	if objects.ReplicationControllers, err = c.replicationCtrlLister.List(everything); err != nil {
+		return nil, err
+	}
+	if objects.ReplicaSets, err = c.replicaSetLister.List(everything); err != nil {
+		return nil, err
+	}
+	if objects.Deployments, err = c.deploymentLister.List(everything); err != nil {
+		return nil, err
+	}
+	if objects.PodDisruptionBudgets, err = c.pdbLister.List(everything); err != nil {
+		return nil, err
+	}
+	if objects.HorizontalPodAutoscalers, err = c.hpaLister.List(everything); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// Return the pods hosted by the given node.
+func (c *ClusterCache) GetPodsOnNode(nodeName string) ([]*api.Pod, error) {
+	objs, err := c.podIndexer.ByIndex(podNodeNameIndex, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]*api.Pod, 0, len(objs))
+	for _, obj := range objs {
+		pod, ok := obj.(*api.Pod)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object %T in the pod cache", obj)
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+func podNodeNameIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*api.Pod)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object %T", obj)
+	}
+	// The pods not scheduled yet are not indexed
+	if pod.Spec.NodeName == "" {
+		return []string{}, nil
+	}
+	return []string{pod.Spec.NodeName}, nil
+}
@@ -0,0 +1,240 @@
+package cluster
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
+	api "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestPod(name, nodeName string, phase api.PodPhase, owners ...metav1.OwnerReference) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "space1",
+			OwnerReferences: owners,
+		},
+		Spec:   api.PodSpec{NodeName: nodeName},
+		Status: api.PodStatus{Phase: phase},
+	}
+}
+
+// Create a cache with the given objects, without any connection to the cluster.
+func newTestClusterCache(t *testing.T, nodes []*api.Node, pods []*api.Pod,
+	replicaSets []*apps.ReplicaSet) *ClusterCache {
+	c, err := NewClusterCacheFromObjects(&ClusterObjects{Nodes: nodes, Pods: pods, ReplicaSets: replicaSets})
+	if err != nil {
+		t.Fatalf("Failed to create the cluster cache: %v", err)
+	}
+	return c
+}
+
+func TestGetPodsOnNode(t *testing.T) {
+	pods := []*api.Pod{
+		newTestPod("pod1", "node1", api.PodRunning),
+		newTestPod("pod2", "node1", api.PodPending),
+		newTestPod("pod3", "node2", api.PodRunning),
+		newTestPod("pod4", "", api.PodPending),
+	}
+	c := newTestClusterCache(t, nil, pods, nil)
+
+	nodePods, err := c.GetPodsOnNode("node1")
+	if err != nil {
+		t.Fatalf("Failed to get the pods on node1: %v", err)
+	}
+	if len(nodePods) != 2 {
+		t.Errorf("Expected 2 pods on node1, got %d", len(nodePods))
+	}
+
+	scraper := NewClusterScraper(nil).WithClusterCache(c)
+	runningPods, err := scraper.findRunningPodsOnNode("node1")
+	if err != nil {
+		t.Fatalf("Failed to find the running pods on node1: %v", err)
+	}
+	if len(runningPods) != 1 || runningPods[0].Name != "pod1" {
+		t.Errorf("Expected pod1 to be the only running pod on node1, got %v", runningPods)
+	}
+
+	allPods, err := scraper.GetAllPods()
+	if err != nil {
+		t.Fatalf("Failed to get all the pods: %v", err)
+	}
+	if len(allPods) != len(pods) {
+		t.Errorf("Expected %d pods, got %d", len(pods), len(allPods))
+	}
+}
+
+func TestGetAllNodesFromCache(t *testing.T) {
+	nodes := []*api.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node2"}},
+	}
+	scraper := NewClusterScraper(nil).WithClusterCache(newTestClusterCache(t, nodes, nil, nil))
+
+	allNodes, err := scraper.GetAllNodes()
+	if err != nil {
+		t.Fatalf("Failed to get all the nodes: %v", err)
+	}
+	if len(allNodes) != len(nodes) {
+		t.Errorf("Expected %d nodes, got %d", len(nodes), len(allNodes))
+	}
+}
+
+func TestGetPodGrandInfoFromCache(t *testing.T) {
+	isController := true
+	rs := &apps.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rs1",
+			Namespace: "space1",
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "Deployment", Name: "deploy1", Controller: &isController},
+			},
+		},
+	}
+	pod := newTestPod("pod1", "node1", api.PodRunning,
+		metav1.OwnerReference{Kind: "ReplicaSet", Name: "rs1", Controller: &isController})
+	scraper := NewClusterScraper(nil).WithClusterCache(
+		newTestClusterCache(t, nil, []*api.Pod{pod}, []*apps.ReplicaSet{rs}))
+
+	kind, name, err := scraper.GetPodGrandInfo(pod)
+	if err != nil {
+		t.Fatalf("Failed to get the grand parent of the pod: %v", err)
+	}
+	if kind != "Deployment" || name != "deploy1" {
+		t.Errorf("Expected Deployment deploy1, got %s %s", kind, name)
+	}
+
+	// The parent is not in the cache
+	orphan := newTestPod("pod2", "node1", api.PodRunning,
+		metav1.OwnerReference{Kind: "ReplicaSet", Name: "rs2", Controller: &isController})
+	if _, _, err := scraper.GetPodGrandInfo(orphan); err == nil {
+		t.Errorf("Expected an error when the parent ReplicaSet is not found")
+	}
+}
+
+func TestGetPodDisruptionBudgetsFromCache(t *testing.T) {
+	pdbs := []*policy.PodDisruptionBudget{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pdb1", Namespace: "space1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pdb2", Namespace: "space2"}},
+	}
+	c, err := NewClusterCacheFromObjects(&ClusterObjects{PodDisruptionBudgets: pdbs})
+	if err != nil {
+		t.Fatalf("Failed to create the cluster cache: %v", err)
+	}
+	// The scraper has no client, so the budgets can only be read from the cache
+	scraper := NewClusterScraper(nil).WithClusterCache(c)
+
+	namespacePDBs, err := scraper.GetPodDisruptionBudgets("space1")
+	if err != nil {
+		t.Fatalf("Failed to get the PodDisruptionBudgets: %v", err)
+	}
+	if len(namespacePDBs) != 1 || namespacePDBs[0].Name != "pdb1" {
+		t.Errorf("Expected the PodDisruptionBudget pdb1 of namespace space1, got %v", namespacePDBs)
+	}
+}
+
+func TestGetHorizontalPodAutoscalersFromCache(t *testing.T) {
+	hpas := []*autoscaling.HorizontalPodAutoscaler{
+		{ObjectMeta: metav1.ObjectMeta{Name: "hpa1", Namespace: "space1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "hpa2", Namespace: "space2"}},
+	}
+	c, err := NewClusterCacheFromObjects(&ClusterObjects{HorizontalPodAutoscalers: hpas})
+	if err != nil {
+		t.Fatalf("Failed to create the cluster cache: %v", err)
+	}
+	// The scraper has no client, so the autoscalers can only be read from the cache
+	scraper := NewClusterScraper(nil).WithClusterCache(c)
+
+	namespaceHPAs, err := scraper.GetHorizontalPodAutoscalers("space2")
+	if err != nil {
+		t.Fatalf("Failed to get the HorizontalPodAutoscalers: %v", err)
+	}
+	if len(namespaceHPAs) != 1 || namespaceHPAs[0].Name != "hpa2" {
+		t.Errorf("Expected the HorizontalPodAutoscaler hpa2 of namespace space2, got %v", namespaceHPAs)
+	}
+}
+
+func TestGetPVAndPVCFromCache(t *testing.T) {
+	objects := &ClusterObjects{
+		PersistentVolumes: []*api.PersistentVolume{{ObjectMeta: metav1.ObjectMeta{Name: "pv1"}}},
+		PersistentVolumeClaims: []*api.PersistentVolumeClaim{{
+			ObjectMeta: metav1.ObjectMeta{Name: "pvc1", Namespace: "space1"},
+			Spec:       api.PersistentVolumeClaimSpec{VolumeName: "pv1"},
+		}},
+	}
+	c, err := NewClusterCacheFromObjects(objects)
+	if err != nil {
+		t.Fatalf("Failed to create the cluster cache: %v", err)
+	}
+	// The scraper has no client, so the volumes can only be read from the cache
+	scraper := NewClusterScraper(nil).WithClusterCache(c)
+
+	pvc, err := scraper.GetPVC("space1", "pvc1")
+	if err != nil || pvc.Spec.VolumeName != "pv1" {
+		t.Fatalf("Expected the persistent volume claim pvc1 bound to pv1, got %v, %v", pvc, err)
+	}
+	if _, err := scraper.GetPVC("space2", "pvc1"); err == nil {
+		t.Errorf("Expected an error for the persistent volume claim of another namespace")
+	}
+	if pv, err := scraper.GetPV("pv1"); err != nil || pv.Name != "pv1" {
+		t.Errorf("Expected the persistent volume pv1, got %v, %v", pv, err)
+	}
+}
+
+func TestGetObjects(t *testing.T) {
+	objects := &ClusterObjects{
+		Nodes:      []*api.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}},
+		Pods:       []*api.Pod{newTestPod("pod1", "node1", api.PodRunning)},
+		Namespaces: []*api.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "space1"}}},
+		Services: []*api.Service{
+			{ObjectMeta: metav1.ObjectMeta{Name: "kubernetes", Namespace: "default", UID: "svc-uid"}},
+		},
+	}
+	c, err := NewClusterCacheFromObjects(objects)
+	if err != nil {
+		t.Fatalf("Failed to create the cluster cache: %v", err)
+	}
+
+	got, err := c.GetObjects()
+	if err != nil {
+		t.Fatalf("Failed to get the objects of the cluster cache: %v", err)
+	}
+	if !reflect.DeepEqual(got, objects) {
+		t.Errorf("Expected the objects %+v, got %+v", objects, got)
+	}
+
+	svcID, err := NewClusterScraper(nil).WithClusterCache(c).GetKubernetesServiceID()
+	if err != nil || svcID != "svc-uid" {
+		t.Errorf("Expected the kubernetes service ID svc-uid, got %s: %v", svcID, err)
+	}
+}
+
+func TestWaitForCacheSyncTimeout(t *testing.T) {
+	// The informers never sync as they are not started
+	c := NewClusterCache(nil, 0)
+	err := c.WaitForCacheSync(make(chan struct{}), 100*time.Millisecond)
+	if err == nil {
+		t.Fatalf("Expected an error as the cache is not synced")
+	}
+	if !strings.Contains(err.Error(), "PodDisruptionBudget") {
+		t.Errorf("Expected the error to name the objects not synced: %v", err)
+	}
+	if c.HasSynced(&policy.PodDisruptionBudget{}) {
+		t.Errorf("Expected the PodDisruptionBudgets not to be synced")
+	}
+
+	// The cache of the snapshot objects is always synced
+	c, err = NewClusterCacheFromObjects(&ClusterObjects{})
+	if err != nil {
+		t.Fatalf("Failed to create the cluster cache: %v", err)
+	}
+	if !c.HasSynced(&policy.PodDisruptionBudget{}) {
+		t.Errorf("Expected the cache of the objects to be synced")
+	}
+}
@@ -3,7 +3,11 @@ package cluster
 import (
 	"fmt"
 
+	apps "k8s.io/api/apps/v1"
+	autoscaling "k8s.io/api/autoscaling/v1"
 	api "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	storage "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -11,6 +15,7 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
 )
 
 const (
@@ -25,16 +30,22 @@ var (
 
 type ClusterScraperInterface interface {
 	GetAllNodes() ([]*api.Node, error)
+	GetNode(name string) (*api.Node, error)
 	GetNamespaces() ([]*api.Namespace, error)
 	GetNamespaceQuotas() (map[string][]*api.ResourceQuota, error)
 	GetAllPods() ([]*api.Pod, error)
 	GetAllEndpoints() ([]*api.Endpoints, error)
 	GetAllServices() ([]*api.Service, error)
 	GetKubernetesServiceID() (svcID string, err error)
+	GetAllPVs() ([]*api.PersistentVolume, error)
+	GetAllPVCs() ([]*api.PersistentVolumeClaim, error)
+	GetAllStorageClasses() ([]*storage.StorageClass, error)
 }
 
 type ClusterScraper struct {
 	*client.Clientset
+	// The shared cluster cache, the objects are listed from the API server when it is not set
+	cache *ClusterCache
 }
 
 func NewClusterScraper(kclient *client.Clientset) *ClusterScraper {
@@ -43,7 +54,27 @@ func NewClusterScraper(kclient *client.Clientset) *ClusterScraper {
 	}
 }
 
+// Get the cluster objects from the given cache instead of the API server.
+// The objects in the cache are shared and must not be modified.
+func (s *ClusterScraper) WithClusterCache(cache *ClusterCache) *ClusterScraper {
+	s.cache = cache
+	return s
+}
+
+func (s *ClusterScraper) GetClusterCache() *ClusterCache {
+	return s.cache
+}
+
+// Whether the objects of the given type are read from the cluster cache. They are listed from the API server
+// when the cache is not set, or when it has not synced them, e.g., as their API is not served by the cluster.
+func (s *ClusterScraper) cacheHasSynced(objType interface{}) bool {
+	return s.cache != nil && s.cache.HasSynced(objType)
+}
+
 func (s *ClusterScraper) GetNamespaces() ([]*api.Namespace, error) {
+	if s.cacheHasSynced(&api.Namespace{}) {
+		return s.cache.NamespaceLister().List(labels.Everything())
+	}
 	namespaceList, err := s.CoreV1().Namespaces().List(metav1.ListOptions{})
 	if err != nil {
 		return nil, err
@@ -56,6 +87,9 @@ func (s *ClusterScraper) GetNamespaces() ([]*api.Namespace, error) {
 }
 
 func (s *ClusterScraper) getResourceQuotas() ([]*api.ResourceQuota, error) {
+	if s.cacheHasSynced(&api.ResourceQuota{}) {
+		return s.cache.ResourceQuotaLister().List(labels.Everything())
+	}
 	namespace := api.NamespaceAll
 	quotaList, err := s.CoreV1().ResourceQuotas(namespace).List(metav1.ListOptions{})
 	if err != nil {
@@ -88,6 +122,9 @@ func (s *ClusterScraper) GetNamespaceQuotas() (map[string][]*api.ResourceQuota,
 }
 
 func (s *ClusterScraper) GetAllNodes() ([]*api.Node, error) {
+	if s.cacheHasSynced(&api.Node{}) {
+		return s.cache.NodeLister().List(labels.Everything())
+	}
 	listOption := metav1.ListOptions{
 		LabelSelector: labelSelectEverything,
 		FieldSelector: fieldSelectEverything,
@@ -95,6 +132,14 @@ func (s *ClusterScraper) GetAllNodes() ([]*api.Node, error) {
 	return s.GetNodes(listOption)
 }
 
+// GetNode returns the node of the given name, or a NotFound error if it does not exist.
+func (s *ClusterScraper) GetNode(name string) (*api.Node, error) {
+	if s.cacheHasSynced(&api.Node{}) {
+		return s.cache.NodeLister().Get(name)
+	}
+	return s.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+}
+
 func (s *ClusterScraper) GetNodes(opts metav1.ListOptions) ([]*api.Node, error) {
 	nodeList, err := s.CoreV1().Nodes().List(opts)
 	if err != nil {
@@ -109,6 +154,9 @@ func (s *ClusterScraper) GetNodes(opts metav1.ListOptions) ([]*api.Node, error)
 }
 
 func (s *ClusterScraper) GetAllPods() ([]*api.Pod, error) {
+	if s.cacheHasSynced(&api.Pod{}) {
+		return s.cache.PodLister().List(labels.Everything())
+	}
 	listOption := metav1.ListOptions{
 		LabelSelector: labelSelectEverything,
 		FieldSelector: fieldSelectEverything,
@@ -130,6 +178,9 @@ func (s *ClusterScraper) GetPods(namespaces string, opts metav1.ListOptions) ([]
 }
 
 func (s *ClusterScraper) GetAllServices() ([]*api.Service, error) {
+	if s.cacheHasSynced(&api.Service{}) {
+		return s.cache.ServiceLister().List(labels.Everything())
+	}
 	listOption := metav1.ListOptions{
 		LabelSelector: labelSelectEverything,
 	}
@@ -164,6 +215,9 @@ func (s *ClusterScraper) GetEndpoints(namespaces string, opts metav1.ListOptions
 }
 
 func (s *ClusterScraper) GetAllEndpoints() ([]*api.Endpoints, error) {
+	if s.cacheHasSynced(&api.Endpoints{}) {
+		return s.cache.EndpointsLister().List(labels.Everything())
+	}
 	listOption := metav1.ListOptions{
 		LabelSelector: labelSelectEverything,
 	}
@@ -171,7 +225,12 @@ func (s *ClusterScraper) GetAllEndpoints() ([]*api.Endpoints, error) {
 }
 
 func (s *ClusterScraper) GetKubernetesServiceID() (svcID string, err error) {
-	svc, err := s.CoreV1().Services(k8sDefaultNamespace).Get(kubernetesServiceName, metav1.GetOptions{})
+	var svc *api.Service
+	if s.cacheHasSynced(&api.Service{}) {
+		svc, err = s.cache.ServiceLister().Services(k8sDefaultNamespace).Get(kubernetesServiceName)
+	} else {
+		svc, err = s.CoreV1().Services(k8sDefaultNamespace).Get(kubernetesServiceName, metav1.GetOptions{})
+	}
 	if err != nil {
 		return
 	}
@@ -179,6 +238,139 @@ func (s *ClusterScraper) GetKubernetesServiceID() (svcID string, err error) {
 	return
 }
 
+func (s *ClusterScraper) GetAllPVs() ([]*api.PersistentVolume, error) {
+	if s.cacheHasSynced(&api.PersistentVolume{}) {
+		return s.cache.PersistentVolumeLister().List(labels.Everything())
+	}
+	pvList, err := s.CoreV1().PersistentVolumes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	pvs := make([]*api.PersistentVolume, len(pvList.Items))
+	for i := 0; i < len(pvList.Items); i++ {
+		pvs[i] = &pvList.Items[i]
+	}
+	return pvs, nil
+}
+
+// GetPV returns the persistent volume of the given name, or a NotFound error if it does not exist.
+func (s *ClusterScraper) GetPV(name string) (*api.PersistentVolume, error) {
+	if s.cacheHasSynced(&api.PersistentVolume{}) {
+		return s.cache.PersistentVolumeLister().Get(name)
+	}
+	return s.CoreV1().PersistentVolumes().Get(name, metav1.GetOptions{})
+}
+
+func (s *ClusterScraper) GetAllPVCs() ([]*api.PersistentVolumeClaim, error) {
+	if s.cacheHasSynced(&api.PersistentVolumeClaim{}) {
+		return s.cache.PersistentVolumeClaimLister().List(labels.Everything())
+	}
+	pvcList, err := s.CoreV1().PersistentVolumeClaims(api.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	pvcs := make([]*api.PersistentVolumeClaim, len(pvcList.Items))
+	for i := 0; i < len(pvcList.Items); i++ {
+		pvcs[i] = &pvcList.Items[i]
+	}
+	return pvcs, nil
+}
+
+// GetPVC returns the persistent volume claim of the given namespace and name, or a NotFound error if it does not exist.
+func (s *ClusterScraper) GetPVC(namespace, name string) (*api.PersistentVolumeClaim, error) {
+	if s.cacheHasSynced(&api.PersistentVolumeClaim{}) {
+		return s.cache.PersistentVolumeClaimLister().PersistentVolumeClaims(namespace).Get(name)
+	}
+	return s.CoreV1().PersistentVolumeClaims(namespace).Get(name, metav1.GetOptions{})
+}
+
+func (s *ClusterScraper) GetAllStorageClasses() ([]*storage.StorageClass, error) {
+	if s.cacheHasSynced(&storage.StorageClass{}) {
+		return s.cache.StorageClassLister().List(labels.Everything())
+	}
+	scList, err := s.StorageV1().StorageClasses().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	storageClasses := make([]*storage.StorageClass, len(scList.Items))
+	for i := 0; i < len(scList.Items); i++ {
+		storageClasses[i] = &scList.Items[i]
+	}
+	return storageClasses, nil
+}
+
+func (s *ClusterScraper) GetAllPodDisruptionBudgets() ([]*policy.PodDisruptionBudget, error) {
+	if s.cacheHasSynced(&policy.PodDisruptionBudget{}) {
+		return s.cache.PodDisruptionBudgetLister().List(labels.Everything())
+	}
+	return s.listPodDisruptionBudgets(api.NamespaceAll)
+}
+
+// Get the PodDisruptionBudgets of the namespace.
+func (s *ClusterScraper) GetPodDisruptionBudgets(namespace string) ([]*policy.PodDisruptionBudget, error) {
+	if s.cacheHasSynced(&policy.PodDisruptionBudget{}) {
+		return s.cache.PodDisruptionBudgetLister().PodDisruptionBudgets(namespace).List(labels.Everything())
+	}
+	return s.listPodDisruptionBudgets(namespace)
+}
+
+// List the PodDisruptionBudgets of the namespace, or of all the namespaces if empty, from the API server,
+// through policy/v1 if it serves it.
+func (s *ClusterScraper) listPodDisruptionBudgets(namespace string) ([]*policy.PodDisruptionBudget, error) {
+	var pdbList *policy.PodDisruptionBudgetList
+	var err error
+	if goutil.ServesPolicyV1(s.Discovery()) {
+		pdbList, err = listPodDisruptionBudgetsV1(s.Clientset, namespace, metav1.ListOptions{})
+	} else {
+		pdbList, err = s.PolicyV1beta1().PodDisruptionBudgets(namespace).List(metav1.ListOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pdbs := make([]*policy.PodDisruptionBudget, len(pdbList.Items))
+	for i := 0; i < len(pdbList.Items); i++ {
+		pdbs[i] = &pdbList.Items[i]
+	}
+	return pdbs, nil
+}
+
+func (s *ClusterScraper) GetAllHorizontalPodAutoscalers() ([]*autoscaling.HorizontalPodAutoscaler, error) {
+	if s.cacheHasSynced(&autoscaling.HorizontalPodAutoscaler{}) {
+		return s.cache.HorizontalPodAutoscalerLister().List(labels.Everything())
+	}
+	hpaList, err := s.AutoscalingV1().HorizontalPodAutoscalers(api.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	hpas := make([]*autoscaling.HorizontalPodAutoscaler, len(hpaList.Items))
+	for i := 0; i < len(hpaList.Items); i++ {
+		hpas[i] = &hpaList.Items[i]
+	}
+	return hpas, nil
+}
+
+// Get the HorizontalPodAutoscalers of the namespace.
+func (s *ClusterScraper) GetHorizontalPodAutoscalers(namespace string) ([]*autoscaling.HorizontalPodAutoscaler, error) {
+	if s.cacheHasSynced(&autoscaling.HorizontalPodAutoscaler{}) {
+		return s.cache.HorizontalPodAutoscalerLister().HorizontalPodAutoscalers(namespace).List(labels.Everything())
+	}
+	hpaList, err := s.AutoscalingV1().HorizontalPodAutoscalers(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	hpas := make([]*autoscaling.HorizontalPodAutoscaler, len(hpaList.Items))
+	for i := 0; i < len(hpaList.Items); i++ {
+		hpas[i] = &hpaList.Items[i]
+	}
+	return hpas, nil
+}
+
 func (s *ClusterScraper) GetRunningAndReadyPodsOnNodes(nodeList []*api.Node) []*api.Pod {
 	pods := []*api.Pod{}
 	for _, node := range nodeList {
@@ -192,8 +384,30 @@ func (s *ClusterScraper) GetRunningAndReadyPodsOnNodes(nodeList []*api.Node) []*
 	return util.GetReadyPods(pods)
 }
 
-// TODO, create a local pod, node cache to avoid too many API request.
+// GetPodsOnNode returns all the pods bound to the node, whatever their phase.
+func (s *ClusterScraper) GetPodsOnNode(nodeName string) ([]*api.Pod, error) {
+	if s.cacheHasSynced(&api.Pod{}) {
+		return s.cache.GetPodsOnNode(nodeName)
+	}
+	fieldSelector, err := fields.ParseSelector("spec.nodeName=" + nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPods(api.NamespaceAll, metav1.ListOptions{FieldSelector: fieldSelector.String()})
+}
+
+// GetPodsBySelector returns the pods of the namespace matching the label selector, whatever their phase.
+func (s *ClusterScraper) GetPodsBySelector(namespace string, selector labels.Selector) ([]*api.Pod, error) {
+	if s.cacheHasSynced(&api.Pod{}) {
+		return s.cache.PodLister().Pods(namespace).List(selector)
+	}
+	return s.GetPods(namespace, metav1.ListOptions{LabelSelector: selector.String()})
+}
+
 func (s *ClusterScraper) findRunningPodsOnNode(nodeName string) ([]*api.Pod, error) {
+	if s.cacheHasSynced(&api.Pod{}) {
+		return s.findRunningPodsOnNodeFromCache(nodeName)
+	}
 	fieldSelector, err := fields.ParseSelector("spec.nodeName=" + nodeName + ",status.phase=" +
 		string(api.PodRunning))
 	if err != nil {
@@ -209,3 +423,29 @@ func (s *ClusterScraper) findRunningPodsOnNode(nodeName string) ([]*api.Pod, err
 	}
 	return pods, nil
 }
+
+func (s *ClusterScraper) findRunningPodsOnNodeFromCache(nodeName string) ([]*api.Pod, error) {
+	nodePods, err := s.cache.GetPodsOnNode(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	pods := []*api.Pod{}
+	for _, pod := range nodePods {
+		if pod.Status.Phase == api.PodRunning {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// GetPodGrandInfo gets the kind and name of the grand parent of the pod, e.g., the Deployment of
+// the ReplicaSet of the pod, or of its parent if the parent has no parent.
+// The parents are read from the cluster cache when it has synced them.
+func (s *ClusterScraper) GetPodGrandInfo(pod *api.Pod) (string, string, error) {
+	if !s.cacheHasSynced(&apps.ReplicaSet{}) {
+		return util.GetPodGrandInfo(s.Clientset, pod)
+	}
+	return util.GetPodGrandInfoFromReplicaSet(pod, func(namespace, name string) (*apps.ReplicaSet, error) {
+		return s.cache.ReplicaSetLister().ReplicaSets(namespace).Get(name)
+	})
+}
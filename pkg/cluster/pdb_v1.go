@@ -0,0 +1,93 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	api "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+)
+
+// The PodDisruptionBudgets are read through policy/v1 from the API servers serving it, as policy/v1beta1 is no
+// longer served since Kubernetes 1.25. This version of the client has no policy/v1 types, so the budgets are decoded
+// into the policy/v1beta1 types, which have the same schema. They keep the policy/v1 API version, as a budget
+// with an empty selector selects all the pods of its namespace in policy/v1, and none in policy/v1beta1.
+
+// List the PodDisruptionBudgets of the namespace, or of all the namespaces if empty, through policy/v1.
+func listPodDisruptionBudgetsV1(kubeClient client.Interface, namespace string,
+	options metav1.ListOptions) (*policy.PodDisruptionBudgetList, error) {
+	body, err := podDisruptionBudgetsV1Request(kubeClient, namespace, options).DoRaw()
+	if err != nil {
+		return nil, err
+	}
+	pdbList := &policy.PodDisruptionBudgetList{}
+	if err := json.Unmarshal(body, pdbList); err != nil {
+		return nil, fmt.Errorf("failed to decode the PodDisruptionBudgets of %s: %v", goutil.PolicyV1GroupVersion, err)
+	}
+	for i := range pdbList.Items {
+		pdbList.Items[i].APIVersion = goutil.PolicyV1GroupVersion
+		pdbList.Items[i].Kind = goutil.KindPodDisruptionBudget
+	}
+	return pdbList, nil
+}
+
+// Watch the PodDisruptionBudgets of all the namespaces through policy/v1.
+func watchPodDisruptionBudgetsV1(kubeClient client.Interface, options metav1.ListOptions) (watch.Interface, error) {
+	options.Watch = true
+	stream, err := podDisruptionBudgetsV1Request(kubeClient, api.NamespaceAll, options).Stream()
+	if err != nil {
+		return nil, err
+	}
+	return watch.NewStreamWatcher(goutil.NewJSONWatchDecoder(stream, decodePodDisruptionBudgetV1)), nil
+}
+
+func podDisruptionBudgetsV1Request(kubeClient client.Interface, namespace string,
+	options metav1.ListOptions) *rest.Request {
+	path := "/apis/" + goutil.PolicyV1GroupVersion
+	if namespace != api.NamespaceAll {
+		path += "/namespaces/" + namespace
+	}
+	return kubeClient.PolicyV1beta1().RESTClient().Get().
+		AbsPath(path, "poddisruptionbudgets").
+		SetHeader("Accept", runtime.ContentTypeJSON).
+		VersionedParams(&options, scheme.ParameterCodec)
+}
+
+// Create the informer of the PodDisruptionBudgets of all the namespaces, read through policy/v1.
+func newPodDisruptionBudgetV1Informer(kubeClient client.Interface, resyncPeriod time.Duration,
+	indexers cache.Indexers) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return listPodDisruptionBudgetsV1(kubeClient, api.NamespaceAll, options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return watchPodDisruptionBudgetsV1(kubeClient, options)
+			},
+		},
+		&policy.PodDisruptionBudget{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+// Decode a PodDisruptionBudget read through policy/v1.
+func decodePodDisruptionBudgetV1(data []byte) (runtime.Object, error) {
+	pdb := &policy.PodDisruptionBudget{}
+	if err := json.Unmarshal(data, pdb); err != nil {
+		return nil, err
+	}
+	pdb.APIVersion = goutil.PolicyV1GroupVersion
+	pdb.Kind = goutil.KindPodDisruptionBudget
+	return pdb, nil
+}
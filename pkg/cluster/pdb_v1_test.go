@@ -0,0 +1,68 @@
+package cluster
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	policy "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
+	client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestPodDisruptionBudgetV1Informer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/version":
+			fmt.Fprint(w, `{"major":"1","minor":"25","gitVersion":"v1.25.0"}`)
+		case r.URL.Path == "/apis/policy/v1/poddisruptionbudgets" && r.URL.Query().Get("watch") == "true":
+			fmt.Fprint(w, `{"type":"ADDED","object":{"apiVersion":"policy/v1","kind":"PodDisruptionBudget",`+
+				`"metadata":{"name":"web-pdb","namespace":"space1","resourceVersion":"2"},`+
+				`"spec":{"selector":{"matchLabels":{"app":"web"}}},"status":{"disruptionsAllowed":0}}}`)
+			// The watch is closed by the server, and started again by the informer
+			time.Sleep(100 * time.Millisecond)
+		case r.URL.Path == "/apis/policy/v1/poddisruptionbudgets":
+			fmt.Fprint(w, `{"apiVersion":"policy/v1","kind":"PodDisruptionBudgetList","metadata":{"resourceVersion":"1"},`+
+				`"items":[{"metadata":{"name":"zk-pdb","namespace":"space1","resourceVersion":"1"},`+
+				`"spec":{"selector":{}},"status":{"disruptionsAllowed":1}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	kubeClient, err := client.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create the client: %v", err)
+	}
+	c := NewClusterCache(kubeClient, 0)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go c.informersByType[reflect.TypeOf(&policy.PodDisruptionBudget{})].Run(stopCh)
+
+	var names []string
+	for i := 0; i < 50; i++ {
+		pdbs, err := c.PodDisruptionBudgetLister().PodDisruptionBudgets("space1").List(labels.Everything())
+		if err != nil {
+			t.Fatalf("Failed to list the PodDisruptionBudgets: %v", err)
+		}
+		if len(pdbs) == 2 {
+			for _, pdb := range pdbs {
+				if pdb.APIVersion != "policy/v1" {
+					t.Errorf("Expected PodDisruptionBudget %s of policy/v1, got %q", pdb.Name, pdb.APIVersion)
+				}
+				names = append(names, pdb.Name)
+			}
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if len(names) != 2 {
+		t.Errorf("Expected the listed and watched PodDisruptionBudgets in the cache, got %v", names)
+	}
+}
@@ -0,0 +1,273 @@
+package discovery
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+)
+
+// The changes of the cluster since the previous discovery.
+type clusterChanges struct {
+	// Names of the nodes whose node, pod, container and application entities must be rediscovered
+	changedNodes map[string]bool
+	// The nodes and pods deleted from the cluster
+	deletedNodes []*api.Node
+	deletedPods  []*api.Pod
+}
+
+func (c *clusterChanges) isEmpty() bool {
+	return len(c.changedNodes) == 0 && len(c.deletedNodes) == 0 && len(c.deletedPods) == 0
+}
+
+// changeTracker records the changes of the nodes, pods and pod owners notified by the shared cluster cache,
+// so that the incremental discovery only rediscovers the nodes affected by the changes.
+type changeTracker struct {
+	podLister corelisters.PodLister
+
+	changedNodes map[string]bool
+	// The deleted nodes by name and the deleted pods by uid
+	deletedNodes map[string]*api.Node
+	deletedPods  map[string]*api.Pod
+	dataMutex    sync.Mutex
+}
+
+func newChangeTracker(clusterCache *cluster.ClusterCache) *changeTracker {
+	t := &changeTracker{
+		podLister: clusterCache.PodLister(),
+	}
+	t.clear()
+
+	clusterCache.AddNodeEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    t.onNodeAdd,
+		UpdateFunc: t.onNodeUpdate,
+		DeleteFunc: t.onNodeDelete,
+	})
+	clusterCache.AddPodEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    t.onPodAdd,
+		UpdateFunc: t.onPodUpdate,
+		DeleteFunc: t.onPodDelete,
+	})
+	clusterCache.AddControllerEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: t.onControllerUpdate,
+	})
+	return t
+}
+
+// Forget all the changes, e.g., when the full discovery starts.
+func (t *changeTracker) reset() {
+	t.dataMutex.Lock()
+	defer t.dataMutex.Unlock()
+	t.clear()
+}
+
+func (t *changeTracker) clear() {
+	t.changedNodes = make(map[string]bool)
+	t.deletedNodes = make(map[string]*api.Node)
+	t.deletedPods = make(map[string]*api.Pod)
+}
+
+// Return the changes recorded since the previous call or reset, and start recording the new changes.
+func (t *changeTracker) takeChanges() *clusterChanges {
+	t.dataMutex.Lock()
+	changes := &clusterChanges{
+		changedNodes: t.changedNodes,
+	}
+	for _, node := range t.deletedNodes {
+		changes.deletedNodes = append(changes.deletedNodes, node)
+	}
+	for _, pod := range t.deletedPods {
+		changes.deletedPods = append(changes.deletedPods, pod)
+	}
+	t.clear()
+	t.dataMutex.Unlock()
+
+	sort.Slice(changes.deletedNodes, func(i, j int) bool {
+		return changes.deletedNodes[i].Name < changes.deletedNodes[j].Name
+	})
+	sort.Slice(changes.deletedPods, func(i, j int) bool {
+		return changes.deletedPods[i].UID < changes.deletedPods[j].UID
+	})
+	return changes
+}
+
+// Record again the changes which could not be discovered, unless they are superseded by newer changes.
+func (t *changeTracker) restore(changes *clusterChanges) {
+	t.dataMutex.Lock()
+	defer t.dataMutex.Unlock()
+	for nodeName := range changes.changedNodes {
+		if _, deleted := t.deletedNodes[nodeName]; !deleted {
+			t.changedNodes[nodeName] = true
+		}
+	}
+	for _, node := range changes.deletedNodes {
+		if !t.changedNodes[node.Name] {
+			t.deletedNodes[node.Name] = node
+		}
+	}
+	for _, pod := range changes.deletedPods {
+		t.deletedPods[string(pod.UID)] = pod
+	}
+}
+
+func (t *changeTracker) markNodeChanged(nodeName string) {
+	if nodeName == "" {
+		// The pod is not scheduled yet
+		return
+	}
+	t.dataMutex.Lock()
+	defer t.dataMutex.Unlock()
+	t.changedNodes[nodeName] = true
+}
+
+func (t *changeTracker) onNodeAdd(obj interface{}) {
+	node, ok := obj.(*api.Node)
+	if !ok {
+		return
+	}
+	t.dataMutex.Lock()
+	defer t.dataMutex.Unlock()
+	t.changedNodes[node.Name] = true
+	delete(t.deletedNodes, node.Name)
+}
+
+func (t *changeTracker) onNodeUpdate(oldObj, newObj interface{}) {
+	oldNode, ok := oldObj.(*api.Node)
+	if !ok {
+		return
+	}
+	newNode, ok := newObj.(*api.Node)
+	if !ok {
+		return
+	}
+	if nodeChanged(oldNode, newNode) {
+		glog.V(4).Infof("Node %s has changed.", newNode.Name)
+		t.markNodeChanged(newNode.Name)
+	}
+}
+
+func (t *changeTracker) onNodeDelete(obj interface{}) {
+	node, ok := deletedObject(obj).(*api.Node)
+	if !ok {
+		return
+	}
+	glog.V(3).Infof("Node %s has been deleted.", node.Name)
+	t.dataMutex.Lock()
+	defer t.dataMutex.Unlock()
+	t.deletedNodes[node.Name] = node
+	delete(t.changedNodes, node.Name)
+}
+
+func (t *changeTracker) onPodAdd(obj interface{}) {
+	if pod, ok := obj.(*api.Pod); ok {
+		t.markNodeChanged(pod.Spec.NodeName)
+	}
+}
+
+func (t *changeTracker) onPodUpdate(oldObj, newObj interface{}) {
+	oldPod, ok := oldObj.(*api.Pod)
+	if !ok {
+		return
+	}
+	newPod, ok := newObj.(*api.Pod)
+	if !ok {
+		return
+	}
+	if podChanged(oldPod, newPod) {
+		glog.V(4).Infof("Pod %s/%s has changed.", newPod.Namespace, newPod.Name)
+		t.markNodeChanged(oldPod.Spec.NodeName)
+		t.markNodeChanged(newPod.Spec.NodeName)
+	}
+}
+
+func (t *changeTracker) onPodDelete(obj interface{}) {
+	pod, ok := deletedObject(obj).(*api.Pod)
+	if !ok {
+		return
+	}
+	glog.V(3).Infof("Pod %s/%s has been deleted.", pod.Namespace, pod.Name)
+	t.markNodeChanged(pod.Spec.NodeName)
+	t.dataMutex.Lock()
+	defer t.dataMutex.Unlock()
+	t.deletedPods[string(pod.UID)] = pod
+}
+
+// A change of the spec of a controller changes the pods it controls.
+// The pods of a Deployment are controlled by its ReplicaSets, whose specs are changed by the Deployment.
+func (t *changeTracker) onControllerUpdate(oldObj, newObj interface{}) {
+	oldController, err := meta.Accessor(oldObj)
+	if err != nil {
+		return
+	}
+	newController, err := meta.Accessor(newObj)
+	if err != nil {
+		return
+	}
+	if oldController.GetGeneration() == newController.GetGeneration() {
+		// Only the status has changed
+		return
+	}
+	pods, err := t.podLister.Pods(newController.GetNamespace()).List(labels.Everything())
+	if err != nil {
+		glog.Errorf("Failed to list the pods of %s/%s: %v", newController.GetNamespace(), newController.GetName(), err)
+		return
+	}
+	for _, pod := range pods {
+		if owner := metav1.GetControllerOf(pod); owner != nil && owner.UID == newController.GetUID() {
+			t.markNodeChanged(pod.Spec.NodeName)
+		}
+	}
+}
+
+// Whether the node has changed in a way that changes its entity, ignoring e.g. the heartbeats.
+func nodeChanged(oldNode, newNode *api.Node) bool {
+	return !reflect.DeepEqual(oldNode.Labels, newNode.Labels) ||
+		!reflect.DeepEqual(oldNode.Spec, newNode.Spec) ||
+		!reflect.DeepEqual(oldNode.Status.Capacity, newNode.Status.Capacity) ||
+		!reflect.DeepEqual(oldNode.Status.Allocatable, newNode.Status.Allocatable) ||
+		!reflect.DeepEqual(oldNode.Status.Addresses, newNode.Status.Addresses) ||
+		nodeReadyStatus(oldNode) != nodeReadyStatus(newNode)
+}
+
+func nodeReadyStatus(node *api.Node) api.ConditionStatus {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == api.NodeReady {
+			return condition.Status
+		}
+	}
+	return api.ConditionUnknown
+}
+
+// Whether the pod has changed in a way that changes its entities.
+func podChanged(oldPod, newPod *api.Pod) bool {
+	return !reflect.DeepEqual(oldPod.Labels, newPod.Labels) ||
+		!reflect.DeepEqual(oldPod.Spec, newPod.Spec) ||
+		oldPod.Status.Phase != newPod.Status.Phase ||
+		podReadyStatus(oldPod) != podReadyStatus(newPod)
+}
+
+func podReadyStatus(pod *api.Pod) api.ConditionStatus {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == api.PodReady {
+			return condition.Status
+		}
+	}
+	return api.ConditionUnknown
+}
+
+// The deleted object, which is wrapped when the deletion was missed by the watch.
+func deletedObject(obj interface{}) interface{} {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return tombstone.Obj
+	}
+	return obj
+}
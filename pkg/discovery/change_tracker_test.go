@@ -0,0 +1,175 @@
+package discovery
+
+import (
+	"testing"
+
+	apps "k8s.io/api/apps/v1"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func newTestChangeTracker(t *testing.T, pods ...*api.Pod) *changeTracker {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, pod := range pods {
+		if err := indexer.Add(pod); err != nil {
+			t.Fatalf("Failed to add pod %s: %v", pod.Name, err)
+		}
+	}
+	tracker := &changeTracker{podLister: corelisters.NewPodLister(indexer)}
+	tracker.clear()
+	return tracker
+}
+
+func newReadyNode(name string, ready api.ConditionStatus) *api.Node {
+	return &api.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name + "-uid")},
+		Status: api.NodeStatus{
+			Conditions: []api.NodeCondition{{Type: api.NodeReady, Status: ready}},
+		},
+	}
+}
+
+func newScheduledPod(name, nodeName string, owners ...metav1.OwnerReference) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "space1",
+			UID:             types.UID(name + "-uid"),
+			OwnerReferences: owners,
+		},
+		Spec: api.PodSpec{
+			NodeName:   nodeName,
+			Containers: []api.Container{{Name: "container1"}, {Name: "container2"}},
+		},
+		Status: api.PodStatus{Phase: api.PodRunning},
+	}
+}
+
+func TestNodeHeartbeatIsNotAChange(t *testing.T) {
+	tracker := newTestChangeTracker(t)
+	oldNode := newReadyNode("node1", api.ConditionTrue)
+	newNode := oldNode.DeepCopy()
+	newNode.ResourceVersion = "2"
+	newNode.Status.Conditions[0].LastHeartbeatTime = metav1.Now()
+
+	tracker.onNodeUpdate(oldNode, newNode)
+	if changes := tracker.takeChanges(); !changes.isEmpty() {
+		t.Errorf("Expected no change for a node heartbeat, got %+v", changes)
+	}
+
+	notReadyNode := newReadyNode("node1", api.ConditionFalse)
+	tracker.onNodeUpdate(oldNode, notReadyNode)
+	if changes := tracker.takeChanges(); !changes.changedNodes["node1"] {
+		t.Errorf("Expected node1 to be changed when it is not ready, got %+v", changes)
+	}
+}
+
+func TestPodChanges(t *testing.T) {
+	tracker := newTestChangeTracker(t)
+
+	tracker.onPodAdd(newScheduledPod("pending", ""))
+	tracker.onPodAdd(newScheduledPod("pod1", "node1"))
+	oldPod := newScheduledPod("pod2", "node2")
+	newPod := newScheduledPod("pod2", "node3")
+	tracker.onPodUpdate(oldPod, newPod)
+	tracker.onPodDelete(cache.DeletedFinalStateUnknown{Key: "space1/pod4", Obj: newScheduledPod("pod4", "node4")})
+
+	changes := tracker.takeChanges()
+	for _, nodeName := range []string{"node1", "node2", "node3", "node4"} {
+		if !changes.changedNodes[nodeName] {
+			t.Errorf("Expected %s to be changed", nodeName)
+		}
+	}
+	if len(changes.changedNodes) != 4 {
+		t.Errorf("Expected 4 changed nodes, got %v", changes.changedNodes)
+	}
+	if len(changes.deletedPods) != 1 || changes.deletedPods[0].Name != "pod4" {
+		t.Errorf("Expected pod4 to be deleted, got %v", changes.deletedPods)
+	}
+
+	// The changes are taken only once
+	if changes := tracker.takeChanges(); !changes.isEmpty() {
+		t.Errorf("Expected no change after the changes have been taken, got %+v", changes)
+	}
+}
+
+func TestNodeDeletedAndRestored(t *testing.T) {
+	tracker := newTestChangeTracker(t)
+	node := newReadyNode("node1", api.ConditionTrue)
+	tracker.onNodeAdd(node)
+	tracker.onNodeDelete(node)
+
+	changes := tracker.takeChanges()
+	if len(changes.changedNodes) != 0 || len(changes.deletedNodes) != 1 {
+		t.Errorf("Expected node1 to be deleted only, got %+v", changes)
+	}
+
+	// The node is added back before the changes are restored
+	tracker.onNodeAdd(node)
+	tracker.restore(changes)
+	changes = tracker.takeChanges()
+	if !changes.changedNodes["node1"] || len(changes.deletedNodes) != 0 {
+		t.Errorf("Expected node1 to be changed only, got %+v", changes)
+	}
+}
+
+func TestControllerUpdate(t *testing.T) {
+	isController := true
+	rs := &apps.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "rs1", Namespace: "space1", UID: "rs1-uid", Generation: 1},
+	}
+	owner := metav1.OwnerReference{Kind: "ReplicaSet", Name: "rs1", UID: "rs1-uid", Controller: &isController}
+	tracker := newTestChangeTracker(t,
+		newScheduledPod("pod1", "node1", owner),
+		newScheduledPod("pod2", "node2"))
+
+	// Status only
+	rsStatus := rs.DeepCopy()
+	rsStatus.Status.ReadyReplicas = 1
+	tracker.onControllerUpdate(rs, rsStatus)
+	if changes := tracker.takeChanges(); !changes.isEmpty() {
+		t.Errorf("Expected no change for a status update, got %+v", changes)
+	}
+
+	rsSpec := rs.DeepCopy()
+	rsSpec.Generation = 2
+	tracker.onControllerUpdate(rs, rsSpec)
+	changes := tracker.takeChanges()
+	if len(changes.changedNodes) != 1 || !changes.changedNodes["node1"] {
+		t.Errorf("Expected only node1 to be changed, got %v", changes.changedNodes)
+	}
+}
+
+func TestBuildDeletedEntityDTOs(t *testing.T) {
+	changes := &clusterChanges{
+		deletedNodes: []*api.Node{newReadyNode("node1", api.ConditionTrue)},
+		deletedPods:  []*api.Pod{newScheduledPod("pod1", "node1")},
+	}
+	dtos := buildDeletedEntityDTOs(changes)
+
+	expected := map[string]proto.EntityDTO_EntityType{
+		"node1-uid":      proto.EntityDTO_VIRTUAL_MACHINE,
+		"pod1-uid":       proto.EntityDTO_CONTAINER_POD,
+		"pod1-uid-0":     proto.EntityDTO_CONTAINER,
+		"pod1-uid-1":     proto.EntityDTO_CONTAINER,
+		"App-pod1-uid-0": proto.EntityDTO_APPLICATION,
+		"App-pod1-uid-1": proto.EntityDTO_APPLICATION,
+	}
+	if len(dtos) != len(expected) {
+		t.Errorf("Expected %d deleted entities, got %d", len(expected), len(dtos))
+	}
+	for _, dto := range dtos {
+		if dto.GetUpdateType() != proto.UpdateType_DELETED {
+			t.Errorf("Entity %s is not deleted", dto.GetId())
+		}
+		if etype, exists := expected[dto.GetId()]; !exists || etype != dto.GetEntityType() {
+			t.Errorf("Unexpected deleted entity %s of type %v", dto.GetId(), dto.GetEntityType())
+		}
+	}
+}
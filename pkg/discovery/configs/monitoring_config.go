@@ -0,0 +1,43 @@
+package configs
+
+import (
+	"fmt"
+)
+
+const (
+	// Sources of the node, pod and container resource usage
+	KubeletResourceSource       = "kubelet"
+	MetricsServerResourceSource = "metricsServer"
+
+	// The cpu frequency assumed for the nodes when it cannot be obtained from the kubelet
+	defaultCpuFrequencyMHz float64 = 2000.0
+)
+
+type ResourceMonitoringConfig struct {
+	// Where the resource usage is collected from: "kubelet" (default) scrapes each kubelet directly,
+	// "metricsServer" reads the metrics.k8s.io API through the Kubernetes API server.
+	Source string `json:"source,omitempty"`
+	// CPU frequency of the nodes in MHz, used when the source doesn't provide the machine info.
+	CpuFrequencyMHz float64 `json:"cpuFrequencyMHz,omitempty"`
+}
+
+func (config *ResourceMonitoringConfig) ValidateResourceMonitoringConfig() error {
+	switch config.Source {
+	case "":
+		config.Source = KubeletResourceSource
+	case KubeletResourceSource, MetricsServerResourceSource:
+	default:
+		return fmt.Errorf("unsupported resource monitoring source %q", config.Source)
+	}
+	if config.CpuFrequencyMHz < 0 {
+		return fmt.Errorf("invalid cpu frequency %f MHz", config.CpuFrequencyMHz)
+	}
+	if config.CpuFrequencyMHz == 0 {
+		config.CpuFrequencyMHz = defaultCpuFrequencyMHz
+	}
+	return nil
+}
+
+func (config *ResourceMonitoringConfig) UseMetricsServer() bool {
+	return config.Source == MetricsServerResourceSource
+}
@@ -1,6 +1,7 @@
 package configs
 
 import (
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
 	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
@@ -16,6 +17,10 @@ type ProbeConfig struct {
 
 	// Rest Client for the kubernetes server API
 	ClusterClient *kubernetes.Clientset
+	// Scraper of the cluster objects, backed by the shared cluster cache
+	ClusterScraper *cluster.ClusterScraper
 	// Rest Client for the kubelet module in each node
 	NodeClient *kubeclient.KubeletClient
+	// Rest Client for the resource metrics API, only set when metrics server is the resource monitoring source
+	MetricsServerClient *kubeclient.MetricsServerClient
 }
@@ -0,0 +1,172 @@
+package discovery
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/master"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
+	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
+)
+
+const (
+	// The validation of the cluster is not part of the replayed discovery
+	replayValidationWorkers    = 1
+	replayValidationTimeoutSec = 0
+)
+
+// DiscoverySnapshot holds everything read from the cluster by a full discovery: the cluster objects
+// and the raw responses of the kubelets. It is saved as a single gzipped JSON archive, so that the
+// discovery can be replayed later without any connection to the cluster, e.g., to reproduce an issue.
+// Only the kubelets are recorded as the source of the resource metrics, not the metrics server.
+type DiscoverySnapshot struct {
+	Time                  time.Time                       `json:"time"`
+	TargetIdentifier      string                          `json:"targetIdentifier"`
+	StitchingPropertyType stitching.StitchingPropertyType `json:"stitchingPropertyType"`
+	Cluster               *cluster.ClusterObjects         `json:"cluster"`
+	// The responses of the /spec and /stats/summary endpoints of the kubelets, keyed by host and endpoint
+	KubeletResponses map[string]json.RawMessage `json:"kubeletResponses"`
+}
+
+// Save the snapshot to the given file. The file is replaced at once, so it always holds a complete snapshot.
+func SaveDiscoverySnapshot(path string, snapshot *DiscoverySnapshot) error {
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create the snapshot file %s: %v", tmpPath, err)
+	}
+	zipWriter := gzip.NewWriter(file)
+	err = json.NewEncoder(zipWriter).Encode(snapshot)
+	if err == nil {
+		err = zipWriter.Close()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write the snapshot file %s: %v", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to save the snapshot file %s: %v", path, err)
+	}
+	return nil
+}
+
+// Load the snapshot saved in the given file.
+func LoadDiscoverySnapshot(path string) (*DiscoverySnapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the snapshot file %s: %v", path, err)
+	}
+	defer file.Close()
+	zipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the snapshot file %s: %v", path, err)
+	}
+	defer zipReader.Close()
+	snapshot := &DiscoverySnapshot{}
+	if err := json.NewDecoder(zipReader).Decode(snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse the snapshot file %s: %v", path, err)
+	}
+	if snapshot.Cluster == nil {
+		return nil, fmt.Errorf("the snapshot file %s has no cluster objects", path)
+	}
+	return snapshot, nil
+}
+
+// ReplayDiscoverySnapshot runs the discovery against the snapshot instead of the cluster and its kubelets.
+func ReplayDiscoverySnapshot(snapshot *DiscoverySnapshot) (*proto.DiscoveryResponse, error) {
+	clusterCache, err := cluster.NewClusterCacheFromObjects(snapshot.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	clusterScraper := cluster.NewClusterScraper(nil).WithClusterCache(clusterCache)
+	kubeletClient := kubeclient.NewReplayKubeletClient(kubeclient.NewKubeletResponseStore(snapshot.KubeletResponses))
+
+	probeConfig := &configs.ProbeConfig{
+		StitchingPropertyType: snapshot.StitchingPropertyType,
+		MonitoringConfigs: []monitoring.MonitorWorkerConfig{
+			kubelet.NewKubeletMonitorConfig(kubeletClient),
+			master.NewClusterMonitorConfig(clusterScraper),
+		},
+		ClusterScraper: clusterScraper,
+		NodeClient:     kubeletClient,
+	}
+	targetConfig := &configs.K8sTargetConfig{TargetIdentifier: snapshot.TargetIdentifier}
+	dc := NewK8sDiscoveryClient(NewDiscoveryConfig(probeConfig, targetConfig,
+		replayValidationWorkers, replayValidationTimeoutSec))
+
+	glog.V(2).Infof("Replaying the discovery snapshot of %s taken at %v.", snapshot.TargetIdentifier, snapshot.Time)
+	entityDTOs, groupDTOs, err := dc.discoverWithNewFramework()
+	if err != nil {
+		return nil, err
+	}
+	return &proto.DiscoveryResponse{
+		EntityDTO:       entityDTOs,
+		DiscoveredGroup: groupDTOs,
+	}, nil
+}
+
+// The recording of the snapshot of a full discovery.
+type snapshotRecording struct {
+	objects   *cluster.ClusterObjects
+	responses *kubeclient.KubeletResponseStore
+	startTime time.Time
+}
+
+// Start recording the snapshot of the discovery, if it is enabled.
+// Returns nil if the snapshot cannot be recorded.
+func (dc *K8sDiscoveryClient) startSnapshotRecording() *snapshotRecording {
+	if dc.config.SnapshotPath == "" {
+		return nil
+	}
+	clusterCache := dc.k8sClusterScraper.GetClusterCache()
+	if clusterCache == nil {
+		glog.Warningf("Cannot record the discovery snapshot without a cluster cache.")
+		return nil
+	}
+	responses := dc.config.probeConfig.NodeClient.RecordedResponses()
+	if responses == nil {
+		glog.Warningf("Cannot record the discovery snapshot as the kubelet responses are not recorded.")
+		return nil
+	}
+	// The discovery reads the cache as it is updated by the watches, the objects may change in between
+	objects, err := clusterCache.GetObjects()
+	if err != nil {
+		glog.Errorf("Failed to get the cluster objects of the discovery snapshot: %v", err)
+		return nil
+	}
+	responses.Reset()
+	return &snapshotRecording{
+		objects:   objects,
+		responses: responses,
+		startTime: time.Now(),
+	}
+}
+
+// Save the snapshot of the discovery.
+func (dc *K8sDiscoveryClient) saveSnapshot(recording *snapshotRecording) {
+	snapshot := &DiscoverySnapshot{
+		Time:                  recording.startTime,
+		TargetIdentifier:      dc.config.targetConfig.TargetIdentifier,
+		StitchingPropertyType: dc.config.probeConfig.StitchingPropertyType,
+		Cluster:               recording.objects,
+		KubeletResponses:      recording.responses.Responses(),
+	}
+	if err := SaveDiscoverySnapshot(dc.config.SnapshotPath, snapshot); err != nil {
+		glog.Errorf("Failed to save the discovery snapshot: %v", err)
+		return
+	}
+	glog.V(2).Infof("Saved the discovery snapshot to %s.", dc.config.SnapshotPath)
+}
@@ -0,0 +1,108 @@
+package discovery
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
+)
+
+func newTestSnapshot() *DiscoverySnapshot {
+	resources := api.ResourceList{
+		api.ResourceCPU:    resource.MustParse("4"),
+		api.ResourceMemory: resource.MustParse("8Gi"),
+		api.ResourcePods:   resource.MustParse("110"),
+	}
+	node := newReadyNode("node1", api.ConditionTrue)
+	node.Status.Addresses = []api.NodeAddress{{Type: api.NodeInternalIP, Address: "10.0.0.1"}}
+	node.Status.Capacity = resources
+	node.Status.Allocatable = resources
+	pod := newScheduledPod("pod1", "node1")
+	pod.Status.Conditions = []api.PodCondition{{Type: api.PodReady, Status: api.ConditionTrue}}
+
+	return &DiscoverySnapshot{
+		Time:                  time.Now(),
+		TargetIdentifier:      "Kubernetes-test",
+		StitchingPropertyType: stitching.IP,
+		Cluster: &cluster.ClusterObjects{
+			Nodes: []*api.Node{node},
+			Pods:  []*api.Pod{pod},
+			Services: []*api.Service{
+				{ObjectMeta: metav1.ObjectMeta{Name: "kubernetes", Namespace: "default", UID: "svc-uid"}},
+			},
+			Namespaces: []*api.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "space1", UID: "space1-uid"}}},
+		},
+		KubeletResponses: map[string]json.RawMessage{
+			"10.0.0.1/spec": json.RawMessage(`{"num_cores":4,"cpu_frequency_khz":2400000}`),
+			"10.0.0.1/stats/summary/": json.RawMessage(`{"node":{"nodeName":"node1",` +
+				`"cpu":{"usageNanoCores":1000000000},"memory":{"workingSetBytes":1073741824}},"pods":[` +
+				`{"podRef":{"name":"pod1","namespace":"space1","uid":"pod1-uid"},"containers":[` +
+				`{"name":"container1","cpu":{"usageNanoCores":100000000},"memory":{"workingSetBytes":1048576}},` +
+				`{"name":"container2","cpu":{"usageNanoCores":100000000},"memory":{"workingSetBytes":1048576}}],` +
+				`"cpu":{"usageNanoCores":200000000},"memory":{"workingSetBytes":2097152}}]}`),
+		},
+	}
+}
+
+func TestSaveAndLoadDiscoverySnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatalf("Failed to create a temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot.json.gz")
+
+	snapshot := newTestSnapshot()
+	if err := SaveDiscoverySnapshot(path, snapshot); err != nil {
+		t.Fatalf("Failed to save the snapshot: %v", err)
+	}
+	loaded, err := LoadDiscoverySnapshot(path)
+	if err != nil {
+		t.Fatalf("Failed to load the snapshot: %v", err)
+	}
+	if loaded.TargetIdentifier != snapshot.TargetIdentifier || loaded.StitchingPropertyType != stitching.IP {
+		t.Errorf("Expected the target %s stitched by IP, got %+v", snapshot.TargetIdentifier, loaded)
+	}
+	if len(loaded.Cluster.Nodes) != 1 || len(loaded.Cluster.Pods) != 1 || len(loaded.KubeletResponses) != 2 {
+		t.Errorf("Unexpected content of the loaded snapshot: %+v", loaded)
+	}
+
+	if _, err := LoadDiscoverySnapshot(filepath.Join(dir, "missing.json.gz")); err == nil {
+		t.Errorf("Expected an error when loading a missing snapshot")
+	}
+}
+
+func TestReplayDiscoverySnapshot(t *testing.T) {
+	response, err := ReplayDiscoverySnapshot(newTestSnapshot())
+	if err != nil {
+		t.Fatalf("Failed to replay the snapshot: %v", err)
+	}
+
+	entities := make(map[string]*proto.EntityDTO)
+	for _, dto := range response.GetEntityDTO() {
+		entities[dto.GetId()] = dto
+	}
+	node, exists := entities["node1-uid"]
+	if !exists || node.GetEntityType() != proto.EntityDTO_VIRTUAL_MACHINE {
+		t.Fatalf("Expected the virtual machine of node1, got %v", entities)
+	}
+	for _, comm := range node.GetCommoditiesSold() {
+		if comm.GetCommodityType() == proto.CommodityDTO_VCPU && comm.GetUsed() <= 0 {
+			t.Errorf("Expected the replayed VCPU usage of node1, got %v", comm.GetUsed())
+		}
+	}
+	if pod, exists := entities["pod1-uid"]; !exists || pod.GetEntityType() != proto.EntityDTO_CONTAINER_POD {
+		t.Errorf("Expected the container pod of pod1, got %v", entities)
+	}
+}
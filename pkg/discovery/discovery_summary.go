@@ -0,0 +1,133 @@
+package discovery
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+// DiscoverySummary summarizes the entities and groups of a discovery response, to check them
+// without a Turbonomic server.
+type DiscoverySummary struct {
+	EntityCounts map[proto.EntityDTO_EntityType]int
+	// The entities sorted by type and display name
+	Entities []*EntitySummary
+	// The providers which are not in the discovered entities
+	DanglingProviders []*DanglingProvider
+	// The groups sorted by display name
+	Groups []*GroupSummary
+}
+
+type EntitySummary struct {
+	EntityType        proto.EntityDTO_EntityType
+	Id                string
+	DisplayName       string
+	SoldCommodities   int
+	BoughtCommodities int
+	Providers         int
+}
+
+type DanglingProvider struct {
+	ConsumerType proto.EntityDTO_EntityType
+	ConsumerId   string
+	ProviderType proto.EntityDTO_EntityType
+	ProviderId   string
+}
+
+type GroupSummary struct {
+	GroupName   string
+	DisplayName string
+	EntityType  proto.EntityDTO_EntityType
+	// Number of the members of a static group, 0 for a dynamic group
+	Members int
+}
+
+func SummarizeDiscoveryResponse(response *proto.DiscoveryResponse) *DiscoverySummary {
+	summary := &DiscoverySummary{
+		EntityCounts: make(map[proto.EntityDTO_EntityType]int),
+	}
+	entityIds := make(map[string]bool)
+	for _, dto := range response.GetEntityDTO() {
+		entityIds[dto.GetId()] = true
+	}
+	for _, dto := range response.GetEntityDTO() {
+		summary.EntityCounts[dto.GetEntityType()]++
+		entity := &EntitySummary{
+			EntityType:      dto.GetEntityType(),
+			Id:              dto.GetId(),
+			DisplayName:     dto.GetDisplayName(),
+			SoldCommodities: len(dto.GetCommoditiesSold()),
+			Providers:       len(dto.GetCommoditiesBought()),
+		}
+		for _, bought := range dto.GetCommoditiesBought() {
+			entity.BoughtCommodities += len(bought.GetBought())
+			if !entityIds[bought.GetProviderId()] {
+				summary.DanglingProviders = append(summary.DanglingProviders, &DanglingProvider{
+					ConsumerType: dto.GetEntityType(),
+					ConsumerId:   dto.GetId(),
+					ProviderType: bought.GetProviderType(),
+					ProviderId:   bought.GetProviderId(),
+				})
+			}
+		}
+		summary.Entities = append(summary.Entities, entity)
+	}
+	sort.SliceStable(summary.Entities, func(i, j int) bool {
+		if summary.Entities[i].EntityType != summary.Entities[j].EntityType {
+			return summary.Entities[i].EntityType < summary.Entities[j].EntityType
+		}
+		return summary.Entities[i].DisplayName < summary.Entities[j].DisplayName
+	})
+
+	for _, group := range response.GetDiscoveredGroup() {
+		summary.Groups = append(summary.Groups, &GroupSummary{
+			GroupName:   group.GetGroupName(),
+			DisplayName: group.GetDisplayName(),
+			EntityType:  group.GetEntityType(),
+			Members:     len(group.GetMemberList().GetMember()),
+		})
+	}
+	sort.SliceStable(summary.Groups, func(i, j int) bool {
+		return summary.Groups[i].DisplayName < summary.Groups[j].DisplayName
+	})
+	return summary
+}
+
+// Print the summary as tables.
+func (s *DiscoverySummary) Print(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(w, "ENTITY TYPE\tCOUNT\n")
+	var entityTypes []proto.EntityDTO_EntityType
+	for entityType := range s.EntityCounts {
+		entityTypes = append(entityTypes, entityType)
+	}
+	sort.Slice(entityTypes, func(i, j int) bool { return entityTypes[i] < entityTypes[j] })
+	for _, entityType := range entityTypes {
+		fmt.Fprintf(w, "%v\t%d\n", entityType, s.EntityCounts[entityType])
+	}
+
+	fmt.Fprintf(w, "\nENTITY TYPE\tDISPLAY NAME\tID\tSOLD\tBOUGHT\tPROVIDERS\n")
+	for _, entity := range s.Entities {
+		fmt.Fprintf(w, "%v\t%s\t%s\t%d\t%d\t%d\n", entity.EntityType, entity.DisplayName, entity.Id,
+			entity.SoldCommodities, entity.BoughtCommodities, entity.Providers)
+	}
+
+	fmt.Fprintf(w, "\nDANGLING PROVIDERS: %d\n", len(s.DanglingProviders))
+	if len(s.DanglingProviders) > 0 {
+		fmt.Fprintf(w, "CONSUMER TYPE\tCONSUMER ID\tPROVIDER TYPE\tPROVIDER ID\n")
+		for _, dangling := range s.DanglingProviders {
+			fmt.Fprintf(w, "%v\t%s\t%v\t%s\n", dangling.ConsumerType, dangling.ConsumerId,
+				dangling.ProviderType, dangling.ProviderId)
+		}
+	}
+
+	fmt.Fprintf(w, "\nGROUP\tNAME\tENTITY TYPE\tMEMBERS\n")
+	for _, group := range s.Groups {
+		fmt.Fprintf(w, "%s\t%s\t%v\t%d\n", group.DisplayName, group.GroupName, group.EntityType, group.Members)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,69 @@
+package discovery
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func newTestEntityDTO(entityType proto.EntityDTO_EntityType, id string, providerIds ...string) *proto.EntityDTO {
+	commType := proto.CommodityDTO_VCPU
+	dto := &proto.EntityDTO{
+		EntityType:      &entityType,
+		Id:              &id,
+		DisplayName:     &id,
+		CommoditiesSold: []*proto.CommodityDTO{{CommodityType: &commType}},
+	}
+	for i := range providerIds {
+		dto.CommoditiesBought = append(dto.CommoditiesBought, &proto.EntityDTO_CommodityBought{
+			ProviderId: &providerIds[i],
+			Bought:     []*proto.CommodityDTO{{CommodityType: &commType}, {CommodityType: &commType}},
+		})
+	}
+	return dto
+}
+
+func TestSummarizeDiscoveryResponse(t *testing.T) {
+	groupName := "group1"
+	podType := proto.EntityDTO_CONTAINER_POD
+	response := &proto.DiscoveryResponse{
+		EntityDTO: []*proto.EntityDTO{
+			newTestEntityDTO(proto.EntityDTO_VIRTUAL_MACHINE, "node1"),
+			newTestEntityDTO(proto.EntityDTO_CONTAINER_POD, "pod1", "node1"),
+			newTestEntityDTO(proto.EntityDTO_CONTAINER_POD, "pod2", "node2"),
+		},
+		DiscoveredGroup: []*proto.GroupDTO{{
+			EntityType:  &podType,
+			DisplayName: &groupName,
+			Members: &proto.GroupDTO_MemberList{
+				MemberList: &proto.GroupDTO_MembersList{Member: []string{"pod1", "pod2"}},
+			},
+		}},
+	}
+
+	summary := SummarizeDiscoveryResponse(response)
+	if summary.EntityCounts[proto.EntityDTO_VIRTUAL_MACHINE] != 1 ||
+		summary.EntityCounts[proto.EntityDTO_CONTAINER_POD] != 2 {
+		t.Errorf("Unexpected entity counts %v", summary.EntityCounts)
+	}
+	pod := summary.Entities[1]
+	if pod.Id != "pod1" || pod.SoldCommodities != 1 || pod.BoughtCommodities != 2 || pod.Providers != 1 {
+		t.Errorf("Unexpected summary of pod1 %+v", pod)
+	}
+	if len(summary.DanglingProviders) != 1 || summary.DanglingProviders[0].ProviderId != "node2" {
+		t.Errorf("Expected node2 to be the only dangling provider, got %v", summary.DanglingProviders)
+	}
+	if len(summary.Groups) != 1 || summary.Groups[0].Members != 2 {
+		t.Errorf("Expected group1 with 2 members, got %v", summary.Groups)
+	}
+
+	var out bytes.Buffer
+	if err := summary.Print(&out); err != nil {
+		t.Fatalf("Failed to print the summary: %v", err)
+	}
+	if !strings.Contains(out.String(), "DANGLING PROVIDERS: 1") {
+		t.Errorf("Expected the dangling provider in the printed summary:\n%s", out.String())
+	}
+}
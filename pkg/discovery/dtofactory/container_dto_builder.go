@@ -31,6 +31,12 @@ var (
 		metrics.CPU,
 		metrics.Memory,
 	}
+
+	// Commodities bought only when their usage is provided by the kubelet
+	optionalCommodityBought = []metrics.ResourceType{
+		metrics.NetworkThroughput,
+		metrics.EphemeralStorage,
+	}
 )
 
 type containerDTOBuilder struct {
@@ -96,7 +102,7 @@ func (builder *containerDTOBuilder) BuildDTOs(pods []*api.Pod) ([]*proto.EntityD
 			ebuilder.SellsCommodities(commoditiesSold)
 
 			//2. commodities bought
-			commoditiesBought, err := builder.getCommoditiesBought(podId, name, containerMId, nodeCPUFrequency)
+			commoditiesBought, err := builder.getCommoditiesBought(podId, podMId, name, containerMId, nodeCPUFrequency)
 			if err != nil {
 				glog.Errorf("failed to create commoditiesBought for container[%s]: %v", name, err)
 				continue
@@ -178,9 +184,9 @@ func (builder *containerDTOBuilder) getCommoditiesSold(containerName, containerI
 	return result, nil
 }
 
-// vCPU, vMem and VMPMAccess are bought by Container from Pod;
+// vCPU, vMem, VMPMAccess, and NetThroughput, StorageAmount if available are bought by Container from Pod;
 // the VMPMAccess is to bind the container to the hosting pod.
-func (builder *containerDTOBuilder) getCommoditiesBought(podId, containerName, containerMId string, cpuFrequency float64) ([]*proto.CommodityDTO, error) {
+func (builder *containerDTOBuilder) getCommoditiesBought(podId, podMId, containerName, containerMId string, cpuFrequency float64) ([]*proto.CommodityDTO, error) {
 	var result []*proto.CommodityDTO
 
 	//1. vCPU & vMem
@@ -200,7 +206,16 @@ func (builder *containerDTOBuilder) getCommoditiesBought(podId, containerName, c
 	}
 	result = append(result, commodities...)
 
-	//2. VMPMAccess
+	//2. NetThroughput & StorageAmount, only bought if they are sold by the pod
+	podResources := builder.availableResourceTypes(metrics.PodType, podMId, optionalCommodityBought)
+	optionalResources := builder.availableResourceTypes(metrics.ContainerType, containerMId, podResources)
+	optionalCommodities, err := builder.getResourceCommoditiesBought(metrics.ContainerType, containerMId, optionalResources, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, optionalCommodities...)
+
+	//3. VMPMAccess
 	podAccessComm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_VMPM_ACCESS).
 		Key(podId).
 		Create()
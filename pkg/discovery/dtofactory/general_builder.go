@@ -1,6 +1,7 @@
 package dtofactory
 
 import (
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
 
@@ -9,6 +10,7 @@ import (
 	"fmt"
 
 	"github.com/golang/glog"
+	autoscaling "k8s.io/api/autoscaling/v1"
 )
 
 var (
@@ -25,9 +27,26 @@ var (
 		metrics.MemoryQuota:        proto.CommodityDTO_MEM_ALLOCATION,
 		metrics.CPURequestQuota:    proto.CommodityDTO_CPU_REQUEST_ALLOCATION,
 		metrics.MemoryRequestQuota: proto.CommodityDTO_MEM_REQUEST_ALLOCATION,
+		metrics.NetworkThroughput:  proto.CommodityDTO_NET_THROUGHPUT,
+		metrics.EphemeralStorage:   proto.CommodityDTO_STORAGE_AMOUNT,
+		metrics.VolumeStorage:      proto.CommodityDTO_STORAGE_AMOUNT,
+		metrics.NumPods:            proto.CommodityDTO_NUMBER_CONSUMERS,
+		// The SDK has no commodity type for the devices, so each extended resource is carried by a buffer
+		// commodity, a generic used and capacity commodity otherwise unused by the VMs, keyed by the name of
+		// the resource. Unlike the license commodities, the server gives it no meaning of its own.
+		metrics.ExtendedResource: proto.CommodityDTO_BUFFER_COMMODITY,
 	}
 )
 
+// Get the commodity type of the resource type, the same for all the extended resources.
+func getCommodityType(resourceType metrics.ResourceType) (proto.CommodityDTO_CommodityType, bool) {
+	if metrics.IsExtendedResourceType(resourceType) {
+		resourceType = metrics.ExtendedResource
+	}
+	cType, exist := rTypeMapping[resourceType]
+	return cType, exist
+}
+
 type ValueConversionFunc func(input float64) float64
 
 type converter struct {
@@ -131,7 +150,7 @@ func (builder generalBuilder) getSoldResourceCommodityWithKey(entityType metrics
 	converter *converter, commodityAttrSetter *attributeSetter) (*proto.CommodityDTO, error) {
 
 	var resourceCommoditySold *proto.CommodityDTO
-	cType, exist := rTypeMapping[resourceType]
+	cType, exist := getCommodityType(resourceType)
 	if !exist {
 		return nil, fmt.Errorf("unsupported commodity type %s", resourceType)
 	}
@@ -152,8 +171,8 @@ func (builder generalBuilder) getSoldResourceCommodityWithKey(entityType metrics
 
 	commSoldBuilder.Used(usedValue)
 
-	// set peak value as the used value
-	commSoldBuilder.Peak(usedValue)
+	// set peak value, which is the used value if the usage is not sampled
+	commSoldBuilder.Peak(builder.peakValue(entityType, entityID, resourceType, usedValue, converter))
 
 	// set capacity value
 	capacityValue, err := builder.metricValue(entityType, entityID,
@@ -221,8 +240,8 @@ func (builder generalBuilder) getResourceCommoditiesBought(entityType metrics.Di
 		}
 		commBoughtBuilder.Used(usedValue)
 
-		// set peak value as the used value
-		commBoughtBuilder.Peak(usedValue)
+		// set peak value, which is the used value if the usage is not sampled
+		commBoughtBuilder.Peak(builder.peakValue(entityType, entityID, rType, usedValue, converter))
 
 		// set reservation value if any
 		reservedMetricUID := metrics.GenerateEntityResourceMetricUID(entityType, entityID,
@@ -262,7 +281,7 @@ func (builder generalBuilder) getResourceCommoditiesBought(entityType metrics.Di
 func (builder generalBuilder) getResourceCommodityBoughtWithKey(entityType metrics.DiscoveredEntityType, entityID string,
 	resourceType metrics.ResourceType, commKey string,
 	converter *converter, commodityAttrSetter *attributeSetter) (*proto.CommodityDTO, error) {
-	cType, exist := rTypeMapping[resourceType]
+	cType, exist := getCommodityType(resourceType)
 	if !exist {
 		return nil, fmt.Errorf("unsupported commodity type %s", resourceType)
 	}
@@ -282,8 +301,8 @@ func (builder generalBuilder) getResourceCommodityBoughtWithKey(entityType metri
 
 	commBoughtBuilder.Used(usedValue)
 
-	// set peak value as the used value
-	commBoughtBuilder.Peak(usedValue)
+	// set peak value, which is the used value if the usage is not sampled
+	commBoughtBuilder.Peak(builder.peakValue(entityType, entityID, resourceType, usedValue, converter))
 
 	// set reservation value if any
 	reservationValue, _ := builder.metricValue(entityType, entityID,
@@ -309,6 +328,30 @@ func (builder generalBuilder) getResourceCommodityBoughtWithKey(entityType metri
 	return commBought, nil
 }
 
+// Get the peak value from the sink if the usage has been sampled, otherwise return the used value.
+func (builder generalBuilder) peakValue(entityType metrics.DiscoveredEntityType, entityID string,
+	resourceType metrics.ResourceType, usedValue float64, converter *converter) float64 {
+	peakValue, err := builder.metricValue(entityType, entityID, resourceType, metrics.Peak, converter)
+	if err != nil {
+		return usedValue
+	}
+	return peakValue
+}
+
+// Filter the given resource types to the ones with a used value in the sink. It is used for the commodities
+// which are only built when the monitoring source provides them, e.g., the network and storage commodities.
+func (builder generalBuilder) availableResourceTypes(entityType metrics.DiscoveredEntityType, entityID string,
+	resourceTypesList []metrics.ResourceType) []metrics.ResourceType {
+	var available []metrics.ResourceType
+	for _, rType := range resourceTypesList {
+		metricUID := metrics.GenerateEntityResourceMetricUID(entityType, entityID, rType, metrics.Used)
+		if _, err := builder.metricsSink.GetMetric(metricUID); err == nil {
+			available = append(available, rType)
+		}
+	}
+	return available
+}
+
 // get cpu frequency
 func (builder generalBuilder) getNodeCPUFrequency(nodeKey string) (float64, error) {
 	cpuFrequencyUID := metrics.GenerateEntityStateMetricUID(metrics.NodeType, nodeKey, metrics.CpuFrequency)
@@ -321,3 +364,52 @@ func (builder generalBuilder) getNodeCPUFrequency(nodeKey string) (float64, erro
 	glog.V(4).Infof("CPU frequency for node %s: %f", nodeKey, cpuFrequency)
 	return cpuFrequency, nil
 }
+
+// Get the properties of the age of the kubelet data of the node, and the hits and misses of its kubelet cache
+// if required. There is no property when the node is not monitored by the kubelet.
+func (builder generalBuilder) getKubeletCacheProperties(nodeKey string, withCacheCounts bool) []*proto.EntityDTO_EntityProperty {
+	var properties []*proto.EntityDTO_EntityProperty
+	if age, exists := builder.nodeStateValue(nodeKey, metrics.KubeletCacheAge); exists {
+		properties = append(properties, property.BuildKubeletDataAgeProperty(age))
+	}
+	if !withCacheCounts {
+		return properties
+	}
+	hits, hitsExist := builder.nodeStateValue(nodeKey, metrics.KubeletCacheHits)
+	misses, missesExist := builder.nodeStateValue(nodeKey, metrics.KubeletCacheMisses)
+	if hitsExist && missesExist {
+		properties = append(properties, property.BuildKubeletCacheProperties(hits, misses)...)
+	}
+	return properties
+}
+
+// Get the names of the PodDisruptionBudgets protecting the pod, if any.
+func (builder generalBuilder) getPodDisruptionBudgets(podKey string) []string {
+	metric, err := builder.metricsSink.GetMetric(metrics.GenerateEntityStateMetricUID(metrics.PodType, podKey,
+		metrics.DisruptionBudgets))
+	if err != nil {
+		return nil
+	}
+	pdbNames, _ := metric.GetValue().([]string)
+	return pdbNames
+}
+
+// Get the HorizontalPodAutoscaler scaling the controller of the pod, if any.
+func (builder generalBuilder) getHorizontalPodAutoscaler(podKey string) *autoscaling.HorizontalPodAutoscaler {
+	metric, err := builder.metricsSink.GetMetric(metrics.GenerateEntityStateMetricUID(metrics.PodType, podKey,
+		metrics.HorizontalPodAutoscaler))
+	if err != nil {
+		return nil
+	}
+	hpa, _ := metric.GetValue().(*autoscaling.HorizontalPodAutoscaler)
+	return hpa
+}
+
+func (builder generalBuilder) nodeStateValue(nodeKey string, rType metrics.ResourceType) (float64, bool) {
+	metric, err := builder.metricsSink.GetMetric(metrics.GenerateEntityStateMetricUID(metrics.NodeType, nodeKey, rType))
+	if err != nil {
+		return 0, false
+	}
+	value, ok := metric.GetValue().(float64)
+	return value, ok
+}
@@ -192,3 +192,84 @@ func TestBuildCommBought(t *testing.T) {
 		fmt.Printf("%++v\n", err)
 	}
 }
+
+func TestBuildCPUSoldWithPeak(t *testing.T) {
+	metricsSink = metrics.NewEntityMetricSink()
+	cpuPeak_node1 := metrics.NewEntityResourceMetric(metrics.NodeType, node1, metrics.CPU, metrics.Peak, 3.0)
+	metricsSink.AddNewMetricEntries(cpuUsed_node1, cpuCap_node1, cpuPeak_node1)
+
+	dtoBuilder := &generalBuilder{
+		metricsSink: metricsSink,
+	}
+
+	commSold, err := dtoBuilder.getSoldResourceCommodityWithKey(metrics.NodeType, node1, metrics.CPU, "", cpuConverter, nil)
+	assert.Nil(t, err)
+	assert.NotNil(t, commSold)
+	assert.Equal(t, cpuConverter.Convert(metrics.CPU, 2.0), commSold.GetUsed())
+	assert.Equal(t, cpuConverter.Convert(metrics.CPU, 3.0), commSold.GetPeak())
+}
+
+func TestBuildMemBoughtWithoutPeak(t *testing.T) {
+	metricsSink = metrics.NewEntityMetricSink()
+	metricsSink.AddNewMetricEntries(memUsed_pod1)
+
+	dtoBuilder := &generalBuilder{
+		metricsSink: metricsSink,
+	}
+
+	commBought, err := dtoBuilder.getResourceCommoditiesBought(metrics.PodType, pod1, []metrics.ResourceType{metrics.Memory}, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(commBought))
+	// peak is the used value when the usage is not sampled
+	assert.Equal(t, memUsed_pod1.GetValue().(float64), commBought[0].GetPeak())
+}
+
+func TestAvailableResourceTypes(t *testing.T) {
+	metricsSink = metrics.NewEntityMetricSink()
+	storageUsed := metrics.NewEntityResourceMetric(metrics.NodeType, node1, metrics.EphemeralStorage, metrics.Used, 1024.0)
+	storageCap := metrics.NewEntityResourceMetric(metrics.NodeType, node1, metrics.EphemeralStorage, metrics.Capacity, 4096.0)
+	metricsSink.AddNewMetricEntries(storageUsed, storageCap)
+
+	dtoBuilder := &generalBuilder{
+		metricsSink: metricsSink,
+	}
+
+	available := dtoBuilder.availableResourceTypes(metrics.NodeType, node1,
+		[]metrics.ResourceType{metrics.NetworkThroughput, metrics.EphemeralStorage})
+	assert.Equal(t, []metrics.ResourceType{metrics.EphemeralStorage}, available)
+
+	commSold, err := dtoBuilder.getResourceCommoditiesSold(metrics.NodeType, node1, available, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(commSold))
+	assert.Equal(t, proto.CommodityDTO_STORAGE_AMOUNT, commSold[0].GetCommodityType())
+	assert.Equal(t, 1024.0, commSold[0].GetUsed())
+	assert.Equal(t, 4096.0, commSold[0].GetCapacity())
+}
+
+func TestGetKubeletCacheProperties(t *testing.T) {
+	metricsSink = metrics.NewEntityMetricSink()
+	dtoBuilder := &generalBuilder{
+		metricsSink: metricsSink,
+	}
+	// The node is not monitored by the kubelet
+	assert.Equal(t, 0, len(dtoBuilder.getKubeletCacheProperties(node1, true)))
+
+	metricsSink.AddNewMetricEntries(
+		metrics.NewEntityStateMetric(metrics.NodeType, node1, metrics.KubeletCacheAge, 90.5),
+		metrics.NewEntityStateMetric(metrics.NodeType, node1, metrics.KubeletCacheHits, 2.0),
+		metrics.NewEntityStateMetric(metrics.NodeType, node1, metrics.KubeletCacheMisses, 1.0))
+
+	properties := make(map[string]string)
+	for _, p := range dtoBuilder.getKubeletCacheProperties(node1, true) {
+		properties[p.GetName()] = p.GetValue()
+	}
+	assert.Equal(t, map[string]string{
+		"KubeletDataAgeSeconds": "90.5",
+		"KubeletCacheHits":      "2",
+		"KubeletCacheMisses":    "1",
+	}, properties)
+
+	podProperties := dtoBuilder.getKubeletCacheProperties(node1, false)
+	assert.Equal(t, 1, len(podProperties))
+	assert.Equal(t, "KubeletDataAgeSeconds", podProperties[0].GetName())
+}
@@ -32,6 +32,14 @@ var (
 		// TODO, add back provisioned commodity later
 	}
 
+	// Commodities sold only when their usage is provided by the kubelet,
+	// or by the cluster for the number of pods when the node has allocatable pods
+	nodeOptionalResourceCommoditiesSold = []metrics.ResourceType{
+		metrics.NetworkThroughput,
+		metrics.EphemeralStorage,
+		metrics.NumPods,
+	}
+
 	allocationResourceCommoditiesSold = []metrics.ResourceType{
 		metrics.CPUQuota,
 		metrics.MemoryQuota,
@@ -138,6 +146,8 @@ func (builder *nodeEntityDTOBuilder) BuildEntityDTOs(nodes []*api.Node) ([]*prot
 
 // Build the sold commodityDTO by each node. They include:
 // VCPU, VMem, CPURequest, MemRequest;
+// NetThroughput, StorageAmount, NumberConsumers if available;
+// one commodity per extended resource, e.g., nvidia.com/gpu or hugepages-2Mi, keyed by its name;
 // VMPMAccessCommodity, ApplicationCommodity, ClusterCommodity.
 func (builder *nodeEntityDTOBuilder) getNodeCommoditiesSold(node *api.Node) ([]*proto.CommodityDTO, error) {
 	var commoditiesSold []*proto.CommodityDTO
@@ -161,6 +171,17 @@ func (builder *nodeEntityDTOBuilder) getNodeCommoditiesSold(node *api.Node) ([]*
 	}
 	commoditiesSold = append(commoditiesSold, resourceCommoditiesSold...)
 
+	// Network and storage commodities
+	optionalResources := builder.availableResourceTypes(metrics.NodeType, key, nodeOptionalResourceCommoditiesSold)
+	optionalCommoditiesSold, err := builder.getResourceCommoditiesSold(metrics.NodeType, key, optionalResources, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	commoditiesSold = append(commoditiesSold, optionalCommoditiesSold...)
+
+	// Extended resource commodities, keyed by the resource name
+	commoditiesSold = append(commoditiesSold, builder.getExtendedResourceCommoditiesSold(node, key)...)
+
 	// Access commodities: labels.
 	for key, value := range node.ObjectMeta.Labels {
 		label := key + "=" + value
@@ -211,6 +232,24 @@ func (builder *nodeEntityDTOBuilder) getNodeCommoditiesSold(node *api.Node) ([]*
 	return commoditiesSold, nil
 }
 
+// Build the commodities sold for the allocatable extended resources of the node, so that the pods requesting
+// them can only be placed on the nodes having them.
+func (builder *nodeEntityDTOBuilder) getExtendedResourceCommoditiesSold(node *api.Node, key string) []*proto.CommodityDTO {
+	var commoditiesSold []*proto.CommodityDTO
+	extendedResources := util.GetExtendedResourceValues(node.Status.Allocatable)
+	for _, name := range util.SortedResourceNames(extendedResources) {
+		commSold, err := builder.getSoldResourceCommodityWithKey(metrics.NodeType, key,
+			metrics.ExtendedResourceType(name), string(name), nil, nil)
+		if err != nil {
+			glog.Errorf("%s::%s: cannot build sold commodity for extended resource %s: %s",
+				metrics.NodeType, key, name, err)
+			continue
+		}
+		commoditiesSold = append(commoditiesSold, commSold)
+	}
+	return commoditiesSold
+}
+
 func (builder *nodeEntityDTOBuilder) getAllocationCommoditiesSold(node *api.Node) ([]*proto.CommodityDTO, error) {
 	var commoditiesSold []*proto.CommodityDTO
 	// get cpu frequency
@@ -258,6 +297,9 @@ func (builder *nodeEntityDTOBuilder) getNodeProperties(node *api.Node) ([]*proto
 	nodeProperty := property.BuildNodeProperties(node)
 	properties = append(properties, nodeProperty)
 
+	// the age of the kubelet data and the kubelet cache hits and misses.
+	properties = append(properties, builder.getKubeletCacheProperties(util.NodeKeyFunc(node), true)...)
+
 	return properties, nil
 }
 
@@ -5,6 +5,9 @@ import (
 	"testing"
 
 	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 
 	api "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -164,3 +167,47 @@ func TestGetNodeIPs(t *testing.T) {
 		}
 	}
 }
+
+func TestGetExtendedResourceCommoditiesSold(t *testing.T) {
+	node := mockNode()
+	node.Status.Allocatable["nvidia.com/gpu"] = resource.MustParse("4")
+	node.Status.Allocatable["hugepages-2Mi"] = resource.MustParse("1Gi")
+	key := util.NodeKeyFunc(node)
+
+	sink := metrics.NewEntityMetricSink()
+	for name, values := range map[api.ResourceName][]float64{
+		"nvidia.com/gpu": {4, 1},
+		"hugepages-2Mi":  {1024 * 1024, 0},
+	} {
+		rType := metrics.ExtendedResourceType(name)
+		sink.AddNewMetricEntries(
+			metrics.NewEntityResourceMetric(metrics.NodeType, key, rType, metrics.Capacity, values[0]),
+			metrics.NewEntityResourceMetric(metrics.NodeType, key, rType, metrics.Used, values[1]))
+	}
+	nodeBuilder := NewNodeEntityDTOBuilder(sink, nil)
+
+	commodities := nodeBuilder.getExtendedResourceCommoditiesSold(node, key)
+	if len(commodities) != 2 {
+		t.Fatalf("Expected 2 extended resource commodities but got %d", len(commodities))
+	}
+	// The commodities are sorted by resource name
+	expected := []struct {
+		key      string
+		capacity float64
+		used     float64
+	}{
+		{"hugepages-2Mi", 1024 * 1024, 0},
+		{"nvidia.com/gpu", 4, 1},
+	}
+	for i, comm := range commodities {
+		if comm.GetCommodityType() != proto.CommodityDTO_BUFFER_COMMODITY || comm.GetKey() != expected[i].key ||
+			comm.GetCapacity() != expected[i].capacity || comm.GetUsed() != expected[i].used {
+			t.Errorf("Unexpected commodity %d: %+v", i, comm)
+		}
+	}
+
+	// No commodity for a node without extended resources
+	if commodities := nodeBuilder.getExtendedResourceCommoditiesSold(mockNode(), key); len(commodities) != 0 {
+		t.Errorf("Expected no extended resource commodity but got %d", len(commodities))
+	}
+}
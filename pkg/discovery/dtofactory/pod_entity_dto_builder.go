@@ -7,6 +7,7 @@ import (
 
 	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 
@@ -34,6 +35,17 @@ var (
 		// TODO, add back provisioned commodity later
 	}
 
+	// Commodities sold to the containers and bought from the node only when their usage is provided by the kubelet
+	podOptionalResourceCommodities = []metrics.ResourceType{
+		metrics.NetworkThroughput,
+		metrics.EphemeralStorage,
+	}
+
+	// Commodity bought from the node for its max number of pods
+	podNumPodsCommodity = []metrics.ResourceType{
+		metrics.NumPods,
+	}
+
 	podResourceCommodityBoughtFromQuota = []metrics.ResourceType{
 		metrics.CPUQuota,
 		metrics.MemoryQuota,
@@ -47,6 +59,8 @@ type podEntityDTOBuilder struct {
 	stitchingManager *stitching.StitchingManager
 	nodeNameUIDMap   map[string]string
 	quotaNameUIDMap  map[string]string
+	// Persistent volume claims by cluster Id
+	volumes map[string]*repository.KubeVolume
 }
 
 func NewPodEntityDTOBuilder(sink *metrics.EntityMetricSink, stitchingManager *stitching.StitchingManager,
@@ -59,6 +73,12 @@ func NewPodEntityDTOBuilder(sink *metrics.EntityMetricSink, stitchingManager *st
 	}
 }
 
+// Set the persistent volume claims that the pods buy storage from.
+func (builder *podEntityDTOBuilder) WithVolumes(volumes map[string]*repository.KubeVolume) *podEntityDTOBuilder {
+	builder.volumes = volumes
+	return builder
+}
+
 // Build entityDTOs based on the given pod list.
 func (builder *podEntityDTOBuilder) BuildEntityDTOs(pods []*api.Pod) ([]*proto.EntityDTO, error) {
 	var result []*proto.EntityDTO
@@ -113,6 +133,19 @@ func (builder *podEntityDTOBuilder) BuildEntityDTOs(pods []*api.Pod) ([]*proto.E
 			glog.Errorf("Failed to get quota for pod: %s", pod.Namespace)
 		}
 
+		// commodities bought from the persistent volume claims
+		for _, volume := range builder.getPodVolumes(pod) {
+			commBought, err := builder.getPodCommodityBoughtFromVolume(pod, volume)
+			if err != nil {
+				glog.Errorf("Error when create commoditiesBought for pod %s from volume %s: %s",
+					displayName, volume.VolumeClusterId, err)
+				continue
+			}
+			provider := sdkbuilder.CreateProvider(proto.EntityDTO_VIRTUAL_VOLUME, string(volume.UID))
+			entityDTOBuilder = entityDTOBuilder.Provider(provider)
+			entityDTOBuilder.BuysCommodities([]*proto.CommodityDTO{commBought})
+		}
+
 		// entities' properties.
 		properties, err := builder.getPodProperties(pod)
 		if err != nil {
@@ -149,7 +182,7 @@ func (builder *podEntityDTOBuilder) BuildEntityDTOs(pods []*api.Pod) ([]*proto.E
 	return result, nil
 }
 
-// Build the CommodityDTOs sold  by the pod for vCPU, vMem and VMPMAcces.
+// Build the CommodityDTOs sold  by the pod for vCPU, vMem, VMPMAcces, and NetThroughput, StorageAmount if available.
 // VMPMAccess is used to bind container to the hosting pod so the container is not moved out of the pod
 func (builder *podEntityDTOBuilder) getPodCommoditiesSold(pod *api.Pod, cpuFrequency float64) ([]*proto.CommodityDTO, error) {
 	var commoditiesSold []*proto.CommodityDTO
@@ -173,6 +206,15 @@ func (builder *podEntityDTOBuilder) getPodCommoditiesSold(pod *api.Pod, cpuFrequ
 	}
 	commoditiesSold = append(commoditiesSold, resourceCommoditiesSold...)
 
+	// Network and storage commodities
+	optionalResources := builder.availableResourceTypes(metrics.PodType, podMId, podOptionalResourceCommodities)
+	attributeSetter.Add(func(commBuilder *sdkbuilder.CommodityDTOBuilder) { commBuilder.Resizable(false) }, optionalResources...)
+	optionalCommoditiesSold, err := builder.getResourceCommoditiesSold(metrics.PodType, podMId, optionalResources, nil, attributeSetter)
+	if err != nil {
+		return nil, err
+	}
+	commoditiesSold = append(commoditiesSold, optionalCommoditiesSold...)
+
 	// vmpmAccess commodity
 	podAccessComm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_VMPM_ACCESS).
 		Key(string(pod.UID)).
@@ -187,7 +229,8 @@ func (builder *podEntityDTOBuilder) getPodCommoditiesSold(pod *api.Pod, cpuFrequ
 }
 
 // Build the CommodityDTOs bought by the pod from the node provider.
-// Commodities bought are vCPU, vMem vmpm access, cluster
+// Commodities bought are vCPU, vMem vmpm access, cluster, and NetThroughput, StorageAmount if available,
+// and one commodity per requested extended resource, keyed by its name
 func (builder *podEntityDTOBuilder) getPodCommoditiesBought(pod *api.Pod, cpuFrequency float64) ([]*proto.CommodityDTO, error) {
 	var commoditiesBought []*proto.CommodityDTO
 
@@ -207,9 +250,46 @@ func (builder *podEntityDTOBuilder) getPodCommoditiesBought(pod *api.Pod, cpuFre
 	}
 	commoditiesBought = append(commoditiesBought, resourceCommoditiesBought...)
 
-	// Access commodities: selectors.
+	// Network and storage commodities, only bought if they are sold by the node
+	nodeResources := builder.availableResourceTypes(metrics.NodeType, util.NodeKeyFromPodFunc(pod), podOptionalResourceCommodities)
+	optionalResources := builder.availableResourceTypes(metrics.PodType, podMId, nodeResources)
+	optionalCommoditiesBought, err := builder.getResourceCommoditiesBought(metrics.PodType, podMId, optionalResources, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	commoditiesBought = append(commoditiesBought, optionalCommoditiesBought...)
+
+	// Number of consumers commodity, only bought if it is sold by the node
+	numPodsResources := builder.availableResourceTypes(metrics.NodeType, util.NodeKeyFromPodFunc(pod), podNumPodsCommodity)
+	numPodsCommoditiesBought, err := builder.getResourceCommoditiesBought(metrics.PodType, podMId, numPodsResources, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	commoditiesBought = append(commoditiesBought, numPodsCommoditiesBought...)
+
+	// Extended resource commodities, keyed by the resource name. They are bought even if the current node
+	// does not sell them, so that the pod is not placed on the nodes lacking the resources.
+	extendedResources := util.GetPodExtendedResourceRequests(pod)
+	for _, name := range util.SortedResourceNames(extendedResources) {
+		commBought, err := builder.getResourceCommodityBoughtWithKey(metrics.PodType, podMId,
+			metrics.ExtendedResourceType(name), string(name), nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build the commodity bought for extended resource %s: %v", name, err)
+		}
+		commoditiesBought = append(commoditiesBought, commBought)
+	}
+
+	// Access commodities: selectors, and the zone or node affinity of the persistent volumes.
+	selectors := make(map[string]bool)
 	for key, value := range pod.Spec.NodeSelector {
-		selector := key + "=" + value
+		selectors[key+"="+value] = true
+	}
+	for _, volume := range builder.getPodVolumes(pod) {
+		for _, selector := range volume.NodeAccessKeys() {
+			selectors[selector] = true
+		}
+	}
+	for selector := range selectors {
 		accessComm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_VMPM_ACCESS).
 			Key(selector).
 			Create()
@@ -276,6 +356,41 @@ func (builder *podEntityDTOBuilder) getPodCommoditiesBoughtFromQuota(quotaUID st
 	return commoditiesBought, nil
 }
 
+// Get the persistent volume claims mounted by the pod.
+func (builder *podEntityDTOBuilder) getPodVolumes(pod *api.Pod) []*repository.KubeVolume {
+	var volumes []*repository.KubeVolume
+	for _, podVolume := range pod.Spec.Volumes {
+		claim := podVolume.PersistentVolumeClaim
+		if claim == nil {
+			continue
+		}
+		volume, exists := builder.volumes[util.BuildK8sEntityClusterID(pod.Namespace, claim.ClaimName)]
+		if !exists {
+			glog.V(3).Infof("Cannot find persistent volume claim %s of pod %s",
+				claim.ClaimName, util.GetPodClusterID(pod))
+			continue
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes
+}
+
+// Build the storage amount CommodityDTO bought by the pod from the persistent volume claim.
+// The key is the claim UID so that the pod cannot be moved to another volume.
+func (builder *podEntityDTOBuilder) getPodCommodityBoughtFromVolume(pod *api.Pod,
+	volume *repository.KubeVolume) (*proto.CommodityDTO, error) {
+	// The used value is not available if the kubelet doesn't report the volume stats
+	used, err := builder.metricValue(metrics.VolumeType, volume.VolumeClusterId, metrics.VolumeStorage, metrics.Used, nil)
+	if err != nil {
+		glog.V(4).Infof("Usage of volume %s mounted by pod %s is not available: %v",
+			volume.VolumeClusterId, util.GetPodClusterID(pod), err)
+	}
+	return sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_STORAGE_AMOUNT).
+		Key(string(volume.UID)).
+		Used(used).
+		Create()
+}
+
 // Get the properties of the pod. This includes property related to pod cluster property.
 func (builder *podEntityDTOBuilder) getPodProperties(pod *api.Pod) ([]*proto.EntityDTO_EntityProperty, error) {
 	var properties []*proto.EntityDTO_EntityProperty
@@ -295,6 +410,18 @@ func (builder *podEntityDTOBuilder) getPodProperties(pod *api.Pod) ([]*proto.Ent
 	}
 	properties = append(properties, stitchingProperty)
 
+	// The usage of the pod is from the kubelet data of its node
+	properties = append(properties, builder.getKubeletCacheProperties(nodeName, false)...)
+
+	if pdbNames := builder.getPodDisruptionBudgets(util.PodKeyFunc(pod)); len(pdbNames) > 0 {
+		properties = append(properties, property.BuildPodDisruptionBudgetsProperty(pdbNames))
+	}
+
+	if hpa := builder.getHorizontalPodAutoscaler(util.PodKeyFunc(pod)); hpa != nil {
+		properties = append(properties, property.BuildHorizontalPodAutoscalerProperties(
+			util.HorizontalPodAutoscalerName(hpa), util.HorizontalPodAutoscalerMinReplicas(hpa), hpa.Spec.MaxReplicas)...)
+	}
+
 	return properties, nil
 }
 
@@ -4,8 +4,10 @@ import (
 	"testing"
 
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"reflect"
 )
@@ -89,3 +91,48 @@ func createPodWithIPs(podIP, hostIP string) *api.Pod {
 		ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar"},
 	}
 }
+
+func Test_podEntityDTOBuilder_getPodCommoditiesBought_ExtendedResources(t *testing.T) {
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar", UID: "bar-UID"},
+		Spec: api.PodSpec{
+			NodeName: "node1",
+			Containers: []api.Container{{
+				Name: "cuda",
+				Resources: api.ResourceRequirements{
+					Requests: api.ResourceList{"nvidia.com/gpu": resource.MustParse("2")},
+				},
+			}},
+		},
+	}
+	podMId := util.PodMetricIdAPI(pod)
+	gpuType := metrics.ExtendedResourceType("nvidia.com/gpu")
+	sink := metrics.NewEntityMetricSink()
+	for _, rType := range append(podResourceCommodityBoughtFromNode, gpuType) {
+		sink.AddNewMetricEntries(metrics.NewEntityResourceMetric(metrics.PodType, podMId, rType, metrics.Used, 2.0))
+	}
+	podBuilder := NewPodEntityDTOBuilder(sink, nil, nil, nil)
+
+	commodities, err := podBuilder.getPodCommoditiesBought(pod, 100.0)
+	if err != nil {
+		t.Fatalf("Failed to build the commodities bought: %v", err)
+	}
+	found := false
+	for _, comm := range commodities {
+		if comm.GetCommodityType() == proto.CommodityDTO_BUFFER_COMMODITY {
+			found = true
+			if comm.GetKey() != "nvidia.com/gpu" || comm.GetUsed() != 2.0 {
+				t.Errorf("Unexpected extended resource commodity %+v", comm)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected the pod to buy the nvidia.com/gpu commodity")
+	}
+
+	// The commodity cannot be built without the metric of the request
+	pod.Spec.Containers[0].Resources.Requests["example.com/fpga"] = resource.MustParse("1")
+	if _, err := podBuilder.getPodCommoditiesBought(pod, 100.0); err == nil {
+		t.Errorf("Error thrown expected")
+	}
+}
@@ -1,11 +1,43 @@
 package property
 
 import (
+	"strconv"
+
 	api "k8s.io/api/core/v1"
 
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 )
 
+const (
+	kubeletDataAge     = "KubeletDataAgeSeconds"
+	kubeletCacheHits   = "KubeletCacheHits"
+	kubeletCacheMisses = "KubeletCacheMisses"
+)
+
+// Build the property of the age of the kubelet data of an entity. The age is 0 unless the kubelet
+// could not be reached and the data comes from the kubelet cache.
+func BuildKubeletDataAgeProperty(ageSeconds float64) *proto.EntityDTO_EntityProperty {
+	return buildNumberProperty(kubeletDataAge, ageSeconds)
+}
+
+// Build the properties of the number of hits and misses of the kubelet cache of a node.
+func BuildKubeletCacheProperties(hits, misses float64) []*proto.EntityDTO_EntityProperty {
+	return []*proto.EntityDTO_EntityProperty{
+		buildNumberProperty(kubeletCacheHits, hits),
+		buildNumberProperty(kubeletCacheMisses, misses),
+	}
+}
+
+func buildNumberProperty(name string, value float64) *proto.EntityDTO_EntityProperty {
+	propertyNamespace := k8sPropertyNamespace
+	propertyValue := strconv.FormatFloat(value, 'f', -1, 64)
+	return &proto.EntityDTO_EntityProperty{
+		Namespace: &propertyNamespace,
+		Name:      &name,
+		Value:     &propertyValue,
+	}
+}
+
 // Build entity properties for a node. The name is the name of the node shown inside Kubernetes cluster.
 func BuildNodeProperties(node *api.Node) *proto.EntityDTO_EntityProperty {
 	propertyNamespace := k8sPropertyNamespace
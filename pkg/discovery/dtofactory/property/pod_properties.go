@@ -4,17 +4,25 @@ import (
 	api "k8s.io/api/core/v1"
 
 	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 )
 
 const (
 	// TODO currently in the server side only properties in "DEFAULT" namespaces are respected. Ideally we should use "Kubernetes-Pod".
-	k8sPropertyNamespace    = "DEFAULT"
-	VCTagsPropertyNamespace = "VCTAGS"
-	k8sNamespace            = "KubernetesNamespace"
-	k8sPodName              = "KubernetesPodName"
-	k8sNodeName             = "KubernetesNodeName"
-	k8sContainerIndex       = "Kubernetes-Container-Index"
+	k8sPropertyNamespace       = "DEFAULT"
+	VCTagsPropertyNamespace    = "VCTAGS"
+	k8sNamespace               = "KubernetesNamespace"
+	k8sPodName                 = "KubernetesPodName"
+	k8sNodeName                = "KubernetesNodeName"
+	k8sContainerIndex          = "Kubernetes-Container-Index"
+	k8sPodDisruptionBudgets    = "KubernetesPodDisruptionBudgets"
+	k8sHorizontalPodAutoscaler = "KubernetesHorizontalPodAutoscaler"
+	k8sHPAMinReplicas          = "KubernetesHPAMinReplicas"
+	k8sHPAMaxReplicas          = "KubernetesHPAMaxReplicas"
+	k8sHorizontallyScalable    = "KubernetesHorizontallyScalable"
 )
 
 // Build entity properties of a pod. The properties are consisted of name and namespace of a pod.
@@ -55,6 +63,44 @@ func BuildPodProperties(pod *api.Pod) []*proto.EntityDTO_EntityProperty {
 	return properties
 }
 
+// Build the property listing the PodDisruptionBudgets protecting a pod, as comma separated namespace/name.
+func BuildPodDisruptionBudgetsProperty(pdbNames []string) *proto.EntityDTO_EntityProperty {
+	propertyNamespace := k8sPropertyNamespace
+	propertyName := k8sPodDisruptionBudgets
+	propertyValue := strings.Join(pdbNames, ",")
+	return &proto.EntityDTO_EntityProperty{
+		Namespace: &propertyNamespace,
+		Name:      &propertyName,
+		Value:     &propertyValue,
+	}
+}
+
+// Build the properties of a pod whose controller is scaled by a HorizontalPodAutoscaler: the namespace/name
+// and the replica bounds of the autoscaler, and the pod marked as not horizontally scalable by kubeturbo,
+// as the autoscaler would revert any change of the replicas of the controller.
+func BuildHorizontalPodAutoscalerProperties(hpaName string, minReplicas, maxReplicas int32) []*proto.EntityDTO_EntityProperty {
+	propertyNamespace := k8sPropertyNamespace
+	var properties []*proto.EntityDTO_EntityProperty
+	for _, item := range []struct {
+		name  string
+		value string
+	}{
+		{k8sHorizontalPodAutoscaler, hpaName},
+		{k8sHPAMinReplicas, strconv.Itoa(int(minReplicas))},
+		{k8sHPAMaxReplicas, strconv.Itoa(int(maxReplicas))},
+		{k8sHorizontallyScalable, strconv.FormatBool(false)},
+	} {
+		propertyName := item.name
+		propertyValue := item.value
+		properties = append(properties, &proto.EntityDTO_EntityProperty{
+			Namespace: &propertyNamespace,
+			Name:      &propertyName,
+			Value:     &propertyValue,
+		})
+	}
+	return properties
+}
+
 // Get the namespace and name of a pod from entity property.
 func GetPodInfoFromProperty(properties []*proto.EntityDTO_EntityProperty) (string, string, error) {
 	podNamespace := ""
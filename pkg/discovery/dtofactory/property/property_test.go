@@ -91,3 +91,24 @@ func TestAddHostingPodProperties(t *testing.T) {
 		t.Error("Appliction property test failed: container index is wrong.")
 	}
 }
+
+func TestBuildHorizontalPodAutoscalerProperties(t *testing.T) {
+	expected := map[string]string{
+		"KubernetesHorizontalPodAutoscaler": "ns1/web-hpa",
+		"KubernetesHPAMinReplicas":          "2",
+		"KubernetesHPAMaxReplicas":          "10",
+		"KubernetesHorizontallyScalable":    "false",
+	}
+	ps := BuildHorizontalPodAutoscalerProperties("ns1/web-hpa", 2, 10)
+	if len(ps) != len(expected) {
+		t.Fatalf("Expected %d properties but got %d", len(expected), len(ps))
+	}
+	for _, p := range ps {
+		if p.GetNamespace() != k8sPropertyNamespace {
+			t.Errorf("Property %s has namespace %s", p.GetName(), p.GetNamespace())
+		}
+		if value, exists := expected[p.GetName()]; !exists || value != p.GetValue() {
+			t.Errorf("Unexpected property %s=%s", p.GetName(), p.GetValue())
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package property
+
+import (
+	api "k8s.io/api/core/v1"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	k8sPersistentVolumeClaimName = "KubernetesPersistentVolumeClaimName"
+	k8sPersistentVolumeName      = "KubernetesPersistentVolumeName"
+)
+
+// Build entity properties of a persistent volume claim. The properties are consisted of name and namespace of
+// the claim, and the name of the bound persistent volume if any.
+func BuildVolumeProperties(claim *api.PersistentVolumeClaim) []*proto.EntityDTO_EntityProperty {
+	var properties []*proto.EntityDTO_EntityProperty
+	propertyNamespace := k8sPropertyNamespace
+
+	namespacePropertyName := k8sNamespace
+	namespacePropertyValue := claim.Namespace
+	properties = append(properties, &proto.EntityDTO_EntityProperty{
+		Namespace: &propertyNamespace,
+		Name:      &namespacePropertyName,
+		Value:     &namespacePropertyValue,
+	})
+
+	claimPropertyName := k8sPersistentVolumeClaimName
+	claimPropertyValue := claim.Name
+	properties = append(properties, &proto.EntityDTO_EntityProperty{
+		Namespace: &propertyNamespace,
+		Name:      &claimPropertyName,
+		Value:     &claimPropertyValue,
+	})
+
+	if claim.Spec.VolumeName != "" {
+		volumePropertyName := k8sPersistentVolumeName
+		volumePropertyValue := claim.Spec.VolumeName
+		properties = append(properties, &proto.EntityDTO_EntityProperty{
+			Namespace: &propertyNamespace,
+			Name:      &volumePropertyName,
+			Value:     &volumePropertyValue,
+		})
+	}
+
+	return properties
+}
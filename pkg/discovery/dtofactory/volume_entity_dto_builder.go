@@ -0,0 +1,70 @@
+package dtofactory
+
+import (
+	api "k8s.io/api/core/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/golang/glog"
+)
+
+type volumeEntityDTOBuilder struct {
+	// Persistent volume claims by cluster Id
+	volumes map[string]*repository.KubeVolume
+	// Used storage in MB by volume UID
+	volumeUsed map[string]float64
+}
+
+func NewVolumeEntityDTOBuilder(volumes map[string]*repository.KubeVolume,
+	volumeUsed map[string]float64) *volumeEntityDTOBuilder {
+	return &volumeEntityDTOBuilder{
+		volumes:    volumes,
+		volumeUsed: volumeUsed,
+	}
+}
+
+// Build the entityDTOs of the persistent volume claims. Each claim sells the storage amount to the pods mounting it.
+func (builder *volumeEntityDTOBuilder) BuildEntityDTOs() ([]*proto.EntityDTO, error) {
+	var result []*proto.EntityDTO
+	for _, volume := range builder.volumes {
+		volumeID := string(volume.UID)
+		entityDTOBuilder := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_VIRTUAL_VOLUME, volumeID).
+			DisplayName(volume.VolumeClusterId)
+
+		// commodities sold, keyed by the claim so that the pods cannot be moved to another volume
+		storageComm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_STORAGE_AMOUNT).
+			Key(volumeID).
+			Capacity(volume.Capacity()).
+			Used(builder.volumeUsed[volumeID]).
+			Resizable(false).
+			Create()
+		if err != nil {
+			glog.Errorf("Failed to build commodity sold by volume %s: %v", volume.VolumeClusterId, err)
+			continue
+		}
+		entityDTOBuilder.SellsCommodities([]*proto.CommodityDTO{storageComm})
+
+		// entities' properties.
+		entityDTOBuilder.WithProperties(property.BuildVolumeProperties(volume.PersistentVolumeClaim))
+
+		if volume.Status.Phase == api.ClaimBound {
+			entityDTOBuilder.WithPowerState(proto.EntityDTO_POWERED_ON)
+		} else {
+			glog.V(3).Infof("Volume claim %s is %s", volume.VolumeClusterId, volume.Status.Phase)
+			entityDTOBuilder.WithPowerState(proto.EntityDTO_POWERSTATE_UNKNOWN)
+		}
+
+		entityDto, err := entityDTOBuilder.Create()
+		if err != nil {
+			glog.Errorf("Failed to build volume entityDTO: %s", err)
+			continue
+		}
+		result = append(result, entityDto)
+		glog.V(4).Infof("volume dto: %++v\n", entityDto)
+	}
+	return result, nil
+}
@@ -0,0 +1,90 @@
+package dtofactory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func createTestVolume() *repository.KubeVolume {
+	claim := &api.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "claim1", Namespace: "space1", UID: "claim1-uid"},
+		Spec:       api.PersistentVolumeClaimSpec{VolumeName: "pv1"},
+		Status: api.PersistentVolumeClaimStatus{
+			Phase:    api.ClaimBound,
+			Capacity: api.ResourceList{api.ResourceStorage: resource.MustParse("2Gi")},
+		},
+	}
+	pv := &api.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "pv1",
+			Labels: map[string]string{"failure-domain.beta.kubernetes.io/zone": "zone1"},
+		},
+	}
+	return repository.NewKubeVolume(claim, pv, nil)
+}
+
+func TestBuildVolumeEntityDTOs(t *testing.T) {
+	volume := createTestVolume()
+	volumes := map[string]*repository.KubeVolume{volume.VolumeClusterId: volume}
+
+	dtos, err := NewVolumeEntityDTOBuilder(volumes, map[string]float64{"claim1-uid": 512}).BuildEntityDTOs()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dtos))
+
+	dto := dtos[0]
+	assert.Equal(t, proto.EntityDTO_VIRTUAL_VOLUME, dto.GetEntityType())
+	assert.Equal(t, "claim1-uid", dto.GetId())
+	assert.Equal(t, "space1/claim1", dto.GetDisplayName())
+	assert.Equal(t, proto.EntityDTO_POWERED_ON, dto.GetPowerState())
+	assert.Equal(t, 1, len(dto.GetCommoditiesSold()))
+	comm := dto.GetCommoditiesSold()[0]
+	assert.Equal(t, proto.CommodityDTO_STORAGE_AMOUNT, comm.GetCommodityType())
+	assert.Equal(t, "claim1-uid", comm.GetKey())
+	assert.Equal(t, 2048.0, comm.GetCapacity())
+	assert.Equal(t, 512.0, comm.GetUsed())
+}
+
+func TestPodCommoditiesBoughtFromVolume(t *testing.T) {
+	volume := createTestVolume()
+	sink := metrics.NewEntityMetricSink()
+	sink.AddNewMetricEntries(metrics.NewEntityResourceMetric(metrics.VolumeType, volume.VolumeClusterId,
+		metrics.VolumeStorage, metrics.Used, 100.0))
+	podBuilder := &podEntityDTOBuilder{
+		generalBuilder: newGeneralBuilder(sink),
+	}
+	podBuilder.WithVolumes(map[string]*repository.KubeVolume{volume.VolumeClusterId: volume})
+
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "space1"},
+		Spec: api.PodSpec{
+			Volumes: []api.Volume{
+				{
+					Name: "data",
+					VolumeSource: api.VolumeSource{
+						PersistentVolumeClaim: &api.PersistentVolumeClaimVolumeSource{ClaimName: "claim1"},
+					},
+				},
+				{
+					Name:         "tmp",
+					VolumeSource: api.VolumeSource{EmptyDir: &api.EmptyDirVolumeSource{}},
+				},
+			},
+		},
+	}
+
+	podVolumes := podBuilder.getPodVolumes(pod)
+	assert.Equal(t, 1, len(podVolumes))
+
+	comm, err := podBuilder.getPodCommodityBoughtFromVolume(pod, podVolumes[0])
+	assert.Nil(t, err)
+	assert.Equal(t, proto.CommodityDTO_STORAGE_AMOUNT, comm.GetCommodityType())
+	assert.Equal(t, "claim1-uid", comm.GetKey())
+	assert.Equal(t, 100.0, comm.GetUsed())
+}
@@ -2,9 +2,12 @@ package discovery
 
 import (
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/metricsserver"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/worker"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/worker/compliance"
 	"github.com/turbonomic/kubeturbo/pkg/registration"
@@ -16,6 +19,7 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/processor"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 )
 
 const (
@@ -28,6 +32,10 @@ type DiscoveryClientConfig struct {
 	targetConfig         *configs.K8sTargetConfig
 	ValidationWorkers    int
 	ValidationTimeoutSec int
+	// Whether the changes of the cluster are tracked for the incremental discoveries
+	IncrementalDiscovery bool
+	// Path of the file where the snapshot of each full discovery is saved, empty to not record the snapshots
+	SnapshotPath string
 }
 
 func NewDiscoveryConfig(probeConfig *configs.ProbeConfig,
@@ -41,6 +49,16 @@ func NewDiscoveryConfig(probeConfig *configs.ProbeConfig,
 	}
 }
 
+func (config *DiscoveryClientConfig) WithIncrementalDiscovery(incrementalDiscovery bool) *DiscoveryClientConfig {
+	config.IncrementalDiscovery = incrementalDiscovery
+	return config
+}
+
+func (config *DiscoveryClientConfig) WithSnapshotPath(snapshotPath string) *DiscoveryClientConfig {
+	config.SnapshotPath = snapshotPath
+	return config
+}
+
 // Implements the go sdk discovery client interface
 type K8sDiscoveryClient struct {
 	config            *DiscoveryClientConfig
@@ -49,13 +67,24 @@ type K8sDiscoveryClient struct {
 	clusterProcessor *processor.ClusterProcessor
 	dispatcher       *worker.Dispatcher
 	resultCollector  *worker.ResultCollector
+
+	// Tracks the changes for the incremental discovery, nil if it is not enabled
+	changeTracker *changeTracker
+	// The full and incremental discoveries share the workers, only one of them runs at a time
+	discoveryLock sync.Mutex
 }
 
 func NewK8sDiscoveryClient(config *DiscoveryClientConfig) *K8sDiscoveryClient {
-	k8sClusterScraper := cluster.NewClusterScraper(config.probeConfig.ClusterClient)
+	k8sClusterScraper := config.probeConfig.ClusterScraper
+	if k8sClusterScraper == nil {
+		k8sClusterScraper = cluster.NewClusterScraper(config.probeConfig.ClusterClient)
+	}
 
 	// for discovery tasks
 	clusterProcessor := processor.NewClusterProcessor(k8sClusterScraper, config.probeConfig.NodeClient, config.ValidationWorkers, config.ValidationTimeoutSec)
+	if clusterProcessor != nil && config.probeConfig.MetricsServerClient != nil {
+		clusterProcessor.WithMetricsServerClient(config.probeConfig.MetricsServerClient)
+	}
 	// make maxWorkerCount of result collector twice the worker count.
 	resultCollector := worker.NewResultCollector(workerCount * 2)
 
@@ -70,6 +99,13 @@ func NewK8sDiscoveryClient(config *DiscoveryClientConfig) *K8sDiscoveryClient {
 		dispatcher:        dispatcher,
 		resultCollector:   resultCollector,
 	}
+	if config.IncrementalDiscovery {
+		if clusterCache := k8sClusterScraper.GetClusterCache(); clusterCache != nil {
+			dc.changeTracker = newChangeTracker(clusterCache)
+		} else {
+			glog.Warningf("Incremental discovery is disabled as there is no cluster cache to track the changes.")
+		}
+	}
 	return dc
 }
 
@@ -138,10 +174,19 @@ func (dc *K8sDiscoveryClient) Validate(accountValues []*proto.AccountValue) (*pr
 // This is a part of the interface that gets registered with and is invoked asynchronously by the GO SDK Probe.
 func (dc *K8sDiscoveryClient) Discover(accountValues []*proto.AccountValue) (*proto.DiscoveryResponse, error) {
 	glog.V(2).Infof("Discovering kubernetes cluster...")
+	dc.discoveryLock.Lock()
+	defer dc.discoveryLock.Unlock()
 	currentTime := time.Now()
+	// The full discovery includes all the changes so far
+	if dc.changeTracker != nil {
+		dc.changeTracker.reset()
+	}
+	snapshotRecording := dc.startSnapshotRecording()
 	newDiscoveryResultDTOs, groupDTOs, err := dc.discoverWithNewFramework()
 	if err != nil {
 		glog.Errorf("Failed to discover kubernetes cluster: %v", err)
+	} else if snapshotRecording != nil {
+		dc.saveSnapshot(snapshotRecording)
 	}
 
 	discoveryResponse := &proto.DiscoveryResponse{
@@ -155,6 +200,121 @@ func (dc *K8sDiscoveryClient) Discover(accountValues []*proto.AccountValue) (*pr
 	return discoveryResponse, nil
 }
 
+// DiscoverIncremental rediscovers the nodes affected by the changes of the cluster since the previous discovery,
+// together with their pods, containers and applications, and reports the deleted nodes and pods.
+// The other entities and the groups are only updated by the full discovery.
+// This is a part of the interface that gets registered with and is invoked asynchronously by the GO SDK Probe.
+func (dc *K8sDiscoveryClient) DiscoverIncremental(accountValues []*proto.AccountValue) (*proto.DiscoveryResponse, error) {
+	if dc.changeTracker == nil {
+		return nil, fmt.Errorf("incremental discovery is not enabled")
+	}
+	dc.discoveryLock.Lock()
+	defer dc.discoveryLock.Unlock()
+	currentTime := time.Now()
+
+	changes := dc.changeTracker.takeChanges()
+	if changes.isEmpty() {
+		glog.V(2).Infof("No change in kubernetes cluster since the previous discovery.")
+		return &proto.DiscoveryResponse{}, nil
+	}
+	glog.V(2).Infof("Incrementally discovering kubernetes cluster: %d changed nodes, %d deleted nodes, %d deleted pods",
+		len(changes.changedNodes), len(changes.deletedNodes), len(changes.deletedPods))
+	entityDTOs, err := dc.discoverChanges(changes)
+	if err != nil {
+		glog.Errorf("Failed to incrementally discover kubernetes cluster: %v", err)
+		// Rediscover them next time
+		dc.changeTracker.restore(changes)
+		return nil, err
+	}
+
+	discTime := time.Now().Sub(currentTime).Seconds()
+	glog.V(2).Infof("Successfully discovered %d changed entities of kubernetes cluster in %.3f seconds",
+		len(entityDTOs), discTime)
+	return &proto.DiscoveryResponse{
+		EntityDTO: entityDTOs,
+	}, nil
+}
+
+func (dc *K8sDiscoveryClient) discoverChanges(changes *clusterChanges) ([]*proto.EntityDTO, error) {
+	var nodeNames []string
+	for nodeName := range changes.changedNodes {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Strings(nodeNames)
+	// Only the changed nodes are discovered, not the whole cluster
+	kubeCluster, err := dc.clusterProcessor.DiscoverNodes(nodeNames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to process the changed nodes: %v", err)
+	}
+	clusterSummary := repository.CreateClusterSummary(kubeCluster)
+	nodes := clusterSummary.NodeList
+
+	var entityDTOs []*proto.EntityDTO
+	if len(nodes) > 0 {
+		dc.resetMonitoringMetrics()
+		// The same discovery workers as the full discovery, limited to the changed nodes
+		workerCount := dc.dispatcher.Dispatch(nodes, clusterSummary)
+		entityDTOs, _, _, _ = dc.resultCollector.Collect(workerCount)
+
+		// The access commodities of the changed pods and nodes
+		affinityProcessor, err := compliance.NewAffinityProcessor(compliance.NewAffinityProcessorConfig(dc.k8sClusterScraper))
+		if err != nil {
+			glog.Errorf("Failed during process affinity rules: %s", err)
+		} else {
+			entityDTOs = affinityProcessor.ProcessAffinityRules(entityDTOs)
+		}
+		taintTolerationProcessor, err := compliance.NewTaintTolerationProcessor(dc.k8sClusterScraper)
+		if err != nil {
+			glog.Errorf("Failed during process taints and tolerations: %v", err)
+		} else {
+			taintTolerationProcessor.Process(entityDTOs)
+		}
+	}
+
+	return append(entityDTOs, buildDeletedEntityDTOs(changes)...), nil
+}
+
+// Build the entities of the deleted nodes, and of the deleted pods with their containers and applications.
+// Only the type and the id of a deleted entity are needed.
+func buildDeletedEntityDTOs(changes *clusterChanges) []*proto.EntityDTO {
+	var result []*proto.EntityDTO
+	for _, node := range changes.deletedNodes {
+		result = append(result, newDeletedEntityDTO(proto.EntityDTO_VIRTUAL_MACHINE, string(node.UID), node.Name))
+	}
+	for _, pod := range changes.deletedPods {
+		podId := string(pod.UID)
+		result = append(result, newDeletedEntityDTO(proto.EntityDTO_CONTAINER_POD, podId, util.GetPodClusterID(pod)))
+		for i := range pod.Spec.Containers {
+			container := &(pod.Spec.Containers[i])
+			containerId := util.ContainerIdFunc(podId, i)
+			result = append(result,
+				newDeletedEntityDTO(proto.EntityDTO_CONTAINER, containerId, util.ContainerNameFunc(pod, container)),
+				newDeletedEntityDTO(proto.EntityDTO_APPLICATION, util.ApplicationIdFunc(containerId),
+					util.ApplicationDisplayName(util.GetPodClusterID(pod), container.Name)))
+		}
+	}
+	return result
+}
+
+func newDeletedEntityDTO(entityType proto.EntityDTO_EntityType, id, displayName string) *proto.EntityDTO {
+	updateType := proto.UpdateType_DELETED
+	return &proto.EntityDTO{
+		EntityType:  &entityType,
+		Id:          &id,
+		DisplayName: &displayName,
+		UpdateType:  &updateType,
+	}
+}
+
+// Make the monitors list the metrics shared by the tasks of the discovery again.
+func (dc *K8sDiscoveryClient) resetMonitoringMetrics() {
+	for _, config := range dc.config.probeConfig.MonitoringConfigs {
+		if metricsServerConfig, ok := config.(*metricsserver.MetricsServerMonitorConfig); ok {
+			metricsServerConfig.ResetMetrics()
+		}
+	}
+}
+
 /*
 	The actual discovery work is done here.
 */
@@ -170,6 +330,7 @@ func (dc *K8sDiscoveryClient) discoverWithNewFramework() ([]*proto.EntityDTO, []
 	nodes := clusterSummary.NodeList
 	// Call cache cleanup
 	dc.config.probeConfig.NodeClient.CleanupCache(nodes)
+	dc.resetMonitoringMetrics()
 
 	// Discover pods and create DTOs for nodes, pods, containers, application.
 	// Collect the kubePod, quota metrics, groups from all the discovery workers
@@ -195,6 +356,16 @@ func (dc *K8sDiscoveryClient) discoverWithNewFramework() ([]*proto.EntityDTO, []
 	// All the DTOs
 	entityDTOs = append(entityDTOs, quotaDtos...)
 
+	glog.V(2).Infof("Begin to generate volume EntityDTOs.")
+	volumeDiscWorker := worker.Newk8sVolumeDiscoveryWorker(clusterSummary)
+	volumeDtos, err := volumeDiscWorker.Do(entityDTOs)
+	if err != nil {
+		glog.Errorf("Failed to discover volumes from current Kubernetes cluster: %s", err)
+	} else {
+		glog.V(2).Infof("There are %d volume entityDTOs.", len(volumeDtos))
+		entityDTOs = append(entityDTOs, volumeDtos...)
+	}
+
 	glog.V(2).Infof("There are totally %d entityDTOs.", len(entityDTOs))
 
 	// affinity process
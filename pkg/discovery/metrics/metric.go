@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"strings"
+
 	v1 "k8s.io/api/core/v1"
 )
 
@@ -16,6 +18,7 @@ const (
 	ContainerType   DiscoveredEntityType = "Container"
 	ApplicationType DiscoveredEntityType = "Application"
 	ServiceType     DiscoveredEntityType = "Service"
+	VolumeType      DiscoveredEntityType = "Volume"
 )
 
 const (
@@ -30,12 +33,27 @@ const (
 	CPUProvisioned     ResourceType = "CPUProvisioned"
 	MemoryProvisioned  ResourceType = "MemoryProvisioned"
 	Transaction        ResourceType = "Transaction"
+	NetworkThroughput  ResourceType = "NetworkThroughput"
+	EphemeralStorage   ResourceType = "EphemeralStorage"
+	VolumeStorage      ResourceType = "VolumeStorage"
+	NumPods            ResourceType = "NumPods"
+	// The prefix of the resource types of the extended resources, e.g., the GPUs and the hugepages
+	ExtendedResource ResourceType = "ExtendedResource"
 
 	Access       ResourceType = "Access"
 	Cluster      ResourceType = "Cluster"
 	CpuFrequency ResourceType = "CpuFrequency"
 	Owner        ResourceType = "Owner"
 	OwnerType    ResourceType = "OwnerType"
+	// The names of the PodDisruptionBudgets protecting a pod
+	DisruptionBudgets ResourceType = "DisruptionBudgets"
+	// The HorizontalPodAutoscaler scaling the controller of a pod
+	HorizontalPodAutoscaler ResourceType = "HorizontalPodAutoscaler"
+
+	// The age in seconds of the kubelet data of a node, and the hits and misses of its kubelet cache
+	KubeletCacheAge    ResourceType = "KubeletCacheAge"
+	KubeletCacheHits   ResourceType = "KubeletCacheHits"
+	KubeletCacheMisses ResourceType = "KubeletCacheMisses"
 )
 
 var (
@@ -118,6 +136,16 @@ func IsComputeType(resourceType ResourceType) bool {
 	return false
 }
 
+// Returns the resource type of the extended resource with the given name, e.g., nvidia.com/gpu or hugepages-2Mi
+func ExtendedResourceType(name v1.ResourceName) ResourceType {
+	return ExtendedResource + "-" + ResourceType(name)
+}
+
+// Returns true if the given resource type argument is the resource type of an extended resource
+func IsExtendedResourceType(resourceType ResourceType) bool {
+	return strings.HasPrefix(string(resourceType), string(ExtendedResource)+"-")
+}
+
 func IsCPUType(resourceType ResourceType) bool {
 	if _, ok := CPUResources[resourceType]; ok {
 		return true
@@ -131,6 +159,7 @@ const (
 	Capacity    MetricProp = "Capacity"
 	Used        MetricProp = "Used"
 	Reservation MetricProp = "Reservation"
+	Peak        MetricProp = "Peak"
 )
 
 type Metric interface {
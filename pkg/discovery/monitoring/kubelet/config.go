@@ -2,11 +2,18 @@ package kubelet
 
 import (
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
 )
 
 type KubeletMonitorConfig struct {
 	kubeletClient *kubeclient.KubeletClient
+	// Optional sampler of the usage between discoveries, shared by all the monitors
+	sampler *KubeletSampler
+	// Network counters of the previous discovery, shared by all the monitors
+	networkTracker *networkTracker
+	// Network throughput capacity of the nodes in KB/s
+	nodeNetworkCapacityKBps float64
 }
 
 // Implement MonitoringWorkerConfig interface.
@@ -19,6 +26,21 @@ func (c KubeletMonitorConfig) GetMonitoringSource() types.MonitoringSource {
 
 func NewKubeletMonitorConfig(kclient *kubeclient.KubeletClient) *KubeletMonitorConfig {
 	return &KubeletMonitorConfig{
-		kubeletClient: kclient,
+		kubeletClient:           kclient,
+		networkTracker:          newNetworkTracker(),
+		nodeNetworkCapacityKBps: defaultNodeNetworkCapacityKBps,
 	}
 }
+
+func (c *KubeletMonitorConfig) WithSampler(sampler *KubeletSampler) *KubeletMonitorConfig {
+	c.sampler = sampler
+	return c
+}
+
+// Set the network throughput capacity of the nodes in Mbit/s. The default capacity is kept if it is not positive.
+func (c *KubeletMonitorConfig) WithNodeNetworkCapacity(mbps int) *KubeletMonitorConfig {
+	if mbps > 0 {
+		c.nodeNetworkCapacityKBps = float64(mbps) * 1e6 / 8 / util.KilobytesToBytes
+	}
+	return c
+}
@@ -3,6 +3,7 @@ package kubelet
 import (
 	"errors"
 	"sync"
+	"time"
 
 	api "k8s.io/api/core/v1"
 	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
@@ -18,12 +19,28 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
 )
 
+const (
+	// The kubelet doesn't report the bandwidth of the network interfaces,
+	// so the network throughput capacity of a node is assumed to be 10 Gbit/s, in KB/s, unless configured.
+	defaultNodeNetworkCapacityKBps float64 = 10 * 1e9 / 8 / util.KilobytesToBytes
+
+	// Network counters not updated for this long belong to entities which are gone
+	networkCountersTTL = time.Hour
+)
+
 // KubeletMonitor is a resource monitoring worker.
 type KubeletMonitor struct {
 	nodeList []*api.Node
 
 	kubeletClient *kubeclient.KubeletClient
 
+	// Usage samples collected between discoveries, nil if sampling is disabled
+	sampler *KubeletSampler
+
+	networkTracker *networkTracker
+	// Network throughput capacity of the nodes in KB/s
+	nodeNetworkCapacityKBps float64
+
 	metricSink *metrics.EntityMetricSink
 
 	stopCh chan struct{}
@@ -32,10 +49,17 @@ type KubeletMonitor struct {
 }
 
 func NewKubeletMonitor(config *KubeletMonitorConfig) (*KubeletMonitor, error) {
+	tracker := config.networkTracker
+	if tracker == nil {
+		tracker = newNetworkTracker()
+	}
 	return &KubeletMonitor{
-		kubeletClient: config.kubeletClient,
-		metricSink:    metrics.NewEntityMetricSink(),
-		stopCh:        make(chan struct{}, 1),
+		kubeletClient:           config.kubeletClient,
+		sampler:                 config.sampler,
+		networkTracker:          tracker,
+		nodeNetworkCapacityKBps: config.nodeNetworkCapacityKBps,
+		metricSink:              metrics.NewEntityMetricSink(),
+		stopCh:                  make(chan struct{}, 1),
 	}, nil
 }
 
@@ -76,6 +100,7 @@ func (m *KubeletMonitor) RetrieveResourceStat() error {
 	if m.nodeList == nil || len(m.nodeList) == 0 {
 		return errors.New("Invalid nodeList or empty nodeList. Finish Immediately...")
 	}
+	m.networkTracker.expire(time.Now().Add(-networkCountersTTL))
 	m.wg.Add(len(m.nodeList))
 
 	for _, node := range m.nodeList {
@@ -95,6 +120,16 @@ func (m *KubeletMonitor) RetrieveResourceStat() error {
 	return nil
 }
 
+// Complete the scrape of the node, and record the age of its kubelet data, and the hits and misses of its kubelet cache.
+func (m *KubeletMonitor) addCacheStatusMetrics(node *api.Node, ip string) {
+	status := m.kubeletClient.CompleteScrape(ip)
+	key := util.NodeKeyFunc(node)
+	m.metricSink.AddNewMetricEntries(
+		metrics.NewEntityStateMetric(metrics.NodeType, key, metrics.KubeletCacheAge, status.Age.Seconds()),
+		metrics.NewEntityStateMetric(metrics.NodeType, key, metrics.KubeletCacheHits, float64(status.Hits)),
+		metrics.NewEntityStateMetric(metrics.NodeType, key, metrics.KubeletCacheMisses, float64(status.Misses)))
+}
+
 // Retrieve resource metrics for the given node.
 func (m *KubeletMonitor) scrapeKubelet(node *api.Node) {
 	kc := m.kubeletClient
@@ -104,6 +139,11 @@ func (m *KubeletMonitor) scrapeKubelet(node *api.Node) {
 		return
 	}
 
+	if m.sampler != nil {
+		m.sampler.trackNode(node, ip)
+	}
+	defer m.addCacheStatusMetrics(node, ip)
+
 	// get machine information
 	machineInfo, err := kc.GetMachineInfo(ip)
 	if err != nil {
@@ -111,6 +151,8 @@ func (m *KubeletMonitor) scrapeKubelet(node *api.Node) {
 		return
 	}
 	glog.V(4).Infof("Machine info of %s is %++v", node.Name, machineInfo)
+	// Record what the discovery used, either received from the kubelet or cached, for the discovery snapshot
+	kc.RecordMachineInfo(ip, machineInfo)
 	m.parseNodeInfo(node, machineInfo)
 
 	// get summary information about the given node and the pods running on it.
@@ -119,6 +161,7 @@ func (m *KubeletMonitor) scrapeKubelet(node *api.Node) {
 		glog.Errorf("Failed to get resource metrics summary from %s: %s", node.Name, err)
 		return
 	}
+	kc.RecordSummary(ip, summary)
 	// Indicate that we have used the cache last time we've asked for some of the info.
 	if kc.HasCacheBeenUsed(ip) {
 		cacheUsedMetric := metrics.NewEntityStateMetric(metrics.NodeType, util.NodeKeyFunc(node), "NodeCacheUsed", 1)
@@ -127,6 +170,8 @@ func (m *KubeletMonitor) scrapeKubelet(node *api.Node) {
 
 	m.parseNodeStats(summary.Node)
 	m.parsePodStats(summary.Pods)
+	m.parseNetworkAndStorageStats(summary)
+	m.parseVolumeStats(summary.Pods)
 
 	glog.V(4).Infof("Finished scrape node %s.", node.Name)
 }
@@ -202,8 +247,119 @@ func (m *KubeletMonitor) parseContainerStats(pod *stats.PodStats) (float64, floa
 	return totalUsedCPU, totalUsedMem
 }
 
+// Parse the network throughput and the ephemeral storage usage of the node, its pods and containers.
+// The pods sell these resources to their containers with the capacity of the node.
+func (m *KubeletMonitor) parseNetworkAndStorageStats(summary *stats.Summary) {
+	nodeStats := summary.Node
+	nodeKey := util.NodeStatsKeyFunc(nodeStats)
+
+	if throughput, ok := m.networkTracker.throughput(metrics.NodeType, nodeKey, nodeStats.Network); ok {
+		glog.V(4).Infof("Network throughput of node %s is %.3f KB/s", nodeStats.NodeName, throughput)
+		m.genResourceMetrics(metrics.NodeType, nodeKey, metrics.NetworkThroughput, throughput, m.nodeNetworkCapacityKBps)
+	}
+	storageCapacity := float64(0.0)
+	if nodeStats.Fs != nil && nodeStats.Fs.UsedBytes != nil && nodeStats.Fs.CapacityBytes != nil {
+		storageCapacity = float64(*nodeStats.Fs.CapacityBytes) / util.MegabytesToBytes
+		storageUsed := float64(*nodeStats.Fs.UsedBytes) / util.MegabytesToBytes
+		glog.V(4).Infof("Ephemeral storage usage of node %s is %.3f/%.3f MB", nodeStats.NodeName, storageUsed, storageCapacity)
+		m.genResourceMetrics(metrics.NodeType, nodeKey, metrics.EphemeralStorage, storageUsed, storageCapacity)
+	}
+
+	for i := range summary.Pods {
+		pod := &summary.Pods[i]
+		podMId := util.PodMetricId(&(pod.PodRef))
+
+		podThroughput, hasThroughput := m.networkTracker.throughput(metrics.PodType, podMId, pod.Network)
+		if hasThroughput {
+			glog.V(4).Infof("Network throughput of pod %s is %.3f KB/s", podMId, podThroughput)
+			m.genResourceMetrics(metrics.PodType, podMId, metrics.NetworkThroughput, podThroughput,
+				m.nodeNetworkCapacityKBps)
+		}
+
+		totalContainerStorage := float64(0.0)
+		for j := range pod.Containers {
+			container := &pod.Containers[j]
+			containerMId := util.ContainerMetricId(podMId, container.Name)
+			if hasThroughput {
+				// The kubelet reports no network stats per container, as the containers share the network of
+				// the pod. The throughput of a container is only an estimate: an even share of the pod throughput.
+				m.genResourceMetrics(metrics.ContainerType, containerMId, metrics.NetworkThroughput,
+					podThroughput/float64(len(pod.Containers)), 0)
+			}
+
+			// The ephemeral storage of a container is its writable layer and its logs
+			rootfsUsed, hasRootfs := fsUsedMegaBytes(container.Rootfs)
+			logsUsed, hasLogs := fsUsedMegaBytes(container.Logs)
+			if hasRootfs || hasLogs {
+				glog.V(4).Infof("container[%s-%s] rootfs/logs usage: %.3f, %.3f MB",
+					pod.PodRef.Name, container.Name, rootfsUsed, logsUsed)
+				m.genResourceMetrics(metrics.ContainerType, containerMId, metrics.EphemeralStorage, rootfsUsed+logsUsed, 0)
+				totalContainerStorage += rootfsUsed + logsUsed
+			}
+		}
+
+		if storageCapacity == 0 {
+			continue
+		}
+		// The ephemeral storage of the pod also includes its emptyDir volumes when reported
+		podStorage, hasPodStorage := fsUsedMegaBytes(pod.EphemeralStorage)
+		if !hasPodStorage {
+			podStorage = totalContainerStorage
+		}
+		glog.V(4).Infof("Ephemeral storage usage of pod %s is %.3f MB", podMId, podStorage)
+		m.genResourceMetrics(metrics.PodType, podMId, metrics.EphemeralStorage, podStorage, storageCapacity)
+	}
+}
+
+// Parse the usage of the persistent volumes mounted by the pods. The same volume may be reported by several pods.
+func (m *KubeletMonitor) parseVolumeStats(podStats []stats.PodStats) {
+	for i := range podStats {
+		pod := &podStats[i]
+		for j := range pod.VolumeStats {
+			volume := &pod.VolumeStats[j]
+			if volume.PVCRef == nil || volume.UsedBytes == nil {
+				continue
+			}
+			key := util.BuildK8sEntityClusterID(volume.PVCRef.Namespace, volume.PVCRef.Name)
+			used := float64(*volume.UsedBytes) / util.MegabytesToBytes
+			glog.V(4).Infof("Usage of volume %s mounted by pod %s is %.3f MB", key, pod.PodRef.Name, used)
+			m.genResourceMetrics(metrics.VolumeType, key, metrics.VolumeStorage, used, 0)
+		}
+	}
+}
+
+func fsUsedMegaBytes(fsStats *stats.FsStats) (float64, bool) {
+	if fsStats == nil || fsStats.UsedBytes == nil {
+		return 0, false
+	}
+	return float64(*fsStats.UsedBytes) / util.MegabytesToBytes, true
+}
+
+// Generate the used metric, and the capacity metric if the capacity is set.
+func (m *KubeletMonitor) genResourceMetrics(etype metrics.DiscoveredEntityType, key string,
+	rType metrics.ResourceType, used, capacity float64) {
+	m.metricSink.AddNewMetricEntries(metrics.NewEntityResourceMetric(etype, key, rType, metrics.Used, used))
+	if capacity > 0 {
+		m.metricSink.AddNewMetricEntries(metrics.NewEntityResourceMetric(etype, key, rType, metrics.Capacity, capacity))
+	}
+}
+
+// Generate the used metrics from the current values. If the usage is sampled between discoveries,
+// the used metrics are the average of the samples and the peak metrics are also generated.
 func (m *KubeletMonitor) genUsedMetrics(etype metrics.DiscoveredEntityType, key string, cpu, memory float64) {
-	cpuMetric := metrics.NewEntityResourceMetric(etype, key, metrics.CPU, metrics.Used, cpu)
-	memMetric := metrics.NewEntityResourceMetric(etype, key, metrics.Memory, metrics.Used, memory)
-	m.metricSink.AddNewMetricEntries(cpuMetric, memMetric)
+	if m.sampler == nil {
+		cpuMetric := metrics.NewEntityResourceMetric(etype, key, metrics.CPU, metrics.Used, cpu)
+		memMetric := metrics.NewEntityResourceMetric(etype, key, metrics.Memory, metrics.Used, memory)
+		m.metricSink.AddNewMetricEntries(cpuMetric, memMetric)
+		return
+	}
+	cpuUsed, cpuPeak := m.sampler.aggregate(etype, key, metrics.CPU, cpu)
+	memUsed, memPeak := m.sampler.aggregate(etype, key, metrics.Memory, memory)
+	glog.V(4).Infof("%s %s sampled cpu used/peak: %.3f/%.3f, memory used/peak: %.3f/%.3f",
+		etype, key, cpuUsed, cpuPeak, memUsed, memPeak)
+	m.metricSink.AddNewMetricEntries(
+		metrics.NewEntityResourceMetric(etype, key, metrics.CPU, metrics.Used, cpuUsed),
+		metrics.NewEntityResourceMetric(etype, key, metrics.CPU, metrics.Peak, cpuPeak),
+		metrics.NewEntityResourceMetric(etype, key, metrics.Memory, metrics.Used, memUsed),
+		metrics.NewEntityResourceMetric(etype, key, metrics.Memory, metrics.Peak, memPeak))
 }
@@ -8,6 +8,7 @@ import (
 	"math"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
@@ -207,3 +208,112 @@ func TestParseStats(t *testing.T) {
 		}
 	}
 }
+
+func createFsStats(used, capacity uint64) *stats.FsStats {
+	return &stats.FsStats{
+		UsedBytes:     &used,
+		CapacityBytes: &capacity,
+	}
+}
+
+func checkResourceMetric(t *testing.T, sink *metrics.EntityMetricSink, etype metrics.DiscoveredEntityType, key string,
+	rType metrics.ResourceType, prop metrics.MetricProp, expected float64) {
+	mid := metrics.GenerateEntityResourceMetricUID(etype, key, rType, prop)
+	metric, err := sink.GetMetric(mid)
+	if err != nil {
+		t.Errorf("Failed to get metric %s: %v", mid, err)
+		return
+	}
+	if value := metric.GetValue().(float64); math.Abs(value-expected) > myzero {
+		t.Errorf("Metric %s check failed: %v Vs. %v", mid, expected, value)
+	}
+}
+
+func TestParseNetworkAndStorageStats(t *testing.T) {
+	klet, err := NewKubeletMonitor(NewKubeletMonitorConfig(nil))
+	if err != nil {
+		t.Fatalf("Failed to create kubeletMonitor: %v", err)
+	}
+
+	start := time.Now()
+	podstat := createPodStat("pod1")
+	podstat.Containers[0].Rootfs = createFsStats(10*1024*1024, 0)
+	podstat.Containers[0].Logs = createFsStats(2*1024*1024, 0)
+	podstat.Containers[1].Rootfs = createFsStats(4*1024*1024, 0)
+	summary := &stats.Summary{
+		Node: stats.NodeStats{
+			NodeName: "node1",
+			Network:  createNetworkStats(start, 0, 0),
+			Fs:       createFsStats(1024*1024*1024, 4*1024*1024*1024),
+		},
+		Pods: []stats.PodStats{*podstat},
+	}
+	summary.Pods[0].Network = createNetworkStats(start, 0, 0)
+
+	// The first scrape only provides the storage
+	klet.parseNetworkAndStorageStats(summary)
+	checkResourceMetric(t, klet.metricSink, metrics.NodeType, "node1", metrics.EphemeralStorage, metrics.Used, 1024)
+	checkResourceMetric(t, klet.metricSink, metrics.NodeType, "node1", metrics.EphemeralStorage, metrics.Capacity, 4096)
+	nodeThroughput := metrics.GenerateEntityResourceMetricUID(metrics.NodeType, "node1", metrics.NetworkThroughput, metrics.Used)
+	if _, err := klet.metricSink.GetMetric(nodeThroughput); err == nil {
+		t.Errorf("Metric %s should not be generated on the first scrape", nodeThroughput)
+	}
+
+	// Pod ephemeral storage is the sum of its containers when not reported
+	podMId := "space1/pod1"
+	checkResourceMetric(t, klet.metricSink, metrics.PodType, podMId, metrics.EphemeralStorage, metrics.Used, 16)
+	checkResourceMetric(t, klet.metricSink, metrics.PodType, podMId, metrics.EphemeralStorage, metrics.Capacity, 4096)
+	container1MId := util.ContainerMetricId(podMId, "container1")
+	checkResourceMetric(t, klet.metricSink, metrics.ContainerType, container1MId, metrics.EphemeralStorage, metrics.Used, 12)
+
+	// The second scrape provides the throughput, the containers are estimated an even share of the pod throughput
+	klet.reset()
+	summary.Node.Network = createNetworkStats(start.Add(10*time.Second), 100*1024, 100*1024)
+	summary.Pods[0].Network = createNetworkStats(start.Add(10*time.Second), 20*1024, 20*1024)
+	summary.Pods[0].EphemeralStorage = createFsStats(20*1024*1024, 0)
+	klet.parseNetworkAndStorageStats(summary)
+	checkResourceMetric(t, klet.metricSink, metrics.NodeType, "node1", metrics.NetworkThroughput, metrics.Used, 20)
+	checkResourceMetric(t, klet.metricSink, metrics.NodeType, "node1", metrics.NetworkThroughput, metrics.Capacity, defaultNodeNetworkCapacityKBps)
+	checkResourceMetric(t, klet.metricSink, metrics.PodType, podMId, metrics.NetworkThroughput, metrics.Used, 4)
+	checkResourceMetric(t, klet.metricSink, metrics.PodType, podMId, metrics.EphemeralStorage, metrics.Used, 20)
+	checkResourceMetric(t, klet.metricSink, metrics.ContainerType, container1MId, metrics.NetworkThroughput, metrics.Used,
+		4/float64(len(podstat.Containers)))
+
+	// The configured network capacity of the nodes
+	klet, err = NewKubeletMonitor(NewKubeletMonitorConfig(nil).WithNodeNetworkCapacity(1000))
+	if err != nil {
+		t.Fatalf("Failed to create kubeletMonitor: %v", err)
+	}
+	summary.Node.Network = createNetworkStats(start, 0, 0)
+	klet.parseNetworkAndStorageStats(summary)
+	summary.Node.Network = createNetworkStats(start.Add(10*time.Second), 100*1024, 100*1024)
+	klet.parseNetworkAndStorageStats(summary)
+	checkResourceMetric(t, klet.metricSink, metrics.NodeType, "node1", metrics.NetworkThroughput, metrics.Capacity, 1e9/8/1024)
+}
+
+func TestParseVolumeStats(t *testing.T) {
+	klet, err := NewKubeletMonitor(NewKubeletMonitorConfig(nil))
+	if err != nil {
+		t.Fatalf("Failed to create kubeletMonitor: %v", err)
+	}
+
+	podstat := createPodStat("pod1")
+	podstat.VolumeStats = []stats.VolumeStats{
+		{
+			Name:    "data",
+			PVCRef:  &stats.PVCReference{Name: "claim1", Namespace: "space1"},
+			FsStats: *createFsStats(300*1024*1024, 1024*1024*1024),
+		},
+		{
+			Name:    "tmp",
+			FsStats: *createFsStats(1024*1024, 1024*1024*1024),
+		},
+	}
+	klet.parseVolumeStats([]stats.PodStats{*podstat})
+
+	checkResourceMetric(t, klet.metricSink, metrics.VolumeType, "space1/claim1", metrics.VolumeStorage, metrics.Used, 300)
+	tmpVolume := metrics.GenerateEntityResourceMetricUID(metrics.VolumeType, "space1/tmp", metrics.VolumeStorage, metrics.Used)
+	if _, err := klet.metricSink.GetMetric(tmpVolume); err == nil {
+		t.Errorf("Metric %s should not be generated for a volume without claim", tmpVolume)
+	}
+}
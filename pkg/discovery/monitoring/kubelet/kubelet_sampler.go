@@ -0,0 +1,271 @@
+package kubelet
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	api "k8s.io/api/core/v1"
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
+)
+
+// ringBuffer keeps the latest usage samples of one resource of an entity.
+type ringBuffer struct {
+	values []float64
+	next   int
+	size   int
+}
+
+func newRingBuffer(capacity int) *ringBuffer {
+	return &ringBuffer{
+		values: make([]float64, capacity),
+	}
+}
+
+func (r *ringBuffer) add(value float64) {
+	r.values[r.next] = value
+	r.next = (r.next + 1) % len(r.values)
+	if r.size < len(r.values) {
+		r.size++
+	}
+}
+
+// Return a copy of the samples currently in the buffer, in no particular order.
+func (r *ringBuffer) samples() []float64 {
+	if r.size < len(r.values) {
+		return append([]float64{}, r.values[:r.size]...)
+	}
+	return append([]float64{}, r.values...)
+}
+
+// The usage samples of an entity.
+type entitySamples struct {
+	cpu         *ringBuffer
+	memory      *ringBuffer
+	lastSampled time.Time
+}
+
+// KubeletSampler polls the stats summary of the nodes between two discoveries, so that the usage
+// reported by the KubeletMonitor is the average over the discovery interval instead of a single sample,
+// and the peak is the configured percentile of the samples.
+type KubeletSampler struct {
+	kubeletClient *kubeclient.KubeletClient
+
+	// Interval between two samples
+	interval time.Duration
+	// Number of samples kept for each entity
+	capacity int
+	// Percentile of the samples reported as the peak, between 0 and 100
+	percentile float64
+
+	// Nodes to sample, by node name. They are tracked by the KubeletMonitor during each discovery.
+	nodes     map[string]*trackedNode
+	samples   map[string]*entitySamples
+	running   bool
+	dataMutex sync.Mutex
+}
+
+type trackedNode struct {
+	ip          string
+	lastTracked time.Time
+}
+
+// Create a new KubeletSampler. The number of samples kept for each entity covers one discovery interval.
+func NewKubeletSampler(kubeletClient *kubeclient.KubeletClient, interval, discoveryInterval time.Duration,
+	percentile float64) *KubeletSampler {
+	capacity := 1
+	if interval > 0 && discoveryInterval > interval {
+		capacity = int(discoveryInterval / interval)
+	}
+	return &KubeletSampler{
+		kubeletClient: kubeletClient,
+		interval:      interval,
+		capacity:      capacity,
+		percentile:    math.Min(math.Max(percentile, 0), 100),
+		nodes:         make(map[string]*trackedNode),
+		samples:       make(map[string]*entitySamples),
+	}
+}
+
+// Run samples the tracked nodes at every interval until the stop channel is closed.
+// It returns at once if the sampler is already running.
+func (s *KubeletSampler) Run(stopCh <-chan struct{}) {
+	if !s.setRunning(true) {
+		glog.Warningf("The kubelet usage sampler is already running.")
+		return
+	}
+	defer s.setRunning(false)
+	glog.V(2).Infof("Start sampling kubelet usage every %v, keeping %d samples.", s.interval, s.capacity)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			glog.V(2).Infof("Stop sampling kubelet usage.")
+			return
+		case <-ticker.C:
+			s.sampleNodes()
+		}
+	}
+}
+
+// IsRunning checks whether the sampler is sampling the tracked nodes.
+func (s *KubeletSampler) IsRunning() bool {
+	s.dataMutex.Lock()
+	defer s.dataMutex.Unlock()
+	return s.running
+}
+
+// Set whether the sampler is running. It returns false if the sampler is to run but it is already running.
+func (s *KubeletSampler) setRunning(running bool) bool {
+	s.dataMutex.Lock()
+	defer s.dataMutex.Unlock()
+	if running && s.running {
+		return false
+	}
+	s.running = running
+	return true
+}
+
+// Track the node so that it is sampled until it is no longer discovered.
+func (s *KubeletSampler) trackNode(node *api.Node, ip string) {
+	s.dataMutex.Lock()
+	defer s.dataMutex.Unlock()
+	s.nodes[node.Name] = &trackedNode{ip: ip, lastTracked: time.Now()}
+}
+
+func (s *KubeletSampler) sampleNodes() {
+	now := time.Now()
+	window := s.interval * time.Duration(s.capacity)
+
+	s.dataMutex.Lock()
+	var ips []string
+	for name, node := range s.nodes {
+		// The node has not been seen by the last two discoveries
+		if now.Sub(node.lastTracked) > 2*window+s.interval {
+			glog.V(3).Infof("Stop sampling node %s as it is no longer discovered.", name)
+			delete(s.nodes, name)
+			continue
+		}
+		ips = append(ips, node.ip)
+	}
+	s.dataMutex.Unlock()
+
+	for _, ip := range ips {
+		// Never go through the cache of the client, which is only for the discovery
+		summary, err := s.kubeletClient.SampleSummary(ip)
+		if err != nil {
+			glog.V(3).Infof("Skip sampling %s: %v", ip, err)
+			continue
+		}
+		s.addSummary(summary, now)
+	}
+
+	// Drop the entities without any recent sample, e.g., deleted pods
+	s.dataMutex.Lock()
+	defer s.dataMutex.Unlock()
+	for key, entity := range s.samples {
+		if now.Sub(entity.lastSampled) > window+s.interval {
+			delete(s.samples, key)
+		}
+	}
+}
+
+// Add the cpu and memory usage of the node, its pods, containers and applications from the given summary.
+func (s *KubeletSampler) addSummary(summary *stats.Summary, now time.Time) {
+	nodeStats := summary.Node
+	if nodeStats.CPU != nil && nodeStats.CPU.UsageNanoCores != nil &&
+		nodeStats.Memory != nil && nodeStats.Memory.WorkingSetBytes != nil {
+		cpuUsageCore := float64(*nodeStats.CPU.UsageNanoCores) / util.NanoToUnit
+		memoryWorkingSetKiloBytes := float64(*nodeStats.Memory.WorkingSetBytes) / util.KilobytesToBytes
+		s.addSample(metrics.NodeType, util.NodeStatsKeyFunc(nodeStats), cpuUsageCore, memoryWorkingSetKiloBytes, now)
+	}
+
+	for i := range summary.Pods {
+		pod := &summary.Pods[i]
+		podMId := util.PodMetricId(&(pod.PodRef))
+		totalUsedCPU := float64(0.0)
+		totalUsedMem := float64(0.0)
+		for j := range pod.Containers {
+			container := &pod.Containers[j]
+			if container.CPU == nil || container.CPU.UsageNanoCores == nil {
+				continue
+			}
+			if container.Memory == nil || container.Memory.WorkingSetBytes == nil {
+				continue
+			}
+			cpuUsed := float64(*(container.CPU.UsageNanoCores)) / util.NanoToUnit
+			memUsed := float64(*(container.Memory.WorkingSetBytes)) / util.KilobytesToBytes
+			totalUsedCPU += cpuUsed
+			totalUsedMem += memUsed
+
+			containerMId := util.ContainerMetricId(podMId, container.Name)
+			s.addSample(metrics.ContainerType, containerMId, cpuUsed, memUsed, now)
+			s.addSample(metrics.ApplicationType, util.ApplicationMetricId(containerMId), cpuUsed, memUsed, now)
+		}
+		s.addSample(metrics.PodType, podMId, totalUsedCPU, totalUsedMem, now)
+	}
+}
+
+func (s *KubeletSampler) addSample(etype metrics.DiscoveredEntityType, key string, cpu, memory float64, now time.Time) {
+	s.dataMutex.Lock()
+	defer s.dataMutex.Unlock()
+	samplesKey := string(etype) + "-" + key
+	entity, exists := s.samples[samplesKey]
+	if !exists {
+		entity = &entitySamples{
+			cpu:    newRingBuffer(s.capacity),
+			memory: newRingBuffer(s.capacity),
+		}
+		s.samples[samplesKey] = entity
+	}
+	entity.cpu.add(cpu)
+	entity.memory.add(memory)
+	entity.lastSampled = now
+}
+
+// Aggregate the samples of the given entity and resource with the current value collected by the discovery.
+// Returns the average as the used value and the configured percentile as the peak value.
+func (s *KubeletSampler) aggregate(etype metrics.DiscoveredEntityType, key string, rType metrics.ResourceType,
+	current float64) (float64, float64) {
+	s.dataMutex.Lock()
+	var values []float64
+	if entity, exists := s.samples[string(etype)+"-"+key]; exists {
+		switch rType {
+		case metrics.CPU:
+			values = entity.cpu.samples()
+		case metrics.Memory:
+			values = entity.memory.samples()
+		}
+	}
+	s.dataMutex.Unlock()
+
+	values = append(values, current)
+	return average(values), percentile(values, s.percentile)
+}
+
+func average(values []float64) float64 {
+	sum := float64(0.0)
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// Nearest-rank percentile of the values.
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
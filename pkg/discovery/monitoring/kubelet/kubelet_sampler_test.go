@@ -0,0 +1,123 @@
+package kubelet
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+func TestRingBuffer(t *testing.T) {
+	r := newRingBuffer(3)
+	r.add(1)
+	r.add(2)
+	if got := r.samples(); len(got) != 2 {
+		t.Errorf("Expected 2 samples, got %v", got)
+	}
+	r.add(3)
+	r.add(4)
+	got := r.samples()
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 samples, got %v", got)
+	}
+	sum := got[0] + got[1] + got[2]
+	if sum != 9 {
+		t.Errorf("Expected the oldest sample to be overwritten, got %v", got)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{5, 1, 4, 2, 3, 10, 9, 8, 7, 6}
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{100, 10},
+		{95, 10},
+		{90, 9},
+		{50, 5},
+		{0, 1},
+	}
+	for _, tt := range tests {
+		if got := percentile(values, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if average(values) != 5.5 {
+		t.Errorf("average = %v, want 5.5", average(values))
+	}
+}
+
+func TestNewKubeletSamplerCapacity(t *testing.T) {
+	s := NewKubeletSampler(nil, time.Minute, 10*time.Minute, 95)
+	if s.capacity != 10 {
+		t.Errorf("capacity = %d, want 10", s.capacity)
+	}
+	s = NewKubeletSampler(nil, time.Hour, 10*time.Minute, 120)
+	if s.capacity != 1 {
+		t.Errorf("capacity = %d, want 1", s.capacity)
+	}
+	if s.percentile != 100 {
+		t.Errorf("percentile = %v, want 100", s.percentile)
+	}
+}
+
+func TestSampledUsedAndPeakMetrics(t *testing.T) {
+	sampler := NewKubeletSampler(nil, time.Minute, 10*time.Minute, 100)
+	conf := NewKubeletMonitorConfig(nil).WithSampler(sampler)
+	klet, err := NewKubeletMonitor(conf)
+	if err != nil {
+		t.Fatalf("Failed to create kubeletMonitor: %v", err)
+	}
+
+	// Two samples between discoveries: 100m and 300m for container1, 0 for container2
+	now := time.Now()
+	for _, cpu := range []int{100, 300} {
+		summary := &stats.Summary{
+			Pods: []stats.PodStats{{
+				PodRef: stats.PodReference{Namespace: "space1", Name: "pod1"},
+				Containers: []stats.ContainerStats{
+					createContainerStat("container1", cpu*1e6, 1024),
+					createContainerStat("container2", 0, 1024),
+				},
+			}},
+		}
+		sampler.addSummary(summary, now)
+	}
+
+	// The discovery collects 200m for container1
+	pod := stats.PodStats{
+		PodRef: stats.PodReference{Namespace: "space1", Name: "pod1"},
+		Containers: []stats.ContainerStats{
+			createContainerStat("container1", 200*1e6, 1024),
+			createContainerStat("container2", 0, 1024),
+		},
+	}
+	klet.parsePodStats([]stats.PodStats{pod})
+
+	podMId := util.PodMetricId(&pod.PodRef)
+	containerMId := util.ContainerMetricId(podMId, "container1")
+	expected := map[string]float64{
+		metrics.GenerateEntityResourceMetricUID(metrics.PodType, podMId, metrics.CPU, metrics.Used):                                         0.2,
+		metrics.GenerateEntityResourceMetricUID(metrics.PodType, podMId, metrics.CPU, metrics.Peak):                                         0.3,
+		metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, containerMId, metrics.CPU, metrics.Used):                             0.2,
+		metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, containerMId, metrics.CPU, metrics.Peak):                             0.3,
+		metrics.GenerateEntityResourceMetricUID(metrics.ApplicationType, util.ApplicationMetricId(containerMId), metrics.CPU, metrics.Peak): 0.3,
+		metrics.GenerateEntityResourceMetricUID(metrics.PodType, podMId, metrics.Memory, metrics.Used):                                      2,
+		metrics.GenerateEntityResourceMetricUID(metrics.PodType, podMId, metrics.Memory, metrics.Peak):                                      2,
+	}
+	for uid, want := range expected {
+		m, err := klet.metricSink.GetMetric(uid)
+		if err != nil {
+			t.Errorf("Failed to get metric %s: %v", uid, err)
+			continue
+		}
+		if got := m.GetValue().(float64); math.Abs(got-want) > myzero {
+			t.Errorf("Metric %s = %v, want %v", uid, got, want)
+		}
+	}
+}
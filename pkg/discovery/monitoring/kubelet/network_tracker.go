@@ -0,0 +1,89 @@
+package kubelet
+
+import (
+	"sync"
+	"time"
+
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+// The network counters of an entity at the time of the last scrape.
+type networkCounters struct {
+	bytes uint64
+	time  time.Time
+}
+
+// networkTracker converts the cumulative rx/tx byte counters reported by the kubelet into a throughput,
+// by keeping the counters of the previous discovery. It is shared by all the kubelet monitors.
+type networkTracker struct {
+	counters  map[string]*networkCounters
+	dataMutex sync.Mutex
+}
+
+func newNetworkTracker() *networkTracker {
+	return &networkTracker{
+		counters: make(map[string]*networkCounters),
+	}
+}
+
+// Return the network throughput in KB/s of the given entity since its previous scrape.
+// The second return value is false when there is no previous scrape to compare with,
+// or when the counters have been reset, e.g., after the pod has been restarted.
+func (t *networkTracker) throughput(etype metrics.DiscoveredEntityType, key string,
+	networkStats *stats.NetworkStats) (float64, bool) {
+	bytes, ok := totalNetworkBytes(networkStats)
+	if !ok {
+		return 0, false
+	}
+	now := networkStats.Time.Time
+
+	t.dataMutex.Lock()
+	defer t.dataMutex.Unlock()
+	trackerKey := string(etype) + "-" + key
+	previous, exists := t.counters[trackerKey]
+	t.counters[trackerKey] = &networkCounters{bytes: bytes, time: now}
+	if !exists || bytes < previous.bytes || !now.After(previous.time) {
+		return 0, false
+	}
+	seconds := now.Sub(previous.time).Seconds()
+	return float64(bytes-previous.bytes) / util.KilobytesToBytes / seconds, true
+}
+
+// Remove the counters that have not been updated since the given time, e.g., of the deleted pods.
+func (t *networkTracker) expire(before time.Time) {
+	t.dataMutex.Lock()
+	defer t.dataMutex.Unlock()
+	for key, counters := range t.counters {
+		if counters.time.Before(before) {
+			delete(t.counters, key)
+		}
+	}
+}
+
+// Sum of the received and transmitted bytes over all the interfaces,
+// or over the default interface if the interfaces are not reported.
+func totalNetworkBytes(networkStats *stats.NetworkStats) (uint64, bool) {
+	if networkStats == nil {
+		return 0, false
+	}
+	interfaces := networkStats.Interfaces
+	if len(interfaces) == 0 {
+		interfaces = []stats.InterfaceStats{networkStats.InterfaceStats}
+	}
+	total := uint64(0)
+	found := false
+	for _, iface := range interfaces {
+		if iface.RxBytes != nil {
+			total += *iface.RxBytes
+			found = true
+		}
+		if iface.TxBytes != nil {
+			total += *iface.TxBytes
+			found = true
+		}
+	}
+	return total, found
+}
@@ -0,0 +1,70 @@
+package kubelet
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+)
+
+func createNetworkStats(t time.Time, rx, tx uint64) *stats.NetworkStats {
+	return &stats.NetworkStats{
+		Time: metav1.NewTime(t),
+		InterfaceStats: stats.InterfaceStats{
+			Name:    "eth0",
+			RxBytes: &rx,
+			TxBytes: &tx,
+		},
+	}
+}
+
+func TestNetworkTrackerThroughput(t *testing.T) {
+	tracker := newNetworkTracker()
+	start := time.Now()
+
+	// No throughput on the first scrape
+	if _, ok := tracker.throughput(metrics.PodType, "space1/pod1", createNetworkStats(start, 1024, 1024)); ok {
+		t.Errorf("Throughput should not be available on the first scrape")
+	}
+
+	// 20 KB received and transmitted in 10 seconds
+	throughput, ok := tracker.throughput(metrics.PodType, "space1/pod1",
+		createNetworkStats(start.Add(10*time.Second), 11*1024, 11*1024))
+	if !ok {
+		t.Fatalf("Throughput should be available on the second scrape")
+	}
+	if math.Abs(throughput-2.0) > myzero {
+		t.Errorf("Wrong throughput: %v Vs. %v", 2.0, throughput)
+	}
+
+	// Counters reset after a restart
+	if _, ok := tracker.throughput(metrics.PodType, "space1/pod1",
+		createNetworkStats(start.Add(20*time.Second), 0, 0)); ok {
+		t.Errorf("Throughput should not be available after the counters are reset")
+	}
+
+	// Same time stamp, e.g., from the kubelet cache
+	if _, ok := tracker.throughput(metrics.PodType, "space1/pod1",
+		createNetworkStats(start.Add(20*time.Second), 1024, 0)); ok {
+		t.Errorf("Throughput should not be available without any time elapsed")
+	}
+
+	tracker.expire(start.Add(time.Minute))
+	if len(tracker.counters) != 0 {
+		t.Errorf("Counters should have been expired: %v", tracker.counters)
+	}
+}
+
+func TestNetworkTrackerMissingStats(t *testing.T) {
+	tracker := newNetworkTracker()
+	if _, ok := tracker.throughput(metrics.NodeType, "node1", nil); ok {
+		t.Errorf("Throughput should not be available without network stats")
+	}
+	if _, ok := tracker.throughput(metrics.NodeType, "node1", &stats.NetworkStats{Time: metav1.Now()}); ok {
+		t.Errorf("Throughput should not be available without counters")
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 
+	autoscaling "k8s.io/api/autoscaling/v1"
 	api "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
 
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
@@ -29,6 +31,8 @@ type ClusterMonitor struct {
 
 	nodePodMap map[string][]*api.Pod
 	podOwners  map[string]*PodOwner
+	pdbs       []*policy.PodDisruptionBudget
+	hpas       []*autoscaling.HorizontalPodAutoscaler
 
 	stopCh chan struct{}
 }
@@ -88,6 +92,8 @@ func (m *ClusterMonitor) RetrieveClusterStat() error {
 		if err != nil {
 			return fmt.Errorf("Failed to find cluster ID based on Kubernetes service: %v", err)
 		}
+		m.findPodDisruptionBudgets()
+		m.findHorizontalPodAutoscalers()
 		select {
 		case <-m.stopCh:
 			return nil
@@ -101,6 +107,8 @@ func (m *ClusterMonitor) RetrieveClusterStat() error {
 
 func (m *ClusterMonitor) reset() {
 	m.sink = metrics.NewEntityMetricSink()
+	m.pdbs = nil
+	m.hpas = nil
 	m.stopCh = make(chan struct{}, 1)
 }
 
@@ -119,6 +127,28 @@ func (m *ClusterMonitor) findClusterID() error {
 	return nil
 }
 
+// Get the PodDisruptionBudgets of the cluster, to find the ones protecting each pod.
+// The pods are discovered without them if they cannot be listed.
+func (m *ClusterMonitor) findPodDisruptionBudgets() {
+	pdbs, err := m.clusterClient.GetAllPodDisruptionBudgets()
+	if err != nil {
+		glog.Warningf("Failed to list the PodDisruptionBudgets: %v", err)
+		return
+	}
+	m.pdbs = pdbs
+}
+
+// Get the HorizontalPodAutoscalers of the cluster, to find the ones scaling the controller of each pod.
+// The pods are discovered without them if they cannot be listed.
+func (m *ClusterMonitor) findHorizontalPodAutoscalers() {
+	hpas, err := m.clusterClient.GetAllHorizontalPodAutoscalers()
+	if err != nil {
+		glog.Warningf("Failed to list the HorizontalPodAutoscalers: %v", err)
+		return
+	}
+	m.hpas = hpas
+}
+
 // ----------------------------------------- Node State --------------------------------------------
 func (m *ClusterMonitor) findNodeStates() {
 	for _, node := range m.nodeList {
@@ -144,6 +174,8 @@ func (m *ClusterMonitor) findNodeStates() {
 // 	Memory          capacity
 //	CPURequest      capacity, used
 //	MemoryRequest   capacity, used
+//	NumPods         capacity, used
+//	ExtendedResource capacity, used for each extended resource, e.g., nvidia.com/gpu or hugepages-2Mi
 func (m *ClusterMonitor) genNodeResourceMetrics(node *api.Node, key string) {
 	glog.V(3).Infof("Now get resouce metrics for node %s", key)
 
@@ -171,6 +203,58 @@ func (m *ClusterMonitor) genNodeResourceMetrics(node *api.Node, key string) {
 	m.genRequestUsedMetrics(metrics.NodeType, key, nodeCPURequestUsed, nodeMemRequestUsed)
 	glog.V(4).Infof("CPURequest used of node %s is %f core", node.Name, nodeCPURequestUsed)
 	glog.V(4).Infof("MemoryRequest used of node %s is %f Kb", node.Name, nodeMemRequestUsed)
+
+	//5. Generate the capacity and used metric for the number of pods of the node
+	m.genNodeNumPodsMetrics(node, key)
+
+	//6. Generate the capacity and used metric for the extended resources of the node
+	m.genNodeExtendedResourceMetrics(node, key)
+}
+
+// genNodeNumPodsMetrics generates the metrics of the number of pods of a node:
+// the capacity is the allocatable pods and the used is the number of running pods
+func (m *ClusterMonitor) genNodeNumPodsMetrics(node *api.Node, key string) {
+	podsCapacity, exists := node.Status.Allocatable[api.ResourcePods]
+	if !exists {
+		glog.V(3).Infof("Node %s has no allocatable pods", node.Name)
+		return
+	}
+	runningPods := 0
+	for _, pod := range m.nodePodMap[node.Name] {
+		if pod.Status.Phase == api.PodRunning {
+			runningPods++
+		}
+	}
+	glog.V(4).Infof("Node %s runs %d pods out of %d allocatable", node.Name, runningPods, podsCapacity.Value())
+	m.sink.AddNewMetricEntries(
+		metrics.NewEntityResourceMetric(metrics.NodeType, key, metrics.NumPods, metrics.Capacity, float64(podsCapacity.Value())),
+		metrics.NewEntityResourceMetric(metrics.NodeType, key, metrics.NumPods, metrics.Used, float64(runningPods)))
+}
+
+// genNodeExtendedResourceMetrics generates the metrics of the extended resources of a node:
+// the capacity is the allocatable resource and the used is the sum of the requests of its active pods
+func (m *ClusterMonitor) genNodeExtendedResourceMetrics(node *api.Node, key string) {
+	capacities := util.GetExtendedResourceValues(node.Status.Allocatable)
+	if len(capacities) == 0 {
+		return
+	}
+	used := make(map[api.ResourceName]float64)
+	for _, pod := range m.nodePodMap[node.Name] {
+		// The devices of the terminated pods are released
+		if pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed {
+			continue
+		}
+		for name, value := range util.GetPodExtendedResourceRequests(pod) {
+			used[name] += value
+		}
+	}
+	for name, capacity := range capacities {
+		rType := metrics.ExtendedResourceType(name)
+		glog.V(4).Infof("%s used of node %s is %f out of %f allocatable", name, node.Name, used[name], capacity)
+		m.sink.AddNewMetricEntries(
+			metrics.NewEntityResourceMetric(metrics.NodeType, key, rType, metrics.Capacity, capacity),
+			metrics.NewEntityResourceMetric(metrics.NodeType, key, rType, metrics.Used, used[name]))
+	}
 }
 
 // Parse the labels of a node and create one EntityStateMetric
@@ -222,7 +306,7 @@ func (m *ClusterMonitor) getPodOwner(pod *api.Pod) (*PodOwner, error) {
 	key := util.PodKeyFunc(pod)
 	glog.V(4).Infof("begin to generate pod[%s]'s Owner metric.", key)
 
-	kind, parentName, err := util.GetPodGrandInfo(m.clusterClient.Clientset, pod)
+	kind, parentName, err := m.clusterClient.GetPodGrandInfo(pod)
 	if err != nil {
 		return nil, fmt.Errorf("Error getting pod owner: %v", err)
 	}
@@ -255,6 +339,17 @@ func (m *ClusterMonitor) genPodMetrics(pod *api.Pod, nodeCPUCapacity, nodeMemCap
 	m.genReservationMetrics(metrics.PodType, podMId, podCPURequest, podMemRequest)
 	//2.3 Generate used metric for CPURequest and MemRequest
 	m.genRequestUsedMetrics(metrics.PodType, podMId, podCPURequest, podMemRequest)
+	//2.4 Generate used metric for the number of pods, a running pod counts for one pod of its node
+	podsUsed := 0.0
+	if pod.Status.Phase == api.PodRunning {
+		podsUsed = 1.0
+	}
+	m.sink.AddNewMetricEntries(metrics.NewEntityResourceMetric(metrics.PodType, podMId, metrics.NumPods, metrics.Used, podsUsed))
+	//2.5 Generate used metric for the extended resources requested by the pod
+	for name, value := range util.GetPodExtendedResourceRequests(pod) {
+		m.sink.AddNewMetricEntries(metrics.NewEntityResourceMetric(metrics.PodType, podMId,
+			metrics.ExtendedResourceType(name), metrics.Used, value))
+	}
 
 	//3. Owner
 	podOwner, exists := m.podOwners[key]
@@ -262,6 +357,14 @@ func (m *ClusterMonitor) genPodMetrics(pod *api.Pod, nodeCPUCapacity, nodeMemCap
 		m.genOwnerMetrics(metrics.PodType, key, podOwner.kind, podOwner.name)
 	}
 
+	//4. PodDisruptionBudgets
+	m.genDisruptionBudgetMetrics(pod, key)
+
+	//5. HorizontalPodAutoscaler of the owner
+	if exists && podOwner != nil {
+		m.genHorizontalPodAutoscalerMetrics(pod, key, podOwner)
+	}
+
 	return podCPURequest, podMemRequest
 }
 
@@ -322,6 +425,29 @@ func (m *ClusterMonitor) genOwnerMetrics(etype metrics.DiscoveredEntityType, key
 	}
 }
 
+// genDisruptionBudgetMetrics generates the state metric of the PodDisruptionBudgets protecting a pod
+func (m *ClusterMonitor) genDisruptionBudgetMetrics(pod *api.Pod, key string) {
+	pdbs := util.GetPodDisruptionBudgetsForPod(pod, m.pdbs)
+	if len(pdbs) == 0 {
+		return
+	}
+	var names []string
+	for _, pdb := range pdbs {
+		names = append(names, util.PodDisruptionBudgetName(pdb))
+	}
+	m.sink.AddNewMetricEntries(metrics.NewEntityStateMetric(metrics.PodType, key, metrics.DisruptionBudgets, names))
+}
+
+func (m *ClusterMonitor) genHorizontalPodAutoscalerMetrics(pod *api.Pod, key string, podOwner *PodOwner) {
+	hpa := util.GetHorizontalPodAutoscalerForController(pod.Namespace, podOwner.kind, podOwner.name, m.hpas)
+	if hpa == nil {
+		return
+	}
+	glog.V(3).Infof("%s %s/%s of pod %s is scaled by HorizontalPodAutoscaler %s", podOwner.kind, pod.Namespace,
+		podOwner.name, key, util.HorizontalPodAutoscalerName(hpa))
+	m.sink.AddNewMetricEntries(metrics.NewEntityStateMetric(metrics.PodType, key, metrics.HorizontalPodAutoscaler, hpa))
+}
+
 // genRequestUsedMetrics generates used metrics for VCPURequest and VMemRequest commodity
 func (m *ClusterMonitor) genRequestUsedMetrics(etype metrics.DiscoveredEntityType, key string, cpu, memory float64) {
 	cpuMetric := metrics.NewEntityResourceMetric(etype, key, metrics.CPURequest, metrics.Used, cpu)
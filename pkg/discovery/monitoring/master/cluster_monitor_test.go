@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	autoscaling "k8s.io/api/autoscaling/v1"
 	api "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,6 +22,8 @@ var expectedMetrics = map[string]float64{
 	"Node-mynode-MemoryRequest-Capacity": 7.340032e+06,
 	"Node-mynode-CPURequest-Used":        0.26,
 	"Node-mynode-MemoryRequest-Used":     262144,
+	"Node-mynode-NumPods-Capacity":       110,
+	"Node-mynode-NumPods-Used":           1,
 
 	// Pod metrics
 	"Pod-default/mypod-CPU-Capacity":       2,
@@ -29,6 +32,7 @@ var expectedMetrics = map[string]float64{
 	"Pod-default/mypod-Memory-Reservation": 262144,
 	"Pod-default/mypod-CPURequest-Used":    0.26,
 	"Pod-default/mypod-MemoryRequest-Used": 262144,
+	"Pod-default/mypod-NumPods-Used":       1,
 
 	// Container metrics
 	"Container-default/mypod/twitter-cass-tweet-CPU-Capacity":       0.25,
@@ -133,8 +137,11 @@ func TestGenNodeResourceMetrics(t *testing.T) {
 		buildResource(2.0, 8192), // node capacity: 2.0 cores, 8 GiB mem
 		buildResource(1.9, 7168), // node allocatable: 1.9 cores, 7 GiB mem
 	)
+	node.Status.Allocatable[api.ResourcePods] = resource.MustParse("110")
 	// Build pods in the node
-	pods := []*api.Pod{mockPod("mypod")}
+	pod := mockPod("mypod")
+	pod.Status.Phase = api.PodRunning
+	pods := []*api.Pod{pod}
 	config := &ClusterMonitorConfig{}
 	clusterMonitor, err := NewClusterMonitor(config)
 	if err != nil {
@@ -155,3 +162,97 @@ func TestGenNodeResourceMetrics(t *testing.T) {
 		assert.EqualValues(t, value, metric.GetValue())
 	}
 }
+
+func TestGenNodeExtendedResourceMetrics(t *testing.T) {
+	node := mockNode("mynode", buildResource(2.0, 8192), buildResource(1.9, 7168))
+	node.Status.Allocatable["nvidia.com/gpu"] = resource.MustParse("4")
+	node.Status.Allocatable["hugepages-2Mi"] = resource.MustParse("1Gi")
+	// A pod requesting GPUs and hugepages, a terminated one whose GPUs are released, and one without extended resources
+	gpuPod := mockPod("gpupod")
+	gpuPod.Spec.Containers[0].Resources.Requests["nvidia.com/gpu"] = resource.MustParse("2")
+	gpuPod.Spec.Containers[1].Resources.Requests["hugepages-2Mi"] = resource.MustParse("512Mi")
+	completedPod := mockPod("completedpod")
+	completedPod.Spec.Containers[0].Resources.Requests["nvidia.com/gpu"] = resource.MustParse("1")
+	completedPod.Status.Phase = api.PodSucceeded
+
+	clusterMonitor, err := NewClusterMonitor(&ClusterMonitorConfig{})
+	if err != nil {
+		t.Errorf("Failed to create clusterMonitor: %v", err)
+	}
+	clusterMonitor.clusterClient = &cluster.ClusterScraper{}
+	clusterMonitor.sink = metrics.NewEntityMetricSink()
+	clusterMonitor.nodeList = []*api.Node{node}
+	clusterMonitor.nodePodMap = map[string][]*api.Pod{"mynode": {gpuPod, completedPod, mockPod("mypod")}}
+	clusterMonitor.findNodeStates()
+
+	gpu := metrics.ExtendedResourceType("nvidia.com/gpu")
+	hugepages := metrics.ExtendedResourceType("hugepages-2Mi")
+	expected := []struct {
+		etype metrics.DiscoveredEntityType
+		key   string
+		rType metrics.ResourceType
+		prop  metrics.MetricProp
+		value float64
+	}{
+		{metrics.NodeType, "mynode", gpu, metrics.Capacity, 4},
+		{metrics.NodeType, "mynode", gpu, metrics.Used, 2},
+		{metrics.NodeType, "mynode", hugepages, metrics.Capacity, 1024 * 1024},
+		{metrics.NodeType, "mynode", hugepages, metrics.Used, 512 * 1024},
+		{metrics.PodType, "default/gpupod", gpu, metrics.Used, 2},
+		{metrics.PodType, "default/gpupod", hugepages, metrics.Used, 512 * 1024},
+		{metrics.PodType, "default/completedpod", gpu, metrics.Used, 1},
+	}
+	for _, item := range expected {
+		metric, err := clusterMonitor.sink.GetMetric(
+			metrics.GenerateEntityResourceMetricUID(item.etype, item.key, item.rType, item.prop))
+		if err != nil {
+			t.Errorf("Failed to validate metric: %v", err)
+			continue
+		}
+		assert.EqualValues(t, item.value, metric.GetValue())
+	}
+	if _, err := clusterMonitor.sink.GetMetric(
+		metrics.GenerateEntityResourceMetricUID(metrics.PodType, "default/mypod", gpu, metrics.Used)); err == nil {
+		t.Errorf("Expected no %s metric for a pod not requesting it", gpu)
+	}
+}
+
+func TestGenHorizontalPodAutoscalerMetrics(t *testing.T) {
+	node := mockNode("mynode", buildResource(2.0, 8192), buildResource(1.9, 7168))
+	hpa := &autoscaling.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "twitter-hpa"},
+		Spec: autoscaling.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{Kind: "Deployment", Name: "twitter-cass-tweet"},
+			MaxReplicas:    5,
+		},
+	}
+	// A pod of the autoscaled Deployment, and one of another Deployment
+	isController := true
+	pod := mockPod("mypod")
+	pod.OwnerReferences[0].Controller = &isController
+	otherPod := mockPod("otherpod")
+	otherPod.OwnerReferences[0].Controller = &isController
+	otherPod.OwnerReferences[0].Name = "other"
+
+	clusterMonitor, err := NewClusterMonitor(&ClusterMonitorConfig{})
+	if err != nil {
+		t.Errorf("Failed to create clusterMonitor: %v", err)
+	}
+	clusterMonitor.clusterClient = &cluster.ClusterScraper{}
+	clusterMonitor.sink = metrics.NewEntityMetricSink()
+	clusterMonitor.nodeList = []*api.Node{node}
+	clusterMonitor.nodePodMap = map[string][]*api.Pod{"mynode": {pod, otherPod}}
+	clusterMonitor.hpas = []*autoscaling.HorizontalPodAutoscaler{hpa}
+	clusterMonitor.findNodeStates()
+
+	metric, err := clusterMonitor.sink.GetMetric(
+		metrics.GenerateEntityStateMetricUID(metrics.PodType, "default/mypod", metrics.HorizontalPodAutoscaler))
+	if err != nil {
+		t.Fatalf("Failed to get the HorizontalPodAutoscaler metric: %v", err)
+	}
+	assert.Equal(t, hpa, metric.GetValue())
+	if _, err := clusterMonitor.sink.GetMetric(metrics.GenerateEntityStateMetricUID(metrics.PodType,
+		"default/otherpod", metrics.HorizontalPodAutoscaler)); err == nil {
+		t.Errorf("Expected no HorizontalPodAutoscaler metric for a pod of another Deployment")
+	}
+}
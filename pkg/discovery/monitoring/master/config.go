@@ -3,16 +3,13 @@ package master
 import (
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
-
-	"k8s.io/client-go/kubernetes"
 )
 
 type ClusterMonitorConfig struct {
 	clusterInfoScraper *cluster.ClusterScraper
 }
 
-func NewClusterMonitorConfig(kclient *kubernetes.Clientset) *ClusterMonitorConfig {
-	k8sClusterScraper := cluster.NewClusterScraper(kclient)
+func NewClusterMonitorConfig(k8sClusterScraper *cluster.ClusterScraper) *ClusterMonitorConfig {
 	return &ClusterMonitorConfig{
 		clusterInfoScraper: k8sClusterScraper,
 	}
@@ -0,0 +1,39 @@
+package metricsserver
+
+import (
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
+)
+
+type MetricsServerMonitorConfig struct {
+	metricsServerClient *kubeclient.MetricsServerClient
+
+	// The resource metrics API doesn't expose the node machine info, so the cpu frequency
+	// used to convert the cpu cores to MHz has to be provided.
+	cpuFrequencyMHz float64
+
+	// The metrics listed once for all the monitors built from the config
+	snapshot *metricsSnapshot
+}
+
+// Implement MonitoringWorkerConfig interface.
+func (c MetricsServerMonitorConfig) GetMonitorType() types.MonitorType {
+	return types.ResourceMonitor
+}
+func (c MetricsServerMonitorConfig) GetMonitoringSource() types.MonitoringSource {
+	return types.MetricsServerSource
+}
+
+func NewMetricsServerMonitorConfig(mclient *kubeclient.MetricsServerClient, cpuFrequencyMHz float64) *MetricsServerMonitorConfig {
+	return &MetricsServerMonitorConfig{
+		metricsServerClient: mclient,
+		cpuFrequencyMHz:     cpuFrequencyMHz,
+		snapshot:            newMetricsSnapshot(mclient),
+	}
+}
+
+// ResetMetrics makes the monitors built from the config list the metrics from the metrics server again.
+// It is called at the start of each discovery.
+func (c *MetricsServerMonitorConfig) ResetMetrics() {
+	c.snapshot.reset()
+}
@@ -0,0 +1,193 @@
+package metricsserver
+
+import (
+	"errors"
+	"fmt"
+
+	api "k8s.io/api/core/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
+)
+
+// MetricsServerMonitor is a resource monitoring worker based on the resource metrics API (metrics.k8s.io).
+// It generates the same CPU/Memory used metrics as the KubeletMonitor, for clusters where the kubelets
+// cannot be reached directly.
+type MetricsServerMonitor struct {
+	nodeList []*api.Node
+	podList  []*api.Pod
+
+	metricsServerClient *kubeclient.MetricsServerClient
+	cpuFrequencyMHz     float64
+	snapshot            *metricsSnapshot
+
+	metricSink *metrics.EntityMetricSink
+
+	stopCh chan struct{}
+}
+
+func NewMetricsServerMonitor(config *MetricsServerMonitorConfig) (*MetricsServerMonitor, error) {
+	if config.metricsServerClient == nil {
+		return nil, errors.New("metrics server client is not provided")
+	}
+	return &MetricsServerMonitor{
+		metricsServerClient: config.metricsServerClient,
+		cpuFrequencyMHz:     config.cpuFrequencyMHz,
+		snapshot:            config.snapshot,
+		metricSink:          metrics.NewEntityMetricSink(),
+		stopCh:              make(chan struct{}, 1),
+	}, nil
+}
+
+func (m *MetricsServerMonitor) reset() {
+	m.metricSink = metrics.NewEntityMetricSink()
+	m.stopCh = make(chan struct{}, 1)
+}
+
+func (m *MetricsServerMonitor) GetMonitoringSource() types.MonitoringSource {
+	return types.MetricsServerSource
+}
+
+func (m *MetricsServerMonitor) ReceiveTask(task *task.Task) {
+	m.reset()
+
+	m.nodeList = task.NodeList()
+	m.podList = task.PodList()
+}
+
+func (m *MetricsServerMonitor) Stop() {
+	m.stopCh <- struct{}{}
+}
+
+func (m *MetricsServerMonitor) Do() *metrics.EntityMetricSink {
+	glog.V(4).Infof("%s has started task.", m.GetMonitoringSource())
+	err := m.RetrieveResourceStat()
+	if err != nil {
+		glog.Errorf("Failed to execute task: %s", err)
+	}
+	glog.V(4).Infof("%s monitor has finished task.", m.GetMonitoringSource())
+	return m.metricSink
+}
+
+// Start to retrieve resource stats for the received list of nodes and the pods running on them.
+func (m *MetricsServerMonitor) RetrieveResourceStat() error {
+	defer close(m.stopCh)
+
+	if m.nodeList == nil || len(m.nodeList) == 0 {
+		return errors.New("Invalid nodeList or empty nodeList. Finish Immediately...")
+	}
+
+	// The cpu frequency is available even without the metrics, so that the nodes can be built
+	for _, node := range m.nodeList {
+		m.parseNodeInfo(node)
+	}
+
+	// The metrics of the whole cluster are shared with the monitors of the other tasks
+	nodeMetrics, podMetrics, err := m.snapshot.get()
+	if err != nil {
+		return fmt.Errorf("failed to get metrics from the metrics server: %v", err)
+	}
+
+	for _, node := range m.nodeList {
+		select {
+		case <-m.stopCh:
+			return nil
+		default:
+			m.scrapeNode(node, nodeMetrics[node.Name])
+		}
+	}
+
+	select {
+	case <-m.stopCh:
+		return nil
+	default:
+		m.parsePodMetrics(podMetrics)
+	}
+
+	return nil
+}
+
+// Parse the resource metrics of the given node, if any.
+func (m *MetricsServerMonitor) scrapeNode(node *api.Node, nodeMetrics *kubeclient.NodeMetrics) {
+	if nodeMetrics == nil {
+		glog.Errorf("Failed to get resource metrics of node %s: not found in the metrics server", node.Name)
+		return
+	}
+	m.parseNodeMetrics(node, nodeMetrics)
+
+	glog.V(4).Infof("Finished scrape node %s.", node.Name)
+}
+
+func (m *MetricsServerMonitor) parseNodeInfo(node *api.Node) {
+	glog.V(4).Infof("node-%s cpuFrequency = %.2fMHz", node.Name, m.cpuFrequencyMHz)
+	cpuFrequencyMetric := metrics.NewEntityStateMetric(metrics.NodeType, util.NodeKeyFunc(node), metrics.CpuFrequency, m.cpuFrequencyMHz)
+	m.metricSink.AddNewMetricEntries(cpuFrequencyMetric)
+}
+
+// Parse node metrics and put it into sink.
+func (m *MetricsServerMonitor) parseNodeMetrics(node *api.Node, nodeMetrics *kubeclient.NodeMetrics) {
+	cpuUsageCore, memoryWorkingSetKiloBytes := util.GetCpuAndMemoryValues(nodeMetrics.Usage)
+
+	key := util.NodeKeyFunc(node)
+	glog.V(4).Infof("CPU usage of node %s is %.3f core", node.Name, cpuUsageCore)
+	glog.V(4).Infof("Memory working set of node %s is %.3f KB", node.Name, memoryWorkingSetKiloBytes)
+	m.genUsedMetrics(metrics.NodeType, key, cpuUsageCore, memoryWorkingSetKiloBytes)
+}
+
+// Parse pod metrics for every pod of the task and put them into sink.
+func (m *MetricsServerMonitor) parsePodMetrics(podMetrics []kubeclient.PodMetrics) {
+	// Only the pods assigned to this task are considered
+	podKeys := make(map[string]bool)
+	for _, pod := range m.podList {
+		podKeys[util.PodKeyFunc(pod)] = true
+	}
+
+	for i := range podMetrics {
+		pod := &(podMetrics[i])
+		key := pod.Namespace + "/" + pod.Name
+		if !podKeys[key] {
+			continue
+		}
+		cpuUsed, memUsed := m.parseContainerMetrics(key, pod)
+
+		glog.V(4).Infof("Cpu usage of pod %s is %.3f core", key, cpuUsed)
+		glog.V(4).Infof("Memory usage of pod %s is %.3f Kb", key, memUsed)
+		m.genUsedMetrics(metrics.PodType, key, cpuUsed, memUsed)
+	}
+}
+
+func (m *MetricsServerMonitor) parseContainerMetrics(podMId string, pod *kubeclient.PodMetrics) (float64, float64) {
+	totalUsedCPU := float64(0.0)
+	totalUsedMem := float64(0.0)
+
+	for i := range pod.Containers {
+		container := &pod.Containers[i]
+		cpuUsed, memUsed := util.GetCpuAndMemoryValues(container.Usage)
+
+		totalUsedCPU += cpuUsed
+		totalUsedMem += memUsed
+
+		//1. container Used
+		containerMId := util.ContainerMetricId(podMId, container.Name)
+		m.genUsedMetrics(metrics.ContainerType, containerMId, cpuUsed, memUsed)
+
+		glog.V(4).Infof("container[%s-%s] cpu/memory usage:%.3f, %.3f", pod.Name, container.Name, cpuUsed, memUsed)
+
+		//2. app Used
+		appMId := util.ApplicationMetricId(containerMId)
+		m.genUsedMetrics(metrics.ApplicationType, appMId, cpuUsed, memUsed)
+	}
+
+	return totalUsedCPU, totalUsedMem
+}
+
+func (m *MetricsServerMonitor) genUsedMetrics(etype metrics.DiscoveredEntityType, key string, cpu, memory float64) {
+	cpuMetric := metrics.NewEntityResourceMetric(etype, key, metrics.CPU, metrics.Used, cpu)
+	memMetric := metrics.NewEntityResourceMetric(etype, key, metrics.Memory, metrics.Used, memory)
+	m.metricSink.AddNewMetricEntries(cpuMetric, memMetric)
+}
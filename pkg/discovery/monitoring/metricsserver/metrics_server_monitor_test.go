@@ -0,0 +1,180 @@
+package metricsserver
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
+)
+
+const (
+	myzero = float64(0.00000001)
+
+	nodeMetricsJSON = `{
+  "kind": "NodeMetrics",
+  "apiVersion": "metrics.k8s.io/v1beta1",
+  "metadata": {"name": "node1"},
+  "timestamp": "2019-07-01T10:00:00Z",
+  "window": "30s",
+  "usage": {"cpu": "1500m", "memory": "2048Ki"}
+}`
+
+	podMetricsListJSON = `{
+  "kind": "PodMetricsList",
+  "apiVersion": "metrics.k8s.io/v1beta1",
+  "metadata": {},
+  "items": [
+    {
+      "metadata": {"name": "pod1", "namespace": "space1"},
+      "timestamp": "2019-07-01T10:00:00Z",
+      "window": "30s",
+      "containers": [
+        {"name": "container1", "usage": {"cpu": "100m", "memory": "1024Ki"}},
+        {"name": "container2", "usage": {"cpu": "200m", "memory": "512Ki"}}
+      ]
+    },
+    {
+      "metadata": {"name": "pod2", "namespace": "space1"},
+      "timestamp": "2019-07-01T10:00:00Z",
+      "window": "30s",
+      "containers": [
+        {"name": "container1", "usage": {"cpu": "300m", "memory": "256Ki"}}
+      ]
+    }
+  ]
+}`
+)
+
+func newTestMetricsServerClient(t *testing.T) (*kubeclient.MetricsServerClient, *httptest.Server) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apis/metrics.k8s.io/v1beta1/nodes", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind": "NodeMetricsList", "apiVersion": "metrics.k8s.io/v1beta1", "metadata": {}, "items": [` +
+			nodeMetricsJSON + `]}`))
+	})
+	mux.HandleFunc("/apis/metrics.k8s.io/v1beta1/pods", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(podMetricsListJSON))
+	})
+	server := httptest.NewServer(mux)
+
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		server.Close()
+		t.Fatalf("Failed to create kube client: %v", err)
+	}
+	return kubeclient.NewMetricsServerClient(kubeClient.DiscoveryClient.RESTClient()), server
+}
+
+func checkMetricValue(t *testing.T, sink *metrics.EntityMetricSink, uid string, expected float64) {
+	metric, err := sink.GetMetric(uid)
+	if err != nil {
+		t.Errorf("Failed to get metric %s: %v", uid, err)
+		return
+	}
+	value := metric.GetValue().(float64)
+	if math.Abs(value-expected) > myzero {
+		t.Errorf("Metric %s check failed: %v Vs. %v", uid, expected, value)
+	}
+}
+
+func TestRetrieveResourceStat(t *testing.T) {
+	client, server := newTestMetricsServerClient(t)
+	defer server.Close()
+
+	monitor, err := NewMetricsServerMonitor(NewMetricsServerMonitorConfig(client, 2600.0))
+	if err != nil {
+		t.Fatalf("Failed to create metrics server monitor: %v", err)
+	}
+
+	node := &api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	pod := &api.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "space1"}}
+	monitor.ReceiveTask(task.NewTask().WithNodes([]*api.Node{node}).WithPods([]*api.Pod{pod}))
+
+	sink := monitor.Do()
+
+	// node
+	checkMetricValue(t, sink, metrics.GenerateEntityStateMetricUID(metrics.NodeType, "node1", metrics.CpuFrequency), 2600.0)
+	checkMetricValue(t, sink, metrics.GenerateEntityResourceMetricUID(metrics.NodeType, "node1", metrics.CPU, metrics.Used), 1.5)
+	checkMetricValue(t, sink, metrics.GenerateEntityResourceMetricUID(metrics.NodeType, "node1", metrics.Memory, metrics.Used), 2048)
+
+	// pod is the sum of its containers
+	checkMetricValue(t, sink, metrics.GenerateEntityResourceMetricUID(metrics.PodType, "space1/pod1", metrics.CPU, metrics.Used), 0.3)
+	checkMetricValue(t, sink, metrics.GenerateEntityResourceMetricUID(metrics.PodType, "space1/pod1", metrics.Memory, metrics.Used), 1536)
+
+	// containers and applications
+	checkMetricValue(t, sink, metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, "space1/pod1/container2", metrics.CPU, metrics.Used), 0.2)
+	checkMetricValue(t, sink, metrics.GenerateEntityResourceMetricUID(metrics.ContainerType, "space1/pod1/container2", metrics.Memory, metrics.Used), 512)
+	checkMetricValue(t, sink, metrics.GenerateEntityResourceMetricUID(metrics.ApplicationType, "App-space1/pod1/container1", metrics.CPU, metrics.Used), 0.1)
+
+	// pods not in the task are ignored
+	podNotInTask := metrics.GenerateEntityResourceMetricUID(metrics.PodType, "space1/pod2", metrics.CPU, metrics.Used)
+	if _, err := sink.GetMetric(podNotInTask); err == nil {
+		t.Errorf("Metric %s should not be generated for a pod not in the task", podNotInTask)
+	}
+}
+
+func TestRetrieveResourceStatNodeUnavailable(t *testing.T) {
+	client, server := newTestMetricsServerClient(t)
+	defer server.Close()
+
+	monitor, err := NewMetricsServerMonitor(NewMetricsServerMonitorConfig(client, 2600.0))
+	if err != nil {
+		t.Fatalf("Failed to create metrics server monitor: %v", err)
+	}
+
+	node := &api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}}
+	monitor.ReceiveTask(task.NewTask().WithNodes([]*api.Node{node}))
+
+	sink := monitor.Do()
+
+	// cpu frequency is still available so that the node can be built
+	checkMetricValue(t, sink, metrics.GenerateEntityStateMetricUID(metrics.NodeType, "node2", metrics.CpuFrequency), 2600.0)
+	nodeCPUUsed := metrics.GenerateEntityResourceMetricUID(metrics.NodeType, "node2", metrics.CPU, metrics.Used)
+	if _, err := sink.GetMetric(nodeCPUUsed); err == nil {
+		t.Errorf("Metric %s should not be generated for a node without metrics", nodeCPUUsed)
+	}
+}
+
+func TestRetrieveResourceStatSharesMetrics(t *testing.T) {
+	client, server := newTestMetricsServerClient(t)
+	defer server.Close()
+
+	config := NewMetricsServerMonitorConfig(client, 2600.0)
+	node := &api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	for i := 0; i < 2; i++ {
+		monitor, err := NewMetricsServerMonitor(config)
+		if err != nil {
+			t.Fatalf("Failed to create metrics server monitor: %v", err)
+		}
+		monitor.ReceiveTask(task.NewTask().WithNodes([]*api.Node{node}))
+		sink := monitor.Do()
+		checkMetricValue(t, sink, metrics.GenerateEntityResourceMetricUID(metrics.NodeType, "node1", metrics.CPU, metrics.Used), 1.5)
+	}
+	// The metrics listed by the first monitor are shared with the second one
+	server.Close()
+	nodeMetrics, _, err := config.snapshot.get()
+	if err != nil || nodeMetrics["node1"] == nil {
+		t.Errorf("Expected the shared metrics of node1 but got %v, %v", nodeMetrics, err)
+	}
+	// The next discovery lists the metrics again
+	config.ResetMetrics()
+	if _, _, err := config.snapshot.get(); err == nil {
+		t.Errorf("Expected the metrics to be listed again after the reset")
+	}
+}
+
+func TestNewMetricsServerMonitorWithoutClient(t *testing.T) {
+	if _, err := NewMetricsServerMonitor(NewMetricsServerMonitorConfig(nil, 2600.0)); err == nil {
+		t.Errorf("Expected an error when the metrics server client is missing")
+	}
+}
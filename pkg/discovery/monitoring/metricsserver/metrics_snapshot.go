@@ -0,0 +1,59 @@
+package metricsserver
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
+)
+
+// metricsSnapshot shares the node and pod metrics listed from the metrics server among the monitors of the tasks.
+// The snapshot is reset at the start of each discovery, so the metrics of the whole cluster are listed once
+// per discovery rather than by every task, and all the tasks of a discovery see the same metrics.
+type metricsSnapshot struct {
+	client *kubeclient.MetricsServerClient
+
+	sync.Mutex
+	nodeMetrics map[string]*kubeclient.NodeMetrics
+	podMetrics  []kubeclient.PodMetrics
+}
+
+func newMetricsSnapshot(client *kubeclient.MetricsServerClient) *metricsSnapshot {
+	return &metricsSnapshot{
+		client: client,
+	}
+}
+
+// reset drops the listed metrics, so that they are listed again by the next task
+func (s *metricsSnapshot) reset() {
+	s.Lock()
+	defer s.Unlock()
+	s.nodeMetrics = nil
+	s.podMetrics = nil
+}
+
+// get returns the metrics of the nodes by name and the metrics of the pods, listed if not listed since the last reset
+func (s *metricsSnapshot) get() (map[string]*kubeclient.NodeMetrics, []kubeclient.PodMetrics, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.nodeMetrics != nil {
+		return s.nodeMetrics, s.podMetrics, nil
+	}
+	nodeMetricsList, err := s.client.GetAllNodeMetrics()
+	if err != nil {
+		return nil, nil, err
+	}
+	podMetricsList, err := s.client.GetAllPodMetrics()
+	if err != nil {
+		return nil, nil, err
+	}
+	s.nodeMetrics = make(map[string]*kubeclient.NodeMetrics, len(nodeMetricsList.Items))
+	for i := range nodeMetricsList.Items {
+		nodeMetrics := &nodeMetricsList.Items[i]
+		s.nodeMetrics[nodeMetrics.Name] = nodeMetrics
+	}
+	s.podMetrics = podMetricsList.Items
+	glog.V(3).Infof("Listed the metrics of %d nodes and %d pods from the metrics server.",
+		len(s.nodeMetrics), len(s.podMetrics))
+	return s.nodeMetrics, s.podMetrics, nil
+}
@@ -3,10 +3,11 @@ package types
 type MonitoringSource string
 
 const (
-	KubeletSource      MonitoringSource = "Kubelet"
-	K8sConntrackSource MonitoringSource = "K8sConntrack"
-	ClusterSource      MonitoringSource = "Cluster"
-	PrometheusSource   MonitoringSource = "Prometheus"
+	KubeletSource       MonitoringSource = "Kubelet"
+	K8sConntrackSource  MonitoringSource = "K8sConntrack"
+	ClusterSource       MonitoringSource = "Cluster"
+	PrometheusSource    MonitoringSource = "Prometheus"
+	MetricsServerSource MonitoringSource = "MetricsServer"
 )
 
 type MonitorType string
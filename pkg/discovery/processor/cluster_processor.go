@@ -9,7 +9,9 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 )
 
 var (
@@ -23,7 +25,9 @@ var (
 type ClusterProcessor struct {
 	clusterInfoScraper cluster.ClusterScraperInterface
 	nodeScrapper       kubeclient.KubeHttpClientInterface
-	isValidated        bool
+	// When set, the nodes are validated through the resource metrics API instead of the kubelets
+	metricsServerClient *kubeclient.MetricsServerClient
+	isValidated         bool
 }
 
 func NewClusterProcessor(kubeClient *cluster.ClusterScraper, kubeletClient *kubeclient.KubeletClient, ValidationWorkers int,
@@ -45,6 +49,12 @@ func NewClusterProcessor(kubeClient *cluster.ClusterScraper, kubeletClient *kube
 	return clusterProcessor
 }
 
+// Validate the nodes with the metrics server instead of connecting to the kubelet of each node.
+func (p *ClusterProcessor) WithMetricsServerClient(metricsServerClient *kubeclient.MetricsServerClient) *ClusterProcessor {
+	p.metricsServerClient = metricsServerClient
+	return p
+}
+
 // Connects to the Kubernetes API Server and the nodes in the cluster.
 // ClusterProcessor is updated with the validation result.
 // Return error only if all the nodes in the cluster are unreachable.
@@ -72,12 +82,23 @@ func (p *ClusterProcessor) checkNodesWorker(work chan *v1.Node, done chan bool,
 			glog.V(4).Infof("Node verifier worker %d finished. No more work.", index)
 			return
 		}
-		nodeCpuFrequency, err := checkNode(node, p.nodeScrapper)
+		var err error
+		if p.metricsServerClient != nil {
+			err = checkNodeMetrics(node, p.metricsServerClient)
+			if err == nil {
+				glog.V(2).Infof("Successfully verified node %s through metrics server.", node.Name)
+			}
+		} else {
+			var nodeCpuFrequency float64
+			nodeCpuFrequency, err = checkNode(node, p.nodeScrapper)
+			if err == nil {
+				glog.V(2).Infof("Successfully verified node %s [cpu:%v MHz].", node.Name, nodeCpuFrequency)
+			}
+		}
 		if err != nil {
 			glog.Errorf("Failed to verify node %s: %v.", node.Name, err)
 		} else {
-			// Log the success and send the response to everybody
-			glog.V(2).Infof("Successfully verified node %s [cpu:%v MHz].", node.Name, nodeCpuFrequency)
+			// Send the response to everybody
 			done <- true
 			// Force return here. We are done and notified everybody.
 			glog.V(4).Infof("Node verifier worker %d finished. Successful verification.", index)
@@ -143,7 +164,7 @@ func (p *ClusterProcessor) connectToNodes() (bool, error) {
 
 // Checks the node connectivity be obtaining its CPU frequency
 func checkNode(node *v1.Node, kc kubeclient.KubeHttpClientInterface) (float64, error) {
-	ip := repository.ParseNodeIP(node, v1.NodeInternalIP)
+	ip := goutil.GetKubeletIP(node)
 	cpuFreq, err := kc.GetMachineCpuFrequency(ip)
 	if err != nil {
 		return 0.0, err
@@ -151,6 +172,12 @@ func checkNode(node *v1.Node, kc kubeclient.KubeHttpClientInterface) (float64, e
 	return float64(cpuFreq) / util.MegaToKilo, nil
 }
 
+// Checks the node metrics are available from the metrics server
+func checkNodeMetrics(node *v1.Node, mc *kubeclient.MetricsServerClient) error {
+	_, err := mc.GetNodeMetrics(node.Name)
+	return err
+}
+
 // Query the Kubernetes API Server to get the cluster nodes and namespaces and set in the cluster object
 func (p *ClusterProcessor) DiscoverCluster() (*repository.KubeCluster, error) {
 	if p.clusterInfoScraper == nil {
@@ -167,6 +194,38 @@ func (p *ClusterProcessor) DiscoverCluster() (*repository.KubeCluster, error) {
 	}
 	glog.V(2).Infof("Discovered cluster with %d nodes.", len(nodeList))
 
+	return p.discoverClusterWithNodes(svcID, nodeList), nil
+}
+
+// DiscoverNodes creates the KubeCluster with only the given nodes, e.g., the nodes changed since the previous
+// discovery, skipping the nodes which no longer exist. The namespaces, services and volumes are discovered
+// as usual, as the pods of the nodes depend on them, but the cluster resources only sum the given nodes.
+func (p *ClusterProcessor) DiscoverNodes(nodeNames []string) (*repository.KubeCluster, error) {
+	if p.clusterInfoScraper == nil {
+		return nil, fmt.Errorf("null kubernetes cluster client")
+	}
+	svcID, err := p.clusterInfoScraper.GetKubernetesServiceID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain service ID for cluster: %v", err)
+	}
+	var nodeList []*v1.Node
+	for _, nodeName := range nodeNames {
+		node, err := p.clusterInfoScraper.GetNode(nodeName)
+		if errors.IsNotFound(err) {
+			glog.V(3).Infof("Skip node %s which no longer exists.", nodeName)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node %s of cluster %s: %v", nodeName, svcID, err)
+		}
+		nodeList = append(nodeList, node)
+	}
+	glog.V(2).Infof("Discovered %d of the %d requested nodes.", len(nodeList), len(nodeNames))
+
+	return p.discoverClusterWithNodes(svcID, nodeList), nil
+}
+
+func (p *ClusterProcessor) discoverClusterWithNodes(svcID string, nodeList []*v1.Node) *repository.KubeCluster {
 	// Create kubeCluster and compute cluster resource
 	kubeCluster := repository.NewKubeCluster(svcID, nodeList)
 
@@ -176,5 +235,8 @@ func (p *ClusterProcessor) DiscoverCluster() (*repository.KubeCluster, error) {
 	// Discover Services
 	NewServiceProcessor(p.clusterInfoScraper, kubeCluster).ProcessServices()
 
-	return kubeCluster, nil
+	// Discover Persistent Volumes and Claims
+	NewVolumeProcessor(p.clusterInfoScraper, kubeCluster).ProcessVolumes()
+
+	return kubeCluster
 }
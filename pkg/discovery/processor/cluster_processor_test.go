@@ -7,6 +7,8 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
 	"k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -162,6 +164,29 @@ func TestDiscoverCluster(t *testing.T) {
 	assert.Equal(t, 0, len(testCluster.Namespaces))
 }
 
+func TestDiscoverNodes(t *testing.T) {
+	nodeList := createMockNodes(allocatableMap, schedulableNodeMap)
+	ms := &MockClusterScrapper{
+		mockGetKubernetesServiceID: func() (string, error) {
+			return testClusterName, nil
+		},
+		mockGetAllNodes: func() ([]*v1.Node, error) {
+			return nodeList, nil
+		},
+	}
+	clusterProcessor := &ClusterProcessor{
+		clusterInfoScraper: ms,
+		isValidated:        true,
+	}
+
+	// The deleted node is skipped
+	testCluster, err := clusterProcessor.DiscoverNodes([]string{"node2", "deleted"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(testCluster.Nodes))
+	assert.NotNil(t, testCluster.Nodes["node2"])
+	assert.Equal(t, testClusterName, testCluster.Name)
+}
+
 func TestDiscoverClusterChangedSchedulableNodes(t *testing.T) {
 	nodeList := createMockNodes(allocatableMap, schedulableNodeMap)
 	ms := &MockClusterScrapper{
@@ -302,6 +327,9 @@ type MockClusterScrapper struct {
 	mockGetAllEndpoints        func() ([]*v1.Endpoints, error)
 	mockGetAllServices         func() ([]*v1.Service, error)
 	mockGetKubernetesServiceID func() (svcID string, err error)
+	mockGetAllPVs              func() ([]*v1.PersistentVolume, error)
+	mockGetAllPVCs             func() ([]*v1.PersistentVolumeClaim, error)
+	mockGetAllStorageClasses   func() ([]*storage.StorageClass, error)
 }
 
 func (s *MockClusterScrapper) GetAllNodes() ([]*v1.Node, error) {
@@ -311,6 +339,22 @@ func (s *MockClusterScrapper) GetAllNodes() ([]*v1.Node, error) {
 	return nil, fmt.Errorf("GetAllNodes Not implemented")
 }
 
+func (s *MockClusterScrapper) GetNode(name string) (*v1.Node, error) {
+	if s.mockGetAllNodes == nil {
+		return nil, fmt.Errorf("GetNode Not implemented")
+	}
+	nodes, err := s.mockGetAllNodes()
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		if node.Name == name {
+			return node, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(v1.Resource("nodes"), name)
+}
+
 func (s *MockClusterScrapper) GetNodes(opts metav1.ListOptions) ([]*v1.Node, error) {
 	if s.mockGetAllNodes != nil {
 		return s.mockGetAllNodes()
@@ -355,6 +399,24 @@ func (s *MockClusterScrapper) GetAllServices() ([]*v1.Service, error) {
 	}
 	return nil, fmt.Errorf("GetAllServices Not implemented")
 }
+func (s *MockClusterScrapper) GetAllPVs() ([]*v1.PersistentVolume, error) {
+	if s.mockGetAllPVs != nil {
+		return s.mockGetAllPVs()
+	}
+	return nil, fmt.Errorf("GetAllPVs Not implemented")
+}
+func (s *MockClusterScrapper) GetAllPVCs() ([]*v1.PersistentVolumeClaim, error) {
+	if s.mockGetAllPVCs != nil {
+		return s.mockGetAllPVCs()
+	}
+	return nil, fmt.Errorf("GetAllPVCs Not implemented")
+}
+func (s *MockClusterScrapper) GetAllStorageClasses() ([]*storage.StorageClass, error) {
+	if s.mockGetAllStorageClasses != nil {
+		return s.mockGetAllStorageClasses()
+	}
+	return nil, fmt.Errorf("GetAllStorageClasses Not implemented")
+}
 
 // Implements the KubeHttpClientInterface
 // Method implementation will check to see if the test has provided the mockXXX method function
@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	"k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+)
+
+// Class to query the persistent volumes, the claims and the storage classes from the Kubernetes API server
+// and create the volume for each claim
+type VolumeProcessor struct {
+	ClusterInfoScraper cluster.ClusterScraperInterface
+	KubeCluster        *repository.KubeCluster
+}
+
+func NewVolumeProcessor(kubeClient cluster.ClusterScraperInterface,
+	kubeCluster *repository.KubeCluster) *VolumeProcessor {
+	return &VolumeProcessor{
+		ClusterInfoScraper: kubeClient,
+		KubeCluster:        kubeCluster,
+	}
+}
+
+// Query the Kubernetes API Server and Get the persistent volume claims with their bound volumes
+func (p *VolumeProcessor) ProcessVolumes() {
+	clusterName := p.KubeCluster.Name
+
+	pvcList, err := p.ClusterInfoScraper.GetAllPVCs()
+	if err != nil {
+		glog.Errorf("Failed to get persistent volume claims for cluster %s: %v.", clusterName, err)
+		return
+	}
+	glog.V(2).Infof("There are %d persistent volume claims.", len(pvcList))
+
+	pvList, err := p.ClusterInfoScraper.GetAllPVs()
+	if err != nil {
+		glog.Errorf("Failed to get persistent volumes for cluster %s: %v.", clusterName, err)
+		return
+	}
+	glog.V(2).Infof("There are %d persistent volumes.", len(pvList))
+
+	// The storage classes are optional, the volumes are still discovered without them
+	storageClassMap := make(map[string]*storage.StorageClass)
+	storageClassList, err := p.ClusterInfoScraper.GetAllStorageClasses()
+	if err != nil {
+		glog.Warningf("Failed to get storage classes for cluster %s: %v.", clusterName, err)
+	}
+	for _, storageClass := range storageClassList {
+		storageClassMap[storageClass.Name] = storageClass
+	}
+
+	pvMap := make(map[string]*v1.PersistentVolume)
+	for _, pv := range pvList {
+		pvMap[pv.Name] = pv
+	}
+
+	volumes := make(map[string]*repository.KubeVolume)
+	for _, pvc := range pvcList {
+		var pv *v1.PersistentVolume
+		if pvc.Spec.VolumeName != "" {
+			pv = pvMap[pvc.Spec.VolumeName]
+		}
+		var storageClass *storage.StorageClass
+		if pvc.Spec.StorageClassName != nil {
+			storageClass = storageClassMap[*pvc.Spec.StorageClassName]
+		}
+		volume := repository.NewKubeVolume(pvc, pv, storageClass)
+		glog.V(4).Infof("Discovered volume: %s", volume)
+		volumes[volume.VolumeClusterId] = volume
+	}
+	p.KubeCluster.Volumes = volumes
+}
@@ -0,0 +1,73 @@
+package processor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	"k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProcessVolumes(t *testing.T) {
+	storageClassName := "fast"
+	claims := []*v1.PersistentVolumeClaim{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "claim1", Namespace: "space1"},
+			Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "pv1", StorageClassName: &storageClassName},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "claim2", Namespace: "space1"},
+		},
+	}
+	ms := &MockClusterScrapper{
+		mockGetAllPVCs: func() ([]*v1.PersistentVolumeClaim, error) {
+			return claims, nil
+		},
+		mockGetAllPVs: func() ([]*v1.PersistentVolume, error) {
+			return []*v1.PersistentVolume{{ObjectMeta: metav1.ObjectMeta{Name: "pv1"}}}, nil
+		},
+		mockGetAllStorageClasses: func() ([]*storage.StorageClass, error) {
+			return []*storage.StorageClass{{ObjectMeta: metav1.ObjectMeta{Name: storageClassName}}}, nil
+		},
+	}
+
+	kubeCluster := repository.NewKubeCluster(testClusterName, []*v1.Node{})
+	NewVolumeProcessor(ms, kubeCluster).ProcessVolumes()
+
+	if len(kubeCluster.Volumes) != 2 {
+		t.Fatalf("Wrong number of volumes: %d", len(kubeCluster.Volumes))
+	}
+	volume1 := kubeCluster.Volumes["space1/claim1"]
+	if volume1 == nil || volume1.PersistentVolume == nil || volume1.PersistentVolume.Name != "pv1" {
+		t.Errorf("Claim1 should be bound to pv1: %v", volume1)
+	}
+	if volume1 == nil || volume1.StorageClass == nil || volume1.StorageClass.Name != storageClassName {
+		t.Errorf("Claim1 should have storage class %s: %v", storageClassName, volume1)
+	}
+	volume2 := kubeCluster.Volumes["space1/claim2"]
+	if volume2 == nil || volume2.PersistentVolume != nil || volume2.StorageClass != nil {
+		t.Errorf("Claim2 should be neither bound nor have a storage class: %v", volume2)
+	}
+}
+
+func TestProcessVolumesWithoutStorageClasses(t *testing.T) {
+	ms := &MockClusterScrapper{
+		mockGetAllPVCs: func() ([]*v1.PersistentVolumeClaim, error) {
+			return []*v1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "claim1", Namespace: "space1"}}}, nil
+		},
+		mockGetAllPVs: func() ([]*v1.PersistentVolume, error) {
+			return nil, nil
+		},
+		mockGetAllStorageClasses: func() ([]*storage.StorageClass, error) {
+			return nil, fmt.Errorf("forbidden")
+		},
+	}
+
+	kubeCluster := repository.NewKubeCluster(testClusterName, []*v1.Node{})
+	NewVolumeProcessor(ms, kubeCluster).ProcessVolumes()
+	if len(kubeCluster.Volumes) != 1 {
+		t.Errorf("Volumes should be discovered without storage classes: %d", len(kubeCluster.Volumes))
+	}
+}
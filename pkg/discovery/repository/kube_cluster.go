@@ -20,6 +20,8 @@ type KubeCluster struct {
 	ClusterResources map[metrics.ResourceType]*KubeDiscoveredResource
 	// Map of Service to Pod Ids
 	Services map[*v1.Service][]string
+	// Map of persistent volume claim cluster Id to volume
+	Volumes map[string]*KubeVolume
 }
 
 func NewKubeCluster(clusterName string, nodes []*v1.Node) *KubeCluster {
@@ -28,6 +30,7 @@ func NewKubeCluster(clusterName string, nodes []*v1.Node) *KubeCluster {
 		Nodes:            make(map[string]*KubeNode),
 		Namespaces:       make(map[string]*KubeNamespace),
 		ClusterResources: make(map[metrics.ResourceType]*KubeDiscoveredResource),
+		Volumes:          make(map[string]*KubeVolume),
 	}
 	kubeCluster.addNodes(nodes)
 	if glog.V(3) {
@@ -78,6 +81,12 @@ func (kc *KubeCluster) computeClusterResources() {
 					computeResources[rt] = computeCap
 				}
 			}
+			// Extended resources, only available on some of the nodes
+			for rt, nodeResource := range node.ComputeResources {
+				if metrics.IsExtendedResourceType(rt) {
+					computeResources[rt] += nodeResource.Capacity
+				}
+			}
 		}
 	}
 	// create KubeDiscoveredResource object for each compute resource type
@@ -91,6 +100,14 @@ func (kc *KubeCluster) computeClusterResources() {
 			kc.ClusterResources[rt] = r
 		}
 	}
+	for rt, capacity := range computeResources {
+		if metrics.IsExtendedResourceType(rt) {
+			kc.ClusterResources[rt] = &KubeDiscoveredResource{
+				Type:     rt,
+				Capacity: capacity,
+			}
+		}
+	}
 }
 
 func (kc *KubeCluster) GetQuota(namespace string) *KubeQuota {
@@ -206,18 +223,10 @@ func (nodeEntity *KubeNode) updateResources(apiNode *v1.Node) {
 			nodeEntity.AddComputeResource(computeResourceType, float64(capacityValue), DEFAULT_METRIC_VALUE)
 		}
 	}
-}
-
-// Parse the Node instances returned by the kubernetes API to get the IP address
-func ParseNodeIP(apiNode *v1.Node, addressType v1.NodeAddressType) string {
-	nodeAddresses := apiNode.Status.Addresses
-	for _, nodeAddress := range nodeAddresses {
-		if nodeAddress.Type == addressType && nodeAddress.Address != "" {
-			glog.V(4).Infof("%s : %s is %s", apiNode.Name, addressType, nodeAddress.Address)
-			return nodeAddress.Address
-		}
+	// Node extended resources, e.g., GPUs and hugepages
+	for name, capacityValue := range util.GetExtendedResourceValues(resourceAllocatableList) {
+		nodeEntity.AddComputeResource(metrics.ExtendedResourceType(name), capacityValue, DEFAULT_METRIC_VALUE)
 	}
-	return ""
 }
 
 func (nodeEntity *KubeNode) String() string {
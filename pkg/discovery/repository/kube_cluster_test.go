@@ -285,3 +285,39 @@ func TestQuotaNames(t *testing.T) {
 		t.Errorf("quota.name is wrong: %v Vs. %v", quota.Name, namespaceName)
 	}
 }
+
+func TestKubeClusterExtendedResources(t *testing.T) {
+	newNode := func(name string, allocatable v1.ResourceList) *v1.Node {
+		allocatable[v1.ResourceCPU] = resource.MustParse("4")
+		allocatable[v1.ResourceMemory] = resource.MustParse("8Gi")
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name)},
+			Status: v1.NodeStatus{
+				Allocatable: allocatable,
+				Conditions:  []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+			},
+		}
+	}
+	nodes := []*v1.Node{
+		newNode("gpu-node1", v1.ResourceList{
+			"nvidia.com/gpu": resource.MustParse("2"),
+			"hugepages-2Mi":  resource.MustParse("1Gi"),
+		}),
+		newNode("gpu-node2", v1.ResourceList{"nvidia.com/gpu": resource.MustParse("4")}),
+		newNode("cpu-node", v1.ResourceList{}),
+	}
+	kubeCluster := NewKubeCluster("cluster1", nodes)
+
+	gpu := metrics.ExtendedResourceType("nvidia.com/gpu")
+	hugepages := metrics.ExtendedResourceType("hugepages-2Mi")
+	nodeResource, _ := kubeCluster.Nodes["gpu-node1"].GetComputeResource(gpu)
+	assert.Equal(t, 2.0, nodeResource.Capacity)
+	nodeResource, _ = kubeCluster.Nodes["gpu-node1"].GetComputeResource(hugepages)
+	assert.Equal(t, 1024.0*1024.0, nodeResource.Capacity)
+	nodeResource, _ = kubeCluster.Nodes["cpu-node"].GetComputeResource(gpu)
+	assert.Nil(t, nodeResource)
+
+	assert.Equal(t, 6.0, kubeCluster.ClusterResources[gpu].Capacity)
+	assert.Equal(t, 1024.0*1024.0, kubeCluster.ClusterResources[hugepages].Capacity)
+	assert.Equal(t, 12.0, kubeCluster.ClusterResources[metrics.CPU].Capacity)
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"k8s.io/api/core/v1"
+	storage "k8s.io/api/storage/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+const (
+	// Zone and region labels set on the zonal persistent volumes
+	zoneLabelName   = "failure-domain.beta.kubernetes.io/zone"
+	regionLabelName = "failure-domain.beta.kubernetes.io/region"
+)
+
+// The persistent volume claim in the cluster, with the persistent volume bound to it if any
+type KubeVolume struct {
+	*v1.PersistentVolumeClaim
+	// The bound persistent volume, nil if the claim is not bound yet
+	PersistentVolume *v1.PersistentVolume
+	// The storage class of the claim, nil if the claim has no storage class
+	StorageClass *storage.StorageClass
+
+	VolumeClusterId string
+}
+
+func NewKubeVolume(claim *v1.PersistentVolumeClaim, pv *v1.PersistentVolume,
+	storageClass *storage.StorageClass) *KubeVolume {
+	return &KubeVolume{
+		PersistentVolumeClaim: claim,
+		PersistentVolume:      pv,
+		StorageClass:          storageClass,
+		VolumeClusterId:       util.BuildK8sEntityClusterID(claim.Namespace, claim.Name),
+	}
+}
+
+// Capacity of the volume in MB: the actual capacity of the bound volume, otherwise the requested storage.
+func (volume *KubeVolume) Capacity() float64 {
+	quantity, exists := volume.Status.Capacity[v1.ResourceStorage]
+	if !exists {
+		quantity, exists = volume.Spec.Resources.Requests[v1.ResourceStorage]
+	}
+	if !exists {
+		return DEFAULT_METRIC_VALUE
+	}
+	return float64(quantity.Value()) / util.MegabytesToBytes
+}
+
+// The node labels, in the form of key=value, required by the bound persistent volume.
+// They come from the zone and region labels of the volume, and from its node affinity terms
+// which only allow a single value for a label, e.g., the host name of a local volume.
+// Terms allowing several values cannot be expressed by a single label and are ignored.
+func (volume *KubeVolume) NodeAccessKeys() []string {
+	pv := volume.PersistentVolume
+	if pv == nil {
+		return nil
+	}
+	keySet := make(map[string]bool)
+	for _, labelName := range []string{zoneLabelName, regionLabelName} {
+		if value, exists := pv.Labels[labelName]; exists && value != "" {
+			keySet[labelName+"="+value] = true
+		}
+	}
+	if pv.Spec.NodeAffinity != nil && pv.Spec.NodeAffinity.Required != nil {
+		terms := pv.Spec.NodeAffinity.Required.NodeSelectorTerms
+		// The terms are ORed, so a label is only required if there is a single term
+		if len(terms) == 1 {
+			for _, expression := range terms[0].MatchExpressions {
+				if expression.Operator == v1.NodeSelectorOpIn && len(expression.Values) == 1 {
+					keySet[expression.Key+"="+expression.Values[0]] = true
+				}
+			}
+		}
+	}
+
+	var keys []string
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (volume *KubeVolume) String() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("Volume claim:%s\n", volume.VolumeClusterId))
+	if volume.PersistentVolume != nil {
+		buffer.WriteString(fmt.Sprintf("Persistent volume:%s\n", volume.PersistentVolume.Name))
+	}
+	if volume.StorageClass != nil {
+		buffer.WriteString(fmt.Sprintf("Storage class:%s\n", volume.StorageClass.Name))
+	}
+	buffer.WriteString(fmt.Sprintf("Capacity:%f MB\n", volume.Capacity()))
+	return buffer.String()
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestClaim(request, capacity string) *v1.PersistentVolumeClaim {
+	claim := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "claim1", Namespace: "space1", UID: "claim1-uid"},
+		Spec: v1.PersistentVolumeClaimSpec{
+			VolumeName: "pv1",
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(request)},
+			},
+		},
+	}
+	if capacity != "" {
+		claim.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse(capacity)}
+	}
+	return claim
+}
+
+func TestKubeVolumeCapacity(t *testing.T) {
+	volume := NewKubeVolume(newTestClaim("1Gi", ""), nil, nil)
+	if volume.VolumeClusterId != "space1/claim1" {
+		t.Errorf("Wrong volume cluster id: %s", volume.VolumeClusterId)
+	}
+	if volume.Capacity() != 1024 {
+		t.Errorf("Capacity of an unbound claim should be the request: %f", volume.Capacity())
+	}
+
+	volume = NewKubeVolume(newTestClaim("1Gi", "2Gi"), nil, nil)
+	if volume.Capacity() != 2048 {
+		t.Errorf("Capacity of a bound claim should be the actual capacity: %f", volume.Capacity())
+	}
+}
+
+func TestKubeVolumeNodeAccessKeys(t *testing.T) {
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pv1",
+			Labels: map[string]string{
+				zoneLabelName: "us-east-1a",
+				"other":       "label",
+			},
+		},
+		Spec: v1.PersistentVolumeSpec{
+			NodeAffinity: &v1.VolumeNodeAffinity{
+				Required: &v1.NodeSelector{
+					NodeSelectorTerms: []v1.NodeSelectorTerm{{
+						MatchExpressions: []v1.NodeSelectorRequirement{
+							{Key: "kubernetes.io/hostname", Operator: v1.NodeSelectorOpIn, Values: []string{"node1"}},
+							{Key: "disktype", Operator: v1.NodeSelectorOpIn, Values: []string{"ssd", "nvme"}},
+							{Key: "gpu", Operator: v1.NodeSelectorOpDoesNotExist},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	volume := NewKubeVolume(newTestClaim("1Gi", "1Gi"), pv, nil)
+	expected := []string{zoneLabelName + "=us-east-1a", "kubernetes.io/hostname=node1"}
+	if keys := volume.NodeAccessKeys(); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Wrong access keys: %v Vs. %v", expected, keys)
+	}
+
+	// Several terms are ORed, so none of them is required
+	pv.Spec.NodeAffinity.Required.NodeSelectorTerms = append(pv.Spec.NodeAffinity.Required.NodeSelectorTerms,
+		pv.Spec.NodeAffinity.Required.NodeSelectorTerms[0])
+	expected = []string{zoneLabelName + "=us-east-1a"}
+	if keys := volume.NodeAccessKeys(); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Wrong access keys: %v Vs. %v", expected, keys)
+	}
+
+	// Unbound claim
+	if keys := NewKubeVolume(newTestClaim("1Gi", ""), nil, nil).NodeAccessKeys(); len(keys) != 0 {
+		t.Errorf("Unbound claim should not have access keys: %v", keys)
+	}
+}
@@ -5,6 +5,8 @@ const (
 
 	KilobytesToBytes float64 = 1024.0
 
+	MegabytesToBytes float64 = 1024.0 * 1024.0
+
 	MilliToUnit float64 = 1e3
 
 	MegaToKilo float64 = 1e3
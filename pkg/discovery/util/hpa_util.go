@@ -0,0 +1,32 @@
+package util
+
+import (
+	autoscaling "k8s.io/api/autoscaling/v1"
+)
+
+// GetHorizontalPodAutoscalerForController returns the HorizontalPodAutoscaler, among the given ones, whose scale
+// target is the controller of the given namespace, kind and name, or nil if the controller is not autoscaled.
+func GetHorizontalPodAutoscalerForController(namespace, kind, name string,
+	hpas []*autoscaling.HorizontalPodAutoscaler) *autoscaling.HorizontalPodAutoscaler {
+	for _, hpa := range hpas {
+		target := hpa.Spec.ScaleTargetRef
+		if hpa.Namespace == namespace && target.Kind == kind && target.Name == name {
+			return hpa
+		}
+	}
+	return nil
+}
+
+// HorizontalPodAutoscalerName returns the name of a HorizontalPodAutoscaler qualified by its namespace.
+func HorizontalPodAutoscalerName(hpa *autoscaling.HorizontalPodAutoscaler) string {
+	return hpa.Namespace + "/" + hpa.Name
+}
+
+// HorizontalPodAutoscalerMinReplicas returns the lower limit of the replicas of a HorizontalPodAutoscaler,
+// which defaults to 1.
+func HorizontalPodAutoscalerMinReplicas(hpa *autoscaling.HorizontalPodAutoscaler) int32 {
+	if hpa.Spec.MinReplicas == nil {
+		return 1
+	}
+	return *hpa.Spec.MinReplicas
+}
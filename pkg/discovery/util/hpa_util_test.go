@@ -0,0 +1,47 @@
+package util
+
+import (
+	"testing"
+
+	autoscaling "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newHPA(namespace, name, kind, targetName string) *autoscaling.HorizontalPodAutoscaler {
+	return &autoscaling.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: autoscaling.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscaling.CrossVersionObjectReference{Kind: kind, Name: targetName},
+			MaxReplicas:    10,
+		},
+	}
+}
+
+func TestGetHorizontalPodAutoscalerForController(t *testing.T) {
+	hpas := []*autoscaling.HorizontalPodAutoscaler{
+		newHPA("ns2", "other-namespace", "Deployment", "web"),
+		newHPA("ns1", "other-kind", "ReplicaSet", "web"),
+		newHPA("ns1", "web-hpa", "Deployment", "web"),
+	}
+
+	hpa := GetHorizontalPodAutoscalerForController("ns1", "Deployment", "web", hpas)
+	if hpa == nil || HorizontalPodAutoscalerName(hpa) != "ns1/web-hpa" {
+		t.Errorf("Expected HorizontalPodAutoscaler ns1/web-hpa but got %v", hpa)
+	}
+	if hpa := GetHorizontalPodAutoscalerForController("ns1", "Deployment", "db", hpas); hpa != nil {
+		t.Errorf("Expected no HorizontalPodAutoscaler for Deployment ns1/db but got %s",
+			HorizontalPodAutoscalerName(hpa))
+	}
+}
+
+func TestHorizontalPodAutoscalerMinReplicas(t *testing.T) {
+	hpa := newHPA("ns1", "web-hpa", "Deployment", "web")
+	if min := HorizontalPodAutoscalerMinReplicas(hpa); min != 1 {
+		t.Errorf("Expected the default minimum of 1 replica but got %d", min)
+	}
+	minReplicas := int32(3)
+	hpa.Spec.MinReplicas = &minReplicas
+	if min := HorizontalPodAutoscalerMinReplicas(hpa); min != 3 {
+		t.Errorf("Expected the minimum of 3 replicas but got %d", min)
+	}
+}
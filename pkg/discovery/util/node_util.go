@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/detectors"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
 	api "k8s.io/api/core/v1"
 
 	"github.com/golang/glog"
@@ -13,18 +14,14 @@ import (
 func GetNodeIPForMonitor(node *api.Node, source types.MonitoringSource) (string, error) {
 	switch source {
 	case types.KubeletSource, types.K8sConntrackSource:
-		hostname, ip := node.Name, ""
-		for _, addr := range node.Status.Addresses {
-			if addr.Type == api.NodeHostName && addr.Address != "" {
-				hostname = addr.Address
-			}
-			if addr.Type == api.NodeInternalIP && addr.Address != "" {
-				ip = addr.Address
-			}
-		}
+		ip := goutil.GetKubeletIP(node)
 		if ip != "" {
 			return ip, nil
 		}
+		hostname := goutil.ParseNodeIP(node, api.NodeHostName)
+		if hostname == "" {
+			hostname = node.Name
+		}
 		return "", fmt.Errorf("Node %v has no valid hostname and/or IP address: %v %v", node.Name, hostname, ip)
 	default:
 		return "", errors.New("Unsupported monitoring source or monitoring source not provided")
@@ -48,12 +45,7 @@ func NodeIsSchedulable(node *api.Node) bool {
 }
 
 func GetNodeIP(node *api.Node) (string, error) {
-	ip := ""
-	for _, addr := range node.Status.Addresses {
-		if addr.Type == api.NodeInternalIP && addr.Address != "" {
-			ip = addr.Address
-		}
-	}
+	ip := goutil.GetKubeletIP(node)
 	if ip != "" {
 		return ip, nil
 	}
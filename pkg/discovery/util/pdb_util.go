@@ -0,0 +1,62 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+	api "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// GetPodDisruptionBudgetsForPod returns the PodDisruptionBudgets, among the given ones, whose selector
+// matches the pod. As for the disruption controller, a budget with a nil selector matches no pod, and a budget
+// with an empty selector matches all the pods of its namespace if read through policy/v1, or no pod otherwise.
+func GetPodDisruptionBudgetsForPod(pod *api.Pod, pdbs []*policy.PodDisruptionBudget) []*policy.PodDisruptionBudget {
+	var result []*policy.PodDisruptionBudget
+	if len(pod.Labels) == 0 {
+		return result
+	}
+	for _, pdb := range pdbs {
+		if pdb.Namespace != pod.Namespace || pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			glog.Warningf("Invalid selector of PodDisruptionBudget %s/%s: %v", pdb.Namespace, pdb.Name, err)
+			continue
+		}
+		if selector.Empty() && pdb.APIVersion != goutil.PolicyV1GroupVersion {
+			continue
+		}
+		if !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		result = append(result, pdb)
+	}
+	return result
+}
+
+// CheckPodDisruptionAllowed returns an error if any of the given PodDisruptionBudgets of a pod
+// does not allow the disruption of one more pod.
+func CheckPodDisruptionAllowed(pod *api.Pod, pdbs []*policy.PodDisruptionBudget) error {
+	for _, pdb := range pdbs {
+		if pdb.Status.ObservedGeneration < pdb.Generation {
+			return fmt.Errorf("the status of PodDisruptionBudget %s/%s protecting pod %s/%s is not up to date",
+				pdb.Namespace, pdb.Name, pod.Namespace, pod.Name)
+		}
+		if pdb.Status.PodDisruptionsAllowed < 1 {
+			return fmt.Errorf("disrupting pod %s/%s would violate PodDisruptionBudget %s/%s "+
+				"(%d healthy pods, %d desired healthy pods)", pod.Namespace, pod.Name, pdb.Namespace, pdb.Name,
+				pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy)
+		}
+	}
+	return nil
+}
+
+// PodDisruptionBudgetName returns the name of a PodDisruptionBudget qualified by its namespace.
+func PodDisruptionBudgetName(pdb *policy.PodDisruptionBudget) string {
+	return pdb.Namespace + "/" + pdb.Name
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newPDB(namespace, name string, selector *metav1.LabelSelector, disruptionsAllowed int32) *policy.PodDisruptionBudget {
+	return &policy.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       policy.PodDisruptionBudgetSpec{Selector: selector},
+		Status:     policy.PodDisruptionBudgetStatus{PodDisruptionsAllowed: disruptionsAllowed},
+	}
+}
+
+func TestGetPodDisruptionBudgetsForPod(t *testing.T) {
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns1",
+			Name:      "zk-0",
+			Labels:    map[string]string{"app": "zk", "tier": "db"},
+		},
+	}
+	appSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "zk"}}
+	pdbs := []*policy.PodDisruptionBudget{
+		newPDB("ns1", "zk-pdb", appSelector, 1),
+		newPDB("ns2", "other-namespace", appSelector, 1),
+		newPDB("ns1", "other-app", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}, 1),
+		newPDB("ns1", "nil-selector", nil, 1),
+		newPDB("ns1", "empty-selector", &metav1.LabelSelector{}, 1),
+		newPDB("ns1", "tier-pdb", &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "tier",
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{"db", "cache"},
+			}},
+		}, 0),
+	}
+
+	matching := GetPodDisruptionBudgetsForPod(pod, pdbs)
+	if len(matching) != 2 {
+		t.Fatalf("Expected 2 matching PodDisruptionBudgets but got %d", len(matching))
+	}
+	if PodDisruptionBudgetName(matching[0]) != "ns1/zk-pdb" || PodDisruptionBudgetName(matching[1]) != "ns1/tier-pdb" {
+		t.Errorf("Unexpected matching PodDisruptionBudgets %s and %s",
+			PodDisruptionBudgetName(matching[0]), PodDisruptionBudgetName(matching[1]))
+	}
+
+	// An empty selector of policy/v1 matches all the pods of the namespace
+	v1Empty := newPDB("ns1", "v1-empty-selector", &metav1.LabelSelector{}, 1)
+	v1Empty.APIVersion = "policy/v1"
+	pdbs = append(pdbs, v1Empty)
+	matching = GetPodDisruptionBudgetsForPod(pod, pdbs)
+	if len(matching) != 3 || PodDisruptionBudgetName(matching[2]) != "ns1/v1-empty-selector" {
+		t.Errorf("Expected the policy/v1 PodDisruptionBudget with an empty selector to match, got %d", len(matching))
+	}
+
+	pod.Labels = nil
+	if matching := GetPodDisruptionBudgetsForPod(pod, pdbs); len(matching) != 0 {
+		t.Errorf("Expected no PodDisruptionBudget matching a pod without labels but got %d", len(matching))
+	}
+}
+
+func TestCheckPodDisruptionAllowed(t *testing.T) {
+	pod := &api.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "zk-0"}}
+	allowed := newPDB("ns1", "allowed", nil, 1)
+	notAllowed := newPDB("ns1", "not-allowed", nil, 0)
+	outdated := newPDB("ns1", "outdated", nil, 1)
+	outdated.Generation = 2
+	outdated.Status.ObservedGeneration = 1
+
+	table := []struct {
+		pdbs    []*policy.PodDisruptionBudget
+		allowed bool
+	}{
+		{pdbs: nil, allowed: true},
+		{pdbs: []*policy.PodDisruptionBudget{allowed}, allowed: true},
+		{pdbs: []*policy.PodDisruptionBudget{allowed, notAllowed}, allowed: false},
+		{pdbs: []*policy.PodDisruptionBudget{outdated}, allowed: false},
+	}
+	for i, item := range table {
+		err := CheckPodDisruptionAllowed(pod, item.pdbs)
+		if item.allowed && err != nil {
+			t.Errorf("Test case %d: expected the disruption to be allowed but got %v", i, err)
+		}
+		if !item.allowed && err == nil {
+			t.Errorf("Test case %d: expected the disruption not to be allowed", i)
+		}
+	}
+}
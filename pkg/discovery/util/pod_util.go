@@ -8,6 +8,7 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/detectors"
+	apps "k8s.io/api/apps/v1"
 	api "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	client "k8s.io/client-go/kubernetes"
@@ -269,6 +270,16 @@ func GetPodParentInfo(pod *api.Pod) (string, string, error) {
 // If parent does not have parent, then return parent info.
 // Note: if parent kind is "ReplicaSet", then its parent's parent can be a "Deployment"
 func GetPodGrandInfo(kclient *client.Clientset, pod *api.Pod) (string, string, error) {
+	return GetPodGrandInfoFromReplicaSet(pod, func(namespace, name string) (*apps.ReplicaSet, error) {
+		return goutil.NewAppsClient(kclient).ReplicaSets(namespace).Get(name, metav1.GetOptions{})
+	})
+}
+
+// ReplicaSetGetter gets the ReplicaSet of the given namespace and name, either from the API server or a cache.
+type ReplicaSetGetter func(namespace, name string) (*apps.ReplicaSet, error)
+
+// GetPodGrandInfoFromReplicaSet is the same as GetPodGrandInfo, with the ReplicaSet parent got by the getter.
+func GetPodGrandInfoFromReplicaSet(pod *api.Pod, getReplicaSet ReplicaSetGetter) (string, string, error) {
 	//1. get Parent info: kind and name;
 	kind, name, err := GetPodParentInfo(pod)
 	if err != nil {
@@ -278,7 +289,7 @@ func GetPodGrandInfo(kclient *client.Clientset, pod *api.Pod) (string, string, e
 	//2. if parent is "ReplicaSet", check parent's parent
 	if strings.EqualFold(kind, Kind_ReplicaSet) {
 		//2.1 get parent object
-		rs, err := kclient.ExtensionsV1beta1().ReplicaSets(pod.Namespace).Get(name, metav1.GetOptions{})
+		rs, err := getReplicaSet(pod.Namespace, name)
 		if err != nil {
 			err = fmt.Errorf("Failed to get ReplicaSet[%v/%v]: %v", pod.Namespace, name, err)
 			glog.Error(err.Error())
@@ -2,6 +2,8 @@ package util
 
 import (
 	"errors"
+	"sort"
+	"strings"
 
 	api "k8s.io/api/core/v1"
 )
@@ -31,3 +33,62 @@ func GetCpuAndMemoryValues(resource api.ResourceList) (cpuCapacityCore, memoryCa
 
 	return
 }
+
+// IsExtendedResourceName returns true for the resources other than the native resources of the kubernetes.io
+// domain, e.g., the resources advertised by the device plugins such as nvidia.com/gpu, and for the hugepages.
+func IsExtendedResourceName(name api.ResourceName) bool {
+	if strings.HasPrefix(string(name), api.ResourceHugePagesPrefix) {
+		return true
+	}
+	// The resource names without a domain, such as cpu or pods, are implicitly in the kubernetes.io domain,
+	// and the requests. prefix is reserved for the quotas
+	return strings.Contains(string(name), "/") &&
+		!strings.Contains(string(name), api.ResourceDefaultNamespacePrefix) &&
+		!strings.HasPrefix(string(name), api.DefaultResourceRequestsPrefix)
+}
+
+// GetExtendedResourceValues returns the values of the extended resources of the given resource list.
+// Hugepages are in Kb like the memory, and the other extended resources are in number of devices.
+func GetExtendedResourceValues(resources api.ResourceList) map[api.ResourceName]float64 {
+	values := make(map[api.ResourceName]float64)
+	for name, quantity := range resources {
+		if !IsExtendedResourceName(name) {
+			continue
+		}
+		if strings.HasPrefix(string(name), api.ResourceHugePagesPrefix) {
+			values[name] = float64(quantity.Value()) / KilobytesToBytes
+		} else {
+			values[name] = float64(quantity.Value())
+		}
+	}
+	return values
+}
+
+// GetPodExtendedResourceRequests returns the extended resources requested by the pod: the sum of the requests
+// of its containers, or the request of its largest init container if it is higher, as they run one by one.
+func GetPodExtendedResourceRequests(pod *api.Pod) map[api.ResourceName]float64 {
+	requests := make(map[api.ResourceName]float64)
+	for _, container := range pod.Spec.Containers {
+		for name, value := range GetExtendedResourceValues(container.Resources.Requests) {
+			requests[name] += value
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, value := range GetExtendedResourceValues(container.Resources.Requests) {
+			if value > requests[name] {
+				requests[name] = value
+			}
+		}
+	}
+	return requests
+}
+
+// SortedResourceNames returns the names of the given resources in alphabetical order.
+func SortedResourceNames(values map[api.ResourceName]float64) []api.ResourceName {
+	names := make([]api.ResourceName, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
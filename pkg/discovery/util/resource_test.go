@@ -0,0 +1,80 @@
+package util
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestIsExtendedResourceName(t *testing.T) {
+	table := []struct {
+		name     api.ResourceName
+		extended bool
+	}{
+		{api.ResourceCPU, false},
+		{api.ResourceMemory, false},
+		{api.ResourcePods, false},
+		{api.ResourceEphemeralStorage, false},
+		{"kubernetes.io/some-native-resource", false},
+		{"requests.nvidia.com/gpu", false},
+		{"nvidia.com/gpu", true},
+		{"example.com/fpga", true},
+		{"hugepages-2Mi", true},
+	}
+	for _, item := range table {
+		if IsExtendedResourceName(item.name) != item.extended {
+			t.Errorf("Expected IsExtendedResourceName(%s) to be %v", item.name, item.extended)
+		}
+	}
+}
+
+func TestGetPodExtendedResourceRequests(t *testing.T) {
+	pod := &api.Pod{
+		Spec: api.PodSpec{
+			InitContainers: []api.Container{{
+				Name: "init",
+				Resources: api.ResourceRequirements{Requests: api.ResourceList{
+					"nvidia.com/gpu":   resource.MustParse("3"),
+					"example.com/fpga": resource.MustParse("1"),
+				}},
+			}},
+			Containers: []api.Container{
+				{
+					Name: "c1",
+					Resources: api.ResourceRequirements{Requests: api.ResourceList{
+						api.ResourceCPU:  resource.MustParse("1"),
+						"nvidia.com/gpu": resource.MustParse("1"),
+						"hugepages-2Mi":  resource.MustParse("4Mi"),
+					}},
+				},
+				{
+					Name: "c2",
+					Resources: api.ResourceRequirements{Requests: api.ResourceList{
+						"nvidia.com/gpu":   resource.MustParse("1"),
+						"example.com/fpga": resource.MustParse("2"),
+					}},
+				},
+			},
+		},
+	}
+	expected := map[api.ResourceName]float64{
+		"nvidia.com/gpu":   3,
+		"example.com/fpga": 2,
+		"hugepages-2Mi":    4096,
+	}
+
+	requests := GetPodExtendedResourceRequests(pod)
+	if len(requests) != len(expected) {
+		t.Errorf("Expected %d extended resources but got %v", len(expected), requests)
+	}
+	for name, value := range expected {
+		if requests[name] != value {
+			t.Errorf("Expected %s request %f but got %f", name, value, requests[name])
+		}
+	}
+	names := SortedResourceNames(requests)
+	if len(names) != 3 || names[0] != "example.com/fpga" || names[2] != "nvidia.com/gpu" {
+		t.Errorf("Unexpected sorted resource names %v", names)
+	}
+}
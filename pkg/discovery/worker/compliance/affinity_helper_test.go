@@ -51,7 +51,7 @@ func TestMatchesSelector(t *testing.T) {
 	}
 
 	for i, item := range table {
-		matches := matchesNodeSelector(item.pod, item.node)
+		matches := MatchesNodeSelector(item.pod, item.node)
 		if matches != item.expectMatches {
 			t.Errorf("Test case %d failed. Expected matches %t, got %t", i, item.expectMatches, matches)
 		}
@@ -130,7 +130,7 @@ func TestMatchesNodeAffinity(t *testing.T) {
 	}
 
 	for i, item := range table {
-		matches := matchesNodeAffinity(item.pod, item.node)
+		matches := MatchesNodeAffinity(item.pod, item.node)
 		if matches != item.expectMatches {
 			t.Errorf("Test case %d failed. Expected matches %t, got %t", i, item.expectMatches, matches)
 		}
@@ -251,7 +251,7 @@ func TestMatchesNodeSelectorTerms(t *testing.T) {
 	}
 
 	for i, item := range table {
-		matches := nodeMatchesNodeSelectorTerms(item.node, item.nodeSelectorTerms)
+		matches := NodeMatchesNodeSelectorTerms(item.node, item.nodeSelectorTerms)
 		if matches != item.expectsMatches {
 			t.Errorf("Test case %d failed. Expects %t, got %t", i, item.expectsMatches, matches)
 		}
@@ -7,6 +7,8 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+	goutil "github.com/turbonomic/kubeturbo/pkg/util"
+	autoscaling "k8s.io/api/autoscaling/v1"
 	"k8s.io/api/core/v1"
 )
 
@@ -32,18 +34,29 @@ func (collector *GroupMetricsCollector) CollectGroupMetrics() ([]*repository.Ent
 	podMembers := make(map[string]map[string][]string)       // pods by parent kind and instance
 	containerMembers := make(map[string]map[string][]string) // container by parent kind and instance
 	podByParentMembers := make(map[string][]string)          // pods by parent kind
+	podByPDBMembers := make(map[string][]string)             // pods by PodDisruptionBudget
+	podByHPAMembers := make(map[string][]string)             // pods by HorizontalPodAutoscaler
 
 	// Iterate over list of pods to get the owner metric for each
 	for _, pod := range collector.PodList {
-		// Parent for the pod
 		podKey := util.PodKeyFunc(pod)
+		podId := string(pod.UID)
+		// PodDisruptionBudgets protecting the pod, regardless of its parent
+		for _, pdbName := range collector.getDisruptionBudgets(etype, podKey) {
+			podByPDBMembers[pdbName] = append(podByPDBMembers[pdbName], podId)
+		}
+		// HorizontalPodAutoscaler scaling the parent of the pod
+		if hpaName := collector.getHorizontalPodAutoscaler(etype, podKey); hpaName != "" {
+			podByHPAMembers[hpaName] = append(podByHPAMembers[hpaName], podId)
+		}
+
+		// Parent for the pod
 		ownerTypeString, ownerString, err := collector.getGroupName(etype, podKey)
 		if err != nil {
 			continue
 		}
 
 		// Add pod id to its owner group membership
-		podId := string(pod.UID)
 		ownerTypeMap, ownerTypeExists := podMembers[ownerTypeString]
 		if !ownerTypeExists {
 			podMembers[ownerTypeString] = make(map[string][]string)
@@ -98,9 +111,54 @@ func (collector *GroupMetricsCollector) CollectGroupMetrics() ([]*repository.Ent
 		entityGroupList = append(entityGroupList, entityGroup)
 		glog.V(4).Infof("[discovery_worker] created group --> %++v\n", entityGroup.GroupId)
 	}
+
+	// Pod Group per PodDisruptionBudget
+	for pdbName, podList := range podByPDBMembers {
+		entityGroup, _ := repository.NewEntityGroup(goutil.KindPodDisruptionBudget, pdbName)
+		for _, pod := range podList {
+			entityGroup.AddMember(metrics.PodType, pod)
+		}
+		entityGroupList = append(entityGroupList, entityGroup)
+		glog.V(4).Infof("[discovery_worker] created group --> %++v\n", entityGroup.GroupId)
+	}
+
+	// Pod Group per HorizontalPodAutoscaler
+	for hpaName, podList := range podByHPAMembers {
+		entityGroup, _ := repository.NewEntityGroup(goutil.KindHorizontalPodAutoscaler, hpaName)
+		for _, pod := range podList {
+			entityGroup.AddMember(metrics.PodType, pod)
+		}
+		entityGroupList = append(entityGroupList, entityGroup)
+		glog.V(4).Infof("[discovery_worker] created group --> %++v\n", entityGroup.GroupId)
+	}
 	return entityGroupList, nil
 }
 
+// Get the names of the PodDisruptionBudgets protecting the pod, if any.
+func (collector *GroupMetricsCollector) getDisruptionBudgets(etype metrics.DiscoveredEntityType, entityKey string) []string {
+	pdbMetric, err := collector.MetricsSink.GetMetric(metrics.GenerateEntityStateMetricUID(etype, entityKey,
+		metrics.DisruptionBudgets))
+	if err != nil {
+		return nil
+	}
+	pdbNames, _ := pdbMetric.GetValue().([]string)
+	return pdbNames
+}
+
+// Get the namespace/name of the HorizontalPodAutoscaler scaling the parent of the pod, if any.
+func (collector *GroupMetricsCollector) getHorizontalPodAutoscaler(etype metrics.DiscoveredEntityType, entityKey string) string {
+	hpaMetric, err := collector.MetricsSink.GetMetric(metrics.GenerateEntityStateMetricUID(etype, entityKey,
+		metrics.HorizontalPodAutoscaler))
+	if err != nil {
+		return ""
+	}
+	hpa, ok := hpaMetric.GetValue().(*autoscaling.HorizontalPodAutoscaler)
+	if !ok {
+		return ""
+	}
+	return util.HorizontalPodAutoscalerName(hpa)
+}
+
 func (collector *GroupMetricsCollector) getGroupName(etype metrics.DiscoveredEntityType, entityKey string) (string, string, error) {
 	ownerTypeMetricId := metrics.GenerateEntityStateMetricUID(etype, entityKey, metrics.OwnerType)
 	ownerMetricId := metrics.GenerateEntityStateMetricUID(etype, entityKey, metrics.Owner)
@@ -331,15 +331,17 @@ func (worker *k8sDiscoveryWorker) buildDTOs(currTask *task.Task) ([]*proto.Entit
 	//2. build entityDTOs for pods
 	quotaNameUIDMap := make(map[string]string)
 	nodeNameUIDMap := make(map[string]string)
+	volumes := make(map[string]*repository.KubeVolume)
 	if cluster != nil {
 		quotaNameUIDMap = cluster.QuotaNameUIDMap // quota providers
 		nodeNameUIDMap = cluster.NodeNameUIDMap   // node providers
+		volumes = cluster.Volumes                 // volume providers
 	}
 	pods := currTask.PodList()
 	glog.V(3).Infof("Worker %s received %d pods.", worker.id, len(pods))
 
 	podEntityDTOBuilder := dtofactory.NewPodEntityDTOBuilder(worker.sink, stitchingManager,
-		nodeNameUIDMap, quotaNameUIDMap)
+		nodeNameUIDMap, quotaNameUIDMap).WithVolumes(volumes)
 	podEntityDTOs, err := podEntityDTOBuilder.BuildEntityDTOs(pods)
 	if err != nil {
 		glog.Errorf("Error while creating pod entityDTOs: %v", err)
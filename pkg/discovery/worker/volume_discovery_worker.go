@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"fmt"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	k8sVolumeDiscWorkerID string = "VolumeDiscoveryWorker"
+)
+
+// Converts the cluster volumes to create the volume DTOs, with the usage reported by the pods mounting them
+type k8sVolumeDiscoveryWorker struct {
+	id      string
+	Cluster *repository.ClusterSummary
+}
+
+func Newk8sVolumeDiscoveryWorker(cluster *repository.ClusterSummary) *k8sVolumeDiscoveryWorker {
+	return &k8sVolumeDiscoveryWorker{
+		Cluster: cluster,
+		id:      k8sVolumeDiscWorkerID,
+	}
+}
+
+func (worker *k8sVolumeDiscoveryWorker) Do(entityDTOs []*proto.EntityDTO) ([]*proto.EntityDTO, error) {
+	volumeEntityDTOBuilder := dtofactory.NewVolumeEntityDTOBuilder(worker.Cluster.Volumes, volumeUsage(entityDTOs))
+	volumeEntityDTOs, err := volumeEntityDTOBuilder.BuildEntityDTOs()
+	if err != nil {
+		return nil, fmt.Errorf("error while creating volume entityDTOs: %v", err)
+	}
+
+	return volumeEntityDTOs, nil
+}
+
+// Get the used storage of each volume from the commodities bought by the pods.
+// The pods mounting the same volume report the usage of the same file system, so the largest one is kept.
+func volumeUsage(entityDTOs []*proto.EntityDTO) map[string]float64 {
+	volumeUsed := make(map[string]float64)
+	for _, dto := range entityDTOs {
+		if dto.GetEntityType() != proto.EntityDTO_CONTAINER_POD {
+			continue
+		}
+		for _, commBought := range dto.GetCommoditiesBought() {
+			if commBought.GetProviderType() != proto.EntityDTO_VIRTUAL_VOLUME {
+				continue
+			}
+			volumeID := commBought.GetProviderId()
+			for _, comm := range commBought.GetBought() {
+				if comm.GetCommodityType() == proto.CommodityDTO_STORAGE_AMOUNT && comm.GetUsed() > volumeUsed[volumeID] {
+					volumeUsed[volumeID] = comm.GetUsed()
+				}
+			}
+		}
+	}
+	return volumeUsed
+}
@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/detectors"
 	"io/ioutil"
+	"time"
 
 	restclient "k8s.io/client-go/rest"
 
 	"github.com/turbonomic/kubeturbo/pkg/action"
+	"github.com/turbonomic/kubeturbo/pkg/action/executor"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
 	"github.com/turbonomic/kubeturbo/pkg/registration"
@@ -21,6 +24,15 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/master"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/metricsserver"
+	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
+)
+
+const (
+	// The cluster cache is kept up to date by the watches, there is no need to resync it
+	clusterCacheResyncPeriod = 0
+	// The objects of the informers not synced in time, e.g., whose API is not served, are read from the API server
+	clusterCacheSyncTimeout = 2 * time.Minute
 )
 
 type K8sTAPServiceSpec struct {
@@ -28,6 +40,7 @@ type K8sTAPServiceSpec struct {
 	*configs.K8sTargetConfig          `json:"targetConfig,omitempty"`
 	*detectors.MasterNodeDetectors    `json:"masterNodeDetectors,omitempty"`
 	*detectors.DaemonPodDetectors     `json:"daemonPodDetectors,omitempty"`
+	*configs.ResourceMonitoringConfig `json:"resourceMonitoringConfig,omitempty"`
 }
 
 func ParseK8sTAPServiceSpec(configFile, defaultTargetName string) (*K8sTAPServiceSpec, error) {
@@ -54,6 +67,12 @@ func ParseK8sTAPServiceSpec(configFile, defaultTargetName string) (*K8sTAPServic
 	if err := tapSpec.ValidateK8sTargetConfig(); err != nil {
 		return nil, err
 	}
+	if tapSpec.ResourceMonitoringConfig == nil {
+		tapSpec.ResourceMonitoringConfig = &configs.ResourceMonitoringConfig{}
+	}
+	if err := tapSpec.ValidateResourceMonitoringConfig(); err != nil {
+		return nil, err
+	}
 	if err := detectors.ValidateAndParseDetectors(tapSpec.MasterNodeDetectors, tapSpec.DaemonPodDetectors); err != nil {
 		return nil, err
 	}
@@ -77,16 +96,31 @@ func createTargetConfig(kubeConfig *restclient.Config) *configs.K8sTargetConfig
 	return &configs.K8sTargetConfig{TargetIdentifier: kubeConfig.Host}
 }
 
-func createProbeConfigOrDie(c *Config) *configs.ProbeConfig {
-	// Create Kubelet monitoring
-	kubeletMonitoringConfig := kubelet.NewKubeletMonitorConfig(c.KubeletClient)
+// Create the probe configuration. The optional kubelet sampler is not started here, but by the caller that owns it.
+func createProbeConfigOrDie(c *Config, clusterScraper *cluster.ClusterScraper,
+	sampler *kubelet.KubeletSampler) *configs.ProbeConfig {
+	// Create resource monitoring, either from the kubelets or from the metrics server
+	var resourceMonitoringConfig monitoring.MonitorWorkerConfig
+	var metricsServerClient *kubeclient.MetricsServerClient
+	if c.tapSpec != nil && c.tapSpec.ResourceMonitoringConfig != nil && c.tapSpec.UseMetricsServer() {
+		glog.V(2).Infof("Using metrics server as the resource monitoring source.")
+		metricsServerClient = c.MetricsServerClient
+		resourceMonitoringConfig = metricsserver.NewMetricsServerMonitorConfig(metricsServerClient,
+			c.tapSpec.CpuFrequencyMHz)
+	} else {
+		kubeletMonitoringConfig := kubelet.NewKubeletMonitorConfig(c.KubeletClient).
+			WithNodeNetworkCapacity(c.NodeNetworkCapacityMbps)
+		if sampler != nil {
+			kubeletMonitoringConfig.WithSampler(sampler)
+		}
+		resourceMonitoringConfig = kubeletMonitoringConfig
+	}
 
 	// Create cluster monitoring
-	masterMonitoringConfig := master.NewClusterMonitorConfig(c.Client)
+	masterMonitoringConfig := master.NewClusterMonitorConfig(clusterScraper)
 
-	// TODO for now kubelet is the only monitoring source. As we have more sources, we should choose what to be added into the slice here.
 	monitoringConfigs := []monitoring.MonitorWorkerConfig{
-		kubeletMonitoringConfig,
+		resourceMonitoringConfig,
 		masterMonitoringConfig,
 	}
 
@@ -94,14 +128,40 @@ func createProbeConfigOrDie(c *Config) *configs.ProbeConfig {
 		StitchingPropertyType: c.StitchingPropType,
 		MonitoringConfigs:     monitoringConfigs,
 		ClusterClient:         c.Client,
+		ClusterScraper:        clusterScraper,
 		NodeClient:            c.KubeletClient,
+		MetricsServerClient:   metricsServerClient,
 	}
 
 	return probeConfig
 }
 
+// Create the discovery client, tracking the changes of the cluster for the incremental discovery if enabled.
+func newDiscoveryClient(config *Config, clusterScraper *cluster.ClusterScraper,
+	sampler *kubelet.KubeletSampler, incrementalDiscovery bool) *discovery.K8sDiscoveryClient {
+	probeConfig := createProbeConfigOrDie(config, clusterScraper, sampler)
+	discoveryClientConfig := discovery.NewDiscoveryConfig(probeConfig, config.tapSpec.K8sTargetConfig, config.ValidationWorkers, config.ValidationTimeoutSec).
+		WithIncrementalDiscovery(incrementalDiscovery).
+		WithSnapshotPath(config.DiscoverySnapshotPath)
+	return discovery.NewK8sDiscoveryClient(discoveryClientConfig)
+}
+
 type K8sTAPService struct {
 	*service.TAPService
+	// The optional sampler of the kubelet usage between discoveries, started when the service connects to the server
+	sampler *kubelet.KubeletSampler
+	stopCh  <-chan struct{}
+}
+
+// Create the sampler of the kubelet usage if it is enabled, and the kubelets are the resource monitoring source.
+func newKubeletSampler(config *Config) *kubelet.KubeletSampler {
+	if config.UsageSamplingIntervalSec <= 0 ||
+		(config.tapSpec.ResourceMonitoringConfig != nil && config.tapSpec.UseMetricsServer()) {
+		return nil
+	}
+	return kubelet.NewKubeletSampler(config.KubeletClient,
+		time.Duration(config.UsageSamplingIntervalSec)*time.Second,
+		time.Duration(config.DiscoveryIntervalSec)*time.Second, config.UsageSamplingPercentile)
 }
 
 func NewKubernetesTAPService(config *Config) (*K8sTAPService, error) {
@@ -112,27 +172,62 @@ func NewKubernetesTAPService(config *Config) (*K8sTAPService, error) {
 	// Create the configurations for the registration, discovery and action clients
 	registrationClientConfig := registration.NewRegistrationClientConfig(config.StitchingPropType, config.VMPriority, config.VMIsBase)
 
-	probeConfig := createProbeConfigOrDie(config)
-	discoveryClientConfig := discovery.NewDiscoveryConfig(probeConfig, config.tapSpec.K8sTargetConfig, config.ValidationWorkers, config.ValidationTimeoutSec)
+	// The cluster cache shared by the discovery and the action execution
+	clusterCache := cluster.NewClusterCache(config.Client, clusterCacheResyncPeriod)
+	clusterScraper := cluster.NewClusterScraper(config.Client).WithClusterCache(clusterCache)
+	config.KubeletClient.WithNodeLister(clusterCache.NodeLister())
+
+	moveStrategies, err := executor.NewMoveStrategySelector(config.MoveStrategy, config.MoveStrategyNamespaces,
+		config.MoveStrategyControllerKinds)
+	if err != nil {
+		return nil, err
+	}
+	glog.V(2).Infof("Pod move strategies: %v", moveStrategies)
 
-	actionHandlerConfig := action.NewActionHandlerConfig(config.CAPINamespace, config.CAClient, config.Client, config.KubeletClient, config.SccSupport)
+	actionHandlerConfig := action.NewActionHandlerConfig(config.CAPINamespace, config.CAClient, config.Client, config.KubeletClient, clusterScraper, config.SccSupport).
+		WithResizeClampedToLimitRange(config.ClampResizeToLimitRange).
+		WithScaleThroughHPA(config.ScaleThroughHPA).
+		WithEventRecorder(config.EventRecorder).
+		WithMoveStrategies(moveStrategies).
+		WithNodeDrain(config.DrainNodes).
+		WithResizeVerification(time.Duration(config.ResizeVerificationWindowSec)*time.Second,
+			int32(config.ResizeRestartThreshold))
 
 	// Kubernetes Probe Registration Client
 	registrationClient := registration.NewK8sRegistrationClient(registrationClientConfig)
 
 	// Kubernetes Probe Discovery Client
-	discoveryClient := discovery.NewK8sDiscoveryClient(discoveryClientConfig)
+	sampler := newKubeletSampler(config)
+	discoveryClient := newDiscoveryClient(config, clusterScraper, sampler, config.IncrementalDiscoveryIntervalSec > 0)
 
 	// Kubernetes Probe Action Execution Client
 	actionHandler := action.NewActionHandler(actionHandlerConfig)
 
+	// Start the cluster cache once the discovery client tracks its changes
+	clusterCache.Start(config.StopEverything)
+	if err := clusterCache.WaitForCacheSync(config.StopEverything, clusterCacheSyncTimeout); err != nil {
+		glog.Warningf("%v, reading these objects from the API server until they are synced.", err)
+	}
+	if config.ClonePodGCIntervalSec > 0 {
+		collector := executor.NewClonePodCollector(config.Client, clusterScraper, config.ClonePodGCDryRun)
+		go collector.Run(time.Duration(config.ClonePodGCIntervalSec)*time.Second, config.StopEverything)
+	}
+
+	discoveryOptions := []probe.DiscoveryMetadataOption{
+		probe.FullRediscoveryIntervalSecondsOption(int32(config.DiscoveryIntervalSec)),
+	}
+	if config.IncrementalDiscoveryIntervalSec > 0 {
+		discoveryOptions = append(discoveryOptions,
+			probe.IncrementalRediscoveryIntervalSecondsOption(int32(config.IncrementalDiscoveryIntervalSec)))
+	}
+
 	// The KubeTurbo TAP Service that will register the kubernetes target with the
 	// Turbonomic server and await for validation, discovery, action execution requests
 	tapService, err :=
 		service.NewTAPServiceBuilder().
 			WithTurboCommunicator(config.tapSpec.TurboCommunicationConfig).
 			WithTurboProbe(probe.NewProbeBuilder(config.tapSpec.TargetType, config.tapSpec.ProbeCategory).
-				WithDiscoveryOptions(probe.FullRediscoveryIntervalSecondsOption(int32(config.DiscoveryIntervalSec))).
+				WithDiscoveryOptions(discoveryOptions...).
 				RegisteredBy(registrationClient).
 				WithActionPolicies(registrationClient).
 				WithEntityMetadata(registrationClient).
@@ -142,10 +237,40 @@ func NewKubernetesTAPService(config *Config) (*K8sTAPService, error) {
 	if err != nil {
 		return nil, err
 	}
+	if config.IncrementalDiscoveryIntervalSec > 0 {
+		if err := registerIncrementalDiscovery(tapService.TurboProbe, config.tapSpec.TargetIdentifier,
+			discoveryClient); err != nil {
+			return nil, err
+		}
+	}
+
+	return &K8sTAPService{
+		TAPService: tapService,
+		sampler:    sampler,
+		stopCh:     config.StopEverything,
+	}, nil
+}
 
-	return &K8sTAPService{tapService}, nil
+// Register the incremental discovery client of the target with the probe, before the probe connects to the server.
+// The probe builder of the vendored SDK has no option for it, and only sets the full discovery client of the target.
+func registerIncrementalDiscovery(turboProbe *probe.TurboProbe, targetID string,
+	incrementalDiscovery probe.IIncrementalDiscovery) error {
+	target, exists := turboProbe.DiscoveryClientMap[targetID]
+	if !exists {
+		return fmt.Errorf("failed to register the incremental discovery of unknown target %s", targetID)
+	}
+	target.IIncrementalDiscovery = incrementalDiscovery
+	return nil
 }
 
 func (s *K8sTAPService) Run() {
 	s.ConnectToTurbo()
 }
+
+// ConnectToTurbo starts the kubelet sampler, if any, and connects to the server until the service is stopped.
+func (s *K8sTAPService) ConnectToTurbo() {
+	if s.sampler != nil {
+		go s.sampler.Run(s.stopCh)
+	}
+	s.TAPService.ConnectToTurbo()
+}
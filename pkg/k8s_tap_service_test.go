@@ -1,10 +1,20 @@
 package kubeturbo
 
 import (
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
+	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
+	restclient "github.com/turbonomic/turbo-api/pkg/client"
+	"github.com/turbonomic/turbo-go-sdk/pkg/probe"
+	"github.com/turbonomic/turbo-go-sdk/pkg/service"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseK8sTAPServiceSpec(t *testing.T) {
@@ -68,6 +78,30 @@ func TestParseK8sTAPServiceSpecWithOldConfig(t *testing.T) {
 	check(got.TargetUsername, "defaultUser", t)
 }
 
+func TestParseK8sTAPServiceSpecWithResourceMonitoringConfig(t *testing.T) {
+	defaultTargetName := "target-foo"
+
+	// Kubelet is the default resource monitoring source
+	got, err := ParseK8sTAPServiceSpec("../test/config/turbo-config", defaultTargetName)
+	if err != nil {
+		t.Fatalf("Error while parsing the spec file: %v", err)
+	}
+	check(got.Source, configs.KubeletResourceSource, t)
+	if got.UseMetricsServer() {
+		t.Errorf("Metrics server should not be used by default")
+	}
+
+	configPath := "../test/config/turbo-config-with-metrics-server"
+	got, err = ParseK8sTAPServiceSpec(configPath, defaultTargetName)
+	if err != nil {
+		t.Fatalf("Error while parsing the spec file %s: %v", configPath, err)
+	}
+	check(got.Source, configs.MetricsServerResourceSource, t)
+	if got.CpuFrequencyMHz != 2400 {
+		t.Errorf("CpuFrequencyMHz = %v, want %v", got.CpuFrequencyMHz, 2400)
+	}
+}
+
 func check(got, want string, t *testing.T) {
 	if got != want {
 		t.Errorf("got: %v, want: %v", got, want)
@@ -100,15 +134,84 @@ func TestCreateProbeConfigOrDie(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			vmtConfig := NewVMTConfig2().UsingUUIDStitch(tt.UseUUID)
-			got := createProbeConfigOrDie(vmtConfig)
+			got := createProbeConfigOrDie(vmtConfig, cluster.NewClusterScraper(nil), nil)
 			checkProbeConfig(t, got, tt.wantStitchingPropertyType)
 		})
 	}
 }
 
+func TestCreateProbeConfigWithMetricsServer(t *testing.T) {
+	spec := &K8sTAPServiceSpec{
+		ResourceMonitoringConfig: &configs.ResourceMonitoringConfig{
+			Source:          configs.MetricsServerResourceSource,
+			CpuFrequencyMHz: 2400,
+		},
+	}
+	metricsServerClient := kubeclient.NewMetricsServerClient(nil)
+	vmtConfig := NewVMTConfig2().WithTapSpec(spec).WithMetricsServerClient(metricsServerClient)
+	got := createProbeConfigOrDie(vmtConfig, cluster.NewClusterScraper(nil), nil)
+
+	if got.MetricsServerClient != metricsServerClient {
+		t.Errorf("MetricsServerClient is not set in the probe config")
+	}
+	var sources []types.MonitoringSource
+	for _, mc := range got.MonitoringConfigs {
+		sources = append(sources, mc.GetMonitoringSource())
+	}
+	want := []types.MonitoringSource{types.MetricsServerSource, types.ClusterSource}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("Monitoring sources = %v, want %v", sources, want)
+	}
+}
+
 func checkProbeConfig(t *testing.T, pc *configs.ProbeConfig, stitchingPropertyType stitching.StitchingPropertyType) {
 
 	if pc.StitchingPropertyType != stitchingPropertyType {
 		t.Errorf("StitchingPropertyType = %v, want %v", pc.StitchingPropertyType, stitchingPropertyType)
 	}
 }
+
+func TestRegisterIncrementalDiscovery(t *testing.T) {
+	discoveryClient := &discovery.K8sDiscoveryClient{}
+	turboProbe, err := probe.NewProbeBuilder("Kubernetes", "Cloud Native").
+		DiscoversTarget("target1", discoveryClient).
+		Create()
+	if err != nil {
+		t.Fatalf("Failed to create the probe: %v", err)
+	}
+	if err := registerIncrementalDiscovery(turboProbe, "target1", discoveryClient); err != nil {
+		t.Errorf("Failed to register the incremental discovery: %v", err)
+	}
+	if turboProbe.DiscoveryClientMap["target1"].IIncrementalDiscovery != discoveryClient {
+		t.Errorf("The incremental discovery of target1 is not registered")
+	}
+	if err := registerIncrementalDiscovery(turboProbe, "target2", discoveryClient); err == nil {
+		t.Errorf("Expected an error for an unknown target")
+	}
+}
+
+func TestConnectToTurboStartsSampler(t *testing.T) {
+	sampler := kubelet.NewKubeletSampler(nil, time.Hour, 10*time.Hour, 95)
+	stopCh := make(chan struct{})
+	// The TAP service without a REST client returns at once instead of connecting to the server
+	tapService := &service.TAPService{Client: &restclient.Client{}}
+	s := &K8sTAPService{TAPService: tapService, sampler: sampler, stopCh: stopCh}
+	s.ConnectToTurbo()
+	if !waitForSampler(sampler, true) {
+		t.Errorf("The sampler is not started when connecting to the server")
+	}
+	close(stopCh)
+	if !waitForSampler(sampler, false) {
+		t.Errorf("The sampler is not stopped with the service")
+	}
+}
+
+func waitForSampler(sampler *kubelet.KubeletSampler, running bool) bool {
+	for i := 0; i < 100; i++ {
+		if sampler.IsRunning() == running {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
@@ -5,10 +5,11 @@ import (
 	"fmt"
 	"github.com/golang/glog"
 	cadvisorapi "github.com/google/cadvisor/info/v1"
-	"github.com/turbonomic/kubeturbo/pkg/discovery/repository"
+	"github.com/turbonomic/kubeturbo/pkg/util"
 	"io/ioutil"
 	"k8s.io/api/core/v1"
 	netutil "k8s.io/apimachinery/pkg/util/net"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/transport"
 	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
@@ -42,6 +43,70 @@ type CacheEntry struct {
 	statsSummary *stats.Summary
 	machineInfo  *cadvisorapi.MachineInfo
 	used         bool
+	// When the cached summary and machine info were received from the kubelet
+	summaryTime     time.Time
+	machineInfoTime time.Time
+	// Use of the cache by the last summary and machine info requests of the current scrape
+	summaryUse     cacheUse
+	machineInfoUse cacheUse
+	// Number of the scrapes answered from the cache, and of those which the cache could not answer
+	// as it had no data, or its data was too old
+	hits   int
+	misses int
+}
+
+// Use of the cache by a request.
+type cacheUse struct {
+	// Whether the request failed and was answered from the cache
+	used bool
+	// Whether the request failed and the cache could not answer it
+	missed bool
+	// Age of the data returned, zero if it was received from the kubelet
+	age time.Duration
+}
+
+// CacheStatus is the status of the cache of a host.
+type CacheStatus struct {
+	// Whether the last requests were answered from the cache
+	Used bool
+	// Age of the oldest data returned by the last requests, zero if it was received from the kubelet
+	Age time.Duration
+	// Number of the scrapes answered from the cache, and not answered by the cache
+	Hits   int
+	Misses int
+}
+
+// Get the cached data received at the given time, if it is not too old.
+func (client *KubeletClient) useCachedData(host string, present bool, receivedTime time.Time) cacheUse {
+	if !present {
+		return cacheUse{missed: true}
+	}
+	age := time.Since(receivedTime)
+	if client.maxCacheStaleness > 0 && age > client.maxCacheStaleness {
+		glog.V(2).Infof("The cached value of machine[%s] is %v old, older than the maximum staleness %v",
+			host, age, client.maxCacheStaleness)
+		return cacheUse{missed: true}
+	}
+	return cacheUse{used: true, age: age}
+}
+
+// Update whether the last requests of the entry were answered from the cache.
+func (entry *CacheEntry) updateUsed() {
+	entry.used = entry.summaryUse.used || entry.machineInfoUse.used
+}
+
+// Get the status of the cache of the entry.
+func (entry *CacheEntry) status() CacheStatus {
+	age := entry.summaryUse.age
+	if entry.machineInfoUse.age > age {
+		age = entry.machineInfoUse.age
+	}
+	return CacheStatus{
+		Used:   entry.used,
+		Age:    age,
+		Hits:   entry.hits,
+		Misses: entry.misses,
+	}
 }
 
 // Cleanup the cache.
@@ -53,12 +118,15 @@ func (client *KubeletClient) CleanupCache(nodes []*v1.Node) int {
 	// Fill in the hash map for subsequent lookup
 	names := make(map[string]bool)
 	for _, node := range nodes {
-		ip := repository.ParseNodeIP(node, v1.NodeInternalIP)
+		ip := util.GetKubeletIP(node)
 		if len(ip) == 0 {
 			glog.Warningf("unable to obtain address for node %s, as it is no longer discovered", node.GetName())
 		}
 		names[ip] = true
 	}
+	if client.proxy != nil {
+		client.proxy.updateNodes(nodes)
+	}
 	// Cleanup
 	count := 0
 	for host, _ := range client.cache {
@@ -80,9 +148,59 @@ type KubeletClient struct {
 	port      int
 	cache     map[string]*CacheEntry
 	cacheLock sync.Mutex
+	// The maximum age of the cached data returned when the kubelet cannot be reached, 0 for no limit
+	maxCacheStaleness time.Duration
+	// The raw responses of the kubelets when they are recorded or replayed, nil otherwise
+	responses *KubeletResponseStore
+	// Whether the responses are replayed instead of requesting the kubelets
+	replay bool
+	// The node proxy of the API server when the kubelets are accessed through it, nil otherwise
+	proxy *nodeProxy
+}
+
+// Create a client replaying the recorded responses of the kubelets, without any connection to the kubelets.
+func NewReplayKubeletClient(responses *KubeletResponseStore) *KubeletClient {
+	return &KubeletClient{
+		cache:     make(map[string]*CacheEntry),
+		responses: responses,
+		replay:    true,
+	}
+}
+
+// Resolve the IPs of the nodes accessed through the node proxy with the given lister, e.g., of the cluster cache,
+// instead of listing the nodes from the API server.
+func (client *KubeletClient) WithNodeLister(nodeLister corelisters.NodeLister) *KubeletClient {
+	if client.proxy != nil {
+		client.proxy.setNodeLister(nodeLister)
+	}
+	return client
+}
+
+// The responses recorded by the client, nil if they are not recorded.
+func (client *KubeletClient) RecordedResponses() *KubeletResponseStore {
+	if client.replay {
+		return nil
+	}
+	return client.responses
 }
 
 func (client *KubeletClient) ExecuteRequestAndGetValue(host string, endpoint string, value interface{}) error {
+	if client.replay {
+		return client.responses.getValue(host, endpoint, value)
+	}
+	body, err := client.executeRequest(host, endpoint)
+	if err != nil {
+		return err
+	}
+	return client.parseResponse(host, endpoint, body, value)
+}
+
+// Request the kubelet of the host, either directly or through the node proxy, and return the response body.
+func (client *KubeletClient) executeRequest(host string, endpoint string) ([]byte, error) {
+	if client.proxy != nil {
+		return client.proxy.get(host, endpoint)
+	}
+
 	requestURL := url.URL{
 		Scheme: client.scheme,
 		Host:   fmt.Sprintf("%s:%d", host, client.port),
@@ -91,36 +209,80 @@ func (client *KubeletClient) ExecuteRequestAndGetValue(host string, endpoint str
 
 	req, err := http.NewRequest("GET", requestURL.String(), nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return client.postRequestAndGetValue(req, value)
+	return client.postRequest(req)
 }
 
-func (client *KubeletClient) postRequestAndGetValue(req *http.Request, value interface{}) error {
+func (client *KubeletClient) postRequest(req *http.Request) ([]byte, error) {
 	httpClient := client.client
 	response, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to execute the request: %s", err)
+		return nil, fmt.Errorf("failed to execute the request: %s", err)
 	}
 	defer response.Body.Close()
 	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body - %v", err)
+		return nil, fmt.Errorf("failed to read response body - %v", err)
 	}
 	if response.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("%q was not found", req.URL.String())
+		return nil, fmt.Errorf("%q was not found", req.URL.String())
 	} else if response.StatusCode != http.StatusOK {
-		return fmt.Errorf("request failed - %q, response: %q", response.Status, string(body))
+		return nil, fmt.Errorf("request failed - %q, response: %q", response.Status, string(body))
 	}
+	return body, nil
+}
 
-	err = json.Unmarshal(body, value)
+// Parse the response of the kubelet.
+func (client *KubeletClient) parseResponse(host, endpoint string, body []byte, value interface{}) error {
+	err := json.Unmarshal(body, value)
 	if err != nil {
 		return fmt.Errorf("failed to parse output. Response: %q. Error: %v", string(body), err)
 	}
 	return nil
 }
 
+// RecordSummary records the stats summary of the host used by the discovery, if the responses are recorded.
+func (client *KubeletClient) RecordSummary(host string, summary *stats.Summary) {
+	client.record(host, summaryPath, summary)
+}
+
+// RecordMachineInfo records the machine info of the host used by the discovery, if the responses are recorded.
+func (client *KubeletClient) RecordMachineInfo(host string, machineInfo *cadvisorapi.MachineInfo) {
+	client.record(host, specPath, machineInfo)
+}
+
+func (client *KubeletClient) record(host, endpoint string, value interface{}) {
+	responses := client.RecordedResponses()
+	if responses == nil {
+		return
+	}
+	body, err := json.Marshal(value)
+	if err != nil {
+		glog.Errorf("Failed to record the response of %s%s: %v", host, endpoint, err)
+		return
+	}
+	responses.put(host, endpoint, body)
+}
+
+// SampleSummary gets the stats summary of the host from its kubelet, bypassing the cache,
+// so that sampling between discoveries does not affect what the discovery sees.
+func (client *KubeletClient) SampleSummary(host string) (*stats.Summary, error) {
+	if client.replay {
+		return nil, fmt.Errorf("the kubelet of %s cannot be sampled when its responses are replayed", host)
+	}
+	body, err := client.executeRequest(host, summaryPath)
+	if err != nil {
+		return nil, err
+	}
+	summary := &stats.Summary{}
+	if err := client.parseResponse(host, summaryPath, body, summary); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
 func (client *KubeletClient) GetSummary(host string) (*stats.Summary, error) {
 	// Get the data
 	summary := &stats.Summary{}
@@ -131,8 +293,10 @@ func (client *KubeletClient) GetSummary(host string) (*stats.Summary, error) {
 	entry, entryPresent := client.cache[host]
 	if err != nil {
 		if entryPresent {
-			entry.used = true
-			if entry.statsSummary == nil {
+			// The cache is only used if it has data to serve
+			entry.summaryUse = client.useCachedData(host, entry.statsSummary != nil, entry.summaryTime)
+			entry.updateUsed()
+			if !entry.summaryUse.used {
 				glog.V(2).Infof("unable to retrieve machine[%s] summary: %v. The cached value unavailable", host, err)
 				return nil, err
 			}
@@ -140,18 +304,22 @@ func (client *KubeletClient) GetSummary(host string) (*stats.Summary, error) {
 			return entry.statsSummary, nil
 		} else {
 			glog.Errorf("failed to get machine[%s] summary: %v. No cache available", host, err)
+			client.cache[host] = &CacheEntry{summaryUse: cacheUse{missed: true}}
 			return summary, err
 		}
 	}
 	// Fill in the cache
 	if entryPresent {
-		entry.used = false
 		entry.statsSummary = summary
+		entry.summaryTime = time.Now()
+		entry.summaryUse = cacheUse{}
+		entry.updateUsed()
 	} else {
 		entry := &CacheEntry{
 			statsSummary: summary,
 			machineInfo:  nil,
 			used:         false,
+			summaryTime:  time.Now(),
 		}
 		client.cache[host] = entry
 	}
@@ -168,8 +336,10 @@ func (client *KubeletClient) GetMachineInfo(host string) (*cadvisorapi.MachineIn
 	entry, entryPresent := client.cache[host]
 	if err != nil {
 		if entryPresent {
-			entry.used = true
-			if entry.machineInfo == nil {
+			// The cache is only used if it has data to serve
+			entry.machineInfoUse = client.useCachedData(host, entry.machineInfo != nil, entry.machineInfoTime)
+			entry.updateUsed()
+			if !entry.machineInfoUse.used {
 				glog.V(2).Infof("unable to retrieve machine[%s] machine info: %v. The cached value unavailable", host, err)
 				return nil, err
 			}
@@ -177,18 +347,22 @@ func (client *KubeletClient) GetMachineInfo(host string) (*cadvisorapi.MachineIn
 			return entry.machineInfo, nil
 		} else {
 			glog.Errorf("failed to get machine[%s] machine info: %v. No cache available", host, err)
+			client.cache[host] = &CacheEntry{machineInfoUse: cacheUse{missed: true}}
 			return &minfo, err
 		}
 	}
 	// Fill in the cache
 	if entryPresent {
-		entry.used = false
 		entry.machineInfo = &minfo
+		entry.machineInfoTime = time.Now()
+		entry.machineInfoUse = cacheUse{}
+		entry.updateUsed()
 	} else {
 		entry := &CacheEntry{
-			statsSummary: nil,
-			machineInfo:  &minfo,
-			used:         false,
+			statsSummary:    nil,
+			machineInfo:     &minfo,
+			used:            false,
+			machineInfoTime: time.Now(),
 		}
 		client.cache[host] = entry
 	}
@@ -205,6 +379,39 @@ func (client *KubeletClient) GetMachineCpuFrequency(host string) (uint64, error)
 	return minfo.CpuFrequency, nil
 }
 
+// Get the status of the cache of the host.
+func (client *KubeletClient) GetCacheStatus(host string) CacheStatus {
+	client.cacheLock.Lock()
+	defer client.cacheLock.Unlock()
+	entry, entryPresent := client.cache[host]
+	if !entryPresent {
+		return CacheStatus{}
+	}
+	return entry.status()
+}
+
+// Complete the scrape of the host, and get the status of its cache.
+// The scrape is counted once as a miss of the cache if any of its requests could not be answered,
+// or else as a hit if any of them was answered from the cache.
+func (client *KubeletClient) CompleteScrape(host string) CacheStatus {
+	client.cacheLock.Lock()
+	defer client.cacheLock.Unlock()
+	entry, entryPresent := client.cache[host]
+	if !entryPresent {
+		return CacheStatus{}
+	}
+	if entry.summaryUse.missed || entry.machineInfoUse.missed {
+		entry.misses++
+	} else if entry.used {
+		entry.hits++
+	}
+	status := entry.status()
+	entry.summaryUse = cacheUse{}
+	entry.machineInfoUse = cacheUse{}
+	entry.updateUsed()
+	return status
+}
+
 func (client *KubeletClient) HasCacheBeenUsed(host string) bool {
 	client.cacheLock.Lock()
 	defer client.cacheLock.Unlock()
@@ -223,6 +430,10 @@ type KubeletConfig struct {
 	port                 int
 	timeout              time.Duration // timeout when fetching information from kubelet;
 	tlsTimeOut           time.Duration
+	recordResponses      bool
+	maxCacheStaleness    time.Duration
+	// Whether the kubelets are accessed through the node proxy of the API server
+	useProxy bool
 }
 
 // Create a new KubeletConfig based on kubeConfig.
@@ -251,6 +462,25 @@ func (kc *KubeletConfig) ForceSelfSignedCerts(forceSelfSignedCerts bool) *Kubele
 	return kc
 }
 
+// Access the kubelets through the node proxy of the API server, with the credentials of kubeConfig,
+// instead of connecting to the nodes. The port and https settings of the kubelets are then ignored.
+func (kc *KubeletConfig) UseProxy(useProxy bool) *KubeletConfig {
+	kc.useProxy = useProxy
+	return kc
+}
+
+// Refuse the cached data older than the given number of seconds when the kubelet cannot be reached, 0 for no limit.
+func (kc *KubeletConfig) WithMaxCacheStaleness(seconds int) *KubeletConfig {
+	kc.maxCacheStaleness = time.Duration(seconds) * time.Second
+	return kc
+}
+
+// Keep the kubelet data used by the discovery, to save them in the discovery snapshots.
+func (kc *KubeletConfig) RecordResponses(record bool) *KubeletConfig {
+	kc.recordResponses = record
+	return kc
+}
+
 func (kc *KubeletConfig) Timeout(timeout int) *KubeletConfig {
 	kc.timeout = time.Duration(timeout) * time.Second
 	return kc
@@ -274,12 +504,22 @@ func (kc *KubeletConfig) Create() (*KubeletClient, error) {
 	}
 
 	// 3. create a KubeletClient
-	return &KubeletClient{
-		client: c,
-		scheme: scheme,
-		port:   kc.port,
-		cache:  make(map[string]*CacheEntry),
-	}, nil
+	kubeletClient := &KubeletClient{
+		client:            c,
+		scheme:            scheme,
+		port:              kc.port,
+		cache:             make(map[string]*CacheEntry),
+		maxCacheStaleness: kc.maxCacheStaleness,
+	}
+	if kc.recordResponses {
+		kubeletClient.responses = NewKubeletResponseStore(nil)
+	}
+	if kc.useProxy {
+		if kubeletClient.proxy, err = newNodeProxy(kc.kubeConfig, kc.timeout); err != nil {
+			return nil, err
+		}
+	}
+	return kubeletClient, nil
 }
 
 // ------------Generate a http.Transport based on rest.Config-------------------
@@ -1,10 +1,22 @@
 package kubeclient
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	cadvisorapi "github.com/google/cadvisor/info/v1"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
-	"testing"
+	"k8s.io/client-go/tools/cache"
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
 )
 
 func constuctNodes(ip string) []*v1.Node {
@@ -77,3 +89,251 @@ func TestKubeletClientCacheNil(t *testing.T) {
 	_, err2 := kc.GetMachineInfo("host_1")
 	assert.NotNil(t, err2)
 }
+
+func TestKubeletClientRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != specPath {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"num_cores":4,"cpu_frequency_khz":2400000}`)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+
+	kc, err := NewKubeletConfig(&rest.Config{}).WithPort(port).RecordResponses(true).Create()
+	assert.Nil(t, err)
+	minfo, err := kc.GetMachineInfo(serverURL.Hostname())
+	assert.Nil(t, err)
+	_, err = kc.GetSummary(serverURL.Hostname())
+	assert.NotNil(t, err)
+
+	// Only the data recorded by the discovery are kept, not every response
+	assert.Equal(t, 0, len(kc.RecordedResponses().Responses()))
+	kc.RecordMachineInfo(serverURL.Hostname(), minfo)
+	responses := kc.RecordedResponses().Responses()
+	assert.Equal(t, 1, len(responses))
+
+	replayClient := NewReplayKubeletClient(NewKubeletResponseStore(responses))
+	assert.Nil(t, replayClient.RecordedResponses())
+	frequency, err := replayClient.GetMachineCpuFrequency(serverURL.Hostname())
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2400000), frequency)
+	minfo, err = replayClient.GetMachineInfo(serverURL.Hostname())
+	assert.Nil(t, err)
+	assert.Equal(t, 4, minfo.NumCores)
+	assert.Equal(t, uint64(2400000), minfo.CpuFrequency)
+	_, err = replayClient.GetMachineInfo("host_2")
+	assert.NotNil(t, err)
+}
+
+func TestKubeletClientThroughProxy(t *testing.T) {
+	specRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/nodes":
+			fmt.Fprint(w, `{"kind":"NodeList","apiVersion":"v1","items":[{"metadata":{"name":"node1"},`+
+				`"status":{"addresses":[{"type":"InternalIP","address":"10.0.0.1"}]}},{"metadata":{"name":"node2"},`+
+				`"status":{"addresses":[{"type":"Hostname","address":"node2"},{"type":"ExternalIP","address":"10.1.0.2"}]}}]}`)
+		case "/api/v1/nodes/node2/proxy/spec":
+			fmt.Fprint(w, `{"num_cores":2,"cpu_frequency_khz":2000000}`)
+		case "/api/v1/nodes/node1/proxy/spec":
+			specRequests++
+			if specRequests > 1 {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, `{"num_cores":4,"cpu_frequency_khz":2400000}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	kc, err := NewKubeletConfig(&rest.Config{Host: server.URL}).UseProxy(true).Create()
+	assert.Nil(t, err)
+	frequency, err := kc.GetMachineCpuFrequency("10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2400000), frequency)
+	assert.False(t, kc.HasCacheBeenUsed("10.0.0.1"))
+
+	// The cached value is used when the proxy fails
+	frequency, err = kc.GetMachineCpuFrequency("10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2400000), frequency)
+	assert.True(t, kc.HasCacheBeenUsed("10.0.0.1"))
+
+	// The node without an internal IP is resolved by its external IP, as by the kubelet monitor
+	frequency, err = kc.GetMachineCpuFrequency("10.1.0.2")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2000000), frequency)
+
+	// The IP of no node
+	_, err = kc.GetMachineInfo("10.0.0.2")
+	assert.NotNil(t, err)
+}
+
+func TestKubeletClientThroughProxyWithNodeLister(t *testing.T) {
+	nodeListRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/nodes":
+			nodeListRequests++
+			fmt.Fprint(w, `{"kind":"NodeList","apiVersion":"v1","items":[]}`)
+		case "/api/v1/nodes/node1/proxy/spec":
+			fmt.Fprint(w, `{"num_cores":4,"cpu_frequency_khz":2400000}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+	node.Status.Addresses = []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}}
+	assert.Nil(t, indexer.Add(node))
+
+	kc, err := NewKubeletConfig(&rest.Config{Host: server.URL}).UseProxy(true).Create()
+	assert.Nil(t, err)
+	kc.WithNodeLister(corelisters.NewNodeLister(indexer))
+	frequency, err := kc.GetMachineCpuFrequency("10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2400000), frequency)
+
+	// The IP of no node is not resolved from the API server either
+	_, err = kc.GetMachineInfo("10.0.0.2")
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, nodeListRequests)
+}
+
+func TestKubeletClientCacheStaleness(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	host := serverURL.Hostname()
+
+	kc, err := NewKubeletConfig(&rest.Config{}).WithPort(port).WithMaxCacheStaleness(60).Create()
+	assert.Nil(t, err)
+
+	// No cached value
+	_, err = kc.GetSummary(host)
+	assert.NotNil(t, err)
+	assert.Equal(t, CacheStatus{Misses: 1}, kc.CompleteScrape(host))
+
+	// Still no cached value, which is not used
+	_, err = kc.GetSummary(host)
+	assert.NotNil(t, err)
+	assert.False(t, kc.HasCacheBeenUsed(host))
+	assert.Equal(t, CacheStatus{Misses: 2}, kc.CompleteScrape(host))
+
+	// Fresh enough cached value
+	kc.cache[host].statsSummary = &stats.Summary{}
+	kc.cache[host].summaryTime = time.Now().Add(-30 * time.Second)
+	summary, err := kc.GetSummary(host)
+	assert.Nil(t, err)
+	assert.NotNil(t, summary)
+	status := kc.CompleteScrape(host)
+	assert.True(t, status.Used)
+	assert.True(t, status.Age >= 30*time.Second)
+	assert.Equal(t, 1, status.Hits)
+
+	// Too old cached value
+	kc.cache[host].summaryTime = time.Now().Add(-2 * time.Minute)
+	_, err = kc.GetSummary(host)
+	assert.NotNil(t, err)
+	status = kc.CompleteScrape(host)
+	assert.False(t, status.Used)
+	assert.Equal(t, 1, status.Hits)
+	assert.Equal(t, 3, status.Misses)
+	assert.Equal(t, time.Duration(0), status.Age)
+}
+
+func TestKubeletClientCacheUsePerScrape(t *testing.T) {
+	summaryAvailable := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == summaryPath && summaryAvailable {
+			fmt.Fprint(w, `{"node":{"nodeName":"node1"}}`)
+			return
+		}
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	host := serverURL.Hostname()
+
+	kc, err := NewKubeletConfig(&rest.Config{}).WithPort(port).Create()
+	assert.Nil(t, err)
+	kc.cache[host] = &CacheEntry{
+		statsSummary:    &stats.Summary{},
+		summaryTime:     time.Now().Add(-30 * time.Second),
+		machineInfo:     &cadvisorapi.MachineInfo{},
+		machineInfoTime: time.Now().Add(-time.Minute),
+	}
+
+	// Both requests of the scrape are answered from the cache, which is counted once
+	_, err = kc.GetMachineInfo(host)
+	assert.Nil(t, err)
+	_, err = kc.GetSummary(host)
+	assert.Nil(t, err)
+	status := kc.CompleteScrape(host)
+	assert.True(t, status.Used)
+	assert.True(t, status.Age >= time.Minute)
+	assert.Equal(t, 1, status.Hits)
+	assert.Equal(t, 0, status.Misses)
+
+	// The summary received from the kubelet does not hide the cached machine info
+	summaryAvailable = true
+	_, err = kc.GetMachineInfo(host)
+	assert.Nil(t, err)
+	_, err = kc.GetSummary(host)
+	assert.Nil(t, err)
+	assert.True(t, kc.HasCacheBeenUsed(host))
+	status = kc.CompleteScrape(host)
+	assert.True(t, status.Used)
+	assert.True(t, status.Age >= time.Minute)
+	assert.Equal(t, 2, status.Hits)
+
+	// A scrape which could not be answered is only counted as a miss
+	kc.cache[host].machineInfo = nil
+	_, err = kc.GetMachineInfo(host)
+	assert.NotNil(t, err)
+	_, err = kc.GetSummary(host)
+	assert.Nil(t, err)
+	assert.Equal(t, CacheStatus{Hits: 2, Misses: 1}, kc.CompleteScrape(host))
+
+	// The state of the scrape does not leak into the next one
+	assert.Equal(t, CacheStatus{Hits: 2, Misses: 1}, kc.GetCacheStatus(host))
+}
+
+func TestKubeletClientSampleSummary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != summaryPath {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"node":{"nodeName":"node1"}}`)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	host := serverURL.Hostname()
+
+	kc, err := NewKubeletConfig(&rest.Config{}).WithPort(port).RecordResponses(true).Create()
+	assert.Nil(t, err)
+	summary, err := kc.SampleSummary(host)
+	assert.Nil(t, err)
+	assert.Equal(t, "node1", summary.Node.NodeName)
+
+	// The sample is neither cached nor recorded
+	assert.Equal(t, CacheStatus{}, kc.GetCacheStatus(host))
+	_, cached := kc.cache[host]
+	assert.False(t, cached)
+	assert.Equal(t, 0, len(kc.RecordedResponses().Responses()))
+}
@@ -0,0 +1,121 @@
+package kubeclient
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/kubeturbo/pkg/util"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+// nodeProxy sends the kubelet requests through the node proxy of the API server, i.e.,
+// /api/v1/nodes/{name}/proxy/..., for the clusters whose nodes are not reachable from kubeturbo.
+// The kubelets are still identified by the IP of their nodes, which is resolved to the node name.
+type nodeProxy struct {
+	client kubernetes.Interface
+	// The lister of the cluster cache to resolve the IPs of the new nodes, nil to list them from the API server
+	nodeLister corelisters.NodeLister
+	// The node names by IP
+	nodeNames map[string]string
+	lock      sync.Mutex
+}
+
+// Create the node proxy, whose requests time out after the given timeout if it is positive.
+func newNodeProxy(kubeConfig *rest.Config, timeout time.Duration) (*nodeProxy, error) {
+	proxyConfig := rest.CopyConfig(kubeConfig)
+	if timeout > 0 {
+		proxyConfig.Timeout = timeout
+	}
+	client, err := kubernetes.NewForConfig(proxyConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the client of the node proxy: %v", err)
+	}
+	return &nodeProxy{
+		client:    client,
+		nodeNames: make(map[string]string),
+	}, nil
+}
+
+// Get the raw response of the endpoint of the kubelet running on the node with the given IP.
+func (p *nodeProxy) get(host, endpoint string) ([]byte, error) {
+	nodeName, err := p.nodeName(host)
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.client.CoreV1().RESTClient().Get().
+		Resource("nodes").Name(nodeName).SubResource("proxy").Suffix(endpoint).
+		DoRaw()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s of node %s through the proxy: %v", endpoint, nodeName, err)
+	}
+	return body, nil
+}
+
+func (p *nodeProxy) nodeName(host string) (string, error) {
+	p.lock.Lock()
+	nodeName, exists := p.nodeNames[host]
+	p.lock.Unlock()
+	if exists {
+		return nodeName, nil
+	}
+
+	// The node is not known yet
+	nodes, err := p.listNodes()
+	if err != nil {
+		return "", fmt.Errorf("failed to list the nodes to find the node of %s: %v", host, err)
+	}
+	p.updateNodes(nodes)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if nodeName, exists = p.nodeNames[host]; !exists {
+		return "", fmt.Errorf("no node has the IP %s", host)
+	}
+	return nodeName, nil
+}
+
+// List the nodes from the cluster cache if it is set, to avoid listing them from the API server for every new IP.
+func (p *nodeProxy) listNodes() ([]*v1.Node, error) {
+	p.lock.Lock()
+	nodeLister := p.nodeLister
+	p.lock.Unlock()
+	if nodeLister != nil {
+		return nodeLister.List(labels.Everything())
+	}
+	nodeList, err := p.client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*v1.Node, 0, len(nodeList.Items))
+	for i := range nodeList.Items {
+		nodes = append(nodes, &nodeList.Items[i])
+	}
+	return nodes, nil
+}
+
+func (p *nodeProxy) setNodeLister(nodeLister corelisters.NodeLister) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.nodeLister = nodeLister
+}
+
+// Replace the node names by the names of the given nodes.
+func (p *nodeProxy) updateNodes(nodes []*v1.Node) {
+	nodeNames := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		if ip := util.GetKubeletIP(node); ip != "" {
+			nodeNames[ip] = node.Name
+		}
+	}
+	glog.V(4).Infof("The node proxy knows %d nodes.", len(nodeNames))
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.nodeNames = nodeNames
+}
@@ -0,0 +1,64 @@
+package kubeclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// KubeletResponseStore keeps the raw responses of the kubelets by host and endpoint, to record them in the
+// discovery snapshots, or to replay them instead of sending the requests to the kubelets.
+type KubeletResponseStore struct {
+	responses map[string]json.RawMessage
+	lock      sync.Mutex
+}
+
+func NewKubeletResponseStore(responses map[string]json.RawMessage) *KubeletResponseStore {
+	if responses == nil {
+		responses = make(map[string]json.RawMessage)
+	}
+	return &KubeletResponseStore{
+		responses: responses,
+	}
+}
+
+func responseKey(host, endpoint string) string {
+	return host + endpoint
+}
+
+func (s *KubeletResponseStore) put(host, endpoint string, body []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses[responseKey(host, endpoint)] = json.RawMessage(body)
+}
+
+func (s *KubeletResponseStore) getValue(host, endpoint string, value interface{}) error {
+	s.lock.Lock()
+	body, exists := s.responses[responseKey(host, endpoint)]
+	s.lock.Unlock()
+	if !exists {
+		return fmt.Errorf("no response of %s%s is recorded", host, endpoint)
+	}
+	if err := json.Unmarshal(body, value); err != nil {
+		return fmt.Errorf("failed to parse the recorded response of %s%s: %v", host, endpoint, err)
+	}
+	return nil
+}
+
+// Forget all the responses.
+func (s *KubeletResponseStore) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses = make(map[string]json.RawMessage)
+}
+
+// Responses returns a copy of the responses, keyed by host and endpoint.
+func (s *KubeletResponseStore) Responses() map[string]json.RawMessage {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	responses := make(map[string]json.RawMessage, len(s.responses))
+	for key, body := range s.responses {
+		responses[key] = body
+	}
+	return responses
+}
@@ -0,0 +1,96 @@
+package kubeclient
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	metricsServerAPIPath string = "/apis/metrics.k8s.io/v1beta1"
+	nodeMetricsResource  string = "nodes"
+	podMetricsResource   string = "pods"
+)
+
+// NodeMetrics is the resource usage of a node as reported by the metrics.k8s.io/v1beta1 API.
+// Only the fields used by kubeturbo are declared to avoid depending on k8s.io/metrics.
+type NodeMetrics struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Timestamp         metav1.Time     `json:"timestamp"`
+	Window            metav1.Duration `json:"window"`
+	Usage             v1.ResourceList `json:"usage"`
+}
+
+type NodeMetricsList struct {
+	Items []NodeMetrics `json:"items"`
+}
+
+// PodMetrics is the resource usage of the containers of a pod as reported by the metrics.k8s.io/v1beta1 API.
+type PodMetrics struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Timestamp         metav1.Time        `json:"timestamp"`
+	Window            metav1.Duration    `json:"window"`
+	Containers        []ContainerMetrics `json:"containers"`
+}
+
+type ContainerMetrics struct {
+	Name  string          `json:"name"`
+	Usage v1.ResourceList `json:"usage"`
+}
+
+type PodMetricsList struct {
+	Items []PodMetrics `json:"items"`
+}
+
+// MetricsServerClient reads the node and pod usage from the resource metrics API served by
+// metrics-server through the Kubernetes API server, so no direct connection to the kubelets is needed.
+type MetricsServerClient struct {
+	restClient rest.Interface
+}
+
+// Create a new MetricsServerClient. The rest client must not have any API group path prefix,
+// e.g., the rest client of the discovery client.
+func NewMetricsServerClient(restClient rest.Interface) *MetricsServerClient {
+	return &MetricsServerClient{
+		restClient: restClient,
+	}
+}
+
+func (client *MetricsServerClient) GetNodeMetrics(nodeName string) (*NodeMetrics, error) {
+	nodeMetrics := &NodeMetrics{}
+	if err := client.getAndDecode(nodeMetrics, nodeMetricsResource, nodeName); err != nil {
+		return nil, err
+	}
+	return nodeMetrics, nil
+}
+
+func (client *MetricsServerClient) GetAllNodeMetrics() (*NodeMetricsList, error) {
+	nodeMetricsList := &NodeMetricsList{}
+	if err := client.getAndDecode(nodeMetricsList, nodeMetricsResource); err != nil {
+		return nil, err
+	}
+	return nodeMetricsList, nil
+}
+
+func (client *MetricsServerClient) GetAllPodMetrics() (*PodMetricsList, error) {
+	podMetricsList := &PodMetricsList{}
+	if err := client.getAndDecode(podMetricsList, podMetricsResource); err != nil {
+		return nil, err
+	}
+	return podMetricsList, nil
+}
+
+func (client *MetricsServerClient) getAndDecode(value interface{}, segments ...string) error {
+	paths := append([]string{metricsServerAPIPath}, segments...)
+	body, err := client.restClient.Get().AbsPath(paths...).DoRaw()
+	if err != nil {
+		return fmt.Errorf("failed to get %v from metrics server: %v", segments, err)
+	}
+	if err := json.Unmarshal(body, value); err != nil {
+		return fmt.Errorf("failed to parse %v from metrics server. Response: %q. Error: %v", segments, string(body), err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
 	"github.com/turbonomic/kubeturbo/pkg/kubeclient"
 	client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/cluster-api/pkg/client/clientset_generated/clientset"
 )
 
@@ -22,15 +23,59 @@ type Config struct {
 	KubeletClient *kubeclient.KubeletClient
 	CAClient      *clientset.Clientset
 
+	// Only used when the metrics server is configured as the resource monitoring source
+	MetricsServerClient *kubeclient.MetricsServerClient
+
 	// Close this to stop all reflectors
 	StopEverything chan struct{}
 
 	DiscoveryIntervalSec int
-	ValidationWorkers    int
-	ValidationTimeoutSec int
+	// Interval of the incremental discovery between the full discoveries, 0 to disable it
+	IncrementalDiscoveryIntervalSec int
+	ValidationWorkers               int
+	ValidationTimeoutSec            int
+
+	// Interval of the usage sampling between discoveries, 0 to disable it
+	UsageSamplingIntervalSec int
+	// Percentile of the usage samples reported as the peak
+	UsageSamplingPercentile float64
+	// Network throughput capacity of the nodes in Mbit/s, 0 for the default capacity of the kubelet monitor
+	NodeNetworkCapacityMbps int
 
 	SccSupport    []string
 	CAPINamespace string
+
+	// Clamp the resized container resources to the LimitRanges instead of failing the resize actions
+	ClampResizeToLimitRange bool
+
+	// Scale the controllers managed by a HorizontalPodAutoscaler through the replica bounds of the autoscaler
+	ScaleThroughHPA bool
+
+	// The time in seconds to verify the health of the pods after the rollout of a consistent resize,
+	// and the number of restarts of a container within that time which fails the resize, 0 to ignore restarts
+	ResizeVerificationWindowSec int
+	ResizeRestartThreshold      int
+
+	// The default strategy of the pod moves, and the strategies of the namespaces and controller kinds,
+	// each in the form of <name>:<strategy>
+	MoveStrategy                string
+	MoveStrategyNamespaces      []string
+	MoveStrategyControllerKinds []string
+
+	// Interval of the collection of the clone pods left behind by the interrupted actions, 0 to disable it
+	ClonePodGCIntervalSec int
+	// Only report the leftover clone pods, without deleting them
+	ClonePodGCDryRun bool
+
+	// Suspend the nodes by cordoning and draining them, and provision nodes by uncordoning the drained nodes,
+	// when the Cluster API is not enabled
+	DrainNodes bool
+
+	// Recorder of the Kubernetes events of the actions, no events are recorded if not set
+	EventRecorder record.EventRecorder
+
+	// Path of the file where the snapshot of each full discovery is saved, empty to not record the snapshots
+	DiscoverySnapshotPath string
 }
 
 func NewVMTConfig2() *Config {
@@ -56,6 +101,11 @@ func (c *Config) WithKubeletClient(client *kubeclient.KubeletClient) *Config {
 	return c
 }
 
+func (c *Config) WithMetricsServerClient(client *kubeclient.MetricsServerClient) *Config {
+	c.MetricsServerClient = client
+	return c
+}
+
 func (c *Config) WithTapSpec(spec *K8sTAPServiceSpec) *Config {
 	c.tapSpec = spec
 	return c
@@ -86,6 +136,27 @@ func (c *Config) WithDiscoveryInterval(di int) *Config {
 	return c
 }
 
+func (c *Config) WithIncrementalDiscoveryInterval(di int) *Config {
+	c.IncrementalDiscoveryIntervalSec = di
+	return c
+}
+
+func (c *Config) WithDiscoverySnapshotPath(path string) *Config {
+	c.DiscoverySnapshotPath = path
+	return c
+}
+
+func (c *Config) WithUsageSampling(intervalSec int, percentile float64) *Config {
+	c.UsageSamplingIntervalSec = intervalSec
+	c.UsageSamplingPercentile = percentile
+	return c
+}
+
+func (c *Config) WithNodeNetworkCapacity(mbps int) *Config {
+	c.NodeNetworkCapacityMbps = mbps
+	return c
+}
+
 func (c *Config) WithValidationTimeout(di int) *Config {
 	c.ValidationTimeoutSec = di
 	return c
@@ -105,3 +176,42 @@ func (c *Config) WithCAPINamespace(CAPINamespace string) *Config {
 	c.CAPINamespace = CAPINamespace
 	return c
 }
+
+func (c *Config) WithResizeClampedToLimitRange(clamp bool) *Config {
+	c.ClampResizeToLimitRange = clamp
+	return c
+}
+
+func (c *Config) WithEventRecorder(recorder record.EventRecorder) *Config {
+	c.EventRecorder = recorder
+	return c
+}
+
+func (c *Config) WithScaleThroughHPA(scaleThroughHPA bool) *Config {
+	c.ScaleThroughHPA = scaleThroughHPA
+	return c
+}
+
+func (c *Config) WithClonePodGC(intervalSec int, dryRun bool) *Config {
+	c.ClonePodGCIntervalSec = intervalSec
+	c.ClonePodGCDryRun = dryRun
+	return c
+}
+
+func (c *Config) WithMoveStrategies(defaultStrategy string, namespaces, controllerKinds []string) *Config {
+	c.MoveStrategy = defaultStrategy
+	c.MoveStrategyNamespaces = namespaces
+	c.MoveStrategyControllerKinds = controllerKinds
+	return c
+}
+
+func (c *Config) WithResizeVerification(windowSec, restartThreshold int) *Config {
+	c.ResizeVerificationWindowSec = windowSec
+	c.ResizeRestartThreshold = restartThreshold
+	return c
+}
+
+func (c *Config) WithNodeDrain(drainNodes bool) *Config {
+	c.DrainNodes = drainNodes
+	return c
+}
@@ -23,6 +23,10 @@ var (
 	clusterType              = proto.CommodityDTO_CLUSTER
 	vmPMAccessType           = proto.CommodityDTO_VMPM_ACCESS
 	appCommType              = proto.CommodityDTO_APPLICATION
+	netThroughputType        = proto.CommodityDTO_NET_THROUGHPUT
+	storageAmountType        = proto.CommodityDTO_STORAGE_AMOUNT
+	numConsumersType         = proto.CommodityDTO_NUMBER_CONSUMERS
+	extendedResourceType     = proto.CommodityDTO_BUFFER_COMMODITY
 
 	fakeKey = "fake"
 
@@ -36,6 +40,11 @@ var (
 	memRequestAllocationTemplateCommWithKey = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &memRequestAllocationType}
 	vmpmAccessTemplateComm                  = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &vmPMAccessType}
 	applicationTemplateCommWithKey          = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &appCommType}
+	netThroughputTemplateComm               = &proto.TemplateCommodity{CommodityType: &netThroughputType}
+	storageAmountTemplateComm               = &proto.TemplateCommodity{CommodityType: &storageAmountType}
+	storageAmountTemplateCommWithKey        = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &storageAmountType}
+	numConsumersTemplateComm                = &proto.TemplateCommodity{CommodityType: &numConsumersType}
+	extendedResourceTemplateCommWithKey     = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &extendedResourceType}
 
 	// Internal matching property
 	proxyVMIP   = "Proxy_VM_IP"
@@ -85,6 +94,13 @@ func (f *SupplyChainFactory) createSupplyChain() ([]*proto.TemplateDTO, error) {
 	}
 	glog.V(4).Infof("Supply chain node: %+v", quotaSupplyChainNode)
 
+	// Volume supply chain template
+	volumeSupplyChainNode, err := f.buildVolumeSupplyBuilder()
+	if err != nil {
+		return nil, err
+	}
+	glog.V(4).Infof("Supply chain node: %+v", volumeSupplyChainNode)
+
 	// Pod supply chain template
 	podSupplyChainNode, err := f.buildPodSupplyBuilder()
 	if err != nil {
@@ -118,6 +134,7 @@ func (f *SupplyChainFactory) createSupplyChain() ([]*proto.TemplateDTO, error) {
 	supplyChainBuilder.Entity(appSupplyChainNode)
 	supplyChainBuilder.Entity(containerSupplyChainNode)
 	supplyChainBuilder.Entity(podSupplyChainNode)
+	supplyChainBuilder.Entity(volumeSupplyChainNode)
 	supplyChainBuilder.Entity(quotaSupplyChainNode)
 	supplyChainBuilder.Entity(nodeSupplyChainNode)
 
@@ -168,6 +185,8 @@ func (f *SupplyChainFactory) buildNodeMergedEntityMetadata() (*proto.MergedEntit
 		PatchSoldMetadata(proto.CommodityDTO_MEM_ALLOCATION, fieldsUsedCapacity).
 		PatchSoldMetadata(proto.CommodityDTO_CPU_REQUEST_ALLOCATION, fieldsUsedCapacity).
 		PatchSoldMetadata(proto.CommodityDTO_MEM_REQUEST_ALLOCATION, fieldsUsedCapacity).
+		PatchSoldMetadata(proto.CommodityDTO_NUMBER_CONSUMERS, fieldsUsedCapacity).
+		PatchSoldMetadata(proto.CommodityDTO_BUFFER_COMMODITY, fieldsUsedCapacity).
 		Build()
 }
 
@@ -177,11 +196,15 @@ func (f *SupplyChainFactory) buildNodeSupplyBuilder() (*proto.TemplateDTO, error
 	nodeSupplyChainNodeBuilder.SetTemplateType(f.vmTemplateType)
 
 	nodeSupplyChainNodeBuilder = nodeSupplyChainNodeBuilder.
-		Sells(vCpuTemplateComm).        // sells to Pods
-		Sells(vMemTemplateComm).        // sells to Pods
-		Sells(vCpuRequestTemplateComm). // sells to Pods
-		Sells(vMemRequestTemplateComm). // sells to Pods
-		Sells(vmpmAccessTemplateComm).  // sells to Pods
+		Sells(vCpuTemplateComm).                    // sells to Pods
+		Sells(vMemTemplateComm).                    // sells to Pods
+		Sells(vCpuRequestTemplateComm).             // sells to Pods
+		Sells(vMemRequestTemplateComm).             // sells to Pods
+		Sells(vmpmAccessTemplateComm).              // sells to Pods
+		Sells(netThroughputTemplateComm).           // sells to Pods
+		Sells(storageAmountTemplateComm).           // sells to Pods
+		Sells(numConsumersTemplateComm).            // sells to Pods
+		Sells(extendedResourceTemplateCommWithKey). // sells to Pods
 		// also sells Cluster to Pods
 		Sells(cpuAllocationTemplateCommWithKey).        //sells to Quotas
 		Sells(memAllocationTemplateCommWithKey).        //sells to Quotas
@@ -225,22 +248,38 @@ func (f *SupplyChainFactory) buildQuotaSupplyBuilder() (*proto.TemplateDTO, erro
 	return nodeSupplyChainNodeBuilder.ConnectsTo(vmQuotaExternalLink).Create()
 }
 
+func (f *SupplyChainFactory) buildVolumeSupplyBuilder() (*proto.TemplateDTO, error) {
+	volumeSupplyChainNodeBuilder := supplychain.NewSupplyChainNodeBuilder(proto.EntityDTO_VIRTUAL_VOLUME)
+	volumeSupplyChainNodeBuilder = volumeSupplyChainNodeBuilder.
+		Sells(storageAmountTemplateCommWithKey) // sells to Pods
+
+	return volumeSupplyChainNodeBuilder.Create()
+}
+
 func (f *SupplyChainFactory) buildPodSupplyBuilder() (*proto.TemplateDTO, error) {
 	podSupplyChainNodeBuilder := supplychain.NewSupplyChainNodeBuilder(proto.EntityDTO_CONTAINER_POD)
 	podSupplyChainNodeBuilder = podSupplyChainNodeBuilder.
 		Sells(vCpuTemplateComm). //sells to containers
 		Sells(vMemTemplateComm).
 		Sells(vmpmAccessTemplateComm).
+		Sells(netThroughputTemplateComm).
+		Sells(storageAmountTemplateComm).
 		Provider(proto.EntityDTO_VIRTUAL_MACHINE, proto.Provider_HOSTING).
 		Buys(vCpuTemplateComm).
 		Buys(vMemTemplateComm).
 		Buys(vCpuRequestTemplateComm).
 		Buys(vMemRequestTemplateComm).
+		Buys(netThroughputTemplateComm).
+		Buys(storageAmountTemplateComm).
+		Buys(numConsumersTemplateComm).
+		Buys(extendedResourceTemplateCommWithKey).
 		Provider(proto.EntityDTO_VIRTUAL_DATACENTER, proto.Provider_LAYERED_OVER).
 		Buys(cpuAllocationTemplateCommWithKey).
 		Buys(memAllocationTemplateCommWithKey).
 		Buys(cpuRequestAllocationTemplateCommWithKey).
-		Buys(memRequestAllocationTemplateCommWithKey)
+		Buys(memRequestAllocationTemplateCommWithKey).
+		Provider(proto.EntityDTO_VIRTUAL_VOLUME, proto.Provider_LAYERED_OVER).
+		Buys(storageAmountTemplateCommWithKey)
 
 	// Link from Pod to VM
 	vmPodExtLinkBuilder := supplychain.NewExternalEntityLinkBuilder()
@@ -249,6 +288,10 @@ func (f *SupplyChainFactory) buildPodSupplyBuilder() (*proto.TemplateDTO, error)
 		Commodity(vMemType, false).
 		Commodity(vCpuRequestType, false).
 		Commodity(vMemRequestType, false).
+		Commodity(netThroughputType, false).
+		Commodity(storageAmountType, false).
+		Commodity(numConsumersType, false).
+		Commodity(extendedResourceType, true).
 		Commodity(vmPMAccessType, true).
 		Commodity(clusterType, true)
 
@@ -288,6 +331,8 @@ func (f *SupplyChainFactory) buildContainer() (*proto.TemplateDTO, error) {
 		Provider(proto.EntityDTO_CONTAINER_POD, proto.Provider_HOSTING).
 		Buys(vCpuTemplateComm).
 		Buys(vMemTemplateComm).
+		Buys(netThroughputTemplateComm).
+		Buys(storageAmountTemplateComm).
 		Buys(vmpmAccessTemplateComm)
 
 	return builder.Create()
@@ -50,3 +50,80 @@ func TestNewSupplyChainFactory_VM(t *testing.T) {
 	}
 
 }
+
+func TestSupplyChainNetworkAndStorage(t *testing.T) {
+	f := NewSupplyChainFactory(stitching.IP, 0, false)
+	dtos, err := f.createSupplyChain()
+	if err != nil {
+		t.Fatalf("Failed to create supply chain: %v", err)
+	}
+
+	expected := []proto.CommodityDTO_CommodityType{proto.CommodityDTO_NET_THROUGHPUT, proto.CommodityDTO_STORAGE_AMOUNT}
+	for _, dto := range dtos {
+		switch dto.GetTemplateClass() {
+		case proto.EntityDTO_VIRTUAL_MACHINE:
+			checkTemplateCommodities(t, "node sold", dto.GetCommoditySold(), expected)
+			// The network and storage measured by the infrastructure probe for the stitched VM are kept
+			for _, soldMetadata := range dto.GetMergedEntityMetaData().GetCommoditiesSoldMetadata() {
+				for _, commType := range expected {
+					if soldMetadata.GetCommodityType() == commType {
+						t.Errorf("%v should not be patched into the stitched VM", commType)
+					}
+				}
+			}
+		case proto.EntityDTO_CONTAINER_POD:
+			checkTemplateCommodities(t, "pod sold", dto.GetCommoditySold(), expected)
+			for _, bought := range dto.GetCommodityBought() {
+				if bought.GetKey().GetTemplateClass() == proto.EntityDTO_VIRTUAL_MACHINE {
+					checkTemplateCommodities(t, "pod bought", bought.GetValue(), expected)
+				}
+			}
+		case proto.EntityDTO_CONTAINER:
+			for _, bought := range dto.GetCommodityBought() {
+				checkTemplateCommodities(t, "container bought", bought.GetValue(), expected)
+			}
+		}
+	}
+}
+
+func checkTemplateCommodities(t *testing.T, name string, comms []*proto.TemplateCommodity,
+	expected []proto.CommodityDTO_CommodityType) {
+	types := make(map[proto.CommodityDTO_CommodityType]bool)
+	for _, comm := range comms {
+		types[comm.GetCommodityType()] = true
+	}
+	for _, commType := range expected {
+		if !types[commType] {
+			t.Errorf("Missing %v in the %s commodities", commType, name)
+		}
+	}
+}
+
+func TestSupplyChainNodeCommoditiesBoughtByPods(t *testing.T) {
+	f := NewSupplyChainFactory(stitching.IP, 0, false)
+	dtos, err := f.createSupplyChain()
+	if err != nil {
+		t.Fatalf("Failed to create supply chain: %v", err)
+	}
+
+	expected := []proto.CommodityDTO_CommodityType{proto.CommodityDTO_NUMBER_CONSUMERS,
+		proto.CommodityDTO_BUFFER_COMMODITY}
+	for _, dto := range dtos {
+		switch dto.GetTemplateClass() {
+		case proto.EntityDTO_VIRTUAL_MACHINE:
+			checkTemplateCommodities(t, "node sold", dto.GetCommoditySold(), expected)
+			var merged []*proto.TemplateCommodity
+			for _, soldMetadata := range dto.GetMergedEntityMetaData().GetCommoditiesSoldMetadata() {
+				commType := soldMetadata.GetCommodityType()
+				merged = append(merged, &proto.TemplateCommodity{CommodityType: &commType})
+			}
+			checkTemplateCommodities(t, "node merged sold", merged, expected)
+		case proto.EntityDTO_CONTAINER_POD:
+			for _, bought := range dto.GetCommodityBought() {
+				if bought.GetKey().GetTemplateClass() == proto.EntityDTO_VIRTUAL_MACHINE {
+					checkTemplateCommodities(t, "pod bought", bought.GetValue(), expected)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package kubeturbo
+
+import (
+	"errors"
+
+	"github.com/golang/glog"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+)
+
+// DiscoverOnce validates and discovers the cluster once with the same discovery client as the TAP service,
+// without connecting to the Turbonomic server. The cluster cache runs until config.StopEverything is closed.
+func DiscoverOnce(config *Config) (*proto.ValidationResponse, *proto.DiscoveryResponse, error) {
+	if config == nil || config.tapSpec == nil {
+		return nil, nil, errors.New("invalid K8sTAPServiceConfig")
+	}
+	clusterCache := cluster.NewClusterCache(config.Client, clusterCacheResyncPeriod)
+	clusterScraper := cluster.NewClusterScraper(config.Client).WithClusterCache(clusterCache)
+	// Only the full discovery is run, without tracking the changes nor sampling the usage
+	discoveryClient := newDiscoveryClient(config, clusterScraper, nil, false)
+
+	clusterCache.Start(config.StopEverything)
+	if err := clusterCache.WaitForCacheSync(config.StopEverything, clusterCacheSyncTimeout); err != nil {
+		glog.Warningf("%v, reading these objects from the API server until they are synced.", err)
+	}
+
+	// The discovery client does not use the account values sent by the server
+	validationResponse, err := discoveryClient.Validate(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(validationResponse.GetErrorDTO()) > 0 {
+		glog.Warningf("The validation of the cluster failed, discovering it anyway.")
+	}
+	discoveryResponse, err := discoveryClient.Discover(nil)
+	if err != nil {
+		return validationResponse, nil, err
+	}
+	return validationResponse, discoveryResponse, nil
+}
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+)
+
+const (
+	// The group version of the PodDisruptionBudgets and the Eviction API since Kubernetes 1.22,
+	// while policy/v1beta1 is no longer served since 1.25
+	PolicyV1GroupVersion = "policy/v1"
+
+	policyV1MajorVersion = 1
+	policyV1MinorVersion = 22
+
+	// The workload controllers are served in apps/v1 since Kubernetes 1.9
+	appsV1MajorVersion = 1
+	appsV1MinorVersion = 9
+)
+
+// ServerVersionAtLeast returns whether the version of the API server is at least the given major and minor versions.
+func ServerVersionAtLeast(client discovery.ServerVersionInterface, major, minor int) (bool, error) {
+	info, err := client.ServerVersion()
+	if err != nil {
+		return false, fmt.Errorf("failed to get the Kubernetes version: %v", err)
+	}
+	return VersionAtLeast(info, major, minor)
+}
+
+// VersionAtLeast returns whether the server version is at least the given major and minor versions.
+// Some distributions append a "+" to the minor version.
+func VersionAtLeast(info *version.Info, major, minor int) (bool, error) {
+	serverMajor, err := strconv.Atoi(strings.TrimSuffix(info.Major, "+"))
+	if err != nil {
+		return false, fmt.Errorf("invalid major version %q of Kubernetes %s", info.Major, info.GitVersion)
+	}
+	serverMinor, err := strconv.Atoi(strings.TrimSuffix(info.Minor, "+"))
+	if err != nil {
+		return false, fmt.Errorf("invalid minor version %q of Kubernetes %s", info.Minor, info.GitVersion)
+	}
+	if serverMajor != major {
+		return serverMajor > major, nil
+	}
+	return serverMinor >= minor, nil
+}
+
+// ServesPolicyV1 returns whether the API server serves the PodDisruptionBudgets and the Eviction API in policy/v1,
+// based on its version. It is assumed to serve policy/v1beta1 only if its version is unknown.
+func ServesPolicyV1(client discovery.ServerVersionInterface) bool {
+	served, err := ServerVersionAtLeast(client, policyV1MajorVersion, policyV1MinorVersion)
+	if err != nil {
+		glog.Warningf("Assuming the PodDisruptionBudgets are served in policy/v1beta1: %v", err)
+		return false
+	}
+	return served
+}
+
+// ServesAppsV1 returns whether the API server serves the workload controllers in apps/v1, based on its version.
+// It is assumed to serve apps/v1 if its version is unknown.
+func ServesAppsV1(client discovery.ServerVersionInterface) bool {
+	served, err := ServerVersionAtLeast(client, appsV1MajorVersion, appsV1MinorVersion)
+	if err != nil {
+		glog.Warningf("Assuming the workload controllers are served in apps/v1: %v", err)
+		return true
+	}
+	return served
+}
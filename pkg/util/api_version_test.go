@@ -0,0 +1,36 @@
+package util
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/version"
+)
+
+func TestVersionAtLeast(t *testing.T) {
+	table := []struct {
+		major    string
+		minor    string
+		expected bool
+		err      bool
+	}{
+		{major: "1", minor: "21", expected: false},
+		{major: "1", minor: "22", expected: true},
+		{major: "1", minor: "25+", expected: true},
+		{major: "1", minor: "13+", expected: false},
+		{major: "2", minor: "0", expected: true},
+		{major: "1", minor: "", err: true},
+	}
+	for _, item := range table {
+		info := &version.Info{Major: item.major, Minor: item.minor, GitVersion: "v" + item.major + "." + item.minor}
+		actual, err := VersionAtLeast(info, 1, 22)
+		if item.err {
+			if err == nil {
+				t.Errorf("%s: expected an error but got %v", info.GitVersion, actual)
+			}
+			continue
+		}
+		if err != nil || actual != item.expected {
+			t.Errorf("%s: expected %v but got %v, %v", info.GitVersion, item.expected, actual, err)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedappsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// The group versions of the workload controllers on the API servers older than Kubernetes 1.9
+	ExtensionsV1beta1GroupVersion = "extensions/v1beta1"
+	AppsV1beta1GroupVersion       = "apps/v1beta1"
+)
+
+// The fields of the legacy specifications of the workload controllers which have no apps/v1 counterpart,
+// i.e., the pending rollback of a Deployment and the template generation of a DaemonSet
+var legacySpecFields = []string{"rollbackTo", "templateGeneration"}
+
+// AppsClient gives the clients of the workload controllers in apps/v1, or in their legacy group versions on the
+// API servers older than Kubernetes 1.9, which do not serve apps/v1.
+// The objects of the legacy group versions are decoded into the apps/v1 types, which have the same schema but for
+// a few deprecated fields, so that the callers handle the same types whatever the version of the server.
+// Only the Get, List, Watch and Update requests are sent in the legacy group versions.
+type AppsClient struct {
+	client kubernetes.Interface
+	legacy bool
+}
+
+// NewAppsClient returns the clients of the workload controllers in the group versions served by the API server.
+func NewAppsClient(client kubernetes.Interface) *AppsClient {
+	return &AppsClient{
+		client: client,
+		legacy: !ServesAppsV1(client.Discovery()),
+	}
+}
+
+// ReplicaSets returns the client of the ReplicaSets of the namespace, in extensions/v1beta1 on the legacy servers.
+func (c *AppsClient) ReplicaSets(namespace string) typedappsv1.ReplicaSetInterface {
+	rsClient := c.client.AppsV1().ReplicaSets(namespace)
+	if !c.legacy {
+		return rsClient
+	}
+	return &legacyReplicaSets{
+		ReplicaSetInterface: rsClient,
+		resource: newLegacyResource(c.client.ExtensionsV1beta1().RESTClient(), ExtensionsV1beta1GroupVersion,
+			KindReplicaSet, "replicasets", namespace),
+	}
+}
+
+// Deployments returns the client of the Deployments of the namespace, in extensions/v1beta1 on the legacy servers.
+func (c *AppsClient) Deployments(namespace string) typedappsv1.DeploymentInterface {
+	depClient := c.client.AppsV1().Deployments(namespace)
+	if !c.legacy {
+		return depClient
+	}
+	return &legacyDeployments{
+		DeploymentInterface: depClient,
+		resource: newLegacyResource(c.client.ExtensionsV1beta1().RESTClient(), ExtensionsV1beta1GroupVersion,
+			KindDeployment, "deployments", namespace),
+	}
+}
+
+// StatefulSets returns the client of the StatefulSets of the namespace, in apps/v1beta1 on the legacy servers.
+func (c *AppsClient) StatefulSets(namespace string) typedappsv1.StatefulSetInterface {
+	stsClient := c.client.AppsV1().StatefulSets(namespace)
+	if !c.legacy {
+		return stsClient
+	}
+	return &legacyStatefulSets{
+		StatefulSetInterface: stsClient,
+		resource: newLegacyResource(c.client.AppsV1beta1().RESTClient(), AppsV1beta1GroupVersion,
+			KindStatefulSet, "statefulsets", namespace),
+	}
+}
+
+// DaemonSets returns the client of the DaemonSets of the namespace, in extensions/v1beta1 on the legacy servers.
+func (c *AppsClient) DaemonSets(namespace string) typedappsv1.DaemonSetInterface {
+	dsClient := c.client.AppsV1().DaemonSets(namespace)
+	if !c.legacy {
+		return dsClient
+	}
+	return &legacyDaemonSets{
+		DaemonSetInterface: dsClient,
+		resource: newLegacyResource(c.client.ExtensionsV1beta1().RESTClient(), ExtensionsV1beta1GroupVersion,
+			KindDaemonSet, "daemonsets", namespace),
+	}
+}
+
+type legacyReplicaSets struct {
+	typedappsv1.ReplicaSetInterface
+	resource *legacyResource
+}
+
+func (c *legacyReplicaSets) Get(name string, options metav1.GetOptions) (*apps.ReplicaSet, error) {
+	rs := &apps.ReplicaSet{}
+	if err := c.resource.get(name, options, rs); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+func (c *legacyReplicaSets) List(options metav1.ListOptions) (*apps.ReplicaSetList, error) {
+	rsList := &apps.ReplicaSetList{}
+	if err := c.resource.list(options, rsList); err != nil {
+		return nil, err
+	}
+	return rsList, nil
+}
+
+func (c *legacyReplicaSets) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	return c.resource.watch(options, func() runtime.Object { return &apps.ReplicaSet{} })
+}
+
+func (c *legacyReplicaSets) Update(rs *apps.ReplicaSet) (*apps.ReplicaSet, error) {
+	updated := &apps.ReplicaSet{}
+	if err := c.resource.update(rs.Name, rs, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+type legacyDeployments struct {
+	typedappsv1.DeploymentInterface
+	resource *legacyResource
+}
+
+func (c *legacyDeployments) Get(name string, options metav1.GetOptions) (*apps.Deployment, error) {
+	dep := &apps.Deployment{}
+	if err := c.resource.get(name, options, dep); err != nil {
+		return nil, err
+	}
+	return dep, nil
+}
+
+func (c *legacyDeployments) List(options metav1.ListOptions) (*apps.DeploymentList, error) {
+	depList := &apps.DeploymentList{}
+	if err := c.resource.list(options, depList); err != nil {
+		return nil, err
+	}
+	return depList, nil
+}
+
+func (c *legacyDeployments) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	return c.resource.watch(options, func() runtime.Object { return &apps.Deployment{} })
+}
+
+func (c *legacyDeployments) Update(dep *apps.Deployment) (*apps.Deployment, error) {
+	updated := &apps.Deployment{}
+	if err := c.resource.update(dep.Name, dep, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+type legacyStatefulSets struct {
+	typedappsv1.StatefulSetInterface
+	resource *legacyResource
+}
+
+func (c *legacyStatefulSets) Get(name string, options metav1.GetOptions) (*apps.StatefulSet, error) {
+	sts := &apps.StatefulSet{}
+	if err := c.resource.get(name, options, sts); err != nil {
+		return nil, err
+	}
+	return sts, nil
+}
+
+func (c *legacyStatefulSets) List(options metav1.ListOptions) (*apps.StatefulSetList, error) {
+	stsList := &apps.StatefulSetList{}
+	if err := c.resource.list(options, stsList); err != nil {
+		return nil, err
+	}
+	return stsList, nil
+}
+
+func (c *legacyStatefulSets) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	return c.resource.watch(options, func() runtime.Object { return &apps.StatefulSet{} })
+}
+
+func (c *legacyStatefulSets) Update(sts *apps.StatefulSet) (*apps.StatefulSet, error) {
+	updated := &apps.StatefulSet{}
+	if err := c.resource.update(sts.Name, sts, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+type legacyDaemonSets struct {
+	typedappsv1.DaemonSetInterface
+	resource *legacyResource
+}
+
+func (c *legacyDaemonSets) Get(name string, options metav1.GetOptions) (*apps.DaemonSet, error) {
+	ds := &apps.DaemonSet{}
+	if err := c.resource.get(name, options, ds); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+func (c *legacyDaemonSets) List(options metav1.ListOptions) (*apps.DaemonSetList, error) {
+	dsList := &apps.DaemonSetList{}
+	if err := c.resource.list(options, dsList); err != nil {
+		return nil, err
+	}
+	return dsList, nil
+}
+
+func (c *legacyDaemonSets) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	return c.resource.watch(options, func() runtime.Object { return &apps.DaemonSet{} })
+}
+
+func (c *legacyDaemonSets) Update(ds *apps.DaemonSet) (*apps.DaemonSet, error) {
+	updated := &apps.DaemonSet{}
+	if err := c.resource.update(ds.Name, ds, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// legacyResource sends the requests of a resource of a namespace in its legacy group version, in JSON.
+type legacyResource struct {
+	client       rest.Interface
+	groupVersion string
+	kind         string
+	resource     string
+	namespace    string
+}
+
+func newLegacyResource(client rest.Interface, groupVersion, kind, resource, namespace string) *legacyResource {
+	return &legacyResource{
+		client:       client,
+		groupVersion: groupVersion,
+		kind:         kind,
+		resource:     resource,
+		namespace:    namespace,
+	}
+}
+
+func (r *legacyResource) get(name string, options metav1.GetOptions, into runtime.Object) error {
+	body, err := r.client.Get().
+		Namespace(r.namespace).
+		Resource(r.resource).
+		Name(name).
+		SetHeader("Accept", runtime.ContentTypeJSON).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Raw()
+	if err != nil {
+		return err
+	}
+	return r.decode(body, into)
+}
+
+func (r *legacyResource) list(options metav1.ListOptions, into runtime.Object) error {
+	body, err := r.client.Get().
+		Namespace(r.namespace).
+		Resource(r.resource).
+		SetHeader("Accept", runtime.ContentTypeJSON).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Raw()
+	if err != nil {
+		return err
+	}
+	return r.decode(body, into)
+}
+
+func (r *legacyResource) watch(options metav1.ListOptions, newObject func() runtime.Object) (watch.Interface, error) {
+	options.Watch = true
+	stream, err := r.client.Get().
+		Namespace(r.namespace).
+		Resource(r.resource).
+		SetHeader("Accept", runtime.ContentTypeJSON).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Stream()
+	if err != nil {
+		return nil, err
+	}
+	return watch.NewStreamWatcher(NewJSONWatchDecoder(stream, func(data []byte) (runtime.Object, error) {
+		obj := newObject()
+		if err := r.decode(data, obj); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	})), nil
+}
+
+// update the object in the legacy group version. The deprecated fields of its current version are kept,
+// as they are not in the apps/v1 object and would be reset otherwise.
+func (r *legacyResource) update(name string, obj runtime.Object, into runtime.Object) error {
+	current, err := r.client.Get().
+		Namespace(r.namespace).
+		Resource(r.resource).
+		Name(name).
+		SetHeader("Accept", runtime.ContentTypeJSON).
+		Do().
+		Raw()
+	if err != nil {
+		return err
+	}
+	body, err := r.encode(obj, current)
+	if err != nil {
+		return err
+	}
+	result, err := r.client.Put().
+		Namespace(r.namespace).
+		Resource(r.resource).
+		Name(name).
+		SetHeader("Accept", runtime.ContentTypeJSON).
+		SetHeader("Content-Type", runtime.ContentTypeJSON).
+		Body(body).
+		Do().
+		Raw()
+	if err != nil {
+		return err
+	}
+	return r.decode(result, into)
+}
+
+// Encode the apps/v1 object in the legacy group version, with the deprecated fields of the current legacy object.
+func (r *legacyResource) encode(obj runtime.Object, current []byte) ([]byte, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %v", r.kind, err)
+	}
+	var object, currentObject map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %v", r.kind, err)
+	}
+	if err := json.Unmarshal(current, &currentObject); err != nil {
+		return nil, fmt.Errorf("failed to decode the current %s of %s: %v", r.kind, r.groupVersion, err)
+	}
+	object["apiVersion"] = r.groupVersion
+	object["kind"] = r.kind
+	spec, _ := object["spec"].(map[string]interface{})
+	currentSpec, _ := currentObject["spec"].(map[string]interface{})
+	if spec != nil {
+		for _, field := range legacySpecFields {
+			if value, exists := currentSpec[field]; exists {
+				spec[field] = value
+			}
+		}
+	}
+	return json.Marshal(object)
+}
+
+// Decode the legacy object into the apps/v1 type. Its type meta is cleared as by the apps/v1 clients.
+func (r *legacyResource) decode(data []byte, into runtime.Object) error {
+	if err := json.Unmarshal(data, into); err != nil {
+		return fmt.Errorf("failed to decode the %s of %s: %v", r.resource, r.groupVersion, err)
+	}
+	into.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{})
+	return nil
+}
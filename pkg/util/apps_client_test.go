@@ -0,0 +1,101 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestAppsClientOfLegacyServer(t *testing.T) {
+	var updated map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/version":
+			fmt.Fprint(w, `{"major":"1","minor":"8","gitVersion":"v1.8.15"}`)
+		case "/apis/extensions/v1beta1/namespaces/ns1/replicasets/web-5d8f":
+			fmt.Fprint(w, `{"apiVersion":"extensions/v1beta1","kind":"ReplicaSet","metadata":{"name":"web-5d8f",`+
+				`"namespace":"ns1","ownerReferences":[{"apiVersion":"extensions/v1beta1","kind":"Deployment",`+
+				`"name":"web","uid":"dep-uid","controller":true}]},"spec":{"replicas":2}}`)
+		case "/apis/extensions/v1beta1/namespaces/ns1/daemonsets/agent":
+			if r.Method == http.MethodPut {
+				if err := json.NewDecoder(r.Body).Decode(&updated); err != nil {
+					t.Errorf("Invalid update of the DaemonSet: %v", err)
+				}
+			}
+			fmt.Fprint(w, `{"apiVersion":"extensions/v1beta1","kind":"DaemonSet","metadata":{"name":"agent",`+
+				`"namespace":"ns1","resourceVersion":"5"},"spec":{"templateGeneration":3,`+
+				`"updateStrategy":{"type":"RollingUpdate"}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create kube client: %v", err)
+	}
+	appsClient := NewAppsClient(kubeClient)
+
+	rs, err := appsClient.ReplicaSets("ns1").Get("web-5d8f", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get the ReplicaSet: %v", err)
+	}
+	if owner := metav1.GetControllerOf(rs); owner == nil || owner.Name != "web" || *rs.Spec.Replicas != 2 {
+		t.Errorf("Expected the ReplicaSet of Deployment web with 2 replicas, got %+v", rs)
+	}
+	if _, err := appsClient.ReplicaSets("ns1").Get("db-7c9b", metav1.GetOptions{}); err == nil {
+		t.Errorf("Expected an error getting a missing ReplicaSet")
+	}
+
+	ds, err := appsClient.DaemonSets("ns1").Get("agent", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get the DaemonSet: %v", err)
+	}
+	ds.Spec.Template.Spec.NodeSelector = map[string]string{"disk": "ssd"}
+	if _, err := appsClient.DaemonSets("ns1").Update(ds); err != nil {
+		t.Fatalf("Failed to update the DaemonSet: %v", err)
+	}
+	spec, _ := updated["spec"].(map[string]interface{})
+	if updated["apiVersion"] != ExtensionsV1beta1GroupVersion || updated["kind"] != KindDaemonSet {
+		t.Errorf("Expected the DaemonSet to be updated in %s, got %v %v", ExtensionsV1beta1GroupVersion,
+			updated["apiVersion"], updated["kind"])
+	}
+	if spec["templateGeneration"] != float64(3) {
+		t.Errorf("Expected the template generation of the DaemonSet to be kept, got %v", spec["templateGeneration"])
+	}
+}
+
+func TestAppsClientOfCurrentServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/version":
+			fmt.Fprint(w, `{"major":"1","minor":"9","gitVersion":"v1.9.0"}`)
+		case "/apis/apps/v1/namespaces/ns1/deployments/web":
+			fmt.Fprint(w, `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"ns1"},`+
+				`"spec":{"replicas":3}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create kube client: %v", err)
+	}
+
+	dep, err := NewAppsClient(kubeClient).Deployments("ns1").Get("web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get the Deployment: %v", err)
+	}
+	if *dep.Spec.Replicas != 3 {
+		t.Errorf("Expected the Deployment with 3 replicas, got %+v", dep)
+	}
+}
@@ -4,4 +4,9 @@ const (
 	KindReplicationController = "ReplicationController"
 	KindReplicaSet            = "ReplicaSet"
 	KindDeployment            = "Deployment"
+	KindStatefulSet           = "StatefulSet"
+	KindDaemonSet             = "DaemonSet"
+
+	KindPodDisruptionBudget     = "PodDisruptionBudget"
+	KindHorizontalPodAutoscaler = "HorizontalPodAutoscaler"
 )
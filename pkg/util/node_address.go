@@ -0,0 +1,36 @@
+package util
+
+import (
+	"github.com/golang/glog"
+	api "k8s.io/api/core/v1"
+)
+
+// NodeLegacyHostIP is the legacy type of the node IP address, which is no longer defined by the API
+// but may still be reported by the nodes of older clusters.
+const NodeLegacyHostIP api.NodeAddressType = "LegacyHostIP"
+
+// The types of the node addresses through which the kubelet is reached, in the order of preference
+var kubeletAddressTypes = []api.NodeAddressType{api.NodeInternalIP, api.NodeExternalIP, NodeLegacyHostIP}
+
+// ParseNodeIP returns the first address of the given type of the node, or "" if the node has none.
+func ParseNodeIP(node *api.Node, addressType api.NodeAddressType) string {
+	for _, nodeAddress := range node.Status.Addresses {
+		if nodeAddress.Type == addressType && nodeAddress.Address != "" {
+			glog.V(4).Infof("%s : %s is %s", node.Name, addressType, nodeAddress.Address)
+			return nodeAddress.Address
+		}
+	}
+	return ""
+}
+
+// GetKubeletIP returns the IP through which the kubelet of the node is reached, i.e., the internal IP of the node,
+// or its external IP or legacy host IP if it has no internal IP. Returns "" if the node has none of them.
+// The kubelets are identified by this IP, so every component reaching them must resolve it the same way.
+func GetKubeletIP(node *api.Node) string {
+	for _, addressType := range kubeletAddressTypes {
+		if ip := ParseNodeIP(node, addressType); ip != "" {
+			return ip
+		}
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+)
+
+func TestGetKubeletIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		addresses []api.NodeAddress
+		expected  string
+	}{
+		{
+			name: "internal IP first",
+			addresses: []api.NodeAddress{
+				{Type: api.NodeExternalIP, Address: "10.1.0.1"},
+				{Type: api.NodeInternalIP, Address: "10.0.0.1"},
+				{Type: api.NodeInternalIP, Address: "fd00::1"},
+			},
+			expected: "10.0.0.1",
+		},
+		{
+			name: "external IP without internal IP",
+			addresses: []api.NodeAddress{
+				{Type: api.NodeHostName, Address: "node1"},
+				{Type: NodeLegacyHostIP, Address: "10.2.0.1"},
+				{Type: api.NodeExternalIP, Address: "10.1.0.1"},
+			},
+			expected: "10.1.0.1",
+		},
+		{
+			name: "legacy host IP",
+			addresses: []api.NodeAddress{
+				{Type: api.NodeInternalIP, Address: ""},
+				{Type: NodeLegacyHostIP, Address: "10.2.0.1"},
+			},
+			expected: "10.2.0.1",
+		},
+		{
+			name:      "hostname only",
+			addresses: []api.NodeAddress{{Type: api.NodeHostName, Address: "node1"}},
+			expected:  "",
+		},
+	}
+	for _, test := range tests {
+		node := &api.Node{}
+		node.Name = "node1"
+		node.Status.Addresses = test.addresses
+		if actual := GetKubeletIP(node); actual != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, actual)
+		}
+	}
+}
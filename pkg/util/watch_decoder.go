@@ -0,0 +1,54 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// NewJSONWatchDecoder returns the decoder of the JSON events of a watch stream, for the objects served in a group
+// version this client has no types for. The object of each event is decoded by the given function, and the errors
+// of the watch into a Status.
+func NewJSONWatchDecoder(stream io.ReadCloser, decode func(data []byte) (runtime.Object, error)) watch.Decoder {
+	return &jsonWatchDecoder{
+		stream:  stream,
+		decoder: json.NewDecoder(stream),
+		decode:  decode,
+	}
+}
+
+type jsonWatchDecoder struct {
+	stream  io.ReadCloser
+	decoder *json.Decoder
+	decode  func(data []byte) (runtime.Object, error)
+}
+
+func (d *jsonWatchDecoder) Decode() (watch.EventType, runtime.Object, error) {
+	var event struct {
+		Type   watch.EventType `json:"type"`
+		Object json.RawMessage `json:"object"`
+	}
+	if err := d.decoder.Decode(&event); err != nil {
+		return "", nil, err
+	}
+	if event.Type == watch.Error {
+		status := &metav1.Status{}
+		if err := json.Unmarshal(event.Object, status); err != nil {
+			return "", nil, fmt.Errorf("failed to decode the error of the watch: %v", err)
+		}
+		return event.Type, status, nil
+	}
+	obj, err := d.decode(event.Object)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decode the object of the %s event: %v", event.Type, err)
+	}
+	return event.Type, obj, nil
+}
+
+func (d *jsonWatchDecoder) Close() {
+	d.stream.Close()
+}